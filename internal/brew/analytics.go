@@ -0,0 +1,127 @@
+package brew
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const (
+	apiBuildErrors90dPath = "analytics/build-error/90d.json"
+
+	buildErrorsJson = "build-errors-90d.json"
+)
+
+var flagFetchBuildErrors = Flags.Bool("fetch-build-errors", false, "Fetch build error analytics and warn about formulae that often fail to build")
+
+type apiBuildErrorAnalytics struct {
+	Items []struct {
+		Name  string `json:"formula"`
+		Count string `json:"count"`
+	} `json:"items"`
+}
+
+func fetchBuildErrorAnalytics(dataChan chan apiBuildErrorAnalytics, errChan chan error) {
+	target := apiBuildErrorAnalytics{}
+	fetchJsonWithCache(
+		apiUrl(apiBuildErrors90dPath),
+		filepath.Join(taproomCacheDir, buildErrorsJson),
+		&target,
+		dataChan,
+		errChan)
+}
+
+// Build errors by formula name, errors of a formula built with options (e.g. "llvm --HEAD") are added up
+func mapBuildErrors(analytics apiBuildErrorAnalytics) map[string]int {
+	buildErrors := make(map[string]int)
+	for _, item := range analytics.Items {
+		name, _, _ := strings.Cut(item.Name, " ")
+		buildErrors[name] += parseInstallCount(item.Count)
+	}
+	return buildErrors
+}
+
+func applyBuildErrors(pkgs []*data.Package, analytics apiBuildErrorAnalytics) {
+	buildErrors := mapBuildErrors(analytics)
+	for _, pkg := range pkgs {
+		// Analytics name formulae of other taps by their tap too, so they don't get the core one's
+		if !pkg.IsCask {
+			pkg.BuildErrors90d = buildErrors[pkg.FullName()]
+		}
+	}
+}
+
+const (
+	apiFormulaInfoPath = "formula/%s.json"
+	apiCaskInfoPath    = "cask/%s.json"
+
+	packageAnalyticsDir = "analytics"
+)
+
+// Analytics of a single package, by category and period, e.g. {"install": {"30d": {"wget": 123, "wget --HEAD": 4}}}
+type apiPackageAnalytics map[string]map[string]map[string]int
+
+type apiPackageInfo struct {
+	Analytics      apiPackageAnalytics `json:"analytics"`
+	AnalyticsLinux apiPackageAnalytics `json:"analytics-linux"`
+}
+
+// Sum of the counts of a category in a period, including all build options of the package
+func (a apiPackageAnalytics) count(category, period string) int {
+	total := 0
+	for _, n := range a[category][period] {
+		total += n
+	}
+	return total
+}
+
+func (a apiPackageAnalytics) counts(category string) data.AnalyticsCounts {
+	return data.AnalyticsCounts{
+		Days30:  a.count(category, "30d"),
+		Days90:  a.count(category, "90d"),
+		Days365: a.count(category, "365d"),
+	}
+}
+
+func (info *apiPackageInfo) toAnalytics() *data.Analytics {
+	return &data.Analytics{
+		Installs:          info.Analytics.counts("install"),
+		InstallsOnRequest: info.Analytics.counts("install_on_request"),
+		InstallsLinux:     info.AnalyticsLinux.counts("install"),
+		BuildErrors:       info.Analytics.counts("build_error"),
+	}
+}
+
+type PackageAnalyticsMsg struct {
+	Pkg *data.Package
+	Err error
+}
+
+// Url of the API JSON of a single package, and where it's cached
+func packageInfoUrl(pkg *data.Package) (url, cachePath string) {
+	if pkg.IsCask {
+		return apiUrl(fmt.Sprintf(apiCaskInfoPath, pkg.Name)), filepath.Join(taproomCacheDir, packageAnalyticsDir, pkg.Name+".cask.json")
+	}
+	return apiUrl(fmt.Sprintf(apiFormulaInfoPath, pkg.Name)), filepath.Join(taproomCacheDir, packageAnalyticsDir, pkg.Name+".json")
+}
+
+// Fetch analytics of a package by OS and its build errors, and save them to the package
+func FetchPackageAnalytics(pkg *data.Package) tea.Cmd {
+	return func() tea.Msg {
+		url, cachePath := packageInfoUrl(pkg)
+		body, err := fetchUrlWithCache(url, cachePath)
+		if err != nil {
+			return PackageAnalyticsMsg{Pkg: pkg, Err: err}
+		}
+		info := apiPackageInfo{}
+		if err := decodeJson(bytes.NewReader(body), &info); err != nil {
+			return PackageAnalyticsMsg{Pkg: pkg, Err: fmt.Errorf("failed to decode json from %s: %w", url, err)}
+		}
+		pkg.Analytics = info.toAnalytics()
+		return PackageAnalyticsMsg{Pkg: pkg}
+	}
+}
@@ -0,0 +1,60 @@
+package brew
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestApplyBuildErrors(t *testing.T) {
+	analytics := apiBuildErrorAnalytics{}
+	payload := `{"items": [
+		{"number": 1, "formula": "llvm", "count": "1,204"},
+		{"number": 2, "formula": "llvm --HEAD", "count": "96"},
+		{"number": 3, "formula": "gcc", "count": "7"}
+	]}`
+	if err := json.Unmarshal([]byte(payload), &analytics); err != nil {
+		t.Fatalf("failed to decode analytics: %v", err)
+	}
+
+	pkgs := []*data.Package{{Name: "llvm"}, {Name: "gcc"}, {Name: "wget"}, {Name: "gcc", IsCask: true}}
+	applyBuildErrors(pkgs, analytics)
+	for i, want := range []int{1300, 7, 0, 0} {
+		if pkgs[i].BuildErrors90d != want {
+			t.Errorf("expected %d build errors for %s, got %d", want, pkgs[i].Name, pkgs[i].BuildErrors90d)
+		}
+	}
+}
+
+func TestPackageInfoAnalytics(t *testing.T) {
+	info := apiPackageInfo{}
+	payload := `{
+		"name": "wget",
+		"analytics": {
+			"install": {"30d": {"wget": 100, "wget --HEAD": 2}, "90d": {"wget": 300}, "365d": {"wget": 1200}},
+			"install_on_request": {"30d": {"wget": 80}, "90d": {"wget": 240}, "365d": {"wget": 1000}},
+			"build_error": {"30d": {"wget": 3}}
+		},
+		"analytics-linux": {
+			"install": {"30d": {"wget": 10}, "90d": {"wget": 30}, "365d": {"wget": 120}}
+		}
+	}`
+	if err := json.Unmarshal([]byte(payload), &info); err != nil {
+		t.Fatalf("failed to decode package info: %v", err)
+	}
+
+	a := info.toAnalytics()
+	if a.Installs != (data.AnalyticsCounts{Days30: 102, Days90: 300, Days365: 1200}) {
+		t.Errorf("unexpected macOS installs %+v", a.Installs)
+	}
+	if a.InstallsLinux != (data.AnalyticsCounts{Days30: 10, Days90: 30, Days365: 120}) {
+		t.Errorf("unexpected Linux installs %+v", a.InstallsLinux)
+	}
+	if a.InstallsOnRequest.Days90 != 240 {
+		t.Errorf("expected 240 installs on request in 90 days, got %d", a.InstallsOnRequest.Days90)
+	}
+	if a.BuildErrors != (data.AnalyticsCounts{Days30: 3}) {
+		t.Errorf("unexpected build errors %+v", a.BuildErrors)
+	}
+}
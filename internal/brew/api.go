@@ -1,19 +1,26 @@
 package brew
 
 import (
+	"bytes"
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"time"
 
-	"github.com/spf13/pflag"
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/util"
 )
 
-var taproomCacheDir = func() string {
+var taproomCacheDir = usableCacheDir(func() string {
 	home, err := os.UserHomeDir()
 	if err == nil {
 		return filepath.Join(home, ".cache", "taproom")
@@ -21,13 +28,14 @@ var taproomCacheDir = func() string {
 		log.Printf("failed to locate user's home dir: %v", err)
 		return ".cache"
 	}
-}()
+}())
 
 const (
-	apiFormulaURL             = "https://formulae.brew.sh/api/formula.jws.json"
-	apiCaskURL                = "https://formulae.brew.sh/api/cask.jws.json"
-	apiFormulaAnalytics90dURL = "https://formulae.brew.sh/api/analytics/install-on-request/90d.json"
-	apiCaskAnalytics90dURL    = "https://formulae.brew.sh/api/analytics/cask-install/90d.json"
+	// Paths in the Homebrew API
+	apiFormulaPath             = "formula.jws.json"
+	apiCaskPath                = "cask.jws.json"
+	apiFormulaAnalytics90dPath = "analytics/install-on-request/90d.json"
+	apiCaskAnalytics90dPath    = "analytics/cask-install/90d.json"
 
 	formulaJwsJson       = "formula.jws.json"
 	caskJwsJson          = "cask.jws.json"
@@ -35,16 +43,23 @@ const (
 	caskAnalyticsJson    = "cask-analytics-90d.json"
 
 	urlCacheTtl = 6 * time.Hour
+
+	// Downloads are tried this many times before loading fails
+	fetchAttempts = 4
 )
 
-var flagInvalidateCache = pflag.BoolP("invalidate-cache", "i", false, "Invalidate cache and force re-downloading data")
+// Wait before the first retry of a download, doubled for each retry after it
+var fetchRetryBackoff = time.Second
+
+var flagInvalidateCache = Flags.BoolP("invalidate-cache", "i", false, "Invalidate cache and force re-downloading data")
 
 // Structs for parsing Homebrew API Json
 type apiFormula struct {
-	Name     string   `json:"name"`
-	Aliases  []string `json:"aliases"`
-	Tap      string   `json:"tap"`
-	Desc     string   `json:"desc"`
+	source   data.Source // Where the formula was loaded from, the API when it's not set
+	Name     string      `json:"name"`
+	Aliases  []string    `json:"aliases"`
+	Tap      string      `json:"tap"`
+	Desc     string      `json:"desc"`
 	Versions struct {
 		Stable string `json:"stable"`
 	} `json:"versions"`
@@ -62,28 +77,289 @@ type apiFormula struct {
 	Dependencies      []string `json:"dependencies"`
 	BuildDependencies []string `json:"build_dependencies"`
 	Conflicts         []string `json:"conflicts_with"`
-	Deprecated        bool     `json:"deprecated"`
-	Disabled          bool     `json:"disabled"`
+	KegOnly           bool     `json:"keg_only"`
+	KegOnlyReason     struct {
+		// A symbol like ":provided_by_macos" or ":versioned_formula", or a custom explanation
+		Reason string `json:"reason"`
+	} `json:"keg_only_reason"`
+	Deprecated bool `json:"deprecated"`
+	Disabled   bool `json:"disabled"`
+	Bottle     struct {
+		Stable struct {
+			// Keyed by bottle tags like "arm64_sequoia", "sonoma", "x86_64_linux" or "all"
+			Files map[string]json.RawMessage `json:"files"`
+		} `json:"stable"`
+	} `json:"bottle"`
+	// Formula specific install options like {"option": "--with-foo", "description": "Build with foo"}
+	Options []struct {
+		Option string `json:"option"`
+	} `json:"options"`
+	// Requirements like {"name": "macos", "version": "13"} or {"name": "linux"}
+	Requirements []struct {
+		Name    string `json:"name"`
+		Version any    `json:"version"`
+	} `json:"requirements"`
+	apiDeprecation
+}
+
+// Why and when a formula or cask was deprecated or disabled, and what replaces it
+type apiDeprecation struct {
+	DeprecationDate               string `json:"deprecation_date"`
+	DeprecationReason             string `json:"deprecation_reason"`
+	DeprecationReplacement        string `json:"deprecation_replacement"` // Older API, before the kind was added
+	DeprecationReplacementFormula string `json:"deprecation_replacement_formula"`
+	DeprecationReplacementCask    string `json:"deprecation_replacement_cask"`
+	DisableDate                   string `json:"disable_date"`
+	DisableReason                 string `json:"disable_reason"`
+	DisableReplacement            string `json:"disable_replacement"`
+	DisableReplacementFormula     string `json:"disable_replacement_formula"`
+	DisableReplacementCask        string `json:"disable_replacement_cask"`
+}
+
+func (d *apiDeprecation) toDeprecation(deprecated, disabled bool) *data.Deprecation {
+	if !deprecated && !disabled {
+		return nil
+	}
+	return &data.Deprecation{
+		Date:          d.DeprecationDate,
+		Reason:        d.DeprecationReason,
+		DisableDate:   d.DisableDate,
+		DisableReason: d.DisableReason,
+		Replacement: cmp.Or(
+			d.DisableReplacementFormula, d.DisableReplacementCask, d.DisableReplacement,
+			d.DeprecationReplacementFormula, d.DeprecationReplacementCask, d.DeprecationReplacement,
+		),
+	}
 }
 
 type apiCask struct {
-	Name         string `json:"token"`
-	Tap          string `json:"tap"`
-	Desc         string `json:"desc"`
-	Version      string `json:"version"`
-	Homepage     string `json:"homepage"`
-	Url          string `json:"url"`
+	source       data.Source // Where the cask was loaded from, the API when it's not set
+	Name         string      `json:"token"`
+	Tap          string      `json:"tap"`
+	Desc         string      `json:"desc"`
+	Version      string      `json:"version"`
+	Homepage     string      `json:"homepage"`
+	Url          string      `json:"url"`
 	Dependencies struct {
 		Formulae []string `json:"formula"`
 		Casks    []string `json:"cask"`
+		// Version constraints like {">=": ["12"]}
+		MacOS map[string][]string `json:"macos"`
 	} `json:"depends_on"`
 	Conflicts struct {
 		Formulae []string `json:"formula"`
 		Casks    []string `json:"cask"`
 	} `json:"conflicts_with"`
-	AutoUpdate bool `json:"auto_updates"`
-	Deprecated bool `json:"deprecated"`
-	Disabled   bool `json:"disabled"`
+	AutoUpdate bool                         `json:"auto_updates"`
+	Deprecated bool                         `json:"deprecated"`
+	Disabled   bool                         `json:"disabled"`
+	Artifacts  []map[string]json.RawMessage `json:"artifacts"`
+	Languages  []string                     `json:"languages"`
+	// Overrides for other macOS versions and architectures, e.g. "arm64_sequoia", "sonoma"
+	Variations map[string]json.RawMessage `json:"variations"`
+	apiDeprecation
+}
+
+// Files removed by 'brew uninstall --zap', listed in the zap stanza of the cask artifacts, e.g.
+// {"zap": [{"trash": ["~/Library/Caches/foo", "~/Library/Preferences/foo.plist"], "rmdir": "~/foo"}]}
+func (c *apiCask) zapPaths() []string {
+	paths := []string{}
+	for _, artifact := range c.Artifacts {
+		raw, ok := artifact["zap"]
+		if !ok {
+			continue
+		}
+		var stanzas []map[string]any
+		if err := json.Unmarshal(raw, &stanzas); err != nil {
+			log.Printf("failed to decode zap stanza of %s: %v", c.Name, err)
+			continue
+		}
+		for _, stanza := range stanzas {
+			for _, directive := range []string{"trash", "delete", "rmdir"} {
+				switch v := stanza[directive].(type) {
+				case string:
+					paths = append(paths, v)
+				case []any:
+					for _, p := range v {
+						if str, ok := p.(string); ok {
+							paths = append(paths, str)
+						}
+					}
+				}
+			}
+		}
+	}
+	return paths
+}
+
+// Artifacts that install files from the download, e.g. {"app": ["Firefox.app"]} or
+// {"binary": ["$APPDIR/Foo.app/Contents/MacOS/foo", {"target": "foo"}]}
+var caskInstallArtifacts = []string{
+	"app", "suite", "binary", "pkg", "font", "manpage", "prefpane", "qlplugin", "mdimporter", "dictionary",
+	"colorpicker", "input_method", "internet_plugin", "keyboard_layout", "screen_saver", "service",
+	"audio_unit_plugin", "vst_plugin", "vst3_plugin", "artifact", "stage_only",
+}
+
+// What the cask installs, and whether installing or uninstalling it requires sudo
+func (c *apiCask) artifacts() ([]data.CaskArtifact, bool) {
+	artifacts := []data.CaskArtifact{}
+	sudo := false
+	for _, artifact := range c.Artifacts {
+		for _, kind := range caskInstallArtifacts {
+			raw, ok := artifact[kind]
+			if !ok {
+				continue
+			}
+			if target := artifactTarget(raw); target != "" {
+				artifacts = append(artifacts, data.CaskArtifact{Kind: kind, Target: target})
+			}
+			// Packages are installed with the macOS installer, which always runs as root
+			sudo = sudo || kind == "pkg"
+		}
+
+		// Installers run a script or ask to open an app, e.g. {"installer": [{"script": {"executable": "foo", "sudo": true}}]}
+		if raw, ok := artifact["installer"]; ok {
+			var installers []struct {
+				Manual string `json:"manual"`
+				Script struct {
+					Executable string `json:"executable"`
+					Sudo       bool   `json:"sudo"`
+				} `json:"script"`
+			}
+			if err := json.Unmarshal(raw, &installers); err != nil {
+				log.Printf("failed to decode installer stanza of %s: %v", c.Name, err)
+			}
+			for _, installer := range installers {
+				if installer.Manual != "" {
+					artifacts = append(artifacts, data.CaskArtifact{Kind: "installer", Target: installer.Manual})
+				} else if installer.Script.Executable != "" {
+					artifacts = append(artifacts, data.CaskArtifact{Kind: "installer", Target: installer.Script.Executable})
+					sudo = sudo || installer.Script.Sudo
+				}
+			}
+		}
+
+		// Launch agents and daemons are only listed in the uninstall stanza, e.g. {"uninstall": [{"launchctl": "com.foo.agent"}]}
+		if raw, ok := artifact["uninstall"]; ok {
+			var stanzas []map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &stanzas); err != nil {
+				log.Printf("failed to decode uninstall stanza of %s: %v", c.Name, err)
+			}
+			for _, stanza := range stanzas {
+				for _, label := range stringOrList(stanza["launchctl"]) {
+					artifacts = append(artifacts, data.CaskArtifact{Kind: "launchctl", Target: label})
+				}
+				// Removing receipts of packages or kernel extensions requires root
+				_, pkgutil := stanza["pkgutil"]
+				_, kext := stanza["kext"]
+				sudo = sudo || pkgutil || kext
+			}
+		}
+	}
+	return artifacts, sudo
+}
+
+// The installed name of an artifact, which is the target when it's renamed, otherwise the base name of the source
+func artifactTarget(raw json.RawMessage) string {
+	var values []any
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return ""
+	}
+	source := ""
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			if source == "" {
+				source = v
+			}
+		case map[string]any:
+			if target, ok := v["target"].(string); ok && target != "" {
+				return filepath.Base(target)
+			}
+		}
+	}
+	return filepath.Base(source)
+}
+
+// Decode a field that is either a single string or a list of strings
+func stringOrList(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil && single != "" {
+		return []string{single}
+	}
+	return nil
+}
+
+func (c *apiCask) variants() []string {
+	variants := make([]string, 0, len(c.Variations))
+	for name := range c.Variations {
+		variants = append(variants, name)
+	}
+	return util.Sort(variants)
+}
+
+func (f *apiFormula) bottleTags() []string {
+	tags := make([]string, 0, len(f.Bottle.Stable.Files))
+	for tag := range f.Bottle.Stable.Files {
+		tags = append(tags, tag)
+	}
+	return util.Sort(tags)
+}
+
+// Keg-only because macOS already ships the tool or library, like curl, sqlite or zlib
+func (f *apiFormula) providedByMacOS() bool {
+	switch f.KegOnlyReason.Reason {
+	case ":provided_by_macos", ":shadowed_by_macos":
+		return f.KegOnly
+	default:
+		return false
+	}
+}
+
+// Install options supported by the formula, including --HEAD when it can be built from the latest source
+func (f *apiFormula) options() []string {
+	options := []string{}
+	if f.Urls.Head.Url != "" {
+		options = append(options, "--HEAD")
+	}
+	for _, opt := range f.Options {
+		options = append(options, opt.Option)
+	}
+	return options
+}
+
+func (f *apiFormula) requiresMacOS() (bool, string) {
+	for _, req := range f.Requirements {
+		if req.Name == "macos" {
+			if req.Version == nil {
+				return true, ""
+			}
+			return true, fmt.Sprint(req.Version)
+		}
+	}
+	return false, ""
+}
+
+// Minimum macOS version, empty when the cask doesn't depend on macOS
+func (c *apiCask) minMacOSVersion() string {
+	if versions := c.Dependencies.MacOS[">="]; len(versions) > 0 {
+		return versions[0]
+	}
+	// Casks limited to a list of macOS versions, e.g. {"==": ["12", "13"]}
+	min := ""
+	for _, v := range c.Dependencies.MacOS["=="] {
+		if min == "" || data.CompareVersions(v, min) < 0 {
+			min = v
+		}
+	}
+	return min
 }
 
 type jwsJson struct {
@@ -107,7 +383,7 @@ type apiCaskAnalytics struct {
 func fetchFormula(dataChan chan []*apiFormula, errChan chan error) {
 	target := []*apiFormula{}
 	fetchJwsJsonWithCache(
-		apiFormulaURL,
+		apiUrl(apiFormulaPath),
 		filepath.Join(taproomCacheDir, formulaJwsJson),
 		&target,
 		dataChan,
@@ -117,7 +393,7 @@ func fetchFormula(dataChan chan []*apiFormula, errChan chan error) {
 func fetchCask(dataChan chan []*apiCask, errChan chan error) {
 	target := []*apiCask{}
 	fetchJwsJsonWithCache(
-		apiCaskURL,
+		apiUrl(apiCaskPath),
 		filepath.Join(taproomCacheDir, caskJwsJson),
 		&target,
 		dataChan,
@@ -127,7 +403,7 @@ func fetchCask(dataChan chan []*apiCask, errChan chan error) {
 func fetchFormulaAnalytics(dataChan chan apiFormulaAnalytics, errChan chan error) {
 	target := apiFormulaAnalytics{}
 	fetchJsonWithCache(
-		apiFormulaAnalytics90dURL,
+		apiUrl(apiFormulaAnalytics90dPath),
 		filepath.Join(taproomCacheDir, formulaAnalyticsJson),
 		&target,
 		dataChan,
@@ -137,7 +413,7 @@ func fetchFormulaAnalytics(dataChan chan apiFormulaAnalytics, errChan chan error
 func fetchCaskAnalytics(dataChan chan apiCaskAnalytics, errChan chan error) {
 	target := apiCaskAnalytics{}
 	fetchJsonWithCache(
-		apiCaskAnalytics90dURL,
+		apiUrl(apiCaskAnalytics90dPath),
 		filepath.Join(taproomCacheDir, caskAnalyticsJson),
 		&target,
 		dataChan,
@@ -160,18 +436,13 @@ func readCacheData(cachePath string) []byte {
 }
 
 // Fetch a JWS json and parse its payload to target
-func fetchJwsJsonWithCache[T any](url, cachePath string, target *T, dataChan chan T, errChan chan error) {
+func fetchJwsJsonWithCache[T any](url, cachePath string, target *[]*T, dataChan chan []*T, errChan chan error) {
 	data, err := fetchUrlWithCache(url, cachePath)
 	if err != nil {
 		errChan <- err
 		return
 	}
-	jws := jwsJson{}
-	if err := json.Unmarshal(data, &jws); err != nil {
-		errChan <- fmt.Errorf("failed to decode jws json from %s: %w", url, err)
-		return
-	}
-	if err := json.Unmarshal([]byte(jws.Payload), target); err != nil {
+	if err := decodeJws(bytes.NewReader(data), target); err != nil {
 		errChan <- fmt.Errorf("failed to decode json from %s: %w", url, err)
 		return
 	}
@@ -185,13 +456,73 @@ func fetchJsonWithCache[T any](url, cachePath string, target *T, dataChan chan T
 		errChan <- err
 		return
 	}
-	if err := json.Unmarshal(data, &target); err != nil {
+	if err := decodeJson(bytes.NewReader(data), target); err != nil {
 		errChan <- fmt.Errorf("failed to decode json from %s: %w", url, err)
 		return
 	}
 	dataChan <- *target
 }
 
+// Decode a JWS json, like formula.jws.json, and parse its payload, an array of formulae or casks, to target
+func decodeJws[T any](r io.Reader, target *[]*T) error {
+	jws := jwsJson{}
+	if err := json.NewDecoder(r).Decode(&jws); err != nil {
+		return fmt.Errorf("invalid jws: %w", err)
+	}
+	items, err := decodeArray[T]([]byte(jws.Payload))
+	if err != nil {
+		return err
+	}
+	*target = items
+	return nil
+}
+
+// Decode a json array with a worker per CPU, each taking a chunk of the items.
+// Decoding thousands of formulae and casks is the most CPU consuming part of loading.
+func decodeArray[T any](payload []byte) ([]*T, error) {
+	if runtime.GOMAXPROCS(0) == 1 {
+		// Splitting the array only costs time without other CPUs to share the work
+		items := []*T{}
+		return items, json.Unmarshal(payload, &items)
+	}
+
+	// Splitting the array only scans it, which is much faster than decoding the items
+	var raws []json.RawMessage
+	if err := json.Unmarshal(payload, &raws); err != nil {
+		return nil, err
+	}
+
+	items := make([]*T, len(raws))
+	workers := min(runtime.GOMAXPROCS(0), max(1, len(raws)))
+	chunkSize := (len(raws) + workers - 1) / workers
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		start, end := w*chunkSize, min(len(raws), (w+1)*chunkSize)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				item := new(T)
+				if err := json.Unmarshal(raws[i], item); err != nil {
+					errs[w] = err
+					return
+				}
+				items[i] = item
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func decodeJson(r io.Reader, target any) error {
+	return json.NewDecoder(r).Decode(target)
+}
+
 func fetchUrlWithCache(url, cachePath string) ([]byte, error) {
 	var jsonData []byte
 	if !*flagInvalidateCache {
@@ -203,19 +534,9 @@ func fetchUrlWithCache(url, cachePath string) ([]byte, error) {
 	}
 
 	// If cache is invalid or missing, fetch from URL
-	resp, err := http.Get(url)
+	body, err := downloadWithRetry(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad HTTP status fetching %s: %s", url, resp.Status)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body from %s: %w", url, err)
+		return nil, err
 	}
 
 	// Save to cache
@@ -229,3 +550,48 @@ func fetchUrlWithCache(url, cachePath string) ([]byte, error) {
 	log.Printf("Downloaded %s", url)
 	return body, nil
 }
+
+// Download a url, retrying dropped connections and server errors so flaky Wi-Fi doesn't stop loading
+func downloadWithRetry(url string) ([]byte, error) {
+	var err error
+	for attempt := range fetchAttempts {
+		if attempt > 0 {
+			delay := retryDelay(attempt)
+			log.Printf("retrying %s in %s: %v", url, delay, err)
+			time.Sleep(delay)
+		}
+		var body []byte
+		var retry bool
+		body, retry, err = download(url)
+		if err == nil || !retry {
+			return body, err
+		}
+	}
+	return nil, err
+}
+
+// Exponential backoff with jitter, so clients failing together don't retry together
+func retryDelay(attempt int) time.Duration {
+	backoff := fetchRetryBackoff << (attempt - 1)
+	return backoff + rand.N(backoff)
+}
+
+// Download a url once, telling whether a failure is worth retrying
+func download(url string) (body []byte, retry bool, err error) {
+	resp, err := apiClient().Get(url)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("bad HTTP status fetching %s: %s", url, resp.Status)
+	}
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read body from %s: %w", url, err)
+	}
+	return body, false, nil
+}
@@ -0,0 +1,177 @@
+package brew
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// A payload as large as the real formula.jws.json, repeating the formulae of the fixture
+func largeFormulaPayload(tb testing.TB, n int) []byte {
+	tb.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", formulaJwsJson))
+	if err != nil {
+		tb.Fatalf("failed to read fixture: %v", err)
+	}
+	jws := jwsJson{}
+	if err := json.Unmarshal(content, &jws); err != nil {
+		tb.Fatalf("failed to decode fixture: %v", err)
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal([]byte(jws.Payload), &raws); err != nil {
+		tb.Fatalf("failed to split fixture: %v", err)
+	}
+	items := make([]json.RawMessage, n)
+	for i := range items {
+		items[i] = raws[i%len(raws)]
+	}
+	payload, err := json.Marshal(items)
+	if err != nil {
+		tb.Fatalf("failed to encode payload: %v", err)
+	}
+	return payload
+}
+
+func TestDecodeArray(t *testing.T) {
+	payload := largeFormulaPayload(t, 100)
+	got, err := decodeArray[apiFormula](payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*apiFormula{}
+	if err := json.Unmarshal(payload, &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the same formulae as decoding them sequentially")
+	}
+
+	if _, err := decodeArray[apiFormula]([]byte(`[{"name": "wget"}, {"name": 1}]`)); err == nil {
+		t.Errorf("expected an error for an invalid item")
+	}
+}
+
+const benchmarkFormulae = 7000
+
+func BenchmarkDecodeFormulae(b *testing.B) {
+	payload := largeFormulaPayload(b, benchmarkFormulae)
+	b.SetBytes(int64(len(payload)))
+	b.ResetTimer()
+	for range b.N {
+		if _, err := decodeArray[apiFormula](payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Baseline of decoding the payload in a single json.Unmarshal
+func BenchmarkDecodeFormulaeSequential(b *testing.B) {
+	payload := largeFormulaPayload(b, benchmarkFormulae)
+	b.SetBytes(int64(len(payload)))
+	b.ResetTimer()
+	for range b.N {
+		formulae := []*apiFormula{}
+		if err := json.Unmarshal(payload, &formulae); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Decoding and merging the catalog, the CPU bound part of loading
+func BenchmarkLoadCatalog(b *testing.B) {
+	useTempCacheDir(b)
+	scope := *flagCatalog
+	*flagCatalog = string(CatalogFull)
+	defer func() { *flagCatalog = scope }()
+	payload := largeFormulaPayload(b, benchmarkFormulae)
+	b.ResetTimer()
+	for range b.N {
+		formulae, err := decodeArray[apiFormula](payload)
+		if err != nil {
+			b.Fatal(err)
+		}
+		processAllData(formulae, nil, apiFormulaAnalytics{}, apiCaskAnalytics{}, nil, nil, nil, nil)
+	}
+}
+
+// Heap kept alive by the packages of a loaded catalog, once the decoded payload is gone.
+// Sharing repeated strings and formatting sizes on demand took it from about 727 to 655 bytes per package.
+func BenchmarkCatalogMemory(b *testing.B) {
+	useTempCacheDir(b)
+	scope := *flagCatalog
+	*flagCatalog = string(CatalogFull)
+	defer func() { *flagCatalog = scope }()
+	payload := largeFormulaPayload(b, benchmarkFormulae)
+	var retained uint64
+	var stats runtime.MemStats
+	b.ResetTimer()
+	for range b.N {
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		before := stats.HeapAlloc
+		formulae, err := decodeArray[apiFormula](payload)
+		if err != nil {
+			b.Fatal(err)
+		}
+		packages := processAllData(formulae, nil, apiFormulaAnalytics{}, apiCaskAnalytics{}, nil, nil, nil, nil)
+		formulae = nil
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		retained += stats.HeapAlloc - before
+		runtime.KeepAlive(packages)
+	}
+	b.ReportMetric(float64(retained)/float64(b.N)/benchmarkFormulae, "retained-B/pkg")
+}
+
+func TestDownloadWithRetry(t *testing.T) {
+	backoff := fetchRetryBackoff
+	fetchRetryBackoff = time.Millisecond
+	defer func() { fetchRetryBackoff = backoff }()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case requests < 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+	defer server.Close()
+
+	body, err := downloadWithRetry(server.URL + "/formula.json")
+	if err != nil || string(body) != "[]" {
+		t.Errorf("expected the body after retrying server errors, got %q, %v", body, err)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	requests = 0
+	if _, err := downloadWithRetry(server.URL + "/missing"); err == nil {
+		t.Errorf("expected an error for a missing url")
+	}
+	if requests != 1 {
+		t.Errorf("expected a missing url not to be retried, got %d requests", requests)
+	}
+}
+
+func TestApiUrl(t *testing.T) {
+	t.Setenv("HOMEBREW_API_DOMAIN", "")
+	if got := apiUrl(apiFormulaPath); got != "https://formulae.brew.sh/api/formula.jws.json" {
+		t.Errorf("expected the default API, got %s", got)
+	}
+	t.Setenv("HOMEBREW_API_DOMAIN", "https://mirrors.ustc.edu.cn/homebrew-bottles/api/")
+	if got := apiUrl(apiFormulaPath); got != "https://mirrors.ustc.edu.cn/homebrew-bottles/api/formula.jws.json" {
+		t.Errorf("expected the mirror, got %s", got)
+	}
+}
@@ -0,0 +1,72 @@
+package brew
+
+import (
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/util"
+)
+
+const (
+	CompilePainLow    = "Low"
+	CompilePainMedium = "Medium"
+	CompilePainHigh   = "High"
+
+	// More missing build dependencies than this take a long time to install
+	maxMissingBuildDeps = 5
+)
+
+// Toolchains that take long to install when they aren't poured from a bottle either
+var heavyBuildDeps = map[string]bool{
+	"gcc":     true,
+	"ghc":     true,
+	"go":      true,
+	"llvm":    true,
+	"openjdk": true,
+	"rust":    true,
+	"swift":   true,
+}
+
+// How hard building a formula from source is likely to be
+type CompilePain struct {
+	Level            string
+	MissingBuildDeps []string // Build dependencies and their dependencies that would be installed first
+}
+
+func EstimateCompilePain(pkg *data.Package) CompilePain {
+	return estimateCompilePain(pkg, GetPackage)
+}
+
+func estimateCompilePain(pkg *data.Package, lookup func(string) *data.Package) CompilePain {
+	missing := []string{}
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		dep := lookup(name)
+		if dep == nil || dep.IsInstalled {
+			return
+		}
+		missing = append(missing, name)
+		for _, d := range dep.Dependencies {
+			visit(d)
+		}
+	}
+	for _, dep := range pkg.BuildDependencies {
+		visit(dep)
+	}
+
+	pain := CompilePain{Level: CompilePainLow, MissingBuildDeps: util.Sort(missing)}
+	if len(missing) > 0 {
+		pain.Level = CompilePainMedium
+	}
+	heavy := false
+	for _, name := range missing {
+		heavy = heavy || heavyBuildDeps[name]
+	}
+	if heavy || len(missing) > maxMissingBuildDeps || pkg.HasHighBuildErrorRate() {
+		pain.Level = CompilePainHigh
+	}
+	return pain
+}
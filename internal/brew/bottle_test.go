@@ -0,0 +1,36 @@
+package brew
+
+import (
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestEstimateCompilePain(t *testing.T) {
+	pkgs := map[string]*data.Package{
+		"pkgconf": {Name: "pkgconf", IsInstalled: true},
+		"cmake":   {Name: "cmake"},
+		"ninja":   {Name: "ninja"},
+		"rust":    {Name: "rust", Dependencies: []string{"libssh2"}},
+		"libssh2": {Name: "libssh2"},
+	}
+	lookup := func(name string) *data.Package { return pkgs[name] }
+
+	tests := []struct {
+		name      string
+		pkg       *data.Package
+		wantLevel string
+		wantDeps  int
+	}{
+		{"installed build deps", &data.Package{BuildDependencies: []string{"pkgconf"}}, CompilePainLow, 0},
+		{"missing build deps", &data.Package{BuildDependencies: []string{"cmake", "ninja", "pkgconf"}}, CompilePainMedium, 2},
+		{"heavy toolchain", &data.Package{BuildDependencies: []string{"rust"}}, CompilePainHigh, 2},
+		{"build errors", &data.Package{BuildErrors90d: 10, Installs90d: 100}, CompilePainHigh, 0},
+	}
+	for _, tt := range tests {
+		pain := estimateCompilePain(tt.pkg, lookup)
+		if pain.Level != tt.wantLevel || len(pain.MissingBuildDeps) != tt.wantDeps {
+			t.Errorf("%s: expected %s with %d missing deps, got %s with %v", tt.name, tt.wantLevel, tt.wantDeps, pain.Level, pain.MissingBuildDeps)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package brew
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+var flagBrewfile = Flags.String(
+	"brewfile",
+	"",
+	"Brewfile to compare installed packages with (default: $HOMEBREW_BUNDLE_FILE, ./Brewfile or ~/.Brewfile)",
+)
+
+// An entry of a Brewfile, e.g. `brew "wget"` or `cask "firefox", args: { appdir: "~/Applications" }`
+type BrewfileEntry struct {
+	Kind string // "tap", "brew" or "cask"
+	Name string
+}
+
+// Name of the package without the tap, e.g. "foo" for `brew "someone/tools/foo"`
+func (e BrewfileEntry) PackageName() string {
+	return e.Name[strings.LastIndex(e.Name, "/")+1:]
+}
+
+// Installed packages compared with a Brewfile
+type BrewfileDiff struct {
+	Path        string
+	Installed   []BrewfileEntry
+	Missing     []BrewfileEntry
+	Unsupported []BrewfileEntry // Missing casks taproom can't install, like .pkg installers that may need sudo
+	Extra       []*data.Package // Explicitly installed packages that aren't in the Brewfile
+}
+
+var brewfileEntryRegex = regexp.MustCompile(`^(tap|brew|cask)\s+["']([^"']+)["']`)
+
+// Find the Brewfile the same way `brew bundle` does, falling back to the global one
+func brewfilePath() string {
+	if *flagBrewfile != "" {
+		return *flagBrewfile
+	}
+	if path := brewSetting("HOMEBREW_BUNDLE_FILE"); path != "" {
+		return path
+	}
+	if _, err := os.Stat("Brewfile"); err == nil {
+		return "Brewfile"
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".Brewfile")
+}
+
+func parseBrewfile(r io.Reader) []BrewfileEntry {
+	entries := []BrewfileEntry{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := brewfileEntryRegex.FindStringSubmatch(line); m != nil {
+			entries = append(entries, BrewfileEntry{Kind: m[1], Name: m[2]})
+		}
+	}
+	return entries
+}
+
+// Compare the Brewfile with installed packages, entries of formulae from taps may use the full name
+func diffBrewfile(path string, entries []BrewfileEntry, pkgs []*data.Package) *BrewfileDiff {
+	diff := &BrewfileDiff{Path: path}
+	installed := make(map[string]*data.Package)
+	for _, pkg := range pkgs {
+		if pkg.IsInstalled {
+			for _, key := range brewfileKeys(pkg) {
+				installed[key] = pkg
+			}
+		}
+	}
+
+	listed := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Kind == "tap" {
+			continue
+		}
+		key := entry.Kind + " " + entry.Name
+		listed[key] = true
+		if _, ok := installed[key]; ok {
+			diff.Installed = append(diff.Installed, entry)
+		} else if pkg := brewfilePackage(pkgs, entry); pkg != nil && pkg.IsCask && !pkg.InstallSupported {
+			diff.Unsupported = append(diff.Unsupported, entry)
+		} else {
+			diff.Missing = append(diff.Missing, entry)
+		}
+	}
+
+	for _, pkg := range pkgs {
+		// App Store apps and whalebrew commands are listed as mas and whalebrew entries, which aren't compared
+		if pkg.IsInstalled && !pkg.InstalledAsDependency && pkg.IsManagedByBrew() {
+			keys := brewfileKeys(pkg)
+			if !listed[keys[0]] && !listed[keys[1]] {
+				diff.Extra = append(diff.Extra, pkg)
+			}
+		}
+	}
+	return diff
+}
+
+// The package of an entry among sorted packages, casks named like a formula are found by their tap
+func brewfilePackage(pkgs []*data.Package, entry BrewfileEntry) *data.Package {
+	pkg := findPackage(pkgs, entry.Name)
+	if pkg != nil && entry.Kind == "cask" && !pkg.IsCask {
+		pkg = findPackage(pkgs, caskTap+"/"+entry.Name)
+	}
+	return pkg
+}
+
+// Entries that may list a package: by its kind and either the name brew prefers or the name with its tap
+func brewfileKeys(pkg *data.Package) []string {
+	kind := "brew"
+	if pkg.IsCask {
+		kind = "cask"
+	}
+	return []string{kind + " " + pkg.UniqueName(), kind + " " + pkg.FullName()}
+}
+
+type BrewfileLoadedMsg struct {
+	Diff *BrewfileDiff
+	Err  error
+}
+
+// Read the Brewfile and compare it with installed packages in the background
+func LoadBrewfile() tea.Cmd {
+	return func() tea.Msg {
+		path := brewfilePath()
+		f, err := os.Open(path)
+		if err != nil {
+			return BrewfileLoadedMsg{Err: fmt.Errorf("failed to read Brewfile: %w", err)}
+		}
+		defer f.Close()
+		return BrewfileLoadedMsg{Diff: diffBrewfile(path, parseBrewfile(f), allBrewPackages)}
+	}
+}
+
+// Install everything missing from the Brewfile, including taps and other entries taproom doesn't list.
+// Unsupported casks are left out, brew bundle runs a copy of the Brewfile without them.
+func InstallBrewfile(diff *BrewfileDiff) tea.Cmd {
+	pkgs := []*data.Package{}
+	for _, entry := range diff.Missing {
+		if pkg := GetPackage(entry.Name); pkg != nil {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return tea.Batch(startCommand(), func() tea.Msg {
+		path := diff.Path
+		if len(diff.Unsupported) > 0 {
+			var err error
+			if path, err = writeBrewfileWithout(diff.Path, diff.Unsupported); err != nil {
+				ch := make(chan tea.Msg, 1)
+				ch <- CommandFinishMsg{Err: err}
+				close(ch)
+				return CommandOutputMsg{Ch: ch, Line: err.Error()}
+			}
+		}
+		return execute(BrewCommandInstall, pkgs, "bundle", "install", "--no-upgrade", "--file="+path)()
+	})
+}
+
+// Copy a Brewfile to the cache without some of its entries, returning the path of the copy
+func writeBrewfileWithout(path string, skipped []BrewfileEntry) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Brewfile: %w", err)
+	}
+	lines := []string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if m := brewfileEntryRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil &&
+			slices.Contains(skipped, BrewfileEntry{Kind: m[1], Name: m[2]}) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	copyPath := filepath.Join(taproomCacheDir, "Brewfile")
+	if err := os.MkdirAll(taproomCacheDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", taproomCacheDir, err)
+	}
+	if err := os.WriteFile(copyPath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", copyPath, err)
+	}
+	return copyPath, nil
+}
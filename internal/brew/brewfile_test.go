@@ -0,0 +1,99 @@
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestParseBrewfile(t *testing.T) {
+	brewfile := `# Taps
+tap "someone/tools"
+brew "wget"
+brew 'someone/tools/foo', restart_service: true
+cask "firefox", args: { appdir: "~/Applications" }
+mas "Xcode", id: 497799835
+  # brew "commented"
+`
+	entries := parseBrewfile(strings.NewReader(brewfile))
+	want := []BrewfileEntry{
+		{Kind: "tap", Name: "someone/tools"},
+		{Kind: "brew", Name: "wget"},
+		{Kind: "brew", Name: "someone/tools/foo"},
+		{Kind: "cask", Name: "firefox"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %v", len(want), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("expected entry %v, got %v", want[i], entries[i])
+		}
+	}
+}
+
+func TestDiffBrewfile(t *testing.T) {
+	// Sorted by name like loaded packages
+	pkgs := []*data.Package{
+		{Name: "bar", Tap: "homebrew/core"},
+		{Name: "bar", Tap: "someone/tools", Shadowed: true, IsInstalled: true},
+		{Name: "firefox", IsCask: true, InstallSupported: true},
+		{Name: "foo", Tap: "homebrew/core"},
+		{Name: "foo", Tap: "someone/tools", Shadowed: true, IsInstalled: true},
+		{Name: "jq", IsInstalled: true},
+		{Name: "openssl", IsInstalled: true, InstalledAsDependency: true},
+		{Name: "vpn-client", IsCask: true},
+		{Name: "wget", IsInstalled: true},
+	}
+	entries := []BrewfileEntry{
+		{Kind: "tap", Name: "someone/tools"},
+		{Kind: "brew", Name: "wget"},
+		{Kind: "brew", Name: "someone/tools/foo"},
+		{Kind: "brew", Name: "bar"},
+		{Kind: "cask", Name: "firefox"},
+		{Kind: "cask", Name: "vpn-client"},
+	}
+
+	diff := diffBrewfile("Brewfile", entries, pkgs)
+	if len(diff.Installed) != 2 || diff.Installed[0].Name != "wget" || diff.Installed[1].Name != "someone/tools/foo" {
+		t.Errorf("expected wget and foo to be installed, got %v", diff.Installed)
+	}
+	// bar of homebrew/core isn't installed, only the one of someone/tools is
+	if len(diff.Missing) != 2 || diff.Missing[0].Name != "bar" || diff.Missing[1].Name != "firefox" {
+		t.Errorf("expected bar and firefox to be missing, got %v", diff.Missing)
+	}
+	if len(diff.Unsupported) != 1 || diff.Unsupported[0].Name != "vpn-client" {
+		t.Errorf("expected vpn-client to be skipped as unsupported, got %v", diff.Unsupported)
+	}
+	if len(diff.Extra) != 2 || diff.Extra[0].UniqueName() != "someone/tools/bar" || diff.Extra[1].Name != "jq" {
+		t.Errorf("expected someone/tools/bar and jq to be installed but not in the Brewfile, got %v", diff.Extra)
+	}
+}
+
+func TestWriteBrewfileWithout(t *testing.T) {
+	useTempCacheDir(t)
+	path := filepath.Join(t.TempDir(), "Brewfile")
+	brewfile := `tap "someone/tools"
+brew "wget"
+cask "vpn-client"
+cask "firefox"
+`
+	if err := os.WriteFile(path, []byte(brewfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	copyPath, err := writeBrewfileWithout(path, []BrewfileEntry{{Kind: "cask", Name: "vpn-client"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	content, err := os.ReadFile(copyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "tap \"someone/tools\"\nbrew \"wget\"\ncask \"firefox\"\n"; string(content) != want {
+		t.Errorf("expected %q, got %q", want, content)
+	}
+}
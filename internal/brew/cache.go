@@ -0,0 +1,154 @@
+package brew
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Downloads are named like "<sha256>--wget--1.24.5.arm64_sonoma.bottle.tar.gz"
+var cacheDownloadRegex = regexp.MustCompile(`^(?:[0-9a-f]{64}--)?(.+?)--`)
+
+// Files in the download cache of brew that belong to a package, or to a directory like api or bootsnap
+type CacheEntry struct {
+	Name      string
+	IsPackage bool
+	Paths     []string // Files and the symlinks pointing to them
+	Files     int
+	Size      int64 // Size in kbs
+}
+
+type CacheReport struct {
+	Path    string
+	Entries []*CacheEntry
+	Total   int64 // Size in kbs
+}
+
+type CacheLoadedMsg struct {
+	Report *CacheReport
+	Err    error
+}
+
+type CacheEntryDeletedMsg struct {
+	Entry *CacheEntry
+	Err   error
+}
+
+// Scan the download cache of brew in the background
+func LoadCache() tea.Cmd {
+	return func() tea.Msg {
+		output, err := exec.Command("brew", "--cache").Output()
+		if err != nil {
+			return CacheLoadedMsg{Err: fmt.Errorf("failed to locate brew cache: %w", err)}
+		}
+		report, err := scanCache(strings.TrimSpace(string(output)))
+		return CacheLoadedMsg{Report: report, Err: err}
+	}
+}
+
+// Name of the package a cached download belongs to, empty if it's not a download
+func cachePackageName(filename string) string {
+	if m := cacheDownloadRegex.FindStringSubmatch(filename); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func scanCache(root string) (*CacheReport, error) {
+	entries := make(map[string]*CacheEntry)
+	symlinks := make(map[string][]string) // Target to symlinks pointing to it
+	files := make(map[string]*CacheEntry) // File to the entry it's counted in
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			if target, err := filepath.EvalSymlinks(path); err == nil {
+				symlinks[target] = append(symlinks[target], path)
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		top, _, nested := strings.Cut(rel, string(filepath.Separator))
+		key, isPackage := "["+top+"]", false
+		if !nested || top == "downloads" || top == "Cask" {
+			if name := cachePackageName(d.Name()); name != "" {
+				key, isPackage = name, true
+			} else if !nested {
+				key = "[other]"
+			}
+		}
+
+		entry, ok := entries[key]
+		if !ok {
+			entry = &CacheEntry{Name: key, IsPackage: isPackage}
+			entries[key] = entry
+		}
+		entry.Paths = append(entry.Paths, path)
+		entry.Files++
+		entry.Size += (info.Size() + 1023) / 1024
+		files[path] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+
+	// Resolve the root too, the targets of symlinks are resolved paths
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		resolvedRoot = root
+	}
+	for target, links := range symlinks {
+		rel, err := filepath.Rel(resolvedRoot, target)
+		if err != nil {
+			continue
+		}
+		if entry, ok := files[filepath.Join(root, rel)]; ok {
+			entry.Paths = append(entry.Paths, links...)
+		}
+	}
+
+	report := CacheReport{Path: root, Entries: []*CacheEntry{}}
+	for _, entry := range entries {
+		report.Entries = append(report.Entries, entry)
+		report.Total += entry.Size
+	}
+	SortCacheEntries(report.Entries, true)
+	return &report, nil
+}
+
+// Sort entries by size, largest first, or by name
+func SortCacheEntries(entries []*CacheEntry, bySize bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if bySize && entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// Delete all cached files of an entry and the symlinks pointing to them
+func DeleteCacheEntry(entry *CacheEntry) tea.Cmd {
+	return func() tea.Msg {
+		for _, path := range entry.Paths {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return CacheEntryDeletedMsg{Entry: entry, Err: fmt.Errorf("failed to delete %s: %w", path, err)}
+			}
+		}
+		return CacheEntryDeletedMsg{Entry: entry}
+	}
+}
@@ -0,0 +1,74 @@
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanCache(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel string, size int) string {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	sha := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	bottle := write("downloads/"+sha+"--wget--1.24.5.arm64_sonoma.bottle.tar.gz", 4096)
+	write("downloads/"+sha+"--wget--1.25.0.arm64_sonoma.bottle.tar.gz", 2048)
+	write("downloads/"+sha+"--firefox--133.0.dmg", 10240)
+	write("api/formula.jws.json", 1024)
+	write("descriptions.json", 100)
+	link := filepath.Join(root, "wget--1.24.5.arm64_sonoma.bottle.tar.gz")
+	if err := os.Symlink(bottle, link); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := scanCache(root)
+	if err != nil {
+		t.Fatalf("failed to scan cache: %v", err)
+	}
+	if report.Total != 18 {
+		t.Errorf("expected 18KB in total, got %d", report.Total)
+	}
+
+	want := []struct {
+		name      string
+		isPackage bool
+		files     int
+		paths     int
+		size      int64
+	}{
+		{"firefox", true, 1, 1, 10},
+		{"wget", true, 2, 3, 6},
+		{"[api]", false, 1, 1, 1},
+		{"[other]", false, 1, 1, 1},
+	}
+	if len(report.Entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(report.Entries))
+	}
+	for i, w := range want {
+		e := report.Entries[i]
+		if e.Name != w.name || e.IsPackage != w.isPackage || e.Files != w.files || len(e.Paths) != w.paths || e.Size != w.size {
+			t.Errorf("expected %+v, got %+v", w, e)
+		}
+	}
+
+	SortCacheEntries(report.Entries, false)
+	if report.Entries[0].Name != "[api]" || report.Entries[3].Name != "wget" {
+		t.Errorf("expected entries sorted by name, got %s first and %s last", report.Entries[0].Name, report.Entries[3].Name)
+	}
+
+	wget := report.Entries[3]
+	if msg := DeleteCacheEntry(wget)().(CacheEntryDeletedMsg); msg.Err != nil {
+		t.Fatalf("failed to delete cache entry: %v", msg.Err)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Errorf("expected the symlink to the deleted download to be removed")
+	}
+}
@@ -0,0 +1,71 @@
+package brew
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Why the cache was moved to a per-user directory, empty when the usual one is used
+var cacheDirWarning string
+
+// Warning to show when the cache directory couldn't be used, like when it belongs to another
+// user after running taproom with sudo
+func CacheDirWarning() string {
+	return cacheDirWarning
+}
+
+// The cache directory, or a per-user one in the temp directory when it belongs to another user or
+// can't be written, so that cache writes don't fail one by one or leave files other users can't update
+func usableCacheDir(dir string) string {
+	uid := os.Geteuid()
+	problem := cacheDirProblem(dir, uid)
+	if problem == "" {
+		return dir
+	}
+	fallback := filepath.Join(os.TempDir(), fmt.Sprintf("taproom-%d", uid))
+	if cacheDirProblem(fallback, uid) != "" {
+		cacheDirWarning = fmt.Sprintf("Cache %s %s, downloads and sizes won't be cached", dir, problem)
+		log.Print(cacheDirWarning)
+		return dir
+	}
+	cacheDirWarning = fmt.Sprintf("Cache %s %s, using %s instead", dir, problem, fallback)
+	log.Print(cacheDirWarning)
+	return fallback
+}
+
+// Why the user can't use the cache directory, empty when it's fine
+func cacheDirProblem(dir string, uid int) string {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Sprintf("can't be created (%v)", err)
+	}
+	// Checked before writing, root can write anywhere but shouldn't leave files a user can't update
+	var owner string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != uid {
+			owner = fmt.Sprintf("uid %d", stat.Uid)
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if owner != "" {
+		return fmt.Sprintf("has files owned by another user (%s)", owner)
+	}
+	probe, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return "isn't writable"
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return ""
+}
@@ -0,0 +1,25 @@
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCacheDirProblem(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "taproom")
+	uid := os.Geteuid()
+	if problem := cacheDirProblem(dir, uid); problem != "" {
+		t.Errorf("expected no problem with a new cache dir, got %q", problem)
+	}
+	if err := os.WriteFile(filepath.Join(dir, sizesJson), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if problem := cacheDirProblem(dir, uid); problem != "" {
+		t.Errorf("expected no problem with own files, got %q", problem)
+	}
+	if problem := cacheDirProblem(dir, uid+1); !strings.Contains(problem, "another user") {
+		t.Errorf("expected files of another user to be reported, got %q", problem)
+	}
+}
@@ -0,0 +1,149 @@
+package brew
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+type CatalogScope string
+
+const (
+	CatalogFull    CatalogScope = "full"
+	CatalogTrimmed CatalogScope = "trimmed" // Installed, most popular and third-party tap packages only
+
+	catalogConfigJson = "catalog.json"
+)
+
+var (
+	flagCatalog    = Flags.String("catalog", "", "Packages to load: full, trimmed (installed, most popular and third-party taps only)")
+	flagCatalogTop = Flags.Int("catalog-top", 2000, "Number of most popular formulae and casks kept in a trimmed catalog")
+)
+
+var taproomConfigDir = func() string {
+	home, err := os.UserHomeDir()
+	if err == nil {
+		return filepath.Join(home, ".config", "taproom")
+	} else {
+		log.Printf("failed to locate user's home dir: %v", err)
+		return ".config"
+	}
+}()
+
+type catalogConfig struct {
+	Scope CatalogScope `json:"scope"`
+}
+
+func loadCatalogConfig() *catalogConfig {
+	data, err := os.ReadFile(filepath.Join(taproomConfigDir, catalogConfigJson))
+	if err != nil {
+		return nil
+	}
+	config := catalogConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		log.Printf("failed to decode %s: %v", catalogConfigJson, err)
+		return nil
+	}
+	return &config
+}
+
+// Whether the catalog scope was chosen, otherwise users are asked on the first run
+func IsCatalogScopeChosen() bool {
+	return *flagCatalog != "" || loadCatalogConfig() != nil
+}
+
+// Scope of the catalog for the current session, from the flag or the saved choice
+func GetCatalogScope() CatalogScope {
+	scope := CatalogScope(*flagCatalog)
+	if scope == "" {
+		if config := loadCatalogConfig(); config != nil {
+			scope = config.Scope
+		}
+	}
+	if scope == CatalogTrimmed {
+		return CatalogTrimmed
+	}
+	return CatalogFull
+}
+
+// Save the scope used from now on, it's overridden by the --catalog flag
+func SaveCatalogScope(scope CatalogScope) {
+	data, err := json.Marshal(catalogConfig{Scope: scope})
+	if err != nil {
+		log.Printf("failed to encode %s: %v", catalogConfigJson, err)
+		return
+	}
+	path := filepath.Join(taproomConfigDir, catalogConfigJson)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			log.Printf("failed to write %s: %v", path, err)
+		}
+	}
+}
+
+func CatalogTop() int {
+	return *flagCatalogTop
+}
+
+// Load the full catalog for the rest of the session regardless of the saved scope
+func LoadFullCatalog() {
+	*flagCatalog = string(CatalogFull)
+}
+
+// Keep installed packages, the top formulae and casks by installs, packages from third-party taps,
+// and all their dependencies, so that dependency lookups always find a package. Packages are sorted,
+// dependencies find the package brew resolves them to.
+func trimCatalog(pkgs []*data.Package, top int) []*data.Package {
+	kept := make(map[string]bool) // By unique name, packages of different taps can share a name
+	var keep func(pkg *data.Package)
+	keep = func(pkg *data.Package) {
+		if pkg == nil || kept[pkg.UniqueName()] {
+			return
+		}
+		kept[pkg.UniqueName()] = true
+		for _, dep := range pkg.Dependencies {
+			keep(findPackage(pkgs, dep))
+		}
+	}
+
+	popular := make([]*data.Package, len(pkgs))
+	copy(popular, pkgs)
+	sort.SliceStable(popular, func(i, j int) bool {
+		return popular[i].Installs90d > popular[j].Installs90d
+	})
+	formulae, casks := 0, 0
+	for _, pkg := range popular {
+		if pkg.IsCask && casks < top {
+			casks++
+			keep(pkg)
+		} else if !pkg.IsCask && formulae < top {
+			formulae++
+			keep(pkg)
+		}
+	}
+	for _, pkg := range pkgs {
+		if pkg.IsInstalled || (pkg.Tap != coreTap && pkg.Tap != caskTap) {
+			keep(pkg)
+		}
+	}
+
+	trimmed := []*data.Package{}
+	for _, pkg := range pkgs {
+		if !kept[pkg.UniqueName()] {
+			continue
+		}
+		dependents := []string{}
+		for _, name := range pkg.Dependents {
+			if kept[name] {
+				dependents = append(dependents, name)
+			}
+		}
+		pkg.Dependents = dependents
+		trimmed = append(trimmed, pkg)
+	}
+	return trimmed
+}
@@ -0,0 +1,36 @@
+package brew
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestTrimCatalog(t *testing.T) {
+	pkgs := []*data.Package{
+		{Name: "popular", Tap: coreTap, Installs90d: 1000, Dependencies: []string{"lib"}},
+		{Name: "lib", Tap: coreTap, Dependents: []string{"popular", "rare"}},
+		{Name: "rare", Tap: coreTap, Installs90d: 1, Dependencies: []string{"lib"}},
+		{Name: "installed", Tap: coreTap, IsInstalled: true},
+		{Name: "mine", Tap: "someone/tools"},
+		{Name: "popular-app", Tap: caskTap, IsCask: true, Installs90d: 500},
+		{Name: "rare-app", Tap: caskTap, IsCask: true, Installs90d: 5},
+		// Named like a kept cask, but not kept itself
+		{Name: "popular-app", Tap: coreTap, Installs90d: 2},
+	}
+	sortPackages(pkgs)
+
+	trimmed := trimCatalog(pkgs, 1)
+	names := []string{}
+	for _, pkg := range trimmed {
+		names = append(names, pkg.UniqueName())
+	}
+	want := []string{"installed", "lib", "mine", "popular", "homebrew/cask/popular-app"}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected trimmed catalog %v, got %v", want, names)
+	}
+	if got := pkgs[1].Dependents; !slices.Equal(got, []string{"popular"}) {
+		t.Errorf("expected dependents of lib to only have kept packages, got %v", got)
+	}
+}
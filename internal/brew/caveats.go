@@ -0,0 +1,165 @@
+package brew
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const caveatsJson = "caveats.json"
+
+// Lines telling which package brew is working on, e.g. "==> Pouring wget--1.24.5.arm64_sonoma.bottle.tar.gz",
+// "==> Installing wget dependency: openssl@3", "==> Installing Cask firefox" or "==> Upgrading wget"
+var (
+	caveatsPackageRegex    = regexp.MustCompile(`^==> (?:Pouring|Upgrading|Reinstalling|Installing(?: Cask)?) ([^\s:]+)`)
+	caveatsDependencyRegex = regexp.MustCompile(`^==> Installing \S+ dependency: (\S+)`)
+)
+
+// Caveats brew printed when installing a package, kept until they're dismissed
+type Caveat struct {
+	Pkg  string    `json:"pkg"`
+	Time time.Time `json:"time"`
+	Text []string  `json:"text"`
+}
+
+var caveatsMu sync.Mutex
+
+// Extract the caveats of packages from the output of an install or upgrade,
+// including the ones of dependencies installed along the way
+func ParseCaveats(lines []string, pkgs []*data.Package) []Caveat {
+	return parseCaveats(lines, pkgs, func(name string) bool { return GetPackage(name) != nil })
+}
+
+func parseCaveats(lines []string, pkgs []*data.Package, isPackage func(string) bool) []Caveat {
+	current := ""
+	if len(pkgs) == 1 {
+		current = pkgs[0].Name
+	}
+	texts := make(map[string][]string)
+	order := []string{}
+	inCaveats := false
+	for _, line := range lines {
+		if line == "==> Caveats" {
+			inCaveats = true
+			if current != "" {
+				// Caveats are printed again in the summary after installing multiple packages
+				texts[current] = nil
+			}
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "==> "); ok {
+			if inCaveats && isPackage(name) {
+				// In the summary, the caveats of each package start with its name
+				current = name
+				texts[current] = nil
+				continue
+			}
+			inCaveats = false
+			if m := caveatsDependencyRegex.FindStringSubmatch(line); m != nil {
+				current = m[1]
+			} else if m := caveatsPackageRegex.FindStringSubmatch(line); m != nil {
+				// Bottles are named like "wget--1.24.5.arm64_sonoma.bottle.tar.gz"
+				if name, _, _ := strings.Cut(m[1], "--"); isPackage(name) {
+					current = name
+				}
+			}
+			continue
+		}
+		// Lines taproom adds for each brew command it runs
+		if strings.HasPrefix(line, "> brew ") {
+			inCaveats = false
+			continue
+		}
+		if !inCaveats || current == "" {
+			continue
+		}
+		if !slices.Contains(order, current) {
+			order = append(order, current)
+		}
+		texts[current] = append(texts[current], line)
+	}
+
+	now := time.Now()
+	caveats := []Caveat{}
+	for _, name := range order {
+		text := trimBlankLines(texts[name])
+		if len(text) > 0 {
+			caveats = append(caveats, Caveat{Pkg: name, Time: now, Text: text})
+		}
+	}
+	return caveats
+}
+
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Load caveats that haven't been dismissed, newest first
+func LoadCaveats() []Caveat {
+	caveatsMu.Lock()
+	defer caveatsMu.Unlock()
+	return loadCaveats()
+}
+
+func loadCaveats() []Caveat {
+	caveats := []Caveat{}
+	content, err := os.ReadFile(filepath.Join(taproomCacheDir, caveatsJson))
+	if err != nil {
+		return caveats
+	}
+	if err := json.Unmarshal(content, &caveats); err != nil {
+		log.Printf("failed to decode %s: %v", caveatsJson, err)
+	}
+	return caveats
+}
+
+func saveCaveats(caveats []Caveat) {
+	content, err := json.Marshal(caveats)
+	if err != nil {
+		log.Printf("failed to encode caveats: %v", err)
+		return
+	}
+	path := filepath.Join(taproomCacheDir, caveatsJson)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("failed to create %s: %v", filepath.Dir(path), err)
+		return
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		log.Printf("failed to write %s: %v", path, err)
+	}
+}
+
+// Keep new caveats, replacing earlier ones of the same packages
+func AddCaveats(added []Caveat) {
+	caveatsMu.Lock()
+	defer caveatsMu.Unlock()
+
+	caveats := slices.Clone(added)
+	for _, c := range loadCaveats() {
+		if !slices.ContainsFunc(added, func(a Caveat) bool { return a.Pkg == c.Pkg }) {
+			caveats = append(caveats, c)
+		}
+	}
+	saveCaveats(caveats)
+}
+
+func DismissCaveat(pkg string) {
+	caveatsMu.Lock()
+	defer caveatsMu.Unlock()
+
+	saveCaveats(slices.DeleteFunc(loadCaveats(), func(c Caveat) bool { return c.Pkg == pkg }))
+}
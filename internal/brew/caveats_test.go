@@ -0,0 +1,61 @@
+package brew
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestParseCaveats(t *testing.T) {
+	known := map[string]bool{"postgresql@16": true, "icu4c": true, "wget": true}
+	isPackage := func(name string) bool { return known[name] }
+	output := []string{
+		"> brew install postgresql@16",
+		"==> Installing postgresql@16 dependency: icu4c",
+		"==> Pouring icu4c--76.1.arm64_sonoma.bottle.tar.gz",
+		"==> Caveats",
+		"icu4c is keg-only, which means it was not symlinked into /opt/homebrew.",
+		"==> Summary",
+		"🍺  /opt/homebrew/Cellar/icu4c/76.1: 277 files, 81.1MB",
+		"==> Pouring postgresql@16--16.6.arm64_sonoma.bottle.tar.gz",
+		"==> Caveats",
+		"To start postgresql@16 now and restart at login:",
+		"  brew services start postgresql@16",
+		"==> Summary",
+		"==> Caveats",
+		"==> icu4c",
+		"icu4c is keg-only, which means it was not symlinked into /opt/homebrew.",
+		"==> postgresql@16",
+		"To start postgresql@16 now and restart at login:",
+		"  brew services start postgresql@16",
+	}
+	caveats := parseCaveats(output, []*data.Package{{Name: "postgresql@16"}}, isPackage)
+	if len(caveats) != 2 || caveats[0].Pkg != "icu4c" || caveats[1].Pkg != "postgresql@16" {
+		t.Fatalf("expected caveats of icu4c and postgresql@16, got %+v", caveats)
+	}
+	want := []string{"To start postgresql@16 now and restart at login:", "  brew services start postgresql@16"}
+	if !slices.Equal(caveats[1].Text, want) {
+		t.Errorf("expected caveats %q without the repeated summary, got %q", want, caveats[1].Text)
+	}
+
+	if caveats := parseCaveats([]string{"==> Pouring wget--1.24.5.arm64_sonoma.bottle.tar.gz", "==> Summary"}, nil, isPackage); len(caveats) != 0 {
+		t.Errorf("expected no caveats, got %+v", caveats)
+	}
+}
+
+func TestCaveatReminders(t *testing.T) {
+	useTempCacheDir(t)
+
+	AddCaveats([]Caveat{{Pkg: "wget", Text: []string{"old"}}, {Pkg: "curl", Text: []string{"keg-only"}}})
+	AddCaveats([]Caveat{{Pkg: "wget", Text: []string{"new"}}})
+	caveats := LoadCaveats()
+	if len(caveats) != 2 || caveats[0].Pkg != "wget" || caveats[0].Text[0] != "new" {
+		t.Fatalf("expected the newer caveat of wget first, got %+v", caveats)
+	}
+
+	DismissCaveat("wget")
+	if caveats := LoadCaveats(); len(caveats) != 1 || caveats[0].Pkg != "curl" {
+		t.Errorf("expected only curl left, got %+v", caveats)
+	}
+}
@@ -0,0 +1,70 @@
+package brew
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/util"
+)
+
+// Lines of `brew cleanup --dry-run`, e.g. "Would remove: /opt/homebrew/Cellar/wget/1.24.5 (91 files, 4.4MB)"
+var cleanupLineRegex = regexp.MustCompile(`^Would remove: (.+) \((?:([\d,]+) files?, )?([\d.]+[KMG]?B)\)$`)
+
+type CleanupItem struct {
+	Path  string
+	Files int
+	Size  int64 // Size in kbs
+}
+
+// What `brew cleanup --prune=all` would remove
+type CleanupPreview struct {
+	Items []CleanupItem // Largest first
+	Total int64         // Reclaimable size in kbs
+}
+
+type CleanupPreviewMsg struct {
+	Preview *CleanupPreview
+	Err     error
+}
+
+// Run cleanup as a dry run in the background to list what it would remove
+func PreviewCleanup() tea.Cmd {
+	return func() tea.Msg {
+		var errOutput bytes.Buffer
+		cmd := exec.Command("brew", "cleanup", "--prune=all", "--dry-run")
+		cmd.Stderr = &errOutput
+		output, err := cmd.Output()
+		if err != nil {
+			return CleanupPreviewMsg{Err: fmt.Errorf("failed to run brew cleanup --dry-run: %w: %s", err, errOutput.String())}
+		}
+		return CleanupPreviewMsg{Preview: parseCleanupDryRun(bytes.NewReader(output))}
+	}
+}
+
+func parseCleanupDryRun(r io.Reader) *CleanupPreview {
+	preview := CleanupPreview{Items: []CleanupItem{}}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := cleanupLineRegex.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		item := CleanupItem{Path: m[1]}
+		item.Files, _ = strconv.Atoi(strings.ReplaceAll(m[2], ",", ""))
+		item.Size, _ = util.ParseSize(m[3])
+		preview.Items = append(preview.Items, item)
+		preview.Total += item.Size
+	}
+	sort.SliceStable(preview.Items, func(i, j int) bool {
+		return preview.Items[i].Size > preview.Items[j].Size
+	})
+	return &preview
+}
@@ -0,0 +1,28 @@
+package brew
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCleanupDryRun(t *testing.T) {
+	output := `Would remove: /Users/me/Library/Caches/Homebrew/wget--1.24.5.arm64_sonoma.bottle.tar.gz (1.5MB)
+Would remove: /opt/homebrew/Cellar/openssl@3/3.3.2 (7,012 files, 28.3MB)
+Would remove: /Users/me/Library/Caches/Homebrew/downloads/abc--foo.json (512B)
+Would remove: /Users/me/Library/Logs/Homebrew/llvm (2 files, 1GB)
+==> This operation would free approximately 1.0GB of disk space.
+`
+	preview := parseCleanupDryRun(strings.NewReader(output))
+	if len(preview.Items) != 4 {
+		t.Fatalf("expected 4 items, got %d", len(preview.Items))
+	}
+	if item := preview.Items[0]; item.Path != "/Users/me/Library/Logs/Homebrew/llvm" || item.Files != 2 || item.Size != 1<<20 {
+		t.Errorf("expected the llvm logs to be the largest item, got %+v", item)
+	}
+	if item := preview.Items[1]; item.Files != 7012 || item.Size != 28979 {
+		t.Errorf("expected 7012 files of 28979KB, got %+v", item)
+	}
+	if want := int64(1<<20 + 28979 + 1536); preview.Total != want {
+		t.Errorf("expected %dKB in total, got %d", want, preview.Total)
+	}
+}
@@ -2,14 +2,52 @@ package brew
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"log"
+	"os"
 	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
-	"taproom/internal/data"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/util"
+)
+
+const (
+	batchErrorsAbort    = "abort"
+	batchErrorsContinue = "continue"
+)
+
+var flagBatchErrors = Flags.String(
+	"batch-errors",
+	batchErrorsAbort,
+	"When upgrading or uninstalling multiple packages: abort (stop at the first error) or continue (run packages one by one and report failures at the end)",
+)
+
+var flagCaskLanguages = Flags.StringSlice(
+	"cask-languages",
+	[]string{},
+	"Preferred languages of localized casks in order, e.g. de,fr (default: brew picks one from the system languages)",
+)
+
+var flagCaskDirs = Flags.StringSlice(
+	"cask-dirs",
+	[]string{},
+	"Where casks install their artifacts as kind=dir (comma separated no space), e.g. appdir=~/Applications,fontdir=~/Library/Fonts",
+)
+
+var flagNoQuarantine = Flags.Bool(
+	"no-quarantine",
+	false,
+	"Install casks with --no-quarantine, skipping the Gatekeeper check of downloaded apps (pass --quarantine with I to keep it for one install)",
 )
 
 // --- Command Execution Messages ---
@@ -19,10 +57,25 @@ type CommandOutputMsg struct {
 	Ch   chan tea.Msg
 	Line string
 }
+
+// Download progress parsed from curl's progress bar, in the range of [0, 1]
+type CommandProgressMsg struct {
+	Ch      chan tea.Msg
+	Percent float64
+}
+
+// Estimated remaining time of a command upgrading multiple packages
+type CommandEtaMsg struct {
+	Ch        chan tea.Msg
+	Remaining time.Duration
+	Done      int
+	Total     int
+}
 type CommandFinishMsg struct {
 	Err     error
 	Command BrewCommand
 	Pkgs    []*data.Package
+	Failed  []*data.Package // Set when packages ran one by one, the others succeeded
 }
 
 type BrewCommand string
@@ -32,11 +85,33 @@ const (
 	BrewCommandUpgrade    BrewCommand = "upgrade"
 	BrewCommandInstall    BrewCommand = "install"
 	BrewCommandUninstall  BrewCommand = "uninstall"
+	BrewCommandReinstall  BrewCommand = "reinstall"
 	BrewCommandPin        BrewCommand = "pin"
 	BrewCommandUnpin      BrewCommand = "unpin"
+	BrewCommandLink       BrewCommand = "link"
+	BrewCommandUnlink     BrewCommand = "unlink"
 	BrewCommandCleanup    BrewCommand = "cleanup"
+	BrewCommandTapRepair  BrewCommand = "tapRepair"
+	BrewCommandMigrate    BrewCommand = "migrate"
+	BrewCommandMasUpgrade BrewCommand = "masUpgrade"
+	// Uninstall a whalebrew command
+	BrewCommandWhalebrewUninstall BrewCommand = "whalebrewUninstall"
 )
 
+const brewTool = "brew"
+
+// Program running the command, App Store apps are upgraded with mas and whalebrew commands removed with whalebrew
+func (c BrewCommand) tool() string {
+	switch c {
+	case BrewCommandMasUpgrade:
+		return masTool
+	case BrewCommandWhalebrewUninstall:
+		return whalebrewTool
+	default:
+		return brewTool
+	}
+}
+
 // --- Command Functions ---
 
 func startCommand() tea.Cmd {
@@ -51,64 +126,171 @@ func StreamOutput(ch chan tea.Msg) tea.Cmd {
 	}
 }
 
-func feedOutput(ch chan tea.Msg, pipe io.ReadCloser) {
+// curl's progress bar (used by brew for downloads) redraws itself with carriage returns,
+// e.g. "######################                                  31.4%"
+var curlProgressRegex = regexp.MustCompile(`^#*\s*(\d{1,3}(?:\.\d+)?)%$`)
+
+func feedOutput(ch chan tea.Msg, pipe io.ReadCloser, onLine func(string)) {
 	scanner := bufio.NewScanner(pipe)
+	scanner.Split(scanLinesOrCarriageReturns)
 	for scanner.Scan() {
-		ch <- CommandOutputMsg{Ch: ch, Line: scanner.Text()}
+		line := scanner.Text()
+		if percent, ok := parseProgress(line); ok {
+			ch <- CommandProgressMsg{Ch: ch, Percent: percent}
+		} else {
+			ch <- CommandOutputMsg{Ch: ch, Line: line}
+			onLine(line)
+		}
+	}
+}
+
+func parseProgress(line string) (float64, bool) {
+	m := curlProgressRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(m[1], 64)
+	if err != nil || percent > 100 {
+		return 0, false
 	}
+	return percent / 100, true
+}
+
+// Like bufio.ScanLines but also split on '\r', so progress bar updates come in as separate lines
+func scanLinesOrCarriageReturns(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
 }
 
 func execute(BrewCommand BrewCommand, pkgs []*data.Package, args ...string) tea.Cmd {
+	return executeRuns(BrewCommand, pkgs, []brewRun{{pkgs: pkgs, args: args}})
+}
+
+// A single brew command among the ones run for a taproom command
+type brewRun struct {
+	pkgs        []*data.Package
+	args        []string
+	stopOnError bool // Later runs rely on this one, skip them when it fails
+}
+
+// Run each package in its own brew command, so a failed package doesn't stop the others
+func executeEach(BrewCommand BrewCommand, pkgs []*data.Package, argsOf func(*data.Package) []string) tea.Cmd {
+	runs := make([]brewRun, len(pkgs))
+	for i, pkg := range pkgs {
+		runs[i] = brewRun{pkgs: []*data.Package{pkg}, args: argsOf(pkg)}
+	}
+	return executeRuns(BrewCommand, pkgs, runs)
+}
+
+func executeRuns(BrewCommand BrewCommand, pkgs []*data.Package, runs []brewRun) tea.Cmd {
 	return func() tea.Msg {
 		ch := make(chan tea.Msg)
 
 		go func() {
 			defer close(ch)
 
-			cmdLine := fmt.Sprintf("brew %s", strings.Join(args, " "))
+			if BrewCommand == BrewCommandInstall || BrewCommand == BrewCommandUninstall || BrewCommand == BrewCommandReinstall {
+				for _, pkg := range pkgs {
+					if !pkg.InstallSupported {
+						cmdLine := fmt.Sprintf("brew %s", strings.Join(runs[0].args, " "))
+						ch <- CommandOutputMsg{Ch: ch, Line: fmt.Sprintf("%s can’t be %sed here because it may need sudo, which asks for a password", pkg.Name, BrewCommand)}
+						ch <- CommandOutputMsg{Ch: ch, Line: fmt.Sprintf("please run '%s' in command line", cmdLine)}
+						ch <- CommandFinishMsg{Err: fmt.Errorf("install not supported")}
+						return
+					}
+				}
+			}
+
+			if BrewCommand == BrewCommandUpgradeAll {
+				// What was installed before, to roll back to
+				recordSnapshot(allBrewPackages)
+			}
+			journal := startJournal(BrewCommand, pkgs)
 
-			if BrewCommand == BrewCommandInstall || BrewCommand == BrewCommandUninstall {
-				if pkg := pkgs[0]; !pkg.InstallSupported {
-					ch <- CommandOutputMsg{Ch: ch, Line: fmt.Sprintf("%s can’t be %sed because it’s a .pkg and may need sudo", pkg.Name, BrewCommand)}
-					ch <- CommandOutputMsg{Ch: ch, Line: fmt.Sprintf("please run '%s' in command line", cmdLine)}
-					ch <- CommandFinishMsg{Err: fmt.Errorf("install not supported")}
-					return
+			// Time packages being installed or upgraded, and estimate the remaining time of upgrading all
+			var timer *commandTimer
+			if BrewCommand == BrewCommandUpgradeAll || BrewCommand == BrewCommandUpgrade || BrewCommand == BrewCommandInstall {
+				timer = newCommandTimer(pkgs)
+			}
+			sendEta := func() {
+				if BrewCommand == BrewCommandUpgradeAll {
+					remaining, done := timer.eta()
+					ch <- CommandEtaMsg{Ch: ch, Remaining: remaining, Done: done, Total: len(pkgs)}
+				}
+			}
+			tail := &outputTail{}
+			onLine := func(line string) {
+				tail.add(line)
+				if m := packageDoneRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+					journal.markDone(m[1] + m[2])
+				}
+				if timer != nil && timer.observe(line) {
+					sendEta()
 				}
 			}
+			sendEta()
 
-			ch <- CommandOutputMsg{Ch: ch, Line: "> " + cmdLine}
-			cmd := exec.Command("brew", args...)
-			// Connect to stdout and stderr
-			stdout, err := cmd.StdoutPipe()
-			if err != nil {
-				ch <- CommandFinishMsg{Err: fmt.Errorf("failed to get stdout pipe: %w", err)}
-				return
+			var cmdErr error
+			failed := []*data.Package{}
+			addFailed := func(pkgs []*data.Package) {
+				for _, pkg := range pkgs {
+					if !slices.Contains(failed, pkg) {
+						failed = append(failed, pkg)
+					}
+				}
 			}
-			stderr, err := cmd.StderrPipe()
-			if err != nil {
-				ch <- CommandFinishMsg{Err: fmt.Errorf("failed to get stderr pipe: %w", err)}
-				return
+			envConfig := loadPackageEnv()
+			for i, run := range runs {
+				env := envConfig.env(run.pkgs)
+				ch <- CommandOutputMsg{Ch: ch, Line: "> " + commandLine(BrewCommand.tool(), env, run.args)}
+				startTime := time.Now()
+				err := runBrew(ch, BrewCommand.tool(), run.args, env, onLine)
+				recordHistory(HistoryEntry{
+					Time:     startTime,
+					Command:  BrewCommand,
+					Env:      env,
+					Args:     run.args,
+					Pkgs:     uniqueNames(run.pkgs),
+					ExitCode: exitCode(err),
+					Duration: time.Since(startTime),
+					Output:   tail.get(),
+				})
+				if err == nil {
+					journal.markDone(packageNames(run.pkgs)...)
+				} else {
+					cmdErr = err
+					addFailed(run.pkgs)
+					if run.stopOnError {
+						for _, skipped := range runs[i+1:] {
+							addFailed(skipped.pkgs)
+						}
+						break
+					}
+				}
 			}
-			// Start command
-			if err := cmd.Start(); err != nil {
-				ch <- CommandFinishMsg{Err: fmt.Errorf("failed to start command: %w", err)}
+
+			if cmdErr == nil && timer != nil {
+				timer.finish()
+			}
+			journal.finish()
+			if len(runs) > 1 {
+				for _, line := range batchSummary(pkgs, failed) {
+					ch <- CommandOutputMsg{Ch: ch, Line: line}
+				}
+				if len(failed) > 0 {
+					cmdErr = fmt.Errorf("%d of %d packages failed", len(failed), len(pkgs))
+				}
+				ch <- CommandFinishMsg{Err: cmdErr, Command: BrewCommand, Pkgs: pkgs, Failed: failed}
 				return
 			}
-
-			var wg sync.WaitGroup
-			wg.Add(2)
-			// Stream stdout and stderr
-			go func() {
-				defer wg.Done()
-				feedOutput(ch, stdout)
-			}()
-			go func() {
-				defer wg.Done()
-				feedOutput(ch, stderr)
-			}()
-
-			cmdErr := cmd.Wait()
-			wg.Wait()
 			ch <- CommandFinishMsg{Err: cmdErr, Command: BrewCommand, Pkgs: pkgs}
 		}()
 
@@ -116,26 +298,285 @@ func execute(BrewCommand BrewCommand, pkgs []*data.Package, args ...string) tea.
 	}
 }
 
+// A brew command as it would be typed in a shell, with the environment variables added for its packages
+func commandLine(tool string, env, args []string) string {
+	return strings.Join(append(slices.Clone(env), tool), " ") + " " + strings.Join(args, " ")
+}
+
+// Run brew, or the tool managing the packages, and stream its stdout and stderr, returns once the command exits
+func runBrew(ch chan tea.Msg, tool string, args, env []string, onLine func(string)) error {
+	cmd := exec.Command(tool, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	// Connect to stdout and stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stderr pipe: %w", err)
+	}
+	// Start command
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start command: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	// Stream stdout and stderr
+	go func() {
+		defer wg.Done()
+		feedOutput(ch, stdout, onLine)
+	}()
+	go func() {
+		defer wg.Done()
+		feedOutput(ch, stderr, onLine)
+	}()
+
+	cmdErr := cmd.Wait()
+	wg.Wait()
+	return cmdErr
+}
+
+// Per-package results of packages run one by one
+func batchSummary(pkgs, failed []*data.Package) []string {
+	isFailed := make(map[*data.Package]bool)
+	for _, pkg := range failed {
+		isFailed[pkg] = true
+	}
+	lines := []string{fmt.Sprintf("%d succeeded, %d failed:", len(pkgs)-len(failed), len(failed))}
+	for _, pkg := range pkgs {
+		if isFailed[pkg] {
+			lines = append(lines, "  ✗ "+pkg.Name)
+		} else {
+			lines = append(lines, "  ✓ "+pkg.Name)
+		}
+	}
+	return lines
+}
+
+func packageNames(pkgs []*data.Package) []string {
+	names := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		names[i] = pkg.Name
+	}
+	return names
+}
+
+// Names of packages for brew commands, which can't be mistaken for packages of the same name from other taps
+func uniqueNames(pkgs []*data.Package) []string {
+	names := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		names[i] = pkg.UniqueName()
+	}
+	return names
+}
+
 func UpgradeAllPackages(pkgs []*data.Package) tea.Cmd {
+	// A plain 'brew upgrade' would upgrade snoozed packages too
+	if slices.ContainsFunc(GetOutdatedPackages(), (*data.Package).IsSnoozed) {
+		return UpgradeAllOf(pkgs)
+	}
+	if *flagBatchErrors == batchErrorsContinue {
+		return tea.Batch(startCommand(), executeEach(BrewCommandUpgradeAll, pkgs, upgradeArgs))
+	}
 	return tea.Batch(startCommand(), execute(BrewCommandUpgradeAll, pkgs, "upgrade"))
 }
 
-func UpgradePackage(pkg *data.Package) tea.Cmd {
+func upgradeArgs(pkg *data.Package) []string {
+	if pkg.IsCask {
+		return []string{"upgrade", "--cask", pkg.UniqueName()}
+	}
+	return []string{"upgrade", pkg.UniqueName()}
+}
+
+// Upgrade some of the outdated packages as an upgrade all, which shows the time estimate, e.g. to retry
+// packages left by a failed upgrade all or to upgrade the ones fitting in a time budget
+func UpgradeAllOf(pkgs []*data.Package) tea.Cmd {
+	if *flagBatchErrors == batchErrorsContinue {
+		return tea.Batch(startCommand(), executeEach(BrewCommandUpgradeAll, pkgs, upgradeArgs))
+	}
+	args := append([]string{"upgrade"}, uniqueNames(pkgs)...)
+	return tea.Batch(startCommand(), execute(BrewCommandUpgradeAll, pkgs, args...))
+}
+
+// Lines printed by brew when it finishes a package, e.g. "🍺  /opt/homebrew/Cellar/wget/1.25.0: 92 files, 4.5MB"
+// for formulae and "🍺  firefox was successfully upgraded!" for casks
+var packageDoneRegex = regexp.MustCompile(`^🍺\s+(?:\S*/Cellar/([^/\s]+)/|(\S+) was successfully (?:upgraded|installed))`)
+
+// Split packages of a failed command into the ones that finished and the ones that still need to run,
+// based on the output of the command
+func SplitFinishedPackages(pkgs []*data.Package, output []string) (finished, unfinished []*data.Package) {
+	// Finished formulae and casks, which may share a name. Brew prints short names, which are
+	// unique among installed packages of a kind.
+	doneFormulae, doneCasks := make(map[string]bool), make(map[string]bool)
+	for _, line := range output {
+		if m := packageDoneRegex.FindStringSubmatch(strings.TrimSpace(line)); m == nil {
+			continue
+		} else if m[1] != "" {
+			doneFormulae[m[1]] = true
+		} else {
+			doneCasks[m[2][strings.LastIndex(m[2], "/")+1:]] = true
+		}
+	}
+	for _, pkg := range pkgs {
+		if pkg.IsCask && doneCasks[pkg.Name] || !pkg.IsCask && doneFormulae[pkg.Name] {
+			finished = append(finished, pkg)
+		} else {
+			unfinished = append(unfinished, pkg)
+		}
+	}
+	return finished, unfinished
+}
+
+// Options are extra flags for brew, like --build-from-source or --force
+func UpgradePackage(pkg *data.Package, options ...string) tea.Cmd {
 	args := []string{"upgrade"}
 	if pkg.IsCask {
 		args = append(args, "--cask")
 	}
-	args = append(args, pkg.Name)
+	args = append(args, options...)
+	args = append(args, pkg.UniqueName())
 	return tea.Batch(startCommand(), execute(BrewCommandUpgrade, []*data.Package{pkg}, args...))
 }
 
-func InstallPackage(pkg *data.Package) tea.Cmd {
+// Options are extra flags for brew, like --HEAD or --build-from-source
+func InstallPackage(pkg *data.Package, options ...string) tea.Cmd {
+	return tea.Batch(startCommand(), execute(BrewCommandInstall, []*data.Package{pkg}, installArgs(pkg, options)...))
+}
+
+// Install multiple packages one by one, with the flags a single install gets
+func InstallPackages(pkgs []*data.Package) tea.Cmd {
+	return tea.Batch(startCommand(), executeEach(BrewCommandInstall, pkgs, func(pkg *data.Package) []string {
+		return installArgs(pkg, nil)
+	}))
+}
+
+func installArgs(pkg *data.Package, options []string) []string {
 	args := []string{"install"}
 	if pkg.IsCask {
 		args = append(args, "--cask")
+		if lang := CaskLanguage(pkg); lang != "" {
+			args = append(args, "--language="+lang)
+		}
+		if *flagNoQuarantine && !hasQuarantineFlag(options) && !hasQuarantineFlag(caskOpts()) {
+			args = append(args, "--no-quarantine")
+		}
+		for _, dir := range CaskDirs() {
+			// Directories given for this install win
+			kind, _, _ := strings.Cut(dir, "=")
+			if !hasCaskOpt(options, kind) {
+				args = append(args, dir)
+			}
+		}
+	}
+	args = append(args, options...)
+	return append(args, pkg.UniqueName())
+}
+
+// Common flags of brew install for the package, followed by options specific to the formula
+func InstallFlags(pkg *data.Package) []string {
+	if pkg.IsCask {
+		quarantine := "--no-quarantine"
+		if *flagNoQuarantine {
+			quarantine = "--quarantine"
+		}
+		return withoutCaskOpts([]string{"--force", "--adopt", "--skip-cask-deps", quarantine}, caskOpts())
+	}
+	flags := []string{"--build-from-source", "--force-bottle", "--force", "--ignore-dependencies"}
+	return append(flags, pkg.Options...)
+}
+
+// Common flags of brew upgrade for the package
+func UpgradeFlags(pkg *data.Package) []string {
+	if pkg.IsCask {
+		return withoutCaskOpts([]string{"--force", "--greedy"}, caskOpts())
+	}
+	return []string{"--build-from-source", "--force-bottle", "--fetch-HEAD", "--force"}
+}
+
+// Whether installing the package with the options skips quarantining it, so macOS won't check the app
+// with Gatekeeper before its first launch
+func SkipsQuarantine(pkg *data.Package, options []string) bool {
+	if !pkg.IsCask || slices.Contains(options, "--quarantine") {
+		return false
 	}
-	args = append(args, pkg.Name)
-	return tea.Batch(startCommand(), execute(BrewCommandInstall, []*data.Package{pkg}, args...))
+	return *flagNoQuarantine || slices.Contains(options, "--no-quarantine") || hasCaskOpt(caskOpts(), "--no-quarantine")
+}
+
+// Artifact directories brew install --cask takes a --<kind> flag for
+var caskDirKinds = []string{
+	"appdir", "keyboard-layoutdir", "colorpickerdir", "prefpanedir", "qlplugindir", "mdimporterdir", "dictionarydir",
+	"fontdir", "servicedir", "input-methoddir", "internet-plugindir", "audio-unit-plugindir", "vst-plugindir",
+	"vst3-plugindir", "screen-saverdir",
+}
+
+// Flags for the artifact directories set with --cask-dirs, like --appdir=~/Applications
+var CaskDirs = sync.OnceValue(func() []string {
+	flags, err := caskDirFlags(*flagCaskDirs, caskOpts())
+	if err != nil {
+		log.Printf("ignoring --cask-dirs: %v", err)
+	}
+	return flags
+})
+
+// Turn kind=dir pairs into brew flags, skipping the ones HOMEBREW_CASK_OPTS already sets
+func caskDirFlags(pairs, opts []string) ([]string, error) {
+	flags := []string{}
+	for _, pair := range pairs {
+		kind, dir, ok := strings.Cut(pair, "=")
+		if !ok || dir == "" {
+			return nil, fmt.Errorf("%q is not a kind=dir pair", pair)
+		}
+		if !slices.Contains(caskDirKinds, kind) {
+			return nil, fmt.Errorf("unknown cask directory %q, expected one of %s", kind, strings.Join(caskDirKinds, ", "))
+		}
+		if !hasCaskOpt(opts, "--"+kind) {
+			flags = append(flags, fmt.Sprintf("--%s=%s", kind, dir))
+		}
+	}
+	return flags, nil
+}
+
+// Whether the quarantine of casks is already chosen either way
+func hasQuarantineFlag(flags []string) bool {
+	return slices.Contains(flags, "--quarantine") || slices.Contains(flags, "--no-quarantine")
+}
+
+// Drop flags brew already adds from HOMEBREW_CASK_OPTS
+func withoutCaskOpts(flags, opts []string) []string {
+	return slices.DeleteFunc(flags, func(flag string) bool {
+		return hasCaskOpt(opts, flag)
+	})
+}
+
+// The first preferred language supported by a localized cask, empty to let brew decide,
+// which it also does when HOMEBREW_CASK_OPTS sets --language
+func CaskLanguage(pkg *data.Package) string {
+	if hasCaskOpt(caskOpts(), "--language") {
+		return ""
+	}
+	return matchLanguage(*flagCaskLanguages, pkg.Languages)
+}
+
+// Match preferred languages against the ones available, where "de" matches "de-DE" and vice versa
+func matchLanguage(preferred, available []string) string {
+	for _, pref := range preferred {
+		for _, lang := range available {
+			if strings.EqualFold(pref, lang) {
+				return lang
+			}
+		}
+		base, _, _ := strings.Cut(pref, "-")
+		for _, lang := range available {
+			if langBase, _, _ := strings.Cut(lang, "-"); strings.EqualFold(base, langBase) {
+				return lang
+			}
+		}
+	}
+	return ""
 }
 
 func UninstallPackage(pkg *data.Package) tea.Cmd {
@@ -143,40 +584,196 @@ func UninstallPackage(pkg *data.Package) tea.Cmd {
 	if pkg.IsCask {
 		args = append(args, "--cask")
 	}
-	args = append(args, pkg.Name)
+	args = append(args, pkg.UniqueName())
 	return tea.Batch(startCommand(), execute(BrewCommandUninstall, []*data.Package{pkg}, args...))
 }
 
+// Reinstall a package to repair a broken or corrupted installation
+func ReinstallPackage(pkg *data.Package) tea.Cmd {
+	args := []string{"reinstall"}
+	if pkg.IsCask {
+		args = append(args, "--cask")
+	}
+	args = append(args, pkg.UniqueName())
+	return tea.Batch(startCommand(), execute(BrewCommandReinstall, []*data.Package{pkg}, args...))
+}
+
+// Uninstall a cask and remove all its files listed in the zap stanza
+func ZapPackage(pkg *data.Package) tea.Cmd {
+	return tea.Batch(startCommand(), execute(BrewCommandUninstall, []*data.Package{pkg}, "uninstall", "--zap", "--cask", pkg.UniqueName()))
+}
+
+// Uninstall multiple packages one by one, dependents before their dependencies so brew doesn't refuse to remove
+// a package still required by another one in the batch. It stops at the first failure unless --batch-errors=continue.
+// When ignoreDeps is set, brew won't refuse to remove packages that are still required by others.
+func UninstallPackages(pkgs []*data.Package, ignoreDeps bool) tea.Cmd {
+	pkgs = UninstallOrder(pkgs)
+	runs := make([]brewRun, len(pkgs))
+	for i, pkg := range pkgs {
+		args := []string{"uninstall"}
+		if ignoreDeps {
+			args = append(args, "--ignore-dependencies")
+		}
+		if pkg.IsCask {
+			args = append(args, "--cask")
+		}
+		runs[i] = brewRun{
+			pkgs:        []*data.Package{pkg},
+			args:        append(args, pkg.UniqueName()),
+			stopOnError: *flagBatchErrors != batchErrorsContinue,
+		}
+	}
+	return tea.Batch(startCommand(), executeRuns(BrewCommandUninstall, pkgs, runs))
+}
+
+// Order packages to uninstall so the ones depending on others come first,
+// also through installed packages that aren't being uninstalled
+func UninstallOrder(pkgs []*data.Package) []*data.Package {
+	return uninstallOrder(pkgs, GetPackage)
+}
+
+// Packages are known by unique name, like the dependents they list
+func uninstallOrder(pkgs []*data.Package, lookup func(string) *data.Package) []*data.Package {
+	removing := make(map[string]*data.Package)
+	names := []string{}
+	for _, pkg := range pkgs {
+		removing[pkg.UniqueName()] = pkg
+		names = append(names, pkg.UniqueName())
+	}
+	sort.Strings(names)
+
+	ordered := []*data.Package{}
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		pkg := removing[name]
+		if pkg == nil {
+			pkg = lookup(name)
+		}
+		if pkg == nil {
+			return
+		}
+		for _, dependent := range util.Sort(slices.Clone(pkg.Dependents)) {
+			visit(dependent)
+		}
+		if removing[name] != nil {
+			ordered = append(ordered, pkg)
+		}
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return ordered
+}
+
+// Upgrade multiple packages in one brew command
+func UpgradePackages(pkgs []*data.Package) tea.Cmd {
+	if *flagBatchErrors == batchErrorsContinue {
+		return tea.Batch(startCommand(), executeEach(BrewCommandUpgrade, pkgs, upgradeArgs))
+	}
+	args := append([]string{"upgrade"}, uniqueNames(pkgs)...)
+	return tea.Batch(startCommand(), execute(BrewCommandUpgrade, pkgs, args...))
+}
+
+// Upgrade pinned packages, unpinning them first. They're repinned even when the upgrade fails,
+// so a failure never leaves them unpinned.
+func UpgradePinnedPackages(pkgs []*data.Package) tea.Cmd {
+	runs := pinnedUpgradeRuns(pkgs, *flagBatchErrors == batchErrorsContinue)
+	return tea.Batch(startCommand(), executeRuns(BrewCommandUpgrade, pkgs, runs))
+}
+
+// Only a failed unpin skips the rest, packages it didn't unpin are still pinned
+func pinnedUpgradeRuns(pkgs []*data.Package, each bool) []brewRun {
+	names := uniqueNames(pkgs)
+	runs := []brewRun{{pkgs: pkgs, args: append([]string{"unpin"}, names...), stopOnError: true}}
+	if each {
+		for _, pkg := range pkgs {
+			runs = append(runs, brewRun{pkgs: []*data.Package{pkg}, args: upgradeArgs(pkg)})
+		}
+	} else {
+		runs = append(runs, brewRun{pkgs: pkgs, args: append([]string{"upgrade"}, names...)})
+	}
+	return append(runs, brewRun{pkgs: pkgs, args: append([]string{"pin"}, names...)})
+}
+
 func PinPackage(pkg *data.Package) tea.Cmd {
-	return tea.Batch(startCommand(), execute(BrewCommandPin, []*data.Package{pkg}, "pin", pkg.Name))
+	return PinPackages([]*data.Package{pkg})
+}
+
+func PinPackages(pkgs []*data.Package) tea.Cmd {
+	args := append([]string{"pin"}, uniqueNames(pkgs)...)
+	return tea.Batch(startCommand(), execute(BrewCommandPin, pkgs, args...))
 }
 
 func UnpinPackage(pkg *data.Package) tea.Cmd {
-	return tea.Batch(startCommand(), execute(BrewCommandUnpin, []*data.Package{pkg}, "unpin", pkg.Name))
+	return UnpinPackages([]*data.Package{pkg})
+}
+
+func UnpinPackages(pkgs []*data.Package) tea.Cmd {
+	args := append([]string{"unpin"}, uniqueNames(pkgs)...)
+	return tea.Batch(startCommand(), execute(BrewCommandUnpin, pkgs, args...))
+}
+
+// Link a formula into the prefix. Overwrite replaces conflicting files from other formulae or installers,
+// keg-only formulae need to be forced.
+func LinkPackage(pkg *data.Package, overwrite bool) tea.Cmd {
+	args := []string{"link"}
+	if overwrite {
+		args = append(args, "--overwrite")
+	}
+	if pkg.IsKegOnly {
+		args = append(args, "--force")
+	}
+	args = append(args, pkg.UniqueName())
+	return tea.Batch(startCommand(), execute(BrewCommandLink, []*data.Package{pkg}, args...))
+}
+
+func UnlinkPackage(pkg *data.Package) tea.Cmd {
+	return UnlinkPackages([]*data.Package{pkg})
+}
+
+func UnlinkPackages(pkgs []*data.Package) tea.Cmd {
+	args := append([]string{"unlink"}, uniqueNames(pkgs)...)
+	return tea.Batch(startCommand(), execute(BrewCommandUnlink, pkgs, args...))
 }
 
 func Cleanup() tea.Cmd {
 	return tea.Batch(startCommand(), execute(BrewCommandCleanup, []*data.Package{}, "cleanup", "--prune=all"))
 }
 
+// Fix taps that are missing their remote or have an outdated default branch
+func RepairTaps() tea.Cmd {
+	return tea.Batch(startCommand(), execute(BrewCommandTapRepair, []*data.Package{}, "tap", "--repair"))
+}
+
 func UpdatePackageForAction(command BrewCommand, pkgs []*data.Package) {
 	switch command {
-	case BrewCommandUpgradeAll, BrewCommandUpgrade:
+	case BrewCommandUpgradeAll, BrewCommandUpgrade, BrewCommandMasUpgrade:
 		for _, pkg := range pkgs {
 			pkg.MarkInstalled()
 		}
 	case BrewCommandInstall:
 		for _, pkg := range pkgs {
-			pkg.MarkInstalled()
-			// Also mark uninstalled dependencies as installed
-			for _, depName := range GetRecursiveMissingDeps(pkg.Name) {
-				GetPackage(depName).MarkInstalled()
+			// Also mark uninstalled dependencies as installed, found before the package is marked
+			for _, depName := range GetRecursiveMissingDeps(pkg.UniqueName()) {
+				if dep := GetPackage(depName); dep != nil {
+					dep.MarkInstalled()
+				}
 			}
+			pkg.MarkInstalled()
 		}
-	case BrewCommandUninstall:
+	case BrewCommandUninstall, BrewCommandWhalebrewUninstall:
 		for _, pkg := range pkgs {
 			pkg.MarkUninstalled()
 		}
+	case BrewCommandReinstall:
+		for _, pkg := range pkgs {
+			pkg.MarkInstalled()
+		}
 	case BrewCommandPin:
 		for _, pkg := range pkgs {
 			pkg.MarkPinned()
@@ -185,5 +782,13 @@ func UpdatePackageForAction(command BrewCommand, pkgs []*data.Package) {
 		for _, pkg := range pkgs {
 			pkg.MarkUnpinned()
 		}
+	case BrewCommandLink:
+		for _, pkg := range pkgs {
+			pkg.MarkLinked()
+		}
+	case BrewCommandUnlink:
+		for _, pkg := range pkgs {
+			pkg.MarkUnlinked()
+		}
 	}
 }
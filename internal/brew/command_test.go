@@ -0,0 +1,201 @@
+package brew
+
+import (
+	"bufio"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		line    string
+		percent float64
+		ok      bool
+	}{
+		{"######################                                  31.4%", 0.314, true},
+		{"                                                          0.0%", 0, true},
+		{"######################################################## 100.0%", 1, true},
+		{"==> Downloading https://ghcr.io/v2/homebrew/core/wget/manifests/1.25.0", 0, false},
+		{"Progress: 50%", 0, false},
+	}
+
+	for _, tt := range tests {
+		percent, ok := parseProgress(tt.line)
+		if ok != tt.ok || percent != tt.percent {
+			t.Errorf("parseProgress(%q) = %v, %v, want %v, %v", tt.line, percent, ok, tt.percent, tt.ok)
+		}
+	}
+}
+
+func TestScanLinesOrCarriageReturns(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("==> Fetching foo\n### 10.0%\r###### 20.0%\rdone"))
+	scanner.Split(scanLinesOrCarriageReturns)
+	tokens := []string{}
+	for scanner.Scan() {
+		tokens = append(tokens, scanner.Text())
+	}
+	want := []string{"==> Fetching foo", "### 10.0%", "###### 20.0%", "done"}
+	if !slices.Equal(tokens, want) {
+		t.Errorf("expected tokens %q, got %q", want, tokens)
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	available := []string{"en", "de", "fr", "pt-BR", "zh-TW"}
+	tests := []struct {
+		preferred []string
+		want      string
+	}{
+		{[]string{}, ""},
+		{[]string{"ja"}, ""},
+		{[]string{"de"}, "de"},
+		{[]string{"ja", "FR"}, "fr"},
+		{[]string{"de-AT"}, "de"},
+		{[]string{"pt"}, "pt-BR"},
+		{[]string{"zh-CN", "zh-TW"}, "zh-TW"},
+	}
+
+	for _, tt := range tests {
+		if got := matchLanguage(tt.preferred, available); got != tt.want {
+			t.Errorf("matchLanguage(%v) = %q, want %q", tt.preferred, got, tt.want)
+		}
+	}
+}
+
+func TestSplitFinishedPackages(t *testing.T) {
+	pkgs := []*data.Package{
+		{Name: "wget"},
+		{Name: "openssl@3"},
+		{Name: "firefox", IsCask: true},
+		{Name: "wget", IsCask: true},
+		{Name: "node"},
+	}
+	output := []string{
+		"> brew upgrade",
+		"==> Upgrading wget",
+		"🍺  /opt/homebrew/Cellar/wget/1.25.0: 92 files, 4.5MB",
+		"==> Upgrading openssl@3",
+		"🍺  /home/linuxbrew/.linuxbrew/Cellar/openssl@3/3.4.0: 7,236 files, 34.1MB",
+		"==> Upgrading firefox",
+		"🍺  firefox was successfully upgraded!",
+		"==> Upgrading node",
+		"Error: node: Failed to download resource",
+	}
+	finished, unfinished := SplitFinishedPackages(pkgs, output)
+	if got, want := packageNames(finished), []string{"wget", "openssl@3", "firefox"}; !slices.Equal(got, want) {
+		t.Errorf("expected finished %v, got %v", want, got)
+	}
+	if got, want := packageNames(unfinished), []string{"wget", "node"}; !slices.Equal(got, want) {
+		t.Errorf("expected unfinished %v, got %v", want, got)
+	}
+}
+
+func TestBatchSummary(t *testing.T) {
+	pkgs := []*data.Package{{Name: "wget"}, {Name: "node"}, {Name: "git"}}
+	lines := batchSummary(pkgs, pkgs[1:2])
+	want := []string{"2 succeeded, 1 failed:", "  ✓ wget", "  ✗ node", "  ✓ git"}
+	if !slices.Equal(lines, want) {
+		t.Errorf("expected summary %q, got %q", want, lines)
+	}
+}
+
+func TestUninstallOrder(t *testing.T) {
+	// app -> lib -> base, tool -> kept -> base, where kept isn't being uninstalled
+	installed := map[string]*data.Package{
+		"app":  {Name: "app", Dependencies: []string{"lib"}},
+		"lib":  {Name: "lib", Dependencies: []string{"base"}, Dependents: []string{"app"}},
+		"base": {Name: "base", Dependents: []string{"lib", "kept"}},
+		"kept": {Name: "kept", Dependencies: []string{"base"}, Dependents: []string{"tool"}},
+		"tool": {Name: "tool", Dependencies: []string{"kept"}},
+	}
+	lookup := func(name string) *data.Package { return installed[name] }
+
+	pkgs := []*data.Package{installed["base"], installed["lib"], installed["tool"], installed["app"]}
+	order := packageNames(uninstallOrder(pkgs, lookup))
+	if want := []string{"app", "tool", "lib", "base"}; !slices.Equal(order, want) {
+		t.Errorf("expected dependents to be uninstalled first %v, got %v", want, order)
+	}
+
+	// A formula and a cask of the same name are both uninstalled once, bar depends on the cask
+	formula := &data.Package{Name: "foo"}
+	cask := &data.Package{Name: "foo", Tap: caskTap, IsCask: true, Shadowed: true, Dependents: []string{"bar"}}
+	bar := &data.Package{Name: "bar", IsCask: true, Dependencies: []string{"foo"}}
+	order = uniqueNames(uninstallOrder([]*data.Package{cask, formula, bar}, func(string) *data.Package { return nil }))
+	if want := []string{"bar", "foo", "homebrew/cask/foo"}; !slices.Equal(order, want) {
+		t.Errorf("expected %v, got %v", want, order)
+	}
+}
+
+func TestSkipsQuarantine(t *testing.T) {
+	defer func(original bool) { *flagNoQuarantine = original }(*flagNoQuarantine)
+	t.Setenv("HOMEBREW_CASK_OPTS", "")
+	cask := &data.Package{Name: "firefox", IsCask: true}
+	formula := &data.Package{Name: "wget"}
+
+	*flagNoQuarantine = false
+	if SkipsQuarantine(cask, nil) {
+		t.Errorf("expected casks to be quarantined by default")
+	}
+	if !SkipsQuarantine(cask, []string{"--no-quarantine"}) {
+		t.Errorf("expected --no-quarantine to skip quarantine")
+	}
+
+	*flagNoQuarantine = true
+	if !SkipsQuarantine(cask, nil) {
+		t.Errorf("expected --no-quarantine by default to skip quarantine")
+	}
+	if SkipsQuarantine(cask, []string{"--quarantine"}) {
+		t.Errorf("expected --quarantine to override the default")
+	}
+	if SkipsQuarantine(formula, nil) {
+		t.Errorf("expected formulae never to skip quarantine")
+	}
+
+	t.Setenv("HOMEBREW_CASK_OPTS", "--no-quarantine")
+	*flagNoQuarantine = false
+	if !SkipsQuarantine(cask, nil) {
+		t.Errorf("expected --no-quarantine in HOMEBREW_CASK_OPTS to skip quarantine")
+	}
+}
+
+func TestCaskDirFlags(t *testing.T) {
+	flags, err := caskDirFlags([]string{"appdir=~/Applications", "fontdir=~/Library/Fonts"}, []string{"--fontdir=/Library/Fonts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"--appdir=~/Applications"}; !slices.Equal(flags, expected) {
+		t.Errorf("expected %v, got %v", expected, flags)
+	}
+	for _, pairs := range [][]string{{"appdir"}, {"appdir="}, {"bindir=/usr/local/bin"}} {
+		if _, err := caskDirFlags(pairs, nil); err == nil {
+			t.Errorf("expected an error for %v", pairs)
+		}
+	}
+}
+
+func TestPinnedUpgradeRuns(t *testing.T) {
+	pkgs := []*data.Package{{Name: "node"}, {Name: "python@3.13"}}
+	for _, each := range []bool{false, true} {
+		runs := pinnedUpgradeRuns(pkgs, each)
+		commands := []string{}
+		for _, run := range runs {
+			commands = append(commands, strings.Join(run.args, " "))
+		}
+		want := []string{"unpin node python@3.13", "upgrade node python@3.13", "pin node python@3.13"}
+		if each {
+			want = []string{"unpin node python@3.13", "upgrade node", "upgrade python@3.13", "pin node python@3.13"}
+		}
+		if !slices.Equal(commands, want) {
+			t.Errorf("expected runs %q, got %q", want, commands)
+		}
+		// A failed upgrade still repins
+		for _, run := range runs[1:] {
+			if run.stopOnError {
+				t.Errorf("expected %q not to skip the runs after it", strings.Join(run.args, " "))
+			}
+		}
+	}
+}
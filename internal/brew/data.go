@@ -2,102 +2,273 @@ package brew
 
 import (
 	"bytes"
+	"cmp"
+	"fmt"
 	"log"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
-	"taproom/internal/data"
-	"taproom/internal/gh"
-	"taproom/internal/loading"
-	"taproom/internal/util"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/loading"
+	"github.com/hzqtc/taproom/internal/osv"
+	"github.com/hzqtc/taproom/internal/util"
 	"github.com/spf13/pflag"
 )
 
+// Flags of loading and running brew, added to the command line by taproom and left out of pkg/brewdata
+var Flags = pflag.NewFlagSet("brew", pflag.ExitOnError)
+
 // Holding all packages
 var allBrewPackages []*data.Package
 
-var flagFetchReleaseInfo = pflag.Bool("fetch-release", false, "Fetching release data for installed packages")
+// Home pages of the packages being loaded
+var packageTexts *data.TextStore
+
+// Strings shared by the packages being loaded, dropped once they're loaded
+var catalogStrings stringPool
+
+var (
+	flagFetchReleaseInfo = Flags.Bool("fetch-release", false, "Fetching release data for installed packages")
+	flagCheckSecurity    = Flags.Bool("check-security", false, "Check installed packages for known vulnerabilities with OSV")
+	flagSecurityScope    = Flags.String("security-scope", securityScopeInstalled, "Packages --check-security checks: installed or outdated")
+)
+
+const (
+	securityScopeOutdated  = "outdated"
+	securityScopeInstalled = "installed"
+)
 
 type DataLoadedMsg struct {
 	Packages []*data.Package
+	// Installed packages from third-party taps that failed to load, e.g. "someone/tools/foo"
+	BrokenTapPackages []string
+	// Sources packages can do without that failed to load, packages were loaded without them
+	Unavailable []OptionalSource
+	// What changed since the last run, only set for the first load when anything did
+	Summary *LaunchSummary
+
+	// 90d install counts recorded for trends, nil when analytics weren't loaded
+	formulaInstalls, caskInstalls map[string]int
 }
 
+// A data source that isn't needed to list packages, unlike the catalog and installed packages
+type OptionalSource string
+
+const (
+	SourceAnalytics   OptionalSource = "install analytics"
+	SourceBuildErrors OptionalSource = "build error analytics"
+)
+
 type DataLoadingErrMsg struct {
 	Err error
 }
 
 // loadData returns a tea.Cmd that fetches all data concurrently.
-func LoadData(fetchAnalytics, fetchSize bool, loadingPrgs *loading.LoadingProgress) tea.Cmd {
+// When installedOnly is set, only installed packages are loaded, skipping the full catalog.
+func LoadData(fetchAnalytics, fetchSize, installedOnly bool, loadingPrgs *loading.LoadingProgress) tea.Cmd {
 	return func() tea.Msg {
-		formulaeChan := make(chan []*apiFormula)
-		casksChan := make(chan []*apiCask)
-		formulaAnalytics90dChan := make(chan apiFormulaAnalytics)
-		caskAnalytics90dChan := make(chan apiCaskAnalytics)
-		formulaInstallInfoChan := make(chan []*installInfo)
-		caskInstallInfoChan := make(chan []*installInfo)
-		loadingTasksNum := 6
-		errChan := make(chan error, loadingTasksNum)
-
-		var allFormulae []*apiFormula
-		var allCasks []*apiCask
-		var formulaAnalytics90d apiFormulaAnalytics
-		var caskAnalytics90d apiCaskAnalytics
-		var formulaInstallInfo, caskInstallInfo []*installInfo
+		// Update brew in the background, we don't depend on `brew` command to get data
+		// But we need brew to be updated when install/upgrade packages
+		go updateBrew()
+		msg := loadPackages(fetchAnalytics, fetchSize, installedOnly, loadingPrgs)
+		if loaded, ok := msg.(DataLoadedMsg); ok {
+			go indexRelatedPackages(loaded.Packages)
+			return recordLoad(loaded)
+		}
+		return msg
+	}
+}
+
+// Remember what taproom loaded for its next runs. Other tools loading packages with LoadPackages
+// leave it alone, so the next run still compares with what taproom showed.
+func recordLoad(msg DataLoadedMsg) DataLoadedMsg {
+	markUpdatedVersions(msg.Packages)
+	msg.Summary = recordRunState(msg.Packages)
+	if msg.formulaInstalls != nil {
+		recordInstallTrends(msg.Packages, msg.formulaInstalls, msg.caskInstalls)
+	}
+	return msg
+}
 
+// Load packages without the TUI, e.g. for other tools using pkg/brewdata. Unlike LoadData,
+// it doesn't update brew in the background.
+func LoadPackages(fetchAnalytics, fetchSize, installedOnly bool) ([]*data.Package, error) {
+	if err := LocateBrew(); err != nil {
+		return nil, err
+	}
+	switch msg := loadPackages(fetchAnalytics, fetchSize, installedOnly, loading.NewLoadingProgress()).(type) {
+	case DataLoadingErrMsg:
+		return nil, msg.Err
+	case DataLoadedMsg:
+		return msg.Packages, nil
+	default:
+		return nil, fmt.Errorf("unexpected result of loading packages: %T", msg)
+	}
+}
+
+func loadPackages(fetchAnalytics, fetchSize, installedOnly bool, loadingPrgs *loading.LoadingProgress) tea.Msg {
+	formulaeChan := make(chan []*apiFormula)
+	casksChan := make(chan []*apiCask)
+	formulaAnalytics90dChan := make(chan apiFormulaAnalytics)
+	caskAnalytics90dChan := make(chan apiCaskAnalytics)
+	formulaInstallInfoChan := make(chan []*installInfo)
+	caskInstallInfoChan := make(chan []*installInfo)
+	buildErrors90dChan := make(chan apiBuildErrorAnalytics)
+	loadingTasksNum := 7
+	errChan := make(chan error, loadingTasksNum)
+	unavailableChan := make(chan OptionalSource, loadingTasksNum)
+	masAppsChan := make(chan []*data.Package, 1)
+	go func() { masAppsChan <- loadMasApps() }()
+	whalebrewChan := make(chan []*data.Package, 1)
+	go func() { whalebrewChan <- loadWhalebrewPackages() }()
+
+	var allFormulae []*apiFormula
+	var allCasks []*apiCask
+	var formulaAnalytics90d apiFormulaAnalytics
+	var caskAnalytics90d apiCaskAnalytics
+	var formulaInstallInfo, caskInstallInfo []*installInfo
+	var buildErrors90d apiBuildErrorAnalytics
+
+	if installedOnly {
+		go fetchInstalledCatalog(formulaeChan, casksChan, errChan)
+		loadingPrgs.AddTask(formulaeChan, "Loading installed Formulae")
+		loadingPrgs.AddTask(casksChan, "Loading installed Casks")
+	} else if useLocalCatalog() {
+		go fetchLocalCatalog(formulaeChan, casksChan, errChan)
+		loadingPrgs.AddTask(formulaeChan, "Loading all Formulae from local taps")
+		loadingPrgs.AddTask(casksChan, "Loading all Casks from local taps")
+	} else {
 		go fetchFormula(formulaeChan, errChan)
 		loadingPrgs.AddTask(formulaeChan, "Loading all Formulae")
 		go fetchCask(casksChan, errChan)
 		loadingPrgs.AddTask(casksChan, "Loading all Casks")
-		if fetchAnalytics {
-			go fetchFormulaAnalytics(formulaAnalytics90dChan, errChan)
-			loadingPrgs.AddTask(formulaAnalytics90dChan, "Loading Formulae 90d analytics")
-			go fetchCaskAnalytics(caskAnalytics90dChan, errChan)
-			loadingPrgs.AddTask(caskAnalytics90dChan, "Loading Cask 90d analytics")
-		} else {
-			loadingTasksNum -= 2
+	}
+	if fetchAnalytics {
+		go fetchOptional(fetchFormulaAnalytics, SourceAnalytics, formulaAnalytics90dChan, unavailableChan)
+		loadingPrgs.AddTask(formulaAnalytics90dChan, "Loading Formulae 90d analytics")
+		go fetchOptional(fetchCaskAnalytics, SourceAnalytics, caskAnalytics90dChan, unavailableChan)
+		loadingPrgs.AddTask(caskAnalytics90dChan, "Loading Cask 90d analytics")
+	} else {
+		loadingTasksNum -= 2
+	}
+	if *flagFetchBuildErrors {
+		go fetchOptional(fetchBuildErrorAnalytics, SourceBuildErrors, buildErrors90dChan, unavailableChan)
+		loadingPrgs.AddTask(buildErrors90dChan, "Loading build error analytics")
+	} else {
+		loadingTasksNum--
+	}
+	go fetchInstalledFormula(fetchSize, formulaInstallInfoChan)
+	loadingPrgs.AddTask(formulaInstallInfoChan, "Loading formulae installation data")
+	go fetchInstalledCask(fetchSize, caskInstallInfoChan)
+	loadingPrgs.AddTask(caskInstallInfoChan, "Loading casks installation data")
+
+	for range loadingTasksNum {
+		select {
+		case allFormulae = <-formulaeChan:
+			loadingPrgs.MarkCompleted(formulaeChan)
+		case allCasks = <-casksChan:
+			loadingPrgs.MarkCompleted(casksChan)
+		case formulaAnalytics90d = <-formulaAnalytics90dChan:
+			loadingPrgs.MarkCompleted(formulaAnalytics90dChan)
+		case caskAnalytics90d = <-caskAnalytics90dChan:
+			loadingPrgs.MarkCompleted(caskAnalytics90dChan)
+		case formulaInstallInfo = <-formulaInstallInfoChan:
+			loadingPrgs.MarkCompleted(formulaInstallInfoChan)
+		case caskInstallInfo = <-caskInstallInfoChan:
+			loadingPrgs.MarkCompleted(caskInstallInfoChan)
+		case buildErrors90d = <-buildErrors90dChan:
+			loadingPrgs.MarkCompleted(buildErrors90dChan)
+		case err := <-errChan:
+			return DataLoadingErrMsg{err}
 		}
-		go fetchInstalledFormula(fetchSize, formulaInstallInfoChan)
-		loadingPrgs.AddTask(formulaInstallInfoChan, "Loading formulae installation data")
-		go fetchInstalledCask(fetchSize, caskInstallInfoChan)
-		loadingPrgs.AddTask(caskInstallInfoChan, "Loading casks installation data")
+	}
 
-		// Update brew in the background, we don't depend on `brew` command to get data
-		// But we need brew to be updated when install/upgrade packages
-		go updateBrew()
+	if fetchSize {
+		saveSizeCache()
+	}
+
+	// Local catalogs already have every package of the taps
+	var tapFormulae, tapCasks []*installInfo
+	if !installedOnly && !useLocalCatalog() {
+		tapFormulae, tapCasks = listTapPackages(filepath.Join(brewPrefix, "Library", "Taps"))
+	}
 
-		for range loadingTasksNum {
-			select {
-			case allFormulae = <-formulaeChan:
-				loadingPrgs.MarkCompleted(formulaeChan)
-			case allCasks = <-casksChan:
-				loadingPrgs.MarkCompleted(casksChan)
-			case formulaAnalytics90d = <-formulaAnalytics90dChan:
-				loadingPrgs.MarkCompleted(formulaAnalytics90dChan)
-			case caskAnalytics90d = <-caskAnalytics90dChan:
-				loadingPrgs.MarkCompleted(caskAnalytics90dChan)
-			case formulaInstallInfo = <-formulaInstallInfoChan:
-				loadingPrgs.MarkCompleted(formulaInstallInfoChan)
-			case caskInstallInfo = <-caskInstallInfoChan:
-				loadingPrgs.MarkCompleted(caskInstallInfoChan)
-			case err := <-errChan:
-				return DataLoadingErrMsg{err}
+	allBrewPackages = processAllData(
+		allFormulae,
+		allCasks,
+		formulaAnalytics90d,
+		caskAnalytics90d,
+		formulaInstallInfo,
+		caskInstallInfo,
+		tapFormulae,
+		tapCasks,
+	)
+	applyBuildErrors(allBrewPackages, buildErrors90d)
+	if external := append(<-masAppsChan, <-whalebrewChan...); len(external) > 0 {
+		allBrewPackages = append(allBrewPackages, external...)
+		sortPackages(allBrewPackages)
+	}
+	applySnoozes(allBrewPackages)
+	msg := DataLoadedMsg{
+		Packages:          allBrewPackages,
+		BrokenTapPackages: findBrokenTapPackages(append(formulaInstallInfo, caskInstallInfo...)),
+		Unavailable:       drainUnavailable(unavailableChan),
+	}
+	if fetchAnalytics && !slices.Contains(msg.Unavailable, SourceAnalytics) {
+		msg.formulaInstalls = mapFormulaeInstalls(formulaAnalytics90d)
+		msg.caskInstalls = mapCaskInstalls(caskAnalytics90d)
+	}
+	return msg
+}
+
+// Fetch data packages can do without. A failure sends the zero value, so loading goes on
+// without the data, and reports the source as unavailable.
+func fetchOptional[T any](fetch func(chan T, chan error), source OptionalSource, dataChan chan T, unavailableChan chan OptionalSource) {
+	innerChan := make(chan T, 1)
+	errChan := make(chan error, 1)
+	fetch(innerChan, errChan)
+	select {
+	case data := <-innerChan:
+		dataChan <- data
+	case err := <-errChan:
+		log.Printf("failed to load %s, continuing without it: %v", source, err)
+		// Reported before the data, so it's in the channel once all tasks are done
+		unavailableChan <- source
+		var zero T
+		dataChan <- zero
+	}
+}
+
+func drainUnavailable(unavailableChan chan OptionalSource) []OptionalSource {
+	unavailable := []OptionalSource{}
+	for {
+		select {
+		case source := <-unavailableChan:
+			if !slices.Contains(unavailable, source) {
+				unavailable = append(unavailable, source)
 			}
+		default:
+			return unavailable
 		}
+	}
+}
 
-		allBrewPackages = processAllData(
-			allFormulae,
-			allCasks,
-			formulaAnalytics90d,
-			caskAnalytics90d,
-			formulaInstallInfo,
-			caskInstallInfo,
-		)
-		return DataLoadedMsg{Packages: allBrewPackages}
+// Installed packages from third-party taps that couldn't be read, usually because the tap is
+// a shallow or partial clone, or it's been modified locally
+func findBrokenTapPackages(installed []*installInfo) []string {
+	broken := []string{}
+	for _, info := range installed {
+		if info.tap != coreTap && info.tap != caskTap && info.tap != "" && GetPackage(info.tap+"/"+info.name) == nil {
+			broken = append(broken, info.tap+"/"+info.name)
+		}
 	}
+	return util.Sort(broken)
 }
 
 func updateBrew() {
@@ -117,97 +288,251 @@ func processAllData(
 	formulaAnalytics90d apiFormulaAnalytics,
 	caskAnalytics90d apiCaskAnalytics,
 	formulaInstallInfo, caskInstallInfo []*installInfo,
+	tapFormulae, tapCasks []*installInfo, // All packages of third-party taps, installed or not
 ) []*data.Package {
 	formulaInstalls90d := mapFormulaeInstalls(formulaAnalytics90d) // formula name to 90d installs
 	caskInstalls90d := mapCaskInstalls(caskAnalytics90d)           // cask name to 90d installs
 	installedFormulae := mapInstallInfo(formulaInstallInfo)        // formula name to *installInfo
 	installedCasks := mapInstallInfo(caskInstallInfo)              // cask  name to *installInfo
-	formulaDependents := make(map[string][]string)                 // formula name to packages that depends on it
-	caskDependents := make(map[string][]string)                    // cask name to packages that depends on it
+	formulaDependents := make(map[string][]*data.Package)          // formula name to packages that depends on it
+	caskDependents := make(map[string][]*data.Package)             // cask name to packages that depends on it
+
+	// Third-party and broken packages are added on top of the catalog, but there are few of them
+	packages := make([]*data.Package, 0, len(formulae)+len(casks)+len(formulaInstallInfo)+len(caskInstallInfo))
+	packageTexts = newPackageTexts()
+	catalogStrings = make(stringPool)
+	defer func() { catalogStrings = nil }()
+
+	// The catalog from local taps includes third-party packages, which don't need to be read from .rb files
+	catalogFormulae := make(map[string]bool, len(formulae))
+	for _, f := range formulae {
+		catalogFormulae[f.Tap+"/"+f.Name] = true
+	}
+	catalogCasks := make(map[string]bool, len(casks))
+	for _, c := range casks {
+		catalogCasks[c.Tap+"/"+c.Name] = true
+	}
 
-	packages := []*data.Package{}
+	// Third-party packages that can't be parsed from .rb files are evaluated by brew
+	evalFormulae := []*installInfo{}
+	evalCasks := []*installInfo{}
 
 	for _, info := range formulaInstallInfo {
-		if info.tap == coreTap {
+		// Packages without a tap are installed by older brew without a receipt, or broken
+		if info.tap == "" || info.tap == coreTap || catalogFormulae[info.tap+"/"+info.name] {
 			continue
 		}
 		// Add formulae from third-party taps, since they're not in formula.json
 		pkg, err := getCustomTapPackage(info)
 		if err == nil {
-			pkg.Installs90d = formulaInstalls90d[pkg.Name]
+			pkg.Installs90d = formulaInstalls90d[pkg.FullName()]
 			pkg.InstallSupported = true
 			pkg.IsCask = false
+			pkg.Sources.Catalog = data.SourceTapFile
 			pkg = updateInstallInfo(pkg, info)
 			packages = append(packages, pkg)
 			for _, dep := range pkg.Dependencies {
-				formulaDependents[dep] = append(formulaDependents[dep], pkg.Name)
+				formulaDependents[dep] = append(formulaDependents[dep], pkg)
 			}
 		} else {
-			log.Printf("failed to retrieve infomation for %s/%s: %v", info.tap, info.name, err)
+			log.Printf("failed to parse %s/%s, evaluating it with brew: %v", info.tap, info.name, err)
+			evalFormulae = append(evalFormulae, info)
 		}
 	}
 
 	for _, info := range caskInstallInfo {
-		if info.tap == caskTap {
+		if info.tap == "" || info.tap == caskTap || catalogCasks[info.tap+"/"+info.name] {
 			continue
 		}
 		// Add casks from third-party taps, since they're not in cask.json
 		pkg, err := getCustomTapPackage(info)
 		if err == nil {
-			pkg.Installs90d = formulaInstalls90d[pkg.Name]
-			pkg.IsCask = true
-			pkg.InstallSupported = len(pkg.Urls) > 0 && isInstallSupported(pkg.Urls[0])
+			pkg.Installs90d = caskInstalls90d[pkg.FullName()]
+			pkg.Sources.Catalog = data.SourceTapFile
 			pkg = updateInstallInfo(pkg, info)
 			packages = append(packages, pkg)
 		} else {
-			log.Printf("failed to retrieve infomation for %s/%s: %v", info.tap, info.name, err)
+			log.Printf("failed to parse %s/%s, evaluating it with brew: %v", info.tap, info.name, err)
+			evalCasks = append(evalCasks, info)
+		}
+	}
+
+	if catalog, err := evalCustomTapPackages(evalFormulae, false); err == nil {
+		formulae = append(formulae, catalog.Formulae...)
+	} else {
+		log.Printf("failed to retrieve infomation for %d formulae from custom taps: %v", len(evalFormulae), err)
+	}
+	if catalog, err := evalCustomTapPackages(evalCasks, true); err == nil {
+		casks = append(casks, catalog.Casks...)
+	} else {
+		log.Printf("failed to retrieve infomation for %d casks from custom taps: %v", len(evalCasks), err)
+	}
+
+	// Installed and catalog packages take precedence over uninstalled ones of the same name
+	listedFormulae := make(map[string]bool, len(formulae)+len(formulaInstallInfo))
+	for _, f := range formulae {
+		listedFormulae[f.Name] = true
+	}
+	for _, info := range formulaInstallInfo {
+		listedFormulae[info.name] = true
+	}
+	listedCasks := make(map[string]bool, len(casks)+len(caskInstallInfo))
+	for _, c := range casks {
+		listedCasks[c.Name] = true
+	}
+	for _, info := range caskInstallInfo {
+		listedCasks[info.name] = true
+	}
+	for _, pkg := range uninstalledTapPackages(tapFormulae, listedFormulae, false) {
+		packages = append(packages, pkg)
+		for _, dep := range pkg.Dependencies {
+			formulaDependents[dep] = append(formulaDependents[dep], pkg)
 		}
 	}
+	packages = append(packages, uninstalledTapPackages(tapCasks, listedCasks, true)...)
 
 	// Add formulae
 	for _, f := range formulae {
-		packages = append(packages, packageFromFormula(f, formulaInstalls90d[f.Name], installedFormulae[f.Name]))
+		pkg := packageFromFormula(f, formulaInstalls90d[f.Name], matchInstallInfo(installedFormulae, f.Name, f.Tap))
+		packages = append(packages, pkg)
 		for _, dep := range f.Dependencies {
-			formulaDependents[dep] = append(formulaDependents[dep], f.Name)
+			formulaDependents[dep] = append(formulaDependents[dep], pkg)
 		}
 	}
 
 	// Add casks
 	for _, c := range casks {
-		packages = append(packages, packageFromCask(c, caskInstalls90d[c.Name], installedCasks[c.Name]))
+		pkg := packageFromCask(c, caskInstalls90d[c.Name], matchInstallInfo(installedCasks, c.Name, c.Tap))
+		packages = append(packages, pkg)
 		for _, dep := range c.Dependencies.Formulae {
-			formulaDependents[dep] = append(formulaDependents[dep], c.Name)
+			formulaDependents[dep] = append(formulaDependents[dep], pkg)
 		}
 		for _, dep := range c.Dependencies.Casks {
-			caskDependents[dep] = append(caskDependents[dep], c.Name)
+			caskDependents[dep] = append(caskDependents[dep], pkg)
 		}
 	}
 
+	packages = append(packages, brokenPackages(packages, formulaInstallInfo, false)...)
+	packages = append(packages, brokenPackages(packages, caskInstallInfo, true)...)
+
+	sortPackages(packages)
+
 	// Post processing: fetch release info and populate dependents
+	installedPackages := []*data.Package{}
+	outdatedPackages := []*data.Package{}
+	preferred := make(map[string]bool) // Formulae and casks that get dependencies by short name
 	for _, pkg := range packages {
-		if *flagFetchReleaseInfo && pkg.IsInstalled {
-			// Fetch release note in background as non blocking go routines
-			go func() {
-				pkg.ReleaseInfo = gh.GetGithubReleaseInfo(pkg)
-			}()
+		if pkg.IsInstalled {
+			installedPackages = append(installedPackages, pkg)
 		}
+		if pkg.IsOutdated {
+			outdatedPackages = append(outdatedPackages, pkg)
+		}
+		dependents, kind := formulaDependents, "formula"
 		if pkg.IsCask {
-			pkg.Dependents = util.SortAndUniq(caskDependents[pkg.Name])
+			dependents, kind = caskDependents, "cask"
+		}
+		// Dependents are known by unique name once packages are sorted, shadowed ones by their tap too
+		dependentPkgs := slices.Clone(dependents[pkg.FullName()])
+		if !preferred[kind+"/"+pkg.Name] {
+			preferred[kind+"/"+pkg.Name] = true
+			dependentPkgs = append(dependentPkgs, dependents[pkg.Name]...)
+		}
+		pkg.Dependents = util.SortAndUniq(uniqueNames(dependentPkgs))
+	}
+
+	if *flagCheckSecurity {
+		// Check vulnerabilities in background as a non blocking go routine
+		if *flagSecurityScope == securityScopeOutdated {
+			go osv.FetchVulnerabilities(outdatedPackages)
 		} else {
-			pkg.Dependents = util.SortAndUniq(formulaDependents[pkg.Name])
+			go osv.FetchVulnerabilities(installedPackages)
 		}
 	}
 
-	// Sort all packages by name for faster lookups later.
-	sort.Slice(packages, func(i, j int) bool {
-		return packages[i].Name < packages[j].Name
-	})
+	if GetCatalogScope() == CatalogTrimmed {
+		// Keeps the order of packages
+		packages = trimCatalog(packages, *flagCatalogTop)
+	}
 
 	return packages
 }
 
+// Sort packages by name for faster lookups. Packages of the same name are in the order brew resolves
+// the short name: a formula of homebrew/core, a cask of homebrew/cask, then the ones of other taps.
+func sortPackages(packages []*data.Package) {
+	slices.SortFunc(packages, func(a, b *data.Package) int {
+		return cmp.Or(
+			strings.Compare(a.Name, b.Name),
+			cmp.Compare(resolutionRank(a), resolutionRank(b)),
+			strings.Compare(a.Tap, b.Tap),
+		)
+	})
+	for i, pkg := range packages {
+		pkg.Shadowed = i > 0 && packages[i-1].Name == pkg.Name
+	}
+}
+
+func resolutionRank(pkg *data.Package) int {
+	switch {
+	case !pkg.IsCask && pkg.Tap == coreTap:
+		return 0
+	case pkg.IsCask && pkg.Tap == caskTap:
+		return 1
+	case !pkg.IsCask:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Install info of a catalog package, unless what's installed is a package of the same name from another tap.
+// Installations without a known tap, e.g. from older brew, are attributed by name.
+func matchInstallInfo(installed map[string]*installInfo, name, tap string) *installInfo {
+	if info := installed[name]; info != nil && (info.tap == "" || strings.EqualFold(info.tap, tap)) {
+		return info
+	}
+	return nil
+}
+
+// Packages for broken installations that aren't in the catalog, so that they can still be repaired or removed
+func brokenPackages(packages []*data.Package, installed []*installInfo, isCask bool) []*data.Package {
+	found := make(map[string]bool)
+	for _, pkg := range packages {
+		if pkg.IsCask == isCask {
+			found[pkg.Name] = true
+		}
+	}
+	broken := []*data.Package{}
+	for _, info := range installed {
+		if info.broken == "" || found[info.name] {
+			continue
+		}
+		pkg := &data.Package{
+			Name:          info.name,
+			Tap:           info.tap,
+			IsCask:        isCask,
+			IsInstalled:   true,
+			BrokenInstall: info.broken,
+		}
+		pkg.SetTexts(packageTexts, "", "")
+		broken = append(broken, pkg)
+	}
+	return broken
+}
+
+// Keep home pages on disk, or in memory when the cache dir isn't writable
+func newPackageTexts() *data.TextStore {
+	store, err := data.NewTextStore(taproomCacheDir)
+	if err != nil {
+		log.Printf("failed to create text store, keeping package texts in memory: %v", err)
+		return data.NewMemoryTextStore()
+	}
+	return store
+}
+
 func mapFormulaeInstalls(formulaAnalytics apiFormulaAnalytics) map[string]int {
-	formulaInstalls := make(map[string]int)
+	formulaInstalls := make(map[string]int, len(formulaAnalytics.Items))
 	for _, item := range formulaAnalytics.Items {
 		formulaInstalls[item.Name] = parseInstallCount(item.Count)
 	}
@@ -215,7 +540,7 @@ func mapFormulaeInstalls(formulaAnalytics apiFormulaAnalytics) map[string]int {
 }
 
 func mapCaskInstalls(caskAnalytics apiCaskAnalytics) map[string]int {
-	caskInstalls := make(map[string]int)
+	caskInstalls := make(map[string]int, len(caskAnalytics.Items))
 	for _, item := range caskAnalytics.Items {
 		caskInstalls[item.Name] = parseInstallCount(item.Count)
 	}
@@ -229,7 +554,7 @@ func parseInstallCount(str string) int {
 }
 
 func mapInstallInfo(info []*installInfo) map[string]*installInfo {
-	installedMap := make(map[string]*installInfo)
+	installedMap := make(map[string]*installInfo, len(info))
 	for _, item := range info {
 		installedMap[item.name] = item
 	}
@@ -238,23 +563,29 @@ func mapInstallInfo(info []*installInfo) map[string]*installInfo {
 
 func packageFromFormula(f *apiFormula, installs90d int, inst *installInfo) *data.Package {
 	pkg := data.Package{
-		Name:              f.Name,
+		Name:              catalogStrings.intern(f.Name),
 		Aliases:           f.Aliases,
-		Tap:               f.Tap,
+		Tap:               catalogStrings.intern(f.Tap),
 		Version:           f.Versions.Stable,
 		Revision:          f.Revision,
-		Desc:              f.Desc,
-		Homepage:          f.Homepage,
 		Urls:              []string{f.Urls.Stable.Url, f.Urls.Head.Url},
-		License:           f.License,
-		Dependencies:      util.Sort(f.Dependencies),
-		BuildDependencies: util.Sort(f.BuildDependencies),
-		Conflicts:         f.Conflicts,
+		License:           catalogStrings.intern(f.License),
+		Dependencies:      catalogStrings.internAll(util.Sort(f.Dependencies)),
+		BuildDependencies: catalogStrings.internAll(util.Sort(f.BuildDependencies)),
+		Conflicts:         catalogStrings.internAll(f.Conflicts),
 		Installs90d:       installs90d,
 		IsDeprecated:      f.Deprecated,
 		IsDisabled:        f.Disabled,
+		Deprecation:       f.toDeprecation(f.Deprecated, f.Disabled),
 		InstallSupported:  true,
+		Platforms:         catalogStrings.internAll(f.bottleTags()),
+		Options:           f.options(),
+		IsKegOnly:         f.KegOnly,
+		ProvidedByMacOS:   f.providedByMacOS(),
+		Sources:           data.Sources{Catalog: cmp.Or(f.source, data.SourceApi)},
 	}
+	pkg.RequiresMacOS, pkg.MinMacOSVersion = f.requiresMacOS()
+	pkg.SetTexts(packageTexts, f.Desc, f.Homepage)
 
 	if inst != nil {
 		return updateInstallInfo(&pkg, inst)
@@ -264,23 +595,31 @@ func packageFromFormula(f *apiFormula, installs90d int, inst *installInfo) *data
 }
 
 func packageFromCask(c *apiCask, installs90d int, inst *installInfo) *data.Package {
+	artifacts, requiresSudo := c.artifacts()
 	pkg := data.Package{
-		Name:             c.Name,
-		Tap:              c.Tap,
+		Name:             catalogStrings.intern(c.Name),
+		Tap:              catalogStrings.intern(c.Tap),
 		Version:          c.Version,
-		Desc:             c.Desc,
-		Homepage:         c.Homepage,
 		Urls:             []string{c.Url},
 		License:          "N/A",
-		Dependencies:     util.Sort(append(c.Dependencies.Formulae, c.Dependencies.Casks...)),
-		Conflicts:        util.Sort(append(c.Conflicts.Formulae, c.Conflicts.Casks...)),
+		Dependencies:     catalogStrings.internAll(util.Sort(append(c.Dependencies.Formulae, c.Dependencies.Casks...))),
+		Conflicts:        catalogStrings.internAll(util.Sort(append(c.Conflicts.Formulae, c.Conflicts.Casks...))),
 		Installs90d:      installs90d,
 		IsCask:           true,
-		InstallSupported: isInstallSupported(c.Url),
+		InstallSupported: isInstallSupported(c.Url) && !requiresSudo,
 		AutoUpdate:       c.AutoUpdate,
+		ZapPaths:         c.zapPaths(),
+		Artifacts:        artifacts,
+		RequiresSudo:     requiresSudo,
+		Languages:        catalogStrings.internAll(c.Languages),
+		Variants:         c.variants(),
+		MinMacOSVersion:  c.minMacOSVersion(),
 		IsDeprecated:     c.Deprecated,
 		IsDisabled:       c.Disabled,
+		Deprecation:      c.toDeprecation(c.Deprecated, c.Disabled),
+		Sources:          data.Sources{Catalog: cmp.Or(c.source, data.SourceApi)},
 	}
+	pkg.SetTexts(packageTexts, c.Desc, c.Homepage)
 
 	if inst != nil {
 		return updateInstallInfo(&pkg, inst)
@@ -307,26 +646,54 @@ func updateInstallInfo(pkg *data.Package, inst *installInfo) *data.Package {
 	} else {
 		pkg.InstalledVersion = inst.version
 		pkg.InstalledRevision = inst.revision
-		pkg.IsOutdated = inst.version != pkg.Version || inst.revision < pkg.Revision
+		// Broken installs have no version to compare, they're repaired rather than upgraded
+		pkg.IsOutdated = inst.broken == "" && (inst.version != pkg.Version || inst.revision < pkg.Revision)
 	}
+	pkg.BrokenInstall = inst.broken
 	pkg.IsPinned = inst.pinned
+	pkg.IsLinked = inst.linked && !pkg.IsCask
 	pkg.InstalledAsDependency = inst.asDep
 	pkg.Size = inst.size
-	pkg.FormattedSize = util.FormatSize(inst.size)
 	pkg.InstalledDate = time.Unix(inst.timestamp, 0).Format(time.DateOnly)
+	// Casks record their version in the directory name, which is more up-to-date than their receipt
+	pkg.Sources.InstalledVersion, pkg.Sources.InstalledDate = data.SourceInstallDir, data.SourceInstallDir
+	if inst.receipt != "" {
+		pkg.Sources.InstalledDate = data.SourceReceipt
+		if !pkg.IsCask {
+			pkg.Sources.InstalledVersion = data.SourceReceipt
+		}
+	}
+	if pkg.IsCask && pkg.AutoUpdate {
+		pkg.Sources.InstalledVersion = pkg.Sources.Catalog
+	}
+	pkg.Provenance = &data.Provenance{
+		Receipt:    inst.receipt,
+		Tap:        catalogStrings.intern(inst.tap),
+		SourcePath: inst.path,
+	}
 	return pkg
 }
 
+// Find a package by its name, or by tap and name like user/tap/foo. A name shared by packages of
+// different taps finds the one brew prefers, see sortPackages.
 func GetPackage(name string) *data.Package {
-	// allBrewPackages is sorted by name
-	index := sort.Search(len(allBrewPackages), func(i int) bool {
-		return allBrewPackages[i].Name >= name
-	})
+	return findPackage(allBrewPackages, name)
+}
 
-	if index < len(allBrewPackages) && allBrewPackages[index].Name == name {
-		return allBrewPackages[index]
+func findPackage(packages []*data.Package, name string) *data.Package {
+	tap := ""
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		tap, name = name[:i], name[i+1:]
+	}
+	// packages are sorted by name
+	index := sort.Search(len(packages), func(i int) bool {
+		return packages[i].Name >= name
+	})
+	for ; index < len(packages) && packages[index].Name == name; index++ {
+		if tap == "" || strings.EqualFold(packages[index].Tap, tap) {
+			return packages[index]
+		}
 	}
-
 	return nil
 }
 
@@ -340,30 +707,227 @@ func GetOutdatedPackages() []*data.Package {
 	return outdatedPackages
 }
 
+// Outdated packages upgraded by 'brew upgrade', which skips pinned ones, leaving out snoozed ones
+func GetUpgradablePackages() []*data.Package {
+	pkgs := []*data.Package{}
+	for _, pkg := range GetOutdatedPackages() {
+		if !pkg.IsPinned && !pkg.IsMasApp() && !pkg.IsSnoozed() {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs
+}
+
+// Outdated App Store apps, which are upgraded with mas
+func GetOutdatedMasApps() []*data.Package {
+	pkgs := []*data.Package{}
+	for _, pkg := range GetOutdatedPackages() {
+		if pkg.IsMasApp() && !pkg.IsSnoozed() {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs
+}
+
+func GetPinnedOutdatedPackages() []*data.Package {
+	pkgs := []*data.Package{}
+	for _, pkg := range GetOutdatedPackages() {
+		if pkg.IsPinned {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs
+}
+
 // Recursively find uninstalled dependencies
 func GetRecursiveMissingDeps(pkgName string) []string {
-	pkg := GetPackage(pkgName)
-	if pkg.IsInstalled {
-		return []string{}
-	} else {
-		deps := pkg.Dependencies
-		for _, dep := range pkg.Dependencies {
-			deps = append(deps, GetRecursiveMissingDeps(dep)...)
+	return walkPackages(pkgName, func(pkg *data.Package) []string {
+		if pkg.IsInstalled {
+			return nil
 		}
-		return deps
-	}
+		return pkg.Dependencies
+	})
 }
 
 // Recursively find installed dependents
 func GetRecursiveInstalledDependents(pkgName string) []string {
-	pkg := GetPackage(pkgName)
-	if !pkg.IsInstalled {
-		return []string{}
-	} else {
-		deps := pkg.Dependents
-		for _, dep := range pkg.Dependents {
-			deps = append(deps, GetRecursiveInstalledDependents(dep)...)
+	return walkPackages(pkgName, func(pkg *data.Package) []string {
+		if !pkg.IsInstalled {
+			return nil
+		}
+		return pkg.Dependents
+	})
+}
+
+// Names reached from a package by following next, each package is only followed once so cycles end.
+// Names of packages that aren't loaded, e.g. outside of a trimmed catalog, are kept but not followed.
+func walkPackages(pkgName string, next func(pkg *data.Package) []string) []string {
+	names := []string{}
+	visited := map[string]bool{pkgName: true}
+	var walk func(name string)
+	walk = func(name string) {
+		pkg := GetPackage(name)
+		if pkg == nil {
+			return
+		}
+		for _, n := range next(pkg) {
+			names = append(names, n)
+			if !visited[n] {
+				visited[n] = true
+				walk(n)
+			}
+		}
+	}
+	walk(pkgName)
+	return names
+}
+
+// Installed packages that depend on the given package, directly or indirectly
+func GetInstalledDependents(pkgName string) []string {
+	dependents := []string{}
+	for _, dep := range util.SortAndUniq(GetRecursiveInstalledDependents(pkgName)) {
+		if p := GetPackage(dep); p != nil && p.IsInstalled {
+			dependents = append(dependents, dep)
+		}
+	}
+	return dependents
+}
+
+// Installed packages the package conflicts with, declared on either side, brew refuses to install it next to them
+func GetInstalledConflicts(pkg *data.Package) []*data.Package {
+	return installedConflicts(allBrewPackages, pkg)
+}
+
+func installedConflicts(packages []*data.Package, pkg *data.Package) []*data.Package {
+	conflicts := []*data.Package{}
+	for _, p := range packages {
+		if !p.IsInstalled || p == pkg {
+			continue
+		}
+		// Formulae only conflict with formulae, casks name both formulae and casks
+		if (slices.Contains(pkg.Conflicts, p.Name) && (pkg.IsCask || !p.IsCask)) ||
+			(slices.Contains(p.Conflicts, pkg.Name) && (p.IsCask || !pkg.IsCask)) {
+			conflicts = append(conflicts, p)
+		}
+	}
+	return conflicts
+}
+
+// Find packages that were installed as dependencies and would no longer be required by
+// any installed package once all packages in removing are uninstalled
+func GetOrphanedDeps(removing []string) []string {
+	removed := make(map[string]bool)
+	for _, name := range removing {
+		removed[name] = true
+	}
+
+	orphans := []string{}
+	for changed := true; changed; {
+		changed = false
+		for name := range removed {
+			pkg := GetPackage(name)
+			if pkg == nil {
+				continue
+			}
+			for _, dep := range pkg.Dependencies {
+				depPkg := GetPackage(dep)
+				if removed[dep] || depPkg == nil || !depPkg.IsInstalled || !depPkg.InstalledAsDependency {
+					continue
+				}
+				if !isRequiredByInstalled(depPkg, removed) {
+					removed[dep] = true
+					orphans = append(orphans, dep)
+					changed = true
+				}
+			}
+		}
+	}
+	return util.Sort(orphans)
+}
+
+// What uninstalling a package cascades to: its installed dependents and the dependencies left orphaned,
+// by unique name, and the package with all of them in uninstall order
+func UninstallCascade(pkg *data.Package) (dependents, orphans []string, cascade []*data.Package) {
+	dependents = GetInstalledDependents(pkg.UniqueName())
+	orphans = GetOrphanedDeps(append([]string{pkg.UniqueName()}, dependents...))
+	// The package itself isn't looked up again, its short name may find another package
+	cascade = []*data.Package{pkg}
+	for _, name := range append(slices.Clone(dependents), orphans...) {
+		if p := GetPackage(name); p != nil {
+			cascade = append(cascade, p)
+		}
+	}
+	return dependents, orphans, UninstallOrder(cascade)
+}
+
+// What uninstalling a package would take along with it
+type RemovalCost struct {
+	Dependents []string // Installed packages requiring it, brew refuses to uninstall it unless they're removed too
+	Orphans    []string // Dependencies no other installed package needs any more
+	Size       int64    // Size in kbs of the package and its orphans
+}
+
+func GetRemovalCost(pkg *data.Package) RemovalCost {
+	cost := RemovalCost{
+		Dependents: GetInstalledDependents(pkg.UniqueName()),
+		Orphans:    GetOrphanedDeps([]string{pkg.UniqueName()}),
+		Size:       pkg.Size,
+	}
+	for _, name := range cost.Orphans {
+		if p := GetPackage(name); p != nil {
+			cost.Size += p.Size
+		}
+	}
+	return cost
+}
+
+// Shortest chains of installed dependents from packages installed on request down to a package
+// installed as a dependency, each chain starts with the requested package and ends with the package
+func WhyInstalled(pkg *data.Package) [][]string {
+	return whyInstalled(pkg, GetPackage)
+}
+
+func whyInstalled(pkg *data.Package, lookup func(string) *data.Package) [][]string {
+	// Walk up the dependents level by level, remembering where each package was first reached from
+	next := map[string]string{pkg.Name: ""}
+	level := []string{pkg.Name}
+	chains := [][]string{}
+	for len(level) > 0 && len(chains) == 0 {
+		upper := []string{}
+		for _, name := range level {
+			p := lookup(name)
+			if p == nil {
+				continue
+			}
+			for _, dependent := range util.Sort(slices.Clone(p.Dependents)) {
+				d := lookup(dependent)
+				if _, seen := next[dependent]; seen || d == nil || !d.IsInstalled {
+					continue
+				}
+				next[dependent] = name
+				upper = append(upper, dependent)
+				if !d.InstalledAsDependency {
+					chain := []string{dependent}
+					for n := name; n != ""; n = next[n] {
+						chain = append(chain, n)
+					}
+					chains = append(chains, chain)
+				}
+			}
+		}
+		level = upper
+	}
+	return chains
+}
+
+func isRequiredByInstalled(pkg *data.Package, excluded map[string]bool) bool {
+	for _, name := range pkg.Dependents {
+		if excluded[name] {
+			continue
+		}
+		if p := GetPackage(name); p != nil && p.IsInstalled {
+			return true
 		}
-		return deps
 	}
+	return false
 }
@@ -0,0 +1,361 @@
+package brew
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestGetOrphanedDeps(t *testing.T) {
+	// app -> lib -> base; tool -> base; other -> shared; app -> shared
+	usePackages(t, []*data.Package{
+		{Name: "app", IsInstalled: true, Dependencies: []string{"lib", "shared"}},
+		{Name: "base", IsInstalled: true, InstalledAsDependency: true, Dependents: []string{"lib", "tool"}},
+		{Name: "lib", IsInstalled: true, InstalledAsDependency: true, Dependencies: []string{"base"}, Dependents: []string{"app"}},
+		{Name: "other", IsInstalled: true, Dependencies: []string{"shared"}},
+		{Name: "shared", IsInstalled: true, InstalledAsDependency: true, Dependents: []string{"app", "other"}},
+		{Name: "tool", IsInstalled: false, Dependencies: []string{"base"}},
+	})
+
+	orphans := GetOrphanedDeps([]string{"app"})
+	if want := []string{"base", "lib"}; !slices.Equal(orphans, want) {
+		t.Errorf("expected orphans %v, got %v", want, orphans)
+	}
+
+	orphans = GetOrphanedDeps([]string{"app", "other"})
+	if want := []string{"base", "lib", "shared"}; !slices.Equal(orphans, want) {
+		t.Errorf("expected orphans %v, got %v", want, orphans)
+	}
+}
+
+func TestGetRemovalCost(t *testing.T) {
+	usePackages(t, []*data.Package{
+		{Name: "app", IsInstalled: true, Size: 100, Dependencies: []string{"lib", "shared"}},
+		{Name: "lib", IsInstalled: true, InstalledAsDependency: true, Size: 20, Dependencies: []string{"base"}, Dependents: []string{"app"}},
+		{Name: "base", IsInstalled: true, InstalledAsDependency: true, Size: 3, Dependents: []string{"lib"}},
+		{Name: "shared", IsInstalled: true, InstalledAsDependency: true, Size: 50, Dependents: []string{"app", "other"}},
+		{Name: "other", IsInstalled: true, Dependencies: []string{"shared"}},
+	})
+	slices.SortFunc(allBrewPackages, func(a, b *data.Package) int { return strings.Compare(a.Name, b.Name) })
+
+	cost := GetRemovalCost(GetPackage("app"))
+	if want := []string{"base", "lib"}; !slices.Equal(cost.Orphans, want) || cost.Size != 123 || len(cost.Dependents) != 0 {
+		t.Errorf("expected orphans %v totaling 123KB and no dependents, got %+v", want, cost)
+	}
+	if cost := GetRemovalCost(GetPackage("shared")); !slices.Equal(cost.Dependents, []string{"app", "other"}) {
+		t.Errorf("expected shared to be required by app and other, got %+v", cost)
+	}
+}
+
+func TestUninstallCascade(t *testing.T) {
+	// The foo cask is shadowed by the foo formula, which app depends on
+	formula := &data.Package{Name: "foo", Tap: coreTap, IsInstalled: true, Dependents: []string{"app"}}
+	cask := &data.Package{
+		Name:         "foo",
+		Tap:          caskTap,
+		IsCask:       true,
+		Shadowed:     true,
+		IsInstalled:  true,
+		Dependencies: []string{"helper"},
+	}
+	usePackages(t, []*data.Package{
+		{Name: "app", IsInstalled: true, Dependencies: []string{"foo"}},
+		formula,
+		cask,
+		{Name: "helper", IsInstalled: true, InstalledAsDependency: true, Dependents: []string{"homebrew/cask/foo"}},
+	})
+
+	dependents, orphans, cascade := UninstallCascade(cask)
+	if len(dependents) != 0 {
+		t.Errorf("expected the cask to have no dependents, got %v", dependents)
+	}
+	if want := []string{"helper"}; !slices.Equal(orphans, want) {
+		t.Errorf("expected orphans %v, got %v", want, orphans)
+	}
+	if len(cascade) != 2 || cascade[0] != cask || cascade[1].Name != "helper" {
+		t.Errorf("expected the cask then helper to be uninstalled, got %v", uniqueNames(cascade))
+	}
+
+	if dependents, _, _ := UninstallCascade(formula); !slices.Equal(dependents, []string{"app"}) {
+		t.Errorf("expected app to depend on the formula, got %v", dependents)
+	}
+}
+
+func TestGetInstalledDependents(t *testing.T) {
+	usePackages(t, []*data.Package{
+		{Name: "a", IsInstalled: true, Dependents: []string{"b", "c"}},
+		{Name: "b", IsInstalled: true, Dependents: []string{"d", "gone"}},
+		{Name: "c", IsInstalled: false},
+		{Name: "d", IsInstalled: true, Dependents: []string{"b"}}, // A cycle back to b
+	})
+
+	// gone isn't loaded, e.g. it's outside of a trimmed catalog
+	if got, want := GetInstalledDependents("a"), []string{"b", "d"}; !slices.Equal(got, want) {
+		t.Errorf("expected dependents %v, got %v", want, got)
+	}
+}
+
+func TestInstalledConflicts(t *testing.T) {
+	packages := []*data.Package{
+		{Name: "docker", IsCask: true, IsInstalled: true},
+		{Name: "docker", IsInstalled: true},
+		{Name: "docker-desktop", IsCask: true, Conflicts: []string{"docker"}},
+		{Name: "mysql", IsInstalled: true, Conflicts: []string{"mariadb"}},
+		{Name: "mariadb"},
+		{Name: "percona-server", Conflicts: []string{"mysql", "mariadb"}},
+	}
+
+	names := func(pkgs []*data.Package) []string {
+		names := []string{}
+		for _, pkg := range pkgs {
+			names = append(names, fmt.Sprintf("%s:%t", pkg.Name, pkg.IsCask))
+		}
+		return names
+	}
+	if got, want := names(installedConflicts(packages, packages[2])), []string{"docker:true", "docker:false"}; !slices.Equal(got, want) {
+		t.Errorf("expected a cask to conflict with formulae and casks %v, got %v", want, got)
+	}
+	if got, want := names(installedConflicts(packages, packages[4])), []string{"mysql:false"}; !slices.Equal(got, want) {
+		t.Errorf("expected conflicts declared by installed packages %v, got %v", want, got)
+	}
+	if got, want := names(installedConflicts(packages, packages[5])), []string{"mysql:false"}; !slices.Equal(got, want) {
+		t.Errorf("expected only installed conflicts %v, got %v", want, got)
+	}
+}
+
+func TestWhyInstalled(t *testing.T) {
+	// app -> lib -> base; tool -> base; other -> mid -> lib; old (uninstalled) -> orphan
+	packages := map[string]*data.Package{
+		"app":    {Name: "app", IsInstalled: true, Dependencies: []string{"lib"}},
+		"tool":   {Name: "tool", IsInstalled: true, Dependencies: []string{"base"}},
+		"other":  {Name: "other", IsInstalled: true, Dependencies: []string{"mid"}},
+		"mid":    {Name: "mid", IsInstalled: true, InstalledAsDependency: true, Dependencies: []string{"lib"}, Dependents: []string{"other"}},
+		"lib":    {Name: "lib", IsInstalled: true, InstalledAsDependency: true, Dependencies: []string{"base"}, Dependents: []string{"mid", "app"}},
+		"base":   {Name: "base", IsInstalled: true, InstalledAsDependency: true, Dependents: []string{"lib", "tool"}},
+		"old":    {Name: "old", Dependencies: []string{"orphan"}},
+		"orphan": {Name: "orphan", IsInstalled: true, InstalledAsDependency: true, Dependents: []string{"old"}},
+	}
+	lookup := func(name string) *data.Package { return packages[name] }
+
+	chains := whyInstalled(packages["base"], lookup)
+	if len(chains) != 1 || !slices.Equal(chains[0], []string{"tool", "base"}) {
+		t.Errorf("expected only the shortest chain [tool base], got %v", chains)
+	}
+	chains = whyInstalled(packages["lib"], lookup)
+	if len(chains) != 1 || !slices.Equal(chains[0], []string{"app", "lib"}) {
+		t.Errorf("expected [[app lib]], got %v", chains)
+	}
+	chains = whyInstalled(packages["mid"], lookup)
+	if len(chains) != 1 || !slices.Equal(chains[0], []string{"other", "mid"}) {
+		t.Errorf("expected [[other mid]], got %v", chains)
+	}
+	if chains := whyInstalled(packages["orphan"], lookup); len(chains) != 0 {
+		t.Errorf("expected no chains for an orphan, got %v", chains)
+	}
+
+	packages["tool"].IsInstalled = false
+	chains = whyInstalled(packages["base"], lookup)
+	if len(chains) != 1 || !slices.Equal(chains[0], []string{"app", "lib", "base"}) {
+		t.Errorf("expected [[app lib base]], got %v", chains)
+	}
+}
+
+func TestPackageFromCaskZapPaths(t *testing.T) {
+	cask := apiCask{}
+	payload := `{
+		"token": "foo",
+		"artifacts": [
+			{"app": ["Foo.app"]},
+			{"zap": [{"trash": ["~/Library/Caches/foo", "~/Library/Preferences/foo.plist"], "rmdir": "~/foo"}]}
+		]
+	}`
+	if err := json.Unmarshal([]byte(payload), &cask); err != nil {
+		t.Fatalf("failed to decode cask: %v", err)
+	}
+
+	pkg := packageFromCask(&cask, 0, nil)
+	want := []string{"~/Library/Caches/foo", "~/Library/Preferences/foo.plist", "~/foo"}
+	if !slices.Equal(pkg.ZapPaths, want) {
+		t.Errorf("expected zap paths %v, got %v", want, pkg.ZapPaths)
+	}
+}
+
+func TestPackageFromCaskArtifacts(t *testing.T) {
+	cask := apiCask{}
+	payload := `{
+		"token": "foo",
+		"url": "https://example.com/Foo.dmg",
+		"artifacts": [
+			{"app": ["Foo.app"]},
+			{"binary": ["$APPDIR/Foo.app/Contents/MacOS/foo-cli", {"target": "foo"}]},
+			{"installer": [{"script": {"executable": "Install Foo Helper", "sudo": true}}]},
+			{"uninstall": [{"launchctl": ["com.foo.agent", "com.foo.updater"], "quit": "com.foo"}]},
+			{"zap": [{"trash": "~/Library/Caches/foo"}]}
+		]
+	}`
+	if err := json.Unmarshal([]byte(payload), &cask); err != nil {
+		t.Fatalf("failed to decode cask: %v", err)
+	}
+
+	pkg := packageFromCask(&cask, 0, nil)
+	want := []data.CaskArtifact{
+		{Kind: "app", Target: "Foo.app"},
+		{Kind: "binary", Target: "foo"},
+		{Kind: "installer", Target: "Install Foo Helper"},
+		{Kind: "launchctl", Target: "com.foo.agent"},
+		{Kind: "launchctl", Target: "com.foo.updater"},
+	}
+	if !slices.Equal(pkg.Artifacts, want) {
+		t.Errorf("expected artifacts %v, got %v", want, pkg.Artifacts)
+	}
+	if !pkg.RequiresSudo || pkg.InstallSupported {
+		t.Errorf("expected a cask requiring sudo to be unsupported, got sudo %v and supported %v", pkg.RequiresSudo, pkg.InstallSupported)
+	}
+}
+
+func TestLinkedState(t *testing.T) {
+	formula := apiFormula{Name: "curl", KegOnly: true}
+	pkg := packageFromFormula(&formula, 0, &installInfo{name: "curl", version: "8.11.1", linked: false})
+	usePackages(t, []*data.Package{pkg})
+	if !pkg.IsKegOnly || pkg.IsLinked {
+		t.Errorf("expected an unlinked keg-only formula, got keg-only %v and linked %v", pkg.IsKegOnly, pkg.IsLinked)
+	}
+
+	UpdatePackageForAction(BrewCommandLink, []*data.Package{pkg})
+	if !pkg.IsLinked {
+		t.Errorf("expected curl to be linked")
+	}
+	UpdatePackageForAction(BrewCommandUninstall, []*data.Package{pkg})
+	UpdatePackageForAction(BrewCommandInstall, []*data.Package{pkg})
+	if pkg.IsLinked {
+		t.Errorf("expected a reinstalled keg-only formula to stay unlinked")
+	}
+}
+
+func TestSameNamedPackages(t *testing.T) {
+	useTempCacheDir(t)
+	path := filepath.Join(t.TempDir(), "goku.rb")
+	if err := os.WriteFile(path, []byte(`class Goku < Formula
+  desc "Karabiner configurator"
+  homepage "https://github.com/yqrashawn/GokuRakuJoudo"
+  url "https://github.com/yqrashawn/GokuRakuJoudo/releases/download/v0.6.0/goku.zip"
+  version "0.6.0"
+end
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	core := &apiFormula{Name: "goku", Tap: coreTap}
+	core.Versions.Stable = "1.0"
+	user := &apiFormula{Name: "karabiner-tools", Tap: coreTap, Dependencies: []string{"yqrashawn/goku/goku"}}
+	user.Versions.Stable = "2.0"
+	installed := []*installInfo{{name: "goku", tap: "yqrashawn/goku", version: "0.6.0", path: path}}
+
+	usePackages(t, processAllData([]*apiFormula{core, user}, nil, apiFormulaAnalytics{}, apiCaskAnalytics{}, installed, nil, nil, nil))
+	preferred, tapped := GetPackage("goku"), GetPackage("yqrashawn/goku/goku")
+	if preferred == nil || preferred.Tap != coreTap || preferred.IsInstalled || preferred.Shadowed {
+		t.Errorf("expected goku of homebrew/core to be preferred and not installed, got %+v", preferred)
+	}
+	if tapped == nil || !tapped.IsInstalled || !tapped.Shadowed || tapped.UniqueName() != "yqrashawn/goku/goku" {
+		t.Errorf("expected the installed goku of yqrashawn/goku to be shadowed, got %+v", tapped)
+	}
+	if tapped != nil && !slices.Equal(tapped.Dependents, []string{"karabiner-tools"}) {
+		t.Errorf("expected karabiner-tools to depend on the tapped goku, got %v", tapped.Dependents)
+	}
+	if preferred != nil && len(preferred.Dependents) != 0 {
+		t.Errorf("expected no dependents of the core goku, got %v", preferred.Dependents)
+	}
+}
+
+func TestProvidedByMacOS(t *testing.T) {
+	tests := []struct {
+		json string
+		want bool
+	}{
+		{`{"name": "curl", "keg_only": true, "keg_only_reason": {"reason": ":provided_by_macos", "explanation": ""}}`, true},
+		{`{"name": "libressl", "keg_only": true, "keg_only_reason": {"reason": ":shadowed_by_macos", "explanation": ""}}`, true},
+		{`{"name": "python@3.12", "keg_only": true, "keg_only_reason": {"reason": ":versioned_formula", "explanation": ""}}`, false},
+		{`{"name": "wget", "keg_only": false, "keg_only_reason": null}`, false},
+	}
+	for _, tt := range tests {
+		var f apiFormula
+		if err := json.Unmarshal([]byte(tt.json), &f); err != nil {
+			t.Fatal(err)
+		}
+		if got := packageFromFormula(&f, 0, nil).ProvidedByMacOS; got != tt.want {
+			t.Errorf("expected provided by macOS %v for %s, got %v", tt.want, f.Name, got)
+		}
+	}
+}
+
+func TestGetUpgradablePackages(t *testing.T) {
+	usePackages(t, []*data.Package{
+		{Name: "a", IsInstalled: true, IsOutdated: true},
+		{Name: "b", IsInstalled: true, IsOutdated: true, IsPinned: true},
+		{Name: "c", IsInstalled: true},
+		{Name: "d", IsInstalled: true, IsPinned: true},
+	})
+
+	if got := packageNames(GetUpgradablePackages()); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("expected upgradable packages [a], got %v", got)
+	}
+	if got := packageNames(GetPinnedOutdatedPackages()); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("expected pinned outdated packages [b], got %v", got)
+	}
+}
+
+func TestFindBrokenTapPackages(t *testing.T) {
+	usePackages(t, []*data.Package{
+		{Name: "bar", Tap: "someone/tools"},
+		{Name: "wget", Tap: coreTap},
+		{Name: "wget", Tap: "someone/tools", Shadowed: true},
+	})
+	installed := []*installInfo{
+		{name: "wget", tap: coreTap},
+		{name: "gone", tap: coreTap},
+		{name: "bar", tap: "someone/tools"},
+		{name: "foo", tap: "someone/tools"},
+		{name: "wget", tap: "someone/tools"},
+		{name: "wget", tap: "other/tap"}, // Named like a core formula, but its tap couldn't be read
+	}
+
+	if got, want := findBrokenTapPackages(installed), []string{"other/tap/wget", "someone/tools/foo"}; !slices.Equal(got, want) {
+		t.Errorf("expected broken tap packages %v, got %v", want, got)
+	}
+}
+
+func TestFetchOptional(t *testing.T) {
+	failing := func(dataChan chan []*apiFormula, errChan chan error) {
+		errChan <- errors.New("connection refused")
+	}
+	dataChan := make(chan []*apiFormula, 2)
+	unavailableChan := make(chan OptionalSource, 2)
+	fetchOptional(failing, SourceAnalytics, dataChan, unavailableChan)
+	fetchOptional(failing, SourceAnalytics, dataChan, unavailableChan)
+	if got := <-dataChan; got != nil {
+		t.Errorf("expected no data, got %d items", len(got))
+	}
+	got := drainUnavailable(unavailableChan)
+	if len(got) != 1 || got[0] != SourceAnalytics {
+		t.Errorf("expected [%s], got %v", SourceAnalytics, got)
+	}
+
+	working := func(dataChan chan []*apiFormula, errChan chan error) {
+		dataChan <- []*apiFormula{{Name: "wget"}}
+	}
+	<-dataChan
+	fetchOptional(working, SourceAnalytics, dataChan, unavailableChan)
+	if got := <-dataChan; len(got) != 1 {
+		t.Errorf("expected the fetched data, got %d items", len(got))
+	}
+	if got := drainUnavailable(unavailableChan); len(got) != 0 {
+		t.Errorf("expected no unavailable sources, got %v", got)
+	}
+}
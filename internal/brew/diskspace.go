@@ -0,0 +1,95 @@
+package brew
+
+import (
+	"log"
+	"syscall"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const (
+	diskSpaceAsk  = "ask"
+	diskSpaceWarn = "warn"
+	diskSpaceOff  = "off"
+)
+
+var flagDiskSpace = Flags.String(
+	"disk-space-check",
+	diskSpaceAsk,
+	"Before installing or upgrading, compare free disk space with a rough estimate of what's needed: ask (confirm before running when it's short), warn (only print a warning) or off",
+)
+
+// Rough sizes in kbs of packages that aren't installed yet, the catalog doesn't tell the size of bottles
+const (
+	formulaInstallEstimate = 30 * 1024
+	caskInstallEstimate    = 300 * 1024
+)
+
+// Free disk space is less than an install or upgrade is estimated to take
+type DiskSpaceShortage struct {
+	Required int64 // In kbs
+	Free     int64 // In kbs
+}
+
+// Whether a shortage only needs a warning instead of a confirmation
+func DiskSpaceWarnOnly() bool {
+	return *flagDiskSpace == diskSpaceWarn
+}
+
+// Check free space of the brew prefix before installing or upgrading the packages, nil when there's enough
+func CheckDiskSpace(pkgs []*data.Package) *DiskSpaceShortage {
+	if *flagDiskSpace == diskSpaceOff {
+		return nil
+	}
+	free, err := freeDiskSpace(brewPrefix)
+	if err != nil {
+		log.Printf("failed to get free disk space: %v", err)
+		return nil
+	}
+	required := estimateRequiredSpace(pkgs, GetPackage)
+	if required <= free {
+		return nil
+	}
+	return &DiskSpaceShortage{Required: required, Free: free}
+}
+
+// Free space in kbs of the file system the path is on
+func freeDiskSpace(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize) / 1024, nil
+}
+
+// Estimate the space in kbs to download and install the packages and their missing dependencies.
+// An upgrade downloads the new version and keeps the old one until cleanup, so it takes about
+// one and a half times the installed size.
+func estimateRequiredSpace(pkgs []*data.Package, lookup func(string) *data.Package) int64 {
+	var required int64
+	visited := make(map[string]bool)
+	var visit func(pkg *data.Package)
+	visit = func(pkg *data.Package) {
+		if visited[pkg.Name] {
+			return
+		}
+		visited[pkg.Name] = true
+		switch {
+		case pkg.IsInstalled:
+			required += pkg.Size + pkg.Size/2
+		case pkg.IsCask:
+			required += caskInstallEstimate
+		default:
+			required += formulaInstallEstimate
+		}
+		for _, name := range pkg.Dependencies {
+			if dep := lookup(name); dep != nil && !dep.IsInstalled {
+				visit(dep)
+			}
+		}
+	}
+	for _, pkg := range pkgs {
+		visit(pkg)
+	}
+	return required
+}
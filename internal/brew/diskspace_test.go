@@ -0,0 +1,22 @@
+package brew
+
+import (
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestEstimateRequiredSpace(t *testing.T) {
+	installed := &data.Package{Name: "openssl@3", Size: 1000, IsInstalled: true}
+	missing := &data.Package{Name: "libidn2", Dependencies: []string{"openssl@3"}}
+	pkgs := map[string]*data.Package{"openssl@3": installed, "libidn2": missing}
+	lookup := func(name string) *data.Package { return pkgs[name] }
+
+	wget := &data.Package{Name: "wget", Dependencies: []string{"libidn2", "openssl@3"}}
+	if got, want := estimateRequiredSpace([]*data.Package{wget}, lookup), int64(2*formulaInstallEstimate); got != want {
+		t.Errorf("expected %d kbs for wget and its missing dependency, got %d", want, got)
+	}
+	if got := estimateRequiredSpace([]*data.Package{installed}, lookup); got != 1500 {
+		t.Errorf("expected an upgrade to need 1500 kbs, got %d", got)
+	}
+}
@@ -0,0 +1,130 @@
+package brew
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const doctorWarningPrefix = "Warning: "
+
+// A warning of `brew doctor`, e.g. "Some installed formulae are deprecated or disabled." and the formulae
+type DoctorWarning struct {
+	Title   string
+	Details []string
+}
+
+type ConfigEntry struct {
+	Key   string
+	Value string
+}
+
+// Diagnostics of the Homebrew installation
+type Diagnostics struct {
+	Version  string
+	ApiMode  bool // Formulae and casks are read from the API instead of local taps
+	Warnings []DoctorWarning
+	Env      []ConfigEntry // HOMEBREW_* settings in effect
+	System   []ConfigEntry // Everything else reported by `brew config`
+	Errors   []string      // Commands that failed to run
+}
+
+type DiagnosticsLoadedMsg struct {
+	Diagnostics *Diagnostics
+}
+
+// Run `brew --version`, `brew config` and `brew doctor` in the background
+func LoadDiagnostics() tea.Cmd {
+	return func() tea.Msg {
+		var version, config, doctor []byte
+		var versionErr, configErr, doctorErr error
+		var wg sync.WaitGroup
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			version, versionErr = exec.Command("brew", "--version").Output()
+		}()
+		go func() {
+			defer wg.Done()
+			config, configErr = exec.Command("brew", "config").Output()
+		}()
+		go func() {
+			defer wg.Done()
+			// Doctor exits with an error when there are warnings, which are still printed
+			doctor, doctorErr = exec.Command("brew", "doctor").CombinedOutput()
+		}()
+		wg.Wait()
+
+		d := Diagnostics{}
+		if versionErr != nil {
+			d.Errors = append(d.Errors, "brew --version: "+versionErr.Error())
+		} else {
+			d.Version, _, _ = strings.Cut(strings.TrimSpace(string(version)), "\n")
+		}
+		if configErr != nil {
+			d.Errors = append(d.Errors, "brew config: "+configErr.Error())
+		} else {
+			d.Env, d.System = parseBrewConfig(bytes.NewReader(config))
+		}
+		if doctorErr != nil && len(doctor) == 0 {
+			d.Errors = append(d.Errors, "brew doctor: "+doctorErr.Error())
+		} else {
+			d.Warnings = parseBrewDoctor(bytes.NewReader(doctor))
+		}
+		d.ApiMode = isApiMode(d.System)
+		return DiagnosticsLoadedMsg{Diagnostics: &d}
+	}
+}
+
+// Split `brew config` output into HOMEBREW_* settings and system information
+func parseBrewConfig(r io.Reader) (env, system []ConfigEntry) {
+	env, system = []ConfigEntry{}, []ConfigEntry{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		entry := ConfigEntry{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)}
+		if strings.HasPrefix(entry.Key, "HOMEBREW_") {
+			env = append(env, entry)
+		} else {
+			system = append(system, entry)
+		}
+	}
+	return env, system
+}
+
+// Parse warnings of `brew doctor`, each starts with "Warning: " and is followed by lines of details
+func parseBrewDoctor(r io.Reader) []DoctorWarning {
+	warnings := []DoctorWarning{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if title, ok := strings.CutPrefix(line, doctorWarningPrefix); ok {
+			warnings = append(warnings, DoctorWarning{Title: title})
+		} else if len(warnings) > 0 && strings.TrimSpace(line) != "" {
+			last := &warnings[len(warnings)-1]
+			last.Details = append(last.Details, line)
+		}
+	}
+	return warnings
+}
+
+// brew config reports when the core tap JSON from the API was downloaded, unless it reads local taps
+func isApiMode(system []ConfigEntry) bool {
+	if brewSetting("HOMEBREW_NO_INSTALL_FROM_API") != "" {
+		return false
+	}
+	for _, entry := range system {
+		if strings.HasSuffix(entry.Key, "tap JSON") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package brew
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBrewDoctor(t *testing.T) {
+	output := `Please note that these warnings are just used to help the Homebrew maintainers
+with debugging if you file an issue. If everything you use Homebrew for is
+working fine: please don't worry or file an issue; just ignore this. Thanks!
+
+Warning: Some installed formulae are deprecated or disabled.
+You should find replacements for the following formulae:
+  youtube-dl
+
+Warning: Unbrewed dylibs were found in /usr/local/lib.
+  /usr/local/lib/libfoo.dylib
+`
+	warnings := parseBrewDoctor(strings.NewReader(output))
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %d", len(warnings))
+	}
+	if warnings[0].Title != "Some installed formulae are deprecated or disabled." || len(warnings[0].Details) != 2 {
+		t.Errorf("expected the deprecation warning with 2 lines of details, got %+v", warnings[0])
+	}
+	if warnings[1].Title != "Unbrewed dylibs were found in /usr/local/lib." || len(warnings[1].Details) != 1 {
+		t.Errorf("expected the dylibs warning with 1 line of details, got %+v", warnings[1])
+	}
+
+	if warnings := parseBrewDoctor(strings.NewReader("Your system is ready to brew.\n")); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %+v", warnings)
+	}
+}
+
+func TestParseBrewConfig(t *testing.T) {
+	t.Setenv("HOMEBREW_NO_INSTALL_FROM_API", "")
+	output := `HOMEBREW_VERSION: 4.4.9
+ORIGIN: https://github.com/Homebrew/brew
+Core tap JSON: 26 Nov 16:24 UTC
+HOMEBREW_PREFIX: /opt/homebrew
+HOMEBREW_MAKE_JOBS: 8
+Homebrew Ruby: 3.3.6 => /opt/homebrew/Library/Homebrew/vendor/portable-ruby/3.3.6/bin/ruby
+macOS: 15.1.1-arm64
+`
+	env, system := parseBrewConfig(strings.NewReader(output))
+	if len(env) != 3 || env[1] != (ConfigEntry{Key: "HOMEBREW_PREFIX", Value: "/opt/homebrew"}) {
+		t.Errorf("expected 3 HOMEBREW_ settings, got %+v", env)
+	}
+	if len(system) != 4 || system[2].Value != "3.3.6 => /opt/homebrew/Library/Homebrew/vendor/portable-ruby/3.3.6/bin/ruby" {
+		t.Errorf("expected 4 system entries, got %+v", system)
+	}
+	if !isApiMode(system) {
+		t.Errorf("expected API mode with core tap JSON")
+	}
+	if isApiMode(system[:1]) {
+		t.Errorf("expected tap mode without core tap JSON")
+	}
+}
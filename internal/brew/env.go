@@ -0,0 +1,57 @@
+package brew
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// Environment variables added to brew commands of specific packages, by package name, e.g.
+// {"llvm": {"HOMEBREW_NO_SANDBOX": "1"}, "vim": {"CFLAGS": "-O2"}}. Packages named like one
+// brew prefers are set by tap and name, e.g. "someone/tools/vim".
+const packageEnvJson = "env.json"
+
+type packageEnvConfig map[string]map[string]string
+
+// Read on every command, so edits apply without restarting taproom
+func loadPackageEnv() packageEnvConfig {
+	config := packageEnvConfig{}
+	content, err := os.ReadFile(filepath.Join(taproomConfigDir, packageEnvJson))
+	if errors.Is(err, fs.ErrNotExist) {
+		return config
+	} else if err != nil {
+		log.Printf("failed to read %s: %v", packageEnvJson, err)
+		return config
+	}
+	if err := json.Unmarshal(content, &config); err != nil {
+		log.Printf("failed to decode %s: %v", packageEnvJson, err)
+	}
+	return config
+}
+
+// Variables of all packages of a brew command, sorted by name. When packages set the same
+// variable differently, the first package wins since a command has a single environment.
+func (c packageEnvConfig) env(pkgs []*data.Package) []string {
+	values := make(map[string]string)
+	for _, pkg := range pkgs {
+		for name, value := range c[pkg.UniqueName()] {
+			if existing, ok := values[name]; ok && existing != value {
+				log.Printf("ignoring %s=%s of %s, already set to %s by another package", name, value, pkg.UniqueName(), existing)
+				continue
+			}
+			values[name] = value
+		}
+	}
+	env := make([]string, 0, len(values))
+	for name, value := range values {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
@@ -0,0 +1,38 @@
+package brew
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestPackageEnv(t *testing.T) {
+	config := packageEnvConfig{
+		"llvm": {"HOMEBREW_NO_SANDBOX": "1", "CFLAGS": "-O2"},
+		"vim":  {"CFLAGS": "-O3", "HOMEBREW_CC": "clang"},
+	}
+	pkgs := []*data.Package{{Name: "llvm"}, {Name: "vim"}, {Name: "jq"}}
+
+	got := config.env(pkgs)
+	expected := []string{"CFLAGS=-O2", "HOMEBREW_CC=clang", "HOMEBREW_NO_SANDBOX=1"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if got := config.env([]*data.Package{{Name: "jq"}}); len(got) != 0 {
+		t.Errorf("expected no variables, got %v", got)
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	if got := commandLine(brewTool, nil, []string{"upgrade", "jq"}); got != "brew upgrade jq" {
+		t.Errorf("expected %q, got %q", "brew upgrade jq", got)
+	}
+	got := commandLine(brewTool, []string{"HOMEBREW_NO_SANDBOX=1"}, []string{"upgrade", "llvm"})
+	if got != "HOMEBREW_NO_SANDBOX=1 brew upgrade llvm" {
+		t.Errorf("expected %q, got %q", "HOMEBREW_NO_SANDBOX=1 brew upgrade llvm", got)
+	}
+	if got := commandLine(masTool, nil, []string{"upgrade", "497799835"}); got != "mas upgrade 497799835" {
+		t.Errorf("expected %q, got %q", "mas upgrade 497799835", got)
+	}
+}
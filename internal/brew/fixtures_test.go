@@ -0,0 +1,168 @@
+package brew
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+var updateGolden = flag.Bool("update", false, "Update golden files in testdata")
+
+// Decode a fixture from testdata with the same decoder used for the real data source
+func loadFixture(t *testing.T, name string, decode func(f *os.File) error) {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to open fixture %s: %v", name, err)
+	}
+	defer f.Close()
+	if err := decode(f); err != nil {
+		t.Fatalf("failed to decode fixture %s: %v", name, err)
+	}
+}
+
+func loadInstalledFixtures(installDir string, fetcher func(string) *installInfo) []*installInfo {
+	resultCh := make(chan []*installInfo, 1)
+	fetchInstalledPackages(filepath.Join("testdata", installDir), fetcher, resultCh)
+	return <-resultCh
+}
+
+// Fields of a package checked by the golden test
+type goldenPackage struct {
+	Name              string   `json:"name"`
+	Tap               string   `json:"tap"`
+	Desc              string   `json:"desc"`
+	Homepage          string   `json:"homepage"`
+	Version           string   `json:"version"`
+	InstalledVersion  string   `json:"installed_version,omitempty"`
+	Status            string   `json:"status"`
+	Installs90d       int      `json:"installs_90d"`
+	BuildErrors90d    int      `json:"build_errors_90d,omitempty"`
+	Dependencies      []string `json:"dependencies,omitempty"`
+	BuildDependencies []string `json:"build_dependencies,omitempty"`
+	Dependents        []string `json:"dependents,omitempty"`
+	Conflicts         []string `json:"conflicts,omitempty"`
+	InstallSupported  bool     `json:"install_supported"`
+	Options           []string `json:"options,omitempty"`
+	Platforms         []string `json:"platforms,omitempty"`
+	MinMacOSVersion   string   `json:"min_macos_version,omitempty"`
+	Languages         []string `json:"languages,omitempty"`
+	Variants          []string `json:"variants,omitempty"`
+	ZapPaths          []string `json:"zap_paths,omitempty"`
+	Artifacts         []string `json:"artifacts,omitempty"`
+	RequiresSudo      bool     `json:"requires_sudo,omitempty"`
+}
+
+func goldenArtifacts(artifacts []data.CaskArtifact) []string {
+	if len(artifacts) == 0 {
+		return nil
+	}
+	values := make([]string, len(artifacts))
+	for i, a := range artifacts {
+		values[i] = a.Kind + ": " + a.Target
+	}
+	return values
+}
+
+func toGolden(pkg *data.Package) goldenPackage {
+	return goldenPackage{
+		Name:              pkg.Name,
+		Tap:               pkg.Tap,
+		Desc:              pkg.Desc(),
+		Homepage:          pkg.Homepage(),
+		Version:           pkg.ShortVersion(),
+		InstalledVersion:  pkg.InstalledVersion,
+		Status:            pkg.Status(),
+		Installs90d:       pkg.Installs90d,
+		BuildErrors90d:    pkg.BuildErrors90d,
+		Dependencies:      pkg.Dependencies,
+		BuildDependencies: pkg.BuildDependencies,
+		Dependents:        pkg.Dependents,
+		Conflicts:         pkg.Conflicts,
+		InstallSupported:  pkg.InstallSupported,
+		Options:           pkg.Options,
+		Platforms:         pkg.Platforms,
+		MinMacOSVersion:   pkg.MinMacOSVersion,
+		Languages:         pkg.Languages,
+		Variants:          pkg.Variants,
+		ZapPaths:          pkg.ZapPaths,
+		Artifacts:         goldenArtifacts(pkg.Artifacts),
+		RequiresSudo:      pkg.RequiresSudo,
+	}
+}
+
+func TestProcessAllDataGolden(t *testing.T) {
+	useTempCacheDir(t)
+	scope := *flagCatalog
+	*flagCatalog = string(CatalogFull)
+	defer func() { *flagCatalog = scope }()
+
+	var formulae []*apiFormula
+	var casks []*apiCask
+	var formulaAnalytics apiFormulaAnalytics
+	var caskAnalytics apiCaskAnalytics
+	var buildErrors apiBuildErrorAnalytics
+	loadFixture(t, formulaJwsJson, func(f *os.File) error { return decodeJws(f, &formulae) })
+	loadFixture(t, caskJwsJson, func(f *os.File) error { return decodeJws(f, &casks) })
+	loadFixture(t, formulaAnalyticsJson, func(f *os.File) error { return decodeJson(f, &formulaAnalytics) })
+	loadFixture(t, caskAnalyticsJson, func(f *os.File) error { return decodeJson(f, &caskAnalytics) })
+	loadFixture(t, buildErrorsJson, func(f *os.File) error { return decodeJson(f, &buildErrors) })
+	formulaInstalls := loadInstalledFixtures("Cellar", func(path string) *installInfo { return getFormulaInstallInfo(false, path) })
+	caskInstalls := loadInstalledFixtures("Caskroom", func(path string) *installInfo { return getCaskInstallInfo(false, path) })
+
+	pkgs := processAllData(formulae, casks, formulaAnalytics, caskAnalytics, formulaInstalls, caskInstalls, nil, nil)
+	applyBuildErrors(pkgs, buildErrors)
+
+	golden := make([]goldenPackage, len(pkgs))
+	for i, pkg := range pkgs {
+		golden[i] = toGolden(pkg)
+	}
+	got, err := json.MarshalIndent(golden, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to encode packages: %v", err)
+	}
+	got = append(got, '\n')
+
+	goldenPath := filepath.Join("testdata", "packages.golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(goldenPath, got, 0644); err != nil {
+			t.Fatalf("failed to update %s: %v", goldenPath, err)
+		}
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("failed to read %s, run the test with -update to create it: %v", goldenPath, err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("packages don't match %s, run the test with -update if the change is expected\ngot:\n%s", goldenPath, got)
+	}
+}
+
+func TestDecodeInstallReceipt(t *testing.T) {
+	var receipt *installReceipt
+	loadFixture(t, "Cellar/openssl@3/3.4.0/INSTALL_RECEIPT.json", func(f *os.File) (err error) {
+		receipt, err = decodeInstallReceipt(f)
+		return err
+	})
+	if !receipt.InstalledAsDep || receipt.Source.Tap != coreTap || receipt.Source.Versions.Stable != "3.4.0" {
+		t.Errorf("expected openssl@3 3.4.0 from %s installed as a dependency, got %+v", coreTap, receipt)
+	}
+}
+
+// Point the cache at a temporary directory, restoring it once the test is done
+func useTempCacheDir(tb testing.TB) {
+	original := taproomCacheDir
+	tb.Cleanup(func() { taproomCacheDir = original })
+	taproomCacheDir = tb.TempDir()
+}
+
+// Replace the loaded packages, restoring them once the test is done
+func usePackages(tb testing.TB, pkgs []*data.Package) {
+	original := allBrewPackages
+	tb.Cleanup(func() { allBrewPackages = original })
+	allBrewPackages = pkgs
+}
@@ -0,0 +1,117 @@
+package brew
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+var flagHealthCheck = Flags.Bool(
+	"health-check",
+	true,
+	"Check installed formulae for missing dependencies (brew missing) and broken links in the background after loading",
+)
+
+// Problems found by the health check, by the name of the formula
+type HealthCheckedMsg struct {
+	problems map[string][]string
+}
+
+// Look for installed formulae with missing dependencies or broken links, the way brew doctor does
+func CheckHealth() tea.Cmd {
+	if !*flagHealthCheck {
+		return nil
+	}
+	formulae := []string{}
+	linked := []string{}
+	for _, pkg := range allBrewPackages {
+		if pkg.IsInstalled && !pkg.IsCask && pkg.IsManagedByBrew() {
+			formulae = append(formulae, pkg.UniqueName())
+			if pkg.IsLinked {
+				linked = append(linked, pkg.UniqueName())
+			}
+		}
+	}
+	return func() tea.Msg {
+		problems := brokenLinks(brewPrefix, formulae, linked)
+		// Exits with an error when dependencies are missing, which are still printed
+		output, err := exec.Command("brew", "missing").Output()
+		if err != nil && len(output) == 0 {
+			log.Printf("failed to run brew missing: %v", err)
+		}
+		for name, deps := range parseBrewMissing(bytes.NewReader(output)) {
+			problems[name] = append(problems[name], "missing dependencies: "+strings.Join(deps, ", "))
+		}
+		return HealthCheckedMsg{problems: problems}
+	}
+}
+
+// Parse lines of `brew missing` like "wget: libidn2 openssl@3"
+func parseBrewMissing(r io.Reader) map[string][]string {
+	missing := make(map[string][]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, deps, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(deps) == "" {
+			continue
+		}
+		missing[strings.TrimSpace(name)] = strings.Fields(deps)
+	}
+	return missing
+}
+
+// Installed formulae whose opt link, or linked keg link, doesn't point to a keg
+func brokenLinks(prefix string, formulae, linked []string) map[string][]string {
+	problems := make(map[string][]string)
+	check := func(name, path string) {
+		if _, err := os.Lstat(path); err != nil {
+			problems[name] = append(problems[name], fmt.Sprintf("%s is missing", path))
+		} else if _, err := os.Stat(path); err != nil {
+			problems[name] = append(problems[name], fmt.Sprintf("%s is a broken link", path))
+		}
+	}
+	for _, name := range formulae {
+		// Formulae of other taps are linked by their short names
+		short := name[strings.LastIndex(name, "/")+1:]
+		check(name, filepath.Join(prefix, "opt", short))
+		if slices.Contains(linked, name) {
+			check(name, filepath.Join(prefix, "var", "homebrew", "linked", short))
+		}
+	}
+	return problems
+}
+
+// Set the problems found on installed formulae and clear the ones fixed meanwhile,
+// returns the packages whose health changed
+func (msg HealthCheckedMsg) Apply() []*data.Package {
+	return applyHealth(allBrewPackages, msg.problems)
+}
+
+func applyHealth(packages []*data.Package, problems map[string][]string) []*data.Package {
+	changed := []*data.Package{}
+	for _, pkg := range packages {
+		if !pkg.IsInstalled || pkg.IsCask || !pkg.IsManagedByBrew() {
+			continue
+		}
+		found := problems[pkg.UniqueName()]
+		if found == nil {
+			// brew missing names formulae of other taps with the tap
+			found = problems[pkg.FullName()]
+		}
+		if !slices.Equal(pkg.HealthProblems, found) {
+			pkg.HealthProblems = found
+			changed = append(changed, pkg)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,86 @@
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestParseBrewMissing(t *testing.T) {
+	output := `wget: libidn2 openssl@3
+user/tap/tool: pcre2
+`
+	missing := parseBrewMissing(strings.NewReader(output))
+	if len(missing) != 2 {
+		t.Errorf("expected 2 formulae, got %v", missing)
+	}
+	if expected := []string{"libidn2", "openssl@3"}; !slices.Equal(missing["wget"], expected) {
+		t.Errorf("expected %v, got %v", expected, missing["wget"])
+	}
+	if expected := []string{"pcre2"}; !slices.Equal(missing["user/tap/tool"], expected) {
+		t.Errorf("expected %v, got %v", expected, missing["user/tap/tool"])
+	}
+}
+
+func TestBrokenLinks(t *testing.T) {
+	prefix := t.TempDir()
+	for _, dir := range []string{"Cellar/healthy/1.0", "opt", "var/homebrew/linked"} {
+		if err := os.MkdirAll(filepath.Join(prefix, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(name, target string) {
+		if err := os.Symlink(filepath.Join(prefix, target), filepath.Join(prefix, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link("opt/healthy", "Cellar/healthy/1.0")
+	link("var/homebrew/linked/healthy", "Cellar/healthy/1.0")
+	link("opt/dangling", "Cellar/dangling/1.0")
+	link("opt/unlinked", "Cellar/healthy/1.0")
+	link("opt/tool", "Cellar/healthy/1.0")
+
+	problems := brokenLinks(prefix, []string{"healthy", "dangling", "unlinked", "missing", "user/tap/tool"}, []string{"healthy", "unlinked"})
+	if len(problems) != 3 {
+		t.Errorf("expected 3 unhealthy formulae, got %v", problems)
+	}
+	if p := problems["dangling"]; len(p) != 1 || !strings.Contains(p[0], "broken link") {
+		t.Errorf("expected a broken opt link, got %v", p)
+	}
+	if p := problems["unlinked"]; len(p) != 1 || !strings.Contains(p[0], filepath.Join("linked", "unlinked")+" is missing") {
+		t.Errorf("expected a missing linked keg, got %v", p)
+	}
+	if p := problems["missing"]; len(p) != 1 || !strings.Contains(p[0], "is missing") {
+		t.Errorf("expected a missing opt link, got %v", p)
+	}
+}
+
+func TestApplyHealth(t *testing.T) {
+	fixed := &data.Package{Name: "fixed", IsInstalled: true, HealthProblems: []string{"missing dependencies: a"}}
+	broken := &data.Package{Name: "tool", Tap: "user/tap", IsInstalled: true}
+	uninstalled := &data.Package{Name: "gone"}
+	packages := []*data.Package{fixed, uninstalled, broken}
+
+	changed := applyHealth(packages, map[string][]string{
+		"user/tap/tool": {"missing dependencies: pcre2"},
+		"gone":          {"missing dependencies: b"},
+	})
+	if !slices.Equal(changed, []*data.Package{fixed, broken}) {
+		t.Errorf("expected fixed and tool to change, got %v", packageNames(changed))
+	}
+	if fixed.IsUnhealthy() || !broken.IsUnhealthy() || uninstalled.HealthProblems != nil {
+		t.Errorf("expected only tool to be unhealthy")
+	}
+	if broken.Status() != "Unhealthy" {
+		t.Errorf("expected status Unhealthy, got %s", broken.Status())
+	}
+
+	broken.MarkInstalled()
+	if broken.IsUnhealthy() {
+		t.Errorf("expected reinstalling to clear the problems, got %v", broken.HealthProblems)
+	}
+}
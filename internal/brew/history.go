@@ -0,0 +1,145 @@
+package brew
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const (
+	historyJsonl = "history.jsonl"
+
+	// Number of output lines kept for each command
+	historyOutputLines = 30
+	// Number of most recent commands loaded for the history screen
+	maxHistoryEntries = 500
+)
+
+// A brew command run by taproom
+type HistoryEntry struct {
+	Time     time.Time     `json:"time"`
+	Command  BrewCommand   `json:"command"`
+	Env      []string      `json:"env,omitempty"` // Variables configured for the packages
+	Args     []string      `json:"args"`
+	Pkgs     []string      `json:"pkgs"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	Output   []string      `json:"output"` // Last lines of the output
+}
+
+func (e *HistoryEntry) CommandLine() string {
+	return commandLine(e.Command.tool(), e.Env, e.Args)
+}
+
+var historyMu sync.Mutex
+
+// Keeps the last lines of a command's output for its history entry
+type outputTail struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (t *outputTail) add(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = append(t.lines, line)
+	if len(t.lines) > historyOutputLines {
+		t.lines = t.lines[len(t.lines)-historyOutputLines:]
+	}
+}
+
+func (t *outputTail) get() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.lines)
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func recordHistory(entry HistoryEntry) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("failed to encode history entry: %v", err)
+		return
+	}
+	path := filepath.Join(taproomCacheDir, historyJsonl)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("failed to create %s: %v", filepath.Dir(path), err)
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Printf("failed to open %s: %v", path, err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("failed to write %s: %v", path, err)
+	}
+}
+
+// Load the most recent commands, newest first
+func LoadHistory() []HistoryEntry {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	entries := []HistoryEntry{}
+	f, err := os.Open(filepath.Join(taproomCacheDir, historyJsonl))
+	if err != nil {
+		return entries
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("skipping malformed history entry: %v", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) > maxHistoryEntries {
+		entries = entries[len(entries)-maxHistoryEntries:]
+	}
+	slices.Reverse(entries)
+	return entries
+}
+
+// Run a command from history again with the same arguments, and the environment currently configured
+func RerunHistory(entry HistoryEntry) tea.Cmd {
+	pkgs := []*data.Package{}
+	for _, name := range entry.Pkgs {
+		if pkg := GetPackage(name); pkg != nil {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return tea.Batch(startCommand(), execute(entry.Command, pkgs, entry.Args...))
+}
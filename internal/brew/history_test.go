@@ -0,0 +1,51 @@
+package brew
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRecordAndLoadHistory(t *testing.T) {
+	useTempCacheDir(t)
+
+	if entries := LoadHistory(); len(entries) != 0 {
+		t.Fatalf("expected empty history, got %d entries", len(entries))
+	}
+
+	for i := range 3 {
+		recordHistory(HistoryEntry{
+			Time:     time.Unix(int64(i), 0),
+			Command:  BrewCommandInstall,
+			Args:     []string{"install", fmt.Sprintf("pkg%d", i)},
+			Pkgs:     []string{fmt.Sprintf("pkg%d", i)},
+			ExitCode: i,
+			Duration: time.Second,
+			Output:   []string{"done"},
+		})
+	}
+
+	entries := LoadHistory()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	// Newest first
+	if got := entries[0].CommandLine(); got != "brew install pkg2" {
+		t.Errorf("expected newest command 'brew install pkg2', got %q", got)
+	}
+	if entries[0].ExitCode != 2 || entries[2].ExitCode != 0 {
+		t.Errorf("unexpected exit codes %d, %d", entries[0].ExitCode, entries[2].ExitCode)
+	}
+}
+
+func TestOutputTail(t *testing.T) {
+	tail := &outputTail{}
+	for i := range historyOutputLines + 5 {
+		tail.add(fmt.Sprintf("line %d", i))
+	}
+	tail.add("   ")
+	lines := tail.get()
+	if len(lines) != historyOutputLines || lines[0] != "line 5" {
+		t.Errorf("expected the last %d lines starting with 'line 5', got %d lines starting with %q", historyOutputLines, len(lines), lines[0])
+	}
+}
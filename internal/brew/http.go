@@ -0,0 +1,43 @@
+package brew
+
+import (
+	"cmp"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Same default as brew, mirrors like https://mirrors.ustc.edu.cn/homebrew-bottles/api are set with HOMEBREW_API_DOMAIN
+const defaultApiDomain = "https://formulae.brew.sh/api"
+
+var flagHttpTimeout = Flags.Duration(
+	"http-timeout",
+	2*time.Minute,
+	"Timeout of each download from the Homebrew API, including reading the catalog",
+)
+
+// Client for the Homebrew API, created once flags are parsed.
+// Proxies are taken from HTTP_PROXY, HTTPS_PROXY and NO_PROXY, like brew does with curl.
+var apiClient = sync.OnceValue(func() *http.Client {
+	return &http.Client{
+		Timeout: *flagHttpTimeout,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+			IdleConnTimeout:       90 * time.Second,
+			ForceAttemptHTTP2:     true,
+		},
+	}
+})
+
+// Base url of the Homebrew API, the mirror in HOMEBREW_API_DOMAIN when it's set
+func ApiDomain() string {
+	return strings.TrimSuffix(cmp.Or(brewSetting("HOMEBREW_API_DOMAIN"), defaultApiDomain), "/")
+}
+
+// Url of a file of the Homebrew API, like formula.jws.json
+func apiUrl(path string) string {
+	return ApiDomain() + "/" + path
+}
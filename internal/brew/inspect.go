@@ -0,0 +1,77 @@
+package brew
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+type PackageInspectedMsg struct {
+	Pkg   *data.Package
+	Lines []string
+}
+
+// The merged package with the texts kept outside of it
+type inspectedPackage struct {
+	*data.Package
+	Desc     string
+	Homepage string
+}
+
+// Dump the merged package and the JSON it was built from, to diagnose wrong data like a wrong tap or version
+func InspectPackage(pkg *data.Package) tea.Cmd {
+	return func() tea.Msg {
+		lines := []string{fmt.Sprintf("# Package %s as merged by taproom", pkg.Name)}
+		merged, err := json.MarshalIndent(inspectedPackage{pkg, pkg.Desc(), pkg.Homepage()}, "", "  ")
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("failed to encode the package: %v", err))
+		} else {
+			lines = append(lines, strings.Split(string(merged), "\n")...)
+		}
+
+		source, raw, err := rawPackageJson(pkg)
+		lines = append(lines, "", fmt.Sprintf("# Catalog data (%s) from %s", pkg.Sources.Catalog, source))
+		if err != nil {
+			lines = append(lines, err.Error())
+		} else {
+			lines = append(lines, strings.Split(raw, "\n")...)
+		}
+		return PackageInspectedMsg{Pkg: pkg, Lines: lines}
+	}
+}
+
+// JSON of the package from the API, or from brew for packages read from local taps
+func rawPackageJson(pkg *data.Package) (source, raw string, err error) {
+	var body []byte
+	if pkg.Sources.Catalog == data.SourceApi {
+		var cachePath string
+		source, cachePath = packageInfoUrl(pkg)
+		body, err = fetchUrlWithCache(source, cachePath)
+	} else {
+		args := []string{"info", "--json=v2", "--formula", pkg.Tap + "/" + pkg.Name}
+		if pkg.IsCask {
+			args[2] = "--cask"
+		}
+		source = commandLine(brewTool, nil, args)
+		var errOutput bytes.Buffer
+		cmd := exec.Command("brew", args...)
+		cmd.Stderr = &errOutput
+		body, err = cmd.Output()
+		if err != nil {
+			err = fmt.Errorf("failed to run %s: %w: %s", source, err, errOutput.String())
+		}
+	}
+	if err != nil {
+		return source, "", err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		return source, "", fmt.Errorf("failed to decode json from %s: %w", source, err)
+	}
+	return source, indented.String(), nil
+}
@@ -0,0 +1,32 @@
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestInspectPackage(t *testing.T) {
+	useTempCacheDir(t)
+
+	pkg := &data.Package{Name: "wget", Tap: coreTap, Version: "1.25.0", Sources: data.Sources{Catalog: data.SourceApi}}
+	pkg.SetTexts(newPackageTexts(), "Internet file retriever", "https://www.gnu.org/software/wget/")
+	_, cachePath := packageInfoUrl(pkg)
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cachePath, []byte(`{"name":"wget","versions":{"stable":"1.25.0"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := InspectPackage(pkg)().(PackageInspectedMsg)
+	dump := strings.Join(msg.Lines, "\n")
+	for _, want := range []string{`"Name": "wget"`, `"Desc": "Internet file retriever"`, "# Catalog data (API)", `"stable": "1.25.0"`} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("expected %q in the dump, got %s", want, dump)
+		}
+	}
+}
@@ -1,14 +1,17 @@
 package brew
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/hzqtc/taproom/internal/data"
 )
 
 type installInfo struct {
@@ -18,16 +21,20 @@ type installInfo struct {
 	revision  int
 	asDep     bool
 	pinned    bool
+	linked    bool // Formula only, its keg is symlinked into the prefix
 	timestamp int64
 	size      int64
 	path      string
+	receipt   string // Path of INSTALL_RECEIPT.json, empty when it's missing
+	broken    string // Why the installation looks broken, empty when it's fine
 }
 
 // struct to parse INSTALL_RECEIPT.json
 type installReceipt struct {
-	InstalledAsDep bool  `json:"installed_as_dependency"`
-	InstallTime    int64 `json:"time"`
-	Source         struct {
+	InstalledAsDep   bool  `json:"installed_as_dependency"`
+	InstallTime      int64 `json:"time"`
+	PouredFromBottle bool  `json:"poured_from_bottle"` // Formula only
+	Source           struct {
 		Version  string `json:"version"` // Cask only
 		Versions struct {
 			Stable string `json:"stable"` // Formula only
@@ -37,19 +44,39 @@ type installReceipt struct {
 	} `json:"source"`
 }
 
-var brewPrefix = func() string {
-	bytes, err := exec.Command("brew", "--prefix").Output()
-	if err != nil {
-		panic(fmt.Sprintf("failed to locate homebrew path: %v", err))
+// Where brew is installed, found by LocateBrew
+var brewPrefix string
+
+// Where brew is installed, like /opt/homebrew
+func Prefix() string {
+	return brewPrefix
+}
+
+var pinnedPackages = make(map[string]bool)
+
+// Formulae linked into the prefix, brew keeps a symlink to the keg of each
+var linkedKegs = make(map[string]bool)
+
+// Find where brew is installed, and the formulae pinned and linked there. Only runs brew once,
+// before packages are loaded rather than when the package is imported.
+var LocateBrew = sync.OnceValue(func() error {
+	if brewPrefix == "" {
+		bytes, err := exec.Command("brew", "--prefix").Output()
+		if err != nil {
+			return fmt.Errorf("failed to locate homebrew path: %w", err)
+		}
+		brewPrefix = strings.TrimSpace(string(bytes))
 	}
-	return strings.TrimSpace(string(bytes))
-}()
+	pinnedPackages = loadFormulaSet("var/homebrew/pinned")
+	linkedKegs = loadFormulaSet("var/homebrew/linked")
+	return nil
+})
 
-var pinnedPackages = func() map[string]bool {
+// Formulae with an entry in a directory of the prefix
+func loadFormulaSet(dir string) map[string]bool {
 	formulae := make(map[string]bool)
 
-	dir := filepath.Join(brewPrefix, "var/homebrew/pinned")
-	entries, err := os.ReadDir(dir)
+	entries, err := os.ReadDir(filepath.Join(brewPrefix, dir))
 	if err != nil {
 		return formulae
 	}
@@ -58,7 +85,7 @@ var pinnedPackages = func() map[string]bool {
 		formulae[entry.Name()] = true
 	}
 	return formulae
-}()
+}
 
 func fetchInstalledFormula(fetchSize bool, resultCh chan []*installInfo) {
 	fetchInstalledPackages(
@@ -104,6 +131,7 @@ func fetchInstalledPackages(installDir string, fetcher func(string) *installInfo
 			continue
 		}
 		info.pinned = pinnedPackages[info.name]
+		info.linked = linkedKegs[info.name]
 		infoList = append(infoList, info)
 	}
 	resultCh <- infoList
@@ -115,26 +143,27 @@ func getFormulaInstallInfo(fetchSize bool, path string) *installInfo {
 	var subdir string
 	if err != nil {
 		log.Printf("failed to get formula install info from %s: %v", path, err)
-		return nil
+		return &installInfo{name: name, broken: fmt.Sprintf("can't read %s", path)}
 	} else {
 		for _, entry := range entries {
 			// Expect only one subdirectory, which name is the formula version
-			subdir = entry.Name()
-			if subdir == "" || subdir[0] == '.' {
-				continue
-			} else {
+			if n := entry.Name(); n != "" && n[0] != '.' {
+				subdir = n
 				break
 			}
 		}
 	}
+	if subdir == "" {
+		return &installInfo{name: name, broken: fmt.Sprintf("no version directory in %s", path)}
+	}
 	path = filepath.Join(path, subdir)
 
 	var size int64
 	if fetchSize {
-		size = fetchDirSize(path, false)
+		size = cachedDirSize(path, false)
 	}
 
-	receipt := parseInstallReceipt(path)
+	receipt, receiptPath := parseInstallReceipt(path)
 	if receipt == nil {
 		// Fallback when INSTALL_RECEIPT.json is missing
 		return &installInfo{
@@ -159,20 +188,22 @@ func getFormulaInstallInfo(fetchSize bool, path string) *installInfo {
 		asDep:     receipt.InstalledAsDep,
 		timestamp: receipt.InstallTime,
 		path:      receipt.Source.Path,
+		receipt:   receiptPath,
 	}
 }
 
 func getCaskInstallInfo(fetchSize bool, path string) *installInfo {
 	var size int64
 	if fetchSize {
-		size = fetchDirSize(path, true)
+		size = cachedDirSize(path, true)
 	}
 
-	var version string
+	var version, broken string
 	var timestamp int64
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		log.Printf("failed to get cask version and install time: %v", err)
+		broken = fmt.Sprintf("can't read %s", path)
 	} else {
 		for _, entry := range entries {
 			name := entry.Name()
@@ -195,38 +226,67 @@ func getCaskInstallInfo(fetchSize bool, path string) *installInfo {
 		}
 	}
 
+	if version == "" && broken == "" {
+		// Usually an interrupted install or uninstall, which may leave only .metadata behind
+		broken = fmt.Sprintf("no version directory in %s", path)
+	}
+
 	info := installInfo{
 		name:      filepath.Base(path),
 		version:   version,
 		size:      size,
 		timestamp: timestamp,
+		broken:    broken,
 	}
 
 	// Casks installed by older brew (before 4.4.0) does not have INSTALL_RECEIPT.json
-	if receipt := parseInstallReceipt(filepath.Join(path, ".metadata")); receipt != nil {
+	if receipt, receiptPath := parseInstallReceipt(filepath.Join(path, ".metadata")); receipt != nil {
 		info.tap = receipt.Source.Tap
 		info.asDep = receipt.InstalledAsDep
 		info.path = receipt.Source.Path
+		info.receipt = receiptPath
 		info.timestamp = receipt.InstallTime
 	}
 
 	return &info
 }
 
-func parseInstallReceipt(dir string) *installReceipt {
+// Command that fixes a broken installation: reinstall packages still available, otherwise remove what's left
+func RepairSuggestion(pkg *data.Package) string {
+	kind := "--formula"
+	if pkg.IsCask {
+		kind = "--cask"
+	}
+	if pkg.Version == "" {
+		return fmt.Sprintf("brew uninstall %s --force %s", kind, pkg.Name)
+	}
+	return fmt.Sprintf("brew reinstall %s %s", kind, pkg.Name)
+}
+
+// Parse INSTALL_RECEIPT.json in a directory, also returns the path of the receipt
+func parseInstallReceipt(dir string) (*installReceipt, string) {
 	const filename = "INSTALL_RECEIPT.json"
-	var receipt installReceipt
-	file, err := os.Open(filepath.Join(dir, filename))
+	path := filepath.Join(dir, filename)
+	file, err := os.Open(path)
 	if err != nil {
 		log.Printf("failed to open %s in: %s", filename, dir)
-		return nil
+		return nil, ""
 	}
 	defer file.Close()
-	if err := json.NewDecoder(file).Decode(&receipt); err != nil {
+	receipt, err := decodeInstallReceipt(file)
+	if err != nil {
 		log.Printf("failed to parse %s in: %s", filename, dir)
-		return nil
+		return nil, ""
+	}
+	return receipt, path
+}
+
+func decodeInstallReceipt(r io.Reader) (*installReceipt, error) {
+	var receipt installReceipt
+	if err := decodeJson(r, &receipt); err != nil {
+		return nil, err
 	}
-	return &receipt
+	return &receipt, nil
 }
 
 func fetchDirSize(path string, followSymlink bool) int64 {
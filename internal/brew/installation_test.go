@@ -95,4 +95,82 @@ func TestGetFormulaInstallInfoWithRevision(t *testing.T) {
 	if info.tap != "homebrew/core" {
 		t.Errorf("expected tap %q, got %q", "homebrew/core", info.tap)
 	}
+
+	if info.receipt != receiptPath || info.path != "/path/to/formula.rb" {
+		t.Errorf("expected receipt %q with source /path/to/formula.rb, got %q and %q", receiptPath, info.receipt, info.path)
+	}
+}
+
+func TestGetInstallInfoWithMalformedLayouts(t *testing.T) {
+	tmpDir := t.TempDir()
+	mkdir := func(parts ...string) string {
+		dir := filepath.Join(append([]string{tmpDir}, parts...)...)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create test directory: %v", err)
+		}
+		return dir
+	}
+
+	// Empty Caskroom entry
+	if info := getCaskInstallInfo(false, mkdir("Caskroom", "empty")); info == nil || info.broken == "" {
+		t.Errorf("expected a broken install for an empty cask dir, got %+v", info)
+	}
+
+	// Dangling .metadata without a version directory
+	metadata := mkdir("Caskroom", "dangling", ".metadata")
+	receipt := `{"installed_as_dependency": false, "time": 1700000000, "source": {"tap": "homebrew/cask"}}`
+	if err := os.WriteFile(filepath.Join(metadata, "INSTALL_RECEIPT.json"), []byte(receipt), 0644); err != nil {
+		t.Fatalf("failed to write receipt: %v", err)
+	}
+	if info := getCaskInstallInfo(false, filepath.Dir(metadata)); info == nil || info.broken == "" || info.tap != caskTap {
+		t.Errorf("expected a broken install from %s for a dangling .metadata, got %+v", caskTap, info)
+	}
+
+	// Caskroom entry that isn't a directory
+	file := filepath.Join(mkdir("Caskroom"), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if info := getCaskInstallInfo(false, file); info == nil || info.broken == "" {
+		t.Errorf("expected a broken install for a file in the Caskroom, got %+v", info)
+	}
+
+	// Cellar entry with only hidden files
+	hidden := mkdir("Cellar", "hidden", ".DS_Store")
+	if info := getFormulaInstallInfo(false, filepath.Dir(hidden)); info == nil || info.broken == "" || info.version != "" {
+		t.Errorf("expected a broken install without a version for a formula with only hidden files, got %+v", info)
+	}
+
+	// A healthy cask isn't broken
+	mkdir("Caskroom", "healthy", "1.0")
+	if info := getCaskInstallInfo(false, filepath.Join(tmpDir, "Caskroom", "healthy")); info == nil || info.broken != "" || info.version != "1.0" {
+		t.Errorf("expected a healthy install of 1.0, got %+v", info)
+	}
+}
+
+func TestBrokenPackages(t *testing.T) {
+	useTempCacheDir(t)
+	casks := []*apiCask{{Name: "firefox", Tap: caskTap, Version: "133.0"}}
+	caskInstalls := []*installInfo{
+		{name: "firefox", tap: caskTap, broken: "no version directory"},
+		{name: "gone", broken: "no version directory"},
+	}
+	pkgs := processAllData(nil, casks, apiFormulaAnalytics{}, apiCaskAnalytics{}, nil, caskInstalls, nil, nil)
+	if len(pkgs) != 2 {
+		t.Fatalf("expected 2 packages, got %d", len(pkgs))
+	}
+	for _, pkg := range pkgs {
+		if !pkg.IsInstalled || pkg.BrokenInstall == "" {
+			t.Errorf("expected %s to be a broken install, got %+v", pkg.Name, pkg)
+		}
+		if pkg.IsOutdated {
+			t.Errorf("expected broken install %s not to be outdated, so upgrade all leaves it out", pkg.Name)
+		}
+	}
+	if got, want := RepairSuggestion(pkgs[0]), "brew reinstall --cask firefox"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got, want := RepairSuggestion(pkgs[1]), "brew uninstall --cask --force gone"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
 }
@@ -0,0 +1,24 @@
+package brew
+
+// Strings repeated across thousands of packages, like tap names, licenses, bottle tags and names of
+// dependencies, are decoded into a new copy each time. The pool keeps one copy of each to share.
+type stringPool map[string]string
+
+func (p stringPool) intern(s string) string {
+	if p == nil {
+		return s
+	}
+	if shared, ok := p[s]; ok {
+		return shared
+	}
+	p[s] = s
+	return s
+}
+
+// Intern the strings of the slice in place
+func (p stringPool) internAll(strs []string) []string {
+	for i, s := range strs {
+		strs[i] = p.intern(s)
+	}
+	return strs
+}
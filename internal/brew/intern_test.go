@@ -0,0 +1,25 @@
+package brew
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestStringPool(t *testing.T) {
+	pool := make(stringPool)
+	first := pool.intern(string([]byte("homebrew/core")))
+	second := pool.intern(string([]byte("homebrew/core")))
+	if unsafe.StringData(first) != unsafe.StringData(second) {
+		t.Errorf("expected equal strings to share memory")
+	}
+
+	deps := pool.internAll([]string{string([]byte("homebrew/core")), "openssl@3"})
+	if unsafe.StringData(deps[0]) != unsafe.StringData(first) {
+		t.Errorf("expected strings of the slice to be interned")
+	}
+
+	var none stringPool
+	if got := none.intern("openssl@3"); got != "openssl@3" {
+		t.Errorf("expected %q, got %q", "openssl@3", got)
+	}
+}
@@ -0,0 +1,148 @@
+package brew
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const batchJournalJson = "batch-journal.json"
+
+// Progress of a batch of packages being upgraded or uninstalled. It's kept on disk while the batch runs
+// and removed once it finishes, so one left behind means taproom or the terminal died in the middle of it.
+type BatchJournal struct {
+	Command BrewCommand `json:"command"`
+	Started time.Time   `json:"started"`
+	Pkgs    []string    `json:"pkgs"`
+	Done    []string    `json:"done"` // Packages brew reported as finished
+
+	mu sync.Mutex // Output of stdout and stderr is read concurrently
+}
+
+// Start journaling a batch, nil for commands that aren't worth resuming
+func startJournal(command BrewCommand, pkgs []*data.Package) *BatchJournal {
+	switch command {
+	case BrewCommandUpgradeAll, BrewCommandUpgrade, BrewCommandUninstall:
+	default:
+		return nil
+	}
+	if len(pkgs) < 2 {
+		return nil
+	}
+	j := &BatchJournal{Command: command, Started: time.Now(), Pkgs: packageNames(pkgs), Done: []string{}}
+	j.save()
+	return j
+}
+
+func (j *BatchJournal) markDone(names ...string) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	changed := false
+	for _, name := range names {
+		if slices.Contains(j.Pkgs, name) && !slices.Contains(j.Done, name) {
+			j.Done = append(j.Done, name)
+			changed = true
+		}
+	}
+	if changed {
+		j.save()
+	}
+}
+
+// The batch ran to the end, whether packages failed or not, the usual error handling takes it from here
+func (j *BatchJournal) finish() {
+	if j == nil {
+		return
+	}
+	DiscardInterruptedBatch()
+}
+
+// Written to a temporary file first, so a crash while saving doesn't leave a truncated journal
+func (j *BatchJournal) save() {
+	content, err := json.Marshal(j)
+	if err != nil {
+		log.Printf("failed to encode %s: %v", batchJournalJson, err)
+		return
+	}
+	path := filepath.Join(taproomCacheDir, batchJournalJson)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("failed to create %s: %v", filepath.Dir(path), err)
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		log.Printf("failed to write %s: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Printf("failed to write %s: %v", path, err)
+	}
+}
+
+// The batch a previous run of taproom didn't finish, nil when there's none
+func InterruptedBatch() *BatchJournal {
+	content, err := os.ReadFile(filepath.Join(taproomCacheDir, batchJournalJson))
+	if err != nil {
+		return nil
+	}
+	j := &BatchJournal{}
+	if err := json.Unmarshal(content, j); err != nil {
+		log.Printf("failed to decode %s: %v", batchJournalJson, err)
+		DiscardInterruptedBatch()
+		return nil
+	}
+	return j
+}
+
+func DiscardInterruptedBatch() {
+	path := filepath.Join(taproomCacheDir, batchJournalJson)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("failed to remove %s: %v", path, err)
+	}
+}
+
+// Name of the batch command as shown in prompts, like "upgrade all"
+func (j *BatchJournal) Description() string {
+	if j.Command == BrewCommandUpgradeAll {
+		return "upgrade all"
+	}
+	return string(j.Command)
+}
+
+// Packages of the batch that still need to run. The journal may miss packages finished right before
+// taproom died, so they're reconciled with the freshly loaded state rather than taken from Done.
+func (j *BatchJournal) Unfinished() []*data.Package {
+	return j.unfinished(GetPackage)
+}
+
+func (j *BatchJournal) unfinished(lookup func(string) *data.Package) []*data.Package {
+	unfinished := []*data.Package{}
+	for _, name := range j.Pkgs {
+		pkg := lookup(name)
+		if pkg == nil {
+			continue
+		}
+		if j.Command == BrewCommandUninstall && pkg.IsInstalled || j.Command != BrewCommandUninstall && pkg.IsOutdated {
+			unfinished = append(unfinished, pkg)
+		}
+	}
+	return unfinished
+}
+
+// Run the unfinished packages of the batch again
+func (j *BatchJournal) Resume(pkgs []*data.Package) tea.Cmd {
+	if j.Command == BrewCommandUninstall {
+		return UninstallPackages(pkgs, false)
+	}
+	return UpgradeAllOf(pkgs)
+}
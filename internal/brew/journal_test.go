@@ -0,0 +1,53 @@
+package brew
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestBatchJournal(t *testing.T) {
+	useTempCacheDir(t)
+	pkgs := []*data.Package{{Name: "wget"}, {Name: "jq"}, {Name: "git"}}
+
+	if j := startJournal(BrewCommandUpgrade, pkgs[:1]); j != nil {
+		t.Errorf("expected a single package not to be journaled")
+	}
+	if j := startJournal(BrewCommandPin, pkgs); j != nil {
+		t.Errorf("expected pins not to be journaled")
+	}
+
+	j := startJournal(BrewCommandUpgradeAll, pkgs)
+	j.markDone("wget", "curl")
+	interrupted := InterruptedBatch()
+	if interrupted == nil {
+		t.Fatalf("expected the journal of a running batch")
+	}
+	if interrupted.Command != BrewCommandUpgradeAll || !slices.Equal(interrupted.Done, []string{"wget"}) {
+		t.Errorf("expected upgradeAll with wget done, got %s with %v done", interrupted.Command, interrupted.Done)
+	}
+
+	j.finish()
+	if InterruptedBatch() != nil {
+		t.Errorf("expected no journal once the batch finished")
+	}
+}
+
+func TestBatchJournalUnfinished(t *testing.T) {
+	pkgs := map[string]*data.Package{
+		"wget": {Name: "wget", IsInstalled: true},
+		"jq":   {Name: "jq", IsInstalled: true, IsOutdated: true},
+		"git":  {Name: "git"},
+	}
+	lookup := func(name string) *data.Package { return pkgs[name] }
+
+	upgrade := &BatchJournal{Command: BrewCommandUpgradeAll, Pkgs: []string{"wget", "jq", "gone"}}
+	if got := packageNames(upgrade.unfinished(lookup)); !slices.Equal(got, []string{"jq"}) {
+		t.Errorf("expected [jq] left to upgrade, got %v", got)
+	}
+	uninstall := &BatchJournal{Command: BrewCommandUninstall, Pkgs: []string{"wget", "jq", "git"}}
+	if got := packageNames(uninstall.unfinished(lookup)); !slices.Equal(got, []string{"wget", "jq"}) {
+		t.Errorf("expected [wget jq] left to uninstall, got %v", got)
+	}
+}
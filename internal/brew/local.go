@@ -0,0 +1,69 @@
+package brew
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+var flagLocalCatalog = Flags.Bool(
+	"local-catalog",
+	false,
+	"Build the catalog from local tap clones instead of the Homebrew API (implied by HOMEBREW_NO_INSTALL_FROM_API)",
+)
+
+// Output of `brew info --json=v2`, which uses the same schema as the Homebrew API
+type localCatalog struct {
+	Formulae []*apiFormula `json:"formulae"`
+	Casks    []*apiCask    `json:"casks"`
+}
+
+// Whether brew is set to read formulae and casks from local taps, so the web API may not match what brew sees
+func useLocalCatalog() bool {
+	return *flagLocalCatalog || brewSetting("HOMEBREW_NO_INSTALL_FROM_API") != ""
+}
+
+// Load all formulae and casks from locally cloned taps, including third-party ones
+func fetchLocalCatalog(formulaeChan chan []*apiFormula, casksChan chan []*apiCask, errChan chan error) {
+	fetchBrewInfo("--eval-all", formulaeChan, casksChan, errChan)
+}
+
+// Load only installed formulae and casks, which is much faster than loading the full catalog
+func fetchInstalledCatalog(formulaeChan chan []*apiFormula, casksChan chan []*apiCask, errChan chan error) {
+	fetchBrewInfo("--installed", formulaeChan, casksChan, errChan)
+}
+
+func fetchBrewInfo(selector string, formulaeChan chan []*apiFormula, casksChan chan []*apiCask, errChan chan error) {
+	var errOutput bytes.Buffer
+	cmd := exec.Command("brew", "info", "--json=v2", selector)
+	cmd.Stderr = &errOutput
+	output, err := cmd.Output()
+	if err != nil {
+		errChan <- fmt.Errorf("failed to run brew info %s: %w: %s", selector, err, errOutput.String())
+		return
+	}
+	catalog, err := parseLocalCatalog(bytes.NewReader(output))
+	if err != nil {
+		errChan <- err
+		return
+	}
+	formulaeChan <- catalog.Formulae
+	casksChan <- catalog.Casks
+}
+
+func parseLocalCatalog(r io.Reader) (*localCatalog, error) {
+	catalog := localCatalog{}
+	if err := decodeJson(r, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to decode local taps data: %w", err)
+	}
+	for _, f := range catalog.Formulae {
+		f.source = data.SourceBrewInfo
+	}
+	for _, c := range catalog.Casks {
+		c.source = data.SourceBrewInfo
+	}
+	return &catalog, nil
+}
@@ -0,0 +1,43 @@
+package brew
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestLocalCatalogWithThirdPartyTap(t *testing.T) {
+	output := `{
+		"formulae": [
+			{"name": "wget", "tap": "homebrew/core", "versions": {"stable": "1.25.0"}},
+			{"name": "foo", "tap": "someone/tools", "versions": {"stable": "2.0"}}
+		],
+		"casks": [
+			{"token": "bar", "tap": "someone/tools", "version": "3.1"}
+		]
+	}`
+	catalog, err := parseLocalCatalog(strings.NewReader(output))
+	if err != nil {
+		t.Fatalf("failed to parse local catalog: %v", err)
+	}
+	if len(catalog.Formulae) != 2 || len(catalog.Casks) != 1 {
+		t.Fatalf("expected 2 formulae and 1 cask, got %d and %d", len(catalog.Formulae), len(catalog.Casks))
+	}
+
+	// Installed third-party packages come from the catalog instead of their .rb files
+	formulaInstalls := []*installInfo{{name: "foo", tap: "someone/tools", version: "1.0"}}
+	caskInstalls := []*installInfo{{name: "bar", tap: "someone/tools", version: "3.1"}}
+	pkgs := processAllData(catalog.Formulae, catalog.Casks, apiFormulaAnalytics{}, apiCaskAnalytics{}, formulaInstalls, caskInstalls, nil, nil)
+	if len(pkgs) != 3 {
+		t.Fatalf("expected 3 packages, got %d", len(pkgs))
+	}
+	for _, pkg := range pkgs {
+		if pkg.Name == "foo" && (!pkg.IsInstalled || !pkg.IsOutdated || pkg.Tap != "someone/tools") {
+			t.Errorf("expected foo to be an installed and outdated package from someone/tools, got %+v", pkg)
+		}
+		if pkg.Sources.Catalog != data.SourceBrewInfo {
+			t.Errorf("expected %s to be loaded from %s, got %s", pkg.Name, data.SourceBrewInfo, pkg.Sources.Catalog)
+		}
+	}
+}
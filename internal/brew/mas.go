@@ -0,0 +1,108 @@
+package brew
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const masTool = "mas"
+
+var flagMas = Flags.Bool(
+	"mas",
+	true,
+	"List App Store apps alongside casks and upgrade them with mas, when mas is installed",
+)
+
+// A line of `mas list` like "497799835  Xcode  (15.4)", or of `mas outdated` like "497799835 Xcode (15.3 -> 15.4)"
+var masAppRegex = regexp.MustCompile(`^\s*(\d+)\s+(.+?)\s+\(([^)]*)\)\s*$`)
+
+type masApp struct {
+	id      int
+	name    string
+	version string
+	latest  string // Only listed by mas outdated
+}
+
+func parseMasApps(r io.Reader) []masApp {
+	apps := []masApp{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := masAppRegex.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		id, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		app := masApp{id: id, name: m[2], version: m[3]}
+		if version, latest, ok := strings.Cut(m[3], " -> "); ok {
+			app.version, app.latest = version, latest
+		}
+		apps = append(apps, app)
+	}
+	return apps
+}
+
+// Installed App Store apps as packages, none when mas is turned off or isn't installed
+func loadMasApps() []*data.Package {
+	if !*flagMas {
+		return nil
+	}
+	if _, err := exec.LookPath(masTool); err != nil {
+		return nil
+	}
+	installed, err := exec.Command(masTool, "list").Output()
+	if err != nil {
+		log.Printf("failed to run mas list: %v", err)
+		return nil
+	}
+	// Apps are still listed when the App Store can't be reached for updates
+	outdated, err := exec.Command(masTool, "outdated").Output()
+	if err != nil {
+		log.Printf("failed to run mas outdated: %v", err)
+	}
+	return masPackages(parseMasApps(bytes.NewReader(installed)), parseMasApps(bytes.NewReader(outdated)))
+}
+
+func masPackages(installed, outdated []masApp) []*data.Package {
+	latest := make(map[int]string)
+	for _, app := range outdated {
+		latest[app.id] = app.latest
+	}
+	pkgs := []*data.Package{}
+	for _, app := range installed {
+		pkg := &data.Package{
+			Name:             app.name,
+			Tap:              data.MasTap,
+			MasId:            app.id,
+			Version:          app.version,
+			InstalledVersion: app.version,
+			IsCask:           true,
+			IsInstalled:      true,
+		}
+		if v := latest[app.id]; v != "" && v != app.version {
+			pkg.Version = v
+			pkg.IsOutdated = true
+		}
+		pkg.SetTexts(nil, "App Store app", pkg.BrewUrl())
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs
+}
+
+// Upgrade App Store apps with mas, each on its own so that one failing doesn't stop the others
+func UpgradeMasApps(pkgs []*data.Package) tea.Cmd {
+	return tea.Batch(startCommand(), executeEach(BrewCommandMasUpgrade, pkgs, func(pkg *data.Package) []string {
+		return []string{"upgrade", strconv.Itoa(pkg.MasId)}
+	}))
+}
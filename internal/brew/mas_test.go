@@ -0,0 +1,44 @@
+package brew
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMasApps(t *testing.T) {
+	installed := parseMasApps(strings.NewReader(`497799835  Xcode           (15.4)
+1333542190  1Password 7 - Password Manager  (7.9.11)
+not an app
+`))
+	if len(installed) != 2 {
+		t.Fatalf("expected 2 apps, got %v", installed)
+	}
+	if app := installed[1]; app.id != 1333542190 || app.name != "1Password 7 - Password Manager" || app.version != "7.9.11" {
+		t.Errorf("expected 1Password 7.9.11, got %+v", app)
+	}
+
+	outdated := parseMasApps(strings.NewReader("497799835 Xcode (15.3 -> 15.4)\n"))
+	if len(outdated) != 1 || outdated[0].version != "15.3" || outdated[0].latest != "15.4" {
+		t.Errorf("expected Xcode 15.3 -> 15.4, got %v", outdated)
+	}
+}
+
+func TestMasPackages(t *testing.T) {
+	pkgs := masPackages(
+		[]masApp{{id: 497799835, name: "Xcode", version: "15.3"}, {id: 409183694, name: "Keynote", version: "14.1"}},
+		[]masApp{{id: 497799835, name: "Xcode", version: "15.3", latest: "15.4"}},
+	)
+	if len(pkgs) != 2 {
+		t.Fatalf("expected 2 packages, got %v", packageNames(pkgs))
+	}
+	xcode, keynote := pkgs[0], pkgs[1]
+	if !xcode.IsMasApp() || !xcode.IsInstalled || !xcode.IsOutdated || xcode.Version != "15.4" || xcode.InstalledVersion != "15.3" {
+		t.Errorf("expected an outdated App Store app, got %+v", xcode)
+	}
+	if keynote.IsOutdated || keynote.Version != "14.1" {
+		t.Errorf("expected Keynote to be up to date, got %+v", keynote)
+	}
+	if url := xcode.BrewUrl(); url != "https://apps.apple.com/app/id497799835" {
+		t.Errorf("expected the App Store url, got %s", url)
+	}
+}
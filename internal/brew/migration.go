@@ -0,0 +1,217 @@
+package brew
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// An installed package its tap moved elsewhere, as listed in tap_migrations.json of the tap
+type TapMigration struct {
+	Pkg     *data.Package
+	From    string // Tap the package is installed from
+	To      string // Tap the package moved to
+	NewName string // Name in the new tap, the same unless it's renamed too
+}
+
+// Whether the package is a cask once migrated, formulae moved to homebrew/cask become casks and vice versa
+func (m *TapMigration) ToCask() bool {
+	switch m.To {
+	case caskTap:
+		return true
+	case coreTap:
+		return false
+	default:
+		return m.Pkg.IsCask
+	}
+}
+
+// Steps of moving installed packages to the taps they migrated to
+type TapMigrationPlan struct {
+	Taps       []string        // Taps to add before reinstalling packages from them
+	Migrations []*TapMigration // In the order of reinstalling, dependencies first
+	Untaps     []string        // Taps left without installed packages once migrated
+}
+
+type TapMigrationsFoundMsg struct {
+	Plan *TapMigrationPlan
+	Err  error
+}
+
+// Look up installed packages in tap_migrations.json of all taps in the background
+func FindTapMigrations() tea.Cmd {
+	return func() tea.Msg {
+		taps, err := loadTaps()
+		if err != nil {
+			return TapMigrationsFoundMsg{Err: err}
+		}
+		installed := []*data.Package{}
+		for _, pkg := range allBrewPackages {
+			if pkg.IsInstalled {
+				installed = append(installed, pkg)
+			}
+		}
+
+		migrations := []*TapMigration{}
+		tapped := make(map[string]bool)
+		for _, tap := range taps {
+			tapped[tap.Name] = true
+			content, err := os.ReadFile(filepath.Join(tap.Path, "tap_migrations.json"))
+			if errors.Is(err, fs.ErrNotExist) {
+				// Most taps never moved anything
+				continue
+			} else if err != nil {
+				log.Printf("failed to read migrations of %s: %v", tap.Name, err)
+				continue
+			}
+			found, err := parseTapMigrations(tap.Name, content, installed)
+			if err != nil {
+				return TapMigrationsFoundMsg{Err: err}
+			}
+			migrations = append(migrations, found...)
+		}
+		return TapMigrationsFoundMsg{Plan: planTapMigrations(migrations, installed, tapped, GetPackage)}
+	}
+}
+
+// Find installed packages of a tap in its tap_migrations.json, which maps old names to
+// the new tap like "homebrew/cask", or to the new tap and name like "user/repo/new-name"
+func parseTapMigrations(tap string, content []byte, installed []*data.Package) ([]*TapMigration, error) {
+	targets := make(map[string]string)
+	if err := json.Unmarshal(content, &targets); err != nil {
+		return nil, fmt.Errorf("failed to decode migrations of %s: %w", tap, err)
+	}
+
+	migrations := []*TapMigration{}
+	for _, pkg := range installed {
+		target, ok := targets[pkg.Name]
+		if !ok || pkg.Tap != tap {
+			continue
+		}
+		to, name := target, pkg.Name
+		if parts := strings.Split(target, "/"); len(parts) == 3 {
+			to, name = parts[0]+"/"+parts[1], parts[2]
+		}
+		if to == tap {
+			continue
+		}
+		migrations = append(migrations, &TapMigration{Pkg: pkg, From: tap, To: to, NewName: name})
+	}
+	return migrations, nil
+}
+
+func planTapMigrations(
+	migrations []*TapMigration,
+	installed []*data.Package,
+	tapped map[string]bool,
+	lookup func(string) *data.Package,
+) *TapMigrationPlan {
+	plan := &TapMigrationPlan{Migrations: sortMigrations(migrations, lookup)}
+
+	migrating := make(map[*data.Package]bool)
+	for _, m := range migrations {
+		migrating[m.Pkg] = true
+		// Official taps are read from the API and don't need to be tapped
+		if !tapped[m.To] && m.To != coreTap && m.To != caskTap && !slices.Contains(plan.Taps, m.To) {
+			plan.Taps = append(plan.Taps, m.To)
+		}
+	}
+	sort.Strings(plan.Taps)
+
+	// Untap a third-party tap only when none of its installed packages stay behind
+	remaining := make(map[string]int)
+	for _, pkg := range installed {
+		if !migrating[pkg] {
+			remaining[pkg.Tap]++
+		}
+	}
+	for _, m := range migrations {
+		if remaining[m.From] == 0 && !strings.HasPrefix(m.From, "homebrew/") && !slices.Contains(plan.Untaps, m.From) {
+			plan.Untaps = append(plan.Untaps, m.From)
+		}
+	}
+	sort.Strings(plan.Untaps)
+	return plan
+}
+
+// Order migrations so dependencies are reinstalled before the packages depending on them,
+// also through dependencies that aren't migrated themselves
+func sortMigrations(migrations []*TapMigration, lookup func(string) *data.Package) []*TapMigration {
+	byName := make(map[string]*TapMigration)
+	names := []string{}
+	for _, m := range migrations {
+		byName[m.Pkg.Name] = m
+		names = append(names, m.Pkg.Name)
+	}
+	sort.Strings(names)
+
+	sorted := []*TapMigration{}
+	visited := make(map[string]bool)
+	var visit func(name string, deps []string)
+	visit = func(name string, deps []string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, dep := range deps {
+			if m, ok := byName[dep]; ok {
+				visit(dep, m.Pkg.Dependencies)
+			} else if p := lookup(dep); p != nil {
+				visit(dep, p.Dependencies)
+			}
+		}
+		if m, ok := byName[name]; ok {
+			sorted = append(sorted, m)
+		}
+	}
+	for _, name := range names {
+		visit(name, byName[name].Pkg.Dependencies)
+	}
+	return sorted
+}
+
+// Arguments of the brew commands moving packages to their new taps
+func migrationRuns(plan *TapMigrationPlan) []brewRun {
+	runs := []brewRun{}
+	for _, tap := range plan.Taps {
+		runs = append(runs, brewRun{args: []string{"tap", tap}, stopOnError: true})
+	}
+	for _, m := range plan.Migrations {
+		uninstall := []string{"uninstall", "--formula", "--ignore-dependencies", m.Pkg.Name}
+		if m.Pkg.IsCask {
+			uninstall = []string{"uninstall", "--cask", m.Pkg.Name}
+		}
+		kind := "--formula"
+		if m.ToCask() {
+			kind = "--cask"
+		}
+		pkgs := []*data.Package{m.Pkg}
+		runs = append(runs,
+			brewRun{pkgs: pkgs, args: uninstall, stopOnError: true},
+			brewRun{pkgs: pkgs, args: []string{"install", kind, m.To + "/" + m.NewName}, stopOnError: true},
+		)
+	}
+	for _, tap := range plan.Untaps {
+		runs = append(runs, brewRun{args: []string{"untap", tap}})
+	}
+	return runs
+}
+
+// Reinstall packages from the taps they moved to, stopping at the first failure so no dependency goes missing
+func MigratePackages(plan *TapMigrationPlan) tea.Cmd {
+	pkgs := make([]*data.Package, len(plan.Migrations))
+	for i, m := range plan.Migrations {
+		pkgs[i] = m.Pkg
+	}
+	return tea.Batch(startCommand(), executeRuns(BrewCommandMigrate, pkgs, migrationRuns(plan)))
+}
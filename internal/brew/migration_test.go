@@ -0,0 +1,82 @@
+package brew
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestTapMigrationPlan(t *testing.T) {
+	lib := &data.Package{Name: "libfoo", Tap: "someone/old", IsInstalled: true}
+	glue := &data.Package{Name: "glue", Tap: "homebrew/core", Dependencies: []string{"libfoo"}, IsInstalled: true}
+	app := &data.Package{Name: "foo", Tap: "someone/old", Dependencies: []string{"glue"}, IsInstalled: true}
+	gui := &data.Package{Name: "foo-gui", Tap: "someone/old", IsInstalled: true}
+	installed := []*data.Package{app, glue, gui, lib}
+	lookup := func(name string) *data.Package {
+		for _, pkg := range installed {
+			if pkg.Name == name {
+				return pkg
+			}
+		}
+		return nil
+	}
+
+	content := `{"foo": "someone/new", "libfoo": "someone/new/foo-lib", "foo-gui": "homebrew/cask", "bar": "someone/new"}`
+	migrations, err := parseTapMigrations("someone/old", []byte(content), installed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("expected 3 installed packages to migrate, got %d", len(migrations))
+	}
+
+	plan := planTapMigrations(migrations, installed, map[string]bool{"someone/old": true}, lookup)
+	order := []string{}
+	for _, m := range plan.Migrations {
+		order = append(order, m.Pkg.Name)
+	}
+	// libfoo is a dependency of foo through glue, which isn't migrated
+	if want := []string{"libfoo", "foo", "foo-gui"}; !slices.Equal(order, want) {
+		t.Errorf("expected order %v, got %v", want, order)
+	}
+	if !slices.Equal(plan.Taps, []string{"someone/new"}) || !slices.Equal(plan.Untaps, []string{"someone/old"}) {
+		t.Errorf("expected to tap someone/new and untap someone/old, got %v and %v", plan.Taps, plan.Untaps)
+	}
+
+	runs := migrationRuns(plan)
+	args := []string{}
+	for _, run := range runs {
+		args = append(args, strings.Join(run.args, " "))
+	}
+	want := []string{
+		"tap someone/new",
+		"uninstall --formula --ignore-dependencies libfoo",
+		"install --formula someone/new/foo-lib",
+		"uninstall --formula --ignore-dependencies foo",
+		"install --formula someone/new/foo",
+		"uninstall --formula --ignore-dependencies foo-gui",
+		"install --cask homebrew/cask/foo-gui",
+		"untap someone/old",
+	}
+	if !slices.Equal(args, want) {
+		t.Errorf("expected runs %v, got %v", want, args)
+	}
+	if runs[len(runs)-1].stopOnError {
+		t.Errorf("expected a failed untap not to stop anything")
+	}
+}
+
+func TestTapMigrationKeepsUsedTap(t *testing.T) {
+	moved := &data.Package{Name: "foo", Tap: "someone/old", IsInstalled: true}
+	stays := &data.Package{Name: "bar", Tap: "someone/old", IsInstalled: true}
+	migrations, err := parseTapMigrations("someone/old", []byte(`{"foo": "homebrew/core"}`), []*data.Package{moved, stays})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := planTapMigrations(migrations, []*data.Package{moved, stays}, map[string]bool{}, func(string) *data.Package { return nil })
+	if len(plan.Taps) != 0 || len(plan.Untaps) != 0 {
+		t.Errorf("expected no taps to add or remove, got %v and %v", plan.Taps, plan.Untaps)
+	}
+}
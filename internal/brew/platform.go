@@ -0,0 +1,28 @@
+package brew
+
+import (
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// The platform taproom runs on, detected once
+var CurrentPlatform = sync.OnceValue(func() data.Platform {
+	p := data.Platform{OS: data.OSLinux, Arch: "x86_64"}
+	if runtime.GOARCH == "arm64" {
+		p.Arch = "arm64"
+	}
+	if runtime.GOOS == "darwin" {
+		p.OS = data.OSMacOS
+		output, err := exec.Command("sw_vers", "-productVersion").Output()
+		if err != nil {
+			log.Printf("failed to get macOS version: %v", err)
+		}
+		p.Version = strings.TrimSpace(string(output))
+	}
+	return p
+})
@@ -0,0 +1,95 @@
+package brew
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+var flagPowerCheck = Flags.Bool(
+	"power-check",
+	false,
+	"Warn before upgrading all when the Mac runs on battery or is connected through a personal hotspot",
+)
+
+var (
+	// e.g. "Now drawing from 'Battery Power'" followed by " -InternalBattery-0 (id=4653155)	64%; discharging; ..."
+	pmsetSourceRegex  = regexp.MustCompile(`Now drawing from '([^']+)'`)
+	pmsetPercentRegex = regexp.MustCompile(`\t(\d+)%;`)
+	// A service in `networksetup -listnetworkserviceorder`, e.g. "(Hardware Port: iPhone USB, Device: en8)"
+	servicePortRegex = regexp.MustCompile(`\(Hardware Port: ([^,]+), Device: ([^)]+)\)`)
+)
+
+// iPhones and iPads share their connection in 172.20.10.0/28
+const hotspotGatewayPrefix = "172.20.10."
+
+// Reasons to hold off a large upgrade, like running on battery or a metered connection.
+// Empty unless --power-check is set and taproom runs on macOS.
+func PowerWarnings() []string {
+	if !*flagPowerCheck || CurrentPlatform().OS != data.OSMacOS {
+		return nil
+	}
+	warnings := []string{}
+	if output, err := exec.Command("pmset", "-g", "batt").Output(); err != nil {
+		log.Printf("failed to get power source: %v", err)
+	} else if warning := batteryWarning(string(output)); warning != "" {
+		warnings = append(warnings, warning)
+	}
+
+	route, err := exec.Command("route", "-n", "get", "default").Output()
+	if err != nil {
+		// No default route, e.g. offline
+		return warnings
+	}
+	services, err := exec.Command("networksetup", "-listnetworkserviceorder").Output()
+	if err != nil {
+		log.Printf("failed to list network services: %v", err)
+	}
+	if warning := hotspotWarning(string(route), string(services)); warning != "" {
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
+
+func batteryWarning(pmset string) string {
+	m := pmsetSourceRegex.FindStringSubmatch(pmset)
+	if m == nil || m[1] != "Battery Power" {
+		return ""
+	}
+	if p := pmsetPercentRegex.FindStringSubmatch(pmset); p != nil {
+		return fmt.Sprintf("Running on battery (%s%%), large upgrades can drain it", p[1])
+	}
+	return "Running on battery, large upgrades can drain it"
+}
+
+// Tell whether the default route goes through a phone, by the gateway of its hotspot
+// or by the name of the network service of the interface like "iPhone USB"
+func hotspotWarning(route, services string) string {
+	var iface, gateway string
+	for _, line := range strings.Split(route, "\n") {
+		name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		switch name {
+		case "interface":
+			iface = strings.TrimSpace(value)
+		case "gateway":
+			gateway = strings.TrimSpace(value)
+		}
+	}
+	if strings.HasPrefix(gateway, hotspotGatewayPrefix) {
+		return "Connected through a personal hotspot, downloads may use mobile data"
+	}
+	for _, m := range servicePortRegex.FindAllStringSubmatch(services, -1) {
+		port := strings.ToLower(m[1])
+		if m[2] == iface && (strings.Contains(port, "iphone") || strings.Contains(port, "ipad")) {
+			return fmt.Sprintf("Connected through %s, downloads may use mobile data", m[1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package brew
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBatteryWarning(t *testing.T) {
+	battery := "Now drawing from 'Battery Power'\n -InternalBattery-0 (id=4653155)\t64%; discharging; 5:12 remaining present: true\n"
+	if got := batteryWarning(battery); !strings.Contains(got, "64%") {
+		t.Errorf("expected a warning with the battery level, got %q", got)
+	}
+	ac := "Now drawing from 'AC Power'\n -InternalBattery-0 (id=4653155)\t100%; charged; 0:00 remaining present: true\n"
+	if got := batteryWarning(ac); got != "" {
+		t.Errorf("expected no warning on AC power, got %q", got)
+	}
+}
+
+func TestHotspotWarning(t *testing.T) {
+	services := `An asterisk (*) denotes that a network service is disabled.
+(1) Wi-Fi
+(Hardware Port: Wi-Fi, Device: en0)
+
+(2) iPhone USB
+(Hardware Port: iPhone USB, Device: en8)
+`
+	tests := []struct {
+		route   string
+		warning bool
+	}{
+		{"   route to: default\n    gateway: 192.168.1.1\n  interface: en0\n", false},
+		{"   route to: default\n    gateway: 172.20.10.1\n  interface: en0\n", true},
+		{"   route to: default\n    gateway: 10.0.0.1\n  interface: en8\n", true},
+	}
+	for _, tt := range tests {
+		if got := hotspotWarning(tt.route, services); (got != "") != tt.warning {
+			t.Errorf("expected warning %v for route %q, got %q", tt.warning, tt.route, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package brew
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Run a fetch function of a data source and wait for it to be done
+func prewarmTask[T any](fetch func(chan T, chan error)) error {
+	dataCh := make(chan T, 1)
+	errCh := make(chan error, 1)
+	fetch(dataCh, errCh)
+	select {
+	case <-dataCh:
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
+
+// Refresh cached data so the next launch doesn't wait for downloads or du, meant to run from launchd or cron.
+// The catalog and analytics are always downloaded again, sizes are only recomputed for packages that changed.
+func Prewarm() error {
+	// Download again even when the cached data hasn't expired yet
+	*flagInvalidateCache = true
+
+	tasks := []func() error{
+		func() error { return prewarmTask(fetchFormulaAnalytics) },
+		func() error { return prewarmTask(fetchCaskAnalytics) },
+		func() error {
+			formulaCh := make(chan []*installInfo, 1)
+			caskCh := make(chan []*installInfo, 1)
+			fetchInstalledFormula(true, formulaCh)
+			fetchInstalledCask(true, caskCh)
+			saveSizeCache()
+			return nil
+		},
+	}
+	// brew evaluates local taps on every launch, there is nothing to cache
+	if !useLocalCatalog() {
+		tasks = append(tasks,
+			func() error { return prewarmTask(fetchFormula) },
+			func() error { return prewarmTask(fetchCask) },
+		)
+	}
+	if *flagFetchBuildErrors {
+		tasks = append(tasks, func() error { return prewarmTask(fetchBuildErrorAnalytics) })
+	}
+
+	errs := make([]error, len(tasks))
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = task()
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to prewarm caches: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package brew
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const (
+	maxRelated = 8
+	// Lowest score of a related package, about a shared keyword that a dozen packages have
+	minRelatedScore = 0.4
+	// Added to packages of the same third-party tap, which tend to come from the same project
+	sameTapScore = 0.5
+)
+
+// Words too common in descriptions to tell anything about a package
+var stopWords = map[string]bool{
+	"and": true, "the": true, "for": true, "with": true, "from": true, "that": true, "your": true, "into": true,
+	"tool": true, "tools": true, "library": true, "command": true, "line": true, "written": true, "based": true,
+	"using": true, "app": true, "application": true, "simple": true, "fast": true, "small": true, "other": true,
+}
+
+// Packages by the keywords of their names and descriptions and by third-party tap, with the related
+// packages found so far
+type relatedIndex struct {
+	packages  []*data.Package
+	keywords  map[*data.Package][]string
+	byKeyword map[string][]*data.Package
+	byTap     map[string][]*data.Package
+	related   map[*data.Package][]*data.Package
+}
+
+var (
+	relatedPackages   *relatedIndex
+	relatedPackagesMu sync.Mutex
+)
+
+// Packages similar to pkg by their descriptions, shared dependencies and tap, most similar first.
+// None are found until the loaded packages are indexed.
+func RelatedPackages(pkg *data.Package) []*data.Package {
+	relatedPackagesMu.Lock()
+	defer relatedPackagesMu.Unlock()
+	if relatedPackages == nil || len(relatedPackages.packages) != len(allBrewPackages) ||
+		(len(allBrewPackages) > 0 && relatedPackages.packages[0] != allBrewPackages[0]) {
+		return nil
+	}
+	return relatedPackages.find(pkg)
+}
+
+// Index loaded packages for RelatedPackages, in the background rather than when the details panel first asks
+func indexRelatedPackages(packages []*data.Package) {
+	idx := newRelatedIndex(packages)
+	relatedPackagesMu.Lock()
+	defer relatedPackagesMu.Unlock()
+	relatedPackages = idx
+}
+
+func newRelatedIndex(packages []*data.Package) *relatedIndex {
+	idx := &relatedIndex{
+		packages:  packages,
+		keywords:  make(map[*data.Package][]string, len(packages)),
+		byKeyword: make(map[string][]*data.Package),
+		byTap:     make(map[string][]*data.Package),
+		related:   make(map[*data.Package][]*data.Package),
+	}
+	for _, pkg := range packages {
+		words := keywords(pkg.Name + " " + pkg.Desc())
+		idx.keywords[pkg] = words
+		for _, w := range words {
+			idx.byKeyword[w] = append(idx.byKeyword[w], pkg)
+		}
+		if isThirdPartyTap(pkg.Tap) {
+			idx.byTap[pkg.Tap] = append(idx.byTap[pkg.Tap], pkg)
+		}
+	}
+	return idx
+}
+
+func isThirdPartyTap(tap string) bool {
+	return tap != "" && tap != data.CoreTap && tap != data.CaskTap
+}
+
+// Distinct lowercase words of at least 3 letters, leaving out stop words
+func keywords(text string) []string {
+	words := []string{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) >= 3 && !stopWords[w] && !slices.Contains(words, w) {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func (idx *relatedIndex) find(pkg *data.Package) []*data.Package {
+	if related, ok := idx.related[pkg]; ok {
+		return related
+	}
+
+	// Rare keywords and dependencies count more than common ones
+	weight := func(n int) float64 {
+		return 1 / math.Log(float64(n)+1)
+	}
+	scores := make(map[*data.Package]float64)
+	// Packages of the same third-party tap are related even without sharing keywords or dependencies
+	if isThirdPartyTap(pkg.Tap) {
+		for _, p := range idx.byTap[pkg.Tap] {
+			scores[p] = sameTapScore
+		}
+	}
+	for _, w := range idx.keywords[pkg] {
+		for _, p := range idx.byKeyword[w] {
+			scores[p] += weight(len(idx.byKeyword[w]))
+		}
+	}
+	for _, dep := range pkg.Dependencies {
+		depPkg := findPackage(idx.packages, dep)
+		if depPkg == nil {
+			continue
+		}
+		for _, name := range depPkg.Dependents {
+			if p := findPackage(idx.packages, name); p != nil {
+				scores[p] += weight(len(depPkg.Dependents))
+			}
+		}
+	}
+
+	related := []*data.Package{}
+	for p, score := range scores {
+		if p == pkg || p.IsDisabled {
+			continue
+		}
+		if score >= minRelatedScore {
+			related = append(related, p)
+		}
+	}
+	slices.SortFunc(related, func(a, b *data.Package) int {
+		return cmp.Or(
+			cmp.Compare(scores[b], scores[a]),
+			cmp.Compare(b.Installs90d, a.Installs90d),
+			strings.Compare(a.UniqueName(), b.UniqueName()),
+		)
+	})
+	related = related[:min(len(related), maxRelated)]
+	idx.related[pkg] = related
+	return related
+}
@@ -0,0 +1,42 @@
+package brew
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestRelatedPackages(t *testing.T) {
+	newPkg := func(name, tap, desc string, deps ...string) *data.Package {
+		pkg := &data.Package{Name: name, Tap: tap, Dependencies: deps}
+		pkg.SetTexts(nil, desc, "")
+		return pkg
+	}
+	ripgrep := newPkg("ripgrep", data.CoreTap, "Search tool like grep and The Silver Searcher", "pcre2")
+	ugrep := newPkg("ugrep", data.CoreTap, "Ultra fast grep with query UI, fuzzy search, archive search, and more", "pcre2")
+	ag := newPkg("ag", data.CoreTap, "Code-search similar to ack", "pcre2")
+	pcre2 := newPkg("pcre2", data.CoreTap, "Perl compatible regular expressions library with a new API")
+	pcre2.Dependents = []string{"ag", "ripgrep", "ugrep"}
+	wget := newPkg("wget", data.CoreTap, "Internet file retriever")
+	toolA := newPkg("tool-a", "user/tap", "Internet file retriever for user")
+	toolB := newPkg("tool-b", "user/tap", "Unrelated thing for user")
+	toolC := newPkg("tool-c", "user/tap", "Nothing in common")
+	// Sorted by name like the packages of the catalog
+	packages := []*data.Package{ag, pcre2, ripgrep, toolA, toolB, toolC, ugrep, wget}
+
+	idx := newRelatedIndex(packages)
+	related := idx.find(ripgrep)
+	if len(related) < 2 || !slices.Contains(related, ugrep) || !slices.Contains(related, ag) {
+		t.Errorf("expected ugrep and ag to be related to ripgrep, got %v", packageNames(related))
+	}
+	if slices.Contains(related, ripgrep) || slices.Contains(related, wget) {
+		t.Errorf("expected neither ripgrep itself nor wget, got %v", packageNames(related))
+	}
+	if related := idx.find(toolA); len(related) != 3 || related[0] != wget || related[1] != toolB || related[2] != toolC {
+		t.Errorf("expected wget with the same description, then tool-b and tool-c of the same tap, got %v", packageNames(related))
+	}
+	if len(keywords("The fast and Fast CLI")) != 1 {
+		t.Errorf("expected only cli as a keyword, got %v", keywords("The fast and Fast CLI"))
+	}
+}
@@ -0,0 +1,66 @@
+package brew
+
+import (
+	"path/filepath"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/release"
+)
+
+const releaseInfoJson = "release_info.json"
+
+// Set while release info is being fetched, reloading data doesn't start another fetch
+var fetchingReleases atomic.Bool
+
+// Sent each time some packages get their release info, Apply sets it and Next waits for the following ones
+type ReleaseInfoMsg struct {
+	releases map[*data.Package]*data.ReleaseInfo
+	ch       <-chan map[*data.Package]*data.ReleaseInfo
+}
+
+// Fetch release info of installed packages in the background when --fetch-release is set
+func FetchReleaseInfo() tea.Cmd {
+	if !*flagFetchReleaseInfo || !fetchingReleases.CompareAndSwap(false, true) {
+		return nil
+	}
+	installed := []*data.Package{}
+	for _, pkg := range allBrewPackages {
+		if pkg.IsInstalled {
+			installed = append(installed, pkg)
+		}
+	}
+	ch := make(chan map[*data.Package]*data.ReleaseInfo)
+	go func() {
+		defer fetchingReleases.Store(false)
+		defer close(ch)
+		release.FetchReleaseInfo(installed, filepath.Join(taproomCacheDir, releaseInfoJson), func(releases map[*data.Package]*data.ReleaseInfo) {
+			ch <- releases
+		})
+	}()
+	return waitForReleaseInfo(ch)
+}
+
+// Set the fetched release info on the packages and return them, called on the UI goroutine which reads them
+func (msg ReleaseInfoMsg) Apply() []*data.Package {
+	pkgs := []*data.Package{}
+	for pkg, release := range msg.releases {
+		pkg.ReleaseInfo = release
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs
+}
+
+func (msg ReleaseInfoMsg) Next() tea.Cmd {
+	return waitForReleaseInfo(msg.ch)
+}
+
+func waitForReleaseInfo(ch <-chan map[*data.Package]*data.ReleaseInfo) tea.Cmd {
+	return func() tea.Msg {
+		if releases, ok := <-ch; ok {
+			return ReleaseInfoMsg{releases: releases, ch: ch}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+package brew
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Set in the system brew.env to let its values override the user's environment
+const systemEnvTakesPriority = "HOMEBREW_SYSTEM_ENV_TAKES_PRIORITY"
+
+// Files brew loads HOMEBREW_* settings from, later ones override earlier ones, see "Environment" in `man brew`
+func brewEnvFiles() (system string, others []string) {
+	system = "/etc/homebrew/brew.env"
+	others = []string{filepath.Join(brewPrefix, "etc", "homebrew", "brew.env")}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		others = append(others, filepath.Join(xdg, "homebrew", "brew.env"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		others = append(others, filepath.Join(home, ".homebrew", "brew.env"))
+	}
+	return system, others
+}
+
+type brewEnv struct {
+	files          map[string]string // Merged settings of all brew.env files
+	systemPriority map[string]string // Settings of the system file that override the environment
+}
+
+var loadedBrewEnv = sync.OnceValue(func() brewEnv {
+	return loadBrewEnv(brewEnvFiles())
+})
+
+func loadBrewEnv(system string, others []string) brewEnv {
+	env := brewEnv{files: make(map[string]string)}
+	systemSettings := make(map[string]string)
+	readBrewEnvFile(system, systemSettings)
+	for name, value := range systemSettings {
+		env.files[name] = value
+	}
+	for _, path := range others {
+		readBrewEnvFile(path, env.files)
+	}
+	if systemSettings[systemEnvTakesPriority] != "" {
+		env.systemPriority = systemSettings
+	}
+	return env
+}
+
+func readBrewEnvFile(path string, into map[string]string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	parseBrewEnv(f, into)
+}
+
+// Parse KEY=VALUE lines of a brew.env file, brew only reads HOMEBREW_* variables from it
+func parseBrewEnv(r io.Reader, into map[string]string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !strings.HasPrefix(name, "HOMEBREW_") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		into[name] = value
+	}
+}
+
+func (e brewEnv) get(name string) string {
+	if value, ok := e.systemPriority[name]; ok {
+		return value
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	return e.files[name]
+}
+
+// A HOMEBREW_* setting as brew sees it, from the environment or the brew.env files
+func brewSetting(name string) string {
+	return loadedBrewEnv().get(name)
+}
+
+// Flags brew adds to every cask command from HOMEBREW_CASK_OPTS, like --appdir=~/Applications
+func caskOpts() []string {
+	return strings.Fields(brewSetting("HOMEBREW_CASK_OPTS"))
+}
+
+// Whether HOMEBREW_CASK_OPTS already has the flag, with or without a value
+func hasCaskOpt(opts []string, flag string) bool {
+	for _, opt := range opts {
+		if opt == flag || strings.HasPrefix(opt, flag+"=") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseBrewEnv(t *testing.T) {
+	content := `# Mirror in China
+HOMEBREW_API_DOMAIN=https://mirrors.ustc.edu.cn/homebrew-bottles/api
+export HOMEBREW_CASK_OPTS="--appdir=~/Applications --language=de"
+HOMEBREW_NO_ANALYTICS='1'
+PATH=/usr/bin
+`
+	got := make(map[string]string)
+	parseBrewEnv(strings.NewReader(content), got)
+	expected := map[string]string{
+		"HOMEBREW_API_DOMAIN":   "https://mirrors.ustc.edu.cn/homebrew-bottles/api",
+		"HOMEBREW_CASK_OPTS":    "--appdir=~/Applications --language=de",
+		"HOMEBREW_NO_ANALYTICS": "1",
+	}
+	if len(got) != len(expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	for name, value := range expected {
+		if got[name] != value {
+			t.Errorf("expected %s=%q, got %q", name, value, got[name])
+		}
+	}
+}
+
+func TestLoadBrewEnv(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	system := write("system.env", "HOMEBREW_API_DOMAIN=https://system\nHOMEBREW_EDITOR=vi\n")
+	prefix := write("prefix.env", "HOMEBREW_API_DOMAIN=https://prefix\n")
+	user := write("user.env", "HOMEBREW_NO_AUTO_UPDATE=1\n")
+
+	t.Setenv("HOMEBREW_EDITOR", "nano")
+	env := loadBrewEnv(system, []string{prefix, user, filepath.Join(dir, "missing.env")})
+	if got := env.get("HOMEBREW_API_DOMAIN"); got != "https://prefix" {
+		t.Errorf("expected later files to win, got %q", got)
+	}
+	if got := env.get("HOMEBREW_NO_AUTO_UPDATE"); got != "1" {
+		t.Errorf("expected %q, got %q", "1", got)
+	}
+	if got := env.get("HOMEBREW_EDITOR"); got != "nano" {
+		t.Errorf("expected the environment to win, got %q", got)
+	}
+
+	system = write("system.env", "HOMEBREW_SYSTEM_ENV_TAKES_PRIORITY=1\nHOMEBREW_EDITOR=vi\n")
+	env = loadBrewEnv(system, nil)
+	if got := env.get("HOMEBREW_EDITOR"); got != "vi" {
+		t.Errorf("expected the system file to win, got %q", got)
+	}
+}
+
+func TestWithoutCaskOpts(t *testing.T) {
+	opts := []string{"--appdir=~/Applications", "--adopt", "--language=de"}
+	got := withoutCaskOpts([]string{"--force", "--adopt", "--skip-cask-deps"}, opts)
+	expected := []string{"--force", "--skip-cask-deps"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if !hasCaskOpt(opts, "--language") {
+		t.Errorf("expected --language to be found in %v", opts)
+	}
+	if hasCaskOpt(opts, "--lang") {
+		t.Errorf("expected --lang not to match --language")
+	}
+}
@@ -0,0 +1,54 @@
+package brew
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+type ShellExitedMsg struct {
+	Err error
+}
+
+// Environment of a shell that runs the formula's executables first, even when it's keg-only or unlinked
+func packageShellEnv(environ []string, pkg *data.Package) []string {
+	opt := filepath.Join(brewPrefix, "opt", pkg.Name)
+	dirs := []string{filepath.Join(opt, "bin"), filepath.Join(opt, "sbin")}
+
+	env := []string{}
+	path := ""
+	for _, kv := range environ {
+		if value, ok := strings.CutPrefix(kv, "PATH="); ok {
+			path = value
+		} else {
+			env = append(env, kv)
+		}
+	}
+	if path != "" {
+		dirs = append(dirs, path)
+	}
+	return append(env,
+		"PATH="+strings.Join(dirs, string(os.PathListSeparator)),
+		// Lets prompts and scripts tell they're in a taproom shell
+		"TAPROOM_SHELL="+pkg.Name,
+	)
+}
+
+// Suspend the TUI and start an interactive shell with the formula's bin/ in PATH, the TUI resumes once the shell exits
+func PackageShell(pkg *data.Package) tea.Cmd {
+	shell := cmp.Or(os.Getenv("SHELL"), "/bin/sh")
+	cmd := exec.Command(shell)
+	cmd.Env = packageShellEnv(os.Environ(), pkg)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			err = fmt.Errorf("shell for %s exited: %w", pkg.Name, err)
+		}
+		return ShellExitedMsg{Err: err}
+	})
+}
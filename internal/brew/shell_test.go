@@ -0,0 +1,22 @@
+package brew
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestPackageShellEnv(t *testing.T) {
+	env := packageShellEnv([]string{"HOME=/home/me", "PATH=/usr/bin:/bin"}, &data.Package{Name: "curl"})
+	opt := filepath.Join(brewPrefix, "opt", "curl")
+	want := []string{
+		"HOME=/home/me",
+		"PATH=" + opt + "/bin:" + opt + "/sbin:/usr/bin:/bin",
+		"TAPROOM_SHELL=curl",
+	}
+	if !slices.Equal(env, want) {
+		t.Errorf("expected env %v, got %v", want, env)
+	}
+}
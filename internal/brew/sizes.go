@@ -0,0 +1,128 @@
+package brew
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/hzqtc/taproom/internal/util"
+)
+
+const sizesJson = "sizes.json"
+
+// Cached size of an install directory, valid as long as the directory isn't modified
+type sizeCacheEntry struct {
+	Size    int64 `json:"size"`
+	ModTime int64 `json:"mod_time"`
+}
+
+var (
+	sizeCacheMu   sync.Mutex
+	sizeCache     map[string]sizeCacheEntry
+	sizeCacheOnce sync.Once
+)
+
+func loadSizeCache() {
+	sizeCacheOnce.Do(func() {
+		sizeCache = make(map[string]sizeCacheEntry)
+		if *flagInvalidateCache {
+			return
+		}
+		data, err := os.ReadFile(filepath.Join(taproomCacheDir, sizesJson))
+		if err != nil {
+			return
+		}
+		if err := json.Unmarshal(data, &sizeCache); err != nil {
+			log.Printf("failed to decode %s: %v", sizesJson, err)
+		}
+	})
+}
+
+func saveSizeCache() {
+	sizeCacheMu.Lock()
+	data, err := json.Marshal(sizeCache)
+	sizeCacheMu.Unlock()
+	if err != nil {
+		log.Printf("failed to encode %s: %v", sizesJson, err)
+		return
+	}
+	path := filepath.Join(taproomCacheDir, sizesJson)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			log.Printf("failed to write %s: %v", path, err)
+		}
+	}
+}
+
+// Get the size of a directory from cache, or compute it with du when the directory changed
+func cachedDirSize(path string, followSymlink bool) int64 {
+	loadSizeCache()
+	info, err := os.Stat(path)
+	if err != nil {
+		return fetchDirSize(path, followSymlink)
+	}
+
+	sizeCacheMu.Lock()
+	entry, ok := sizeCache[path]
+	sizeCacheMu.Unlock()
+	if ok && entry.ModTime == info.ModTime().Unix() {
+		return entry.Size
+	}
+
+	size := fetchDirSize(path, followSymlink)
+	sizeCacheMu.Lock()
+	sizeCache[path] = sizeCacheEntry{Size: size, ModTime: info.ModTime().Unix()}
+	sizeCacheMu.Unlock()
+	return size
+}
+
+// Recompute sizes of all installed packages, refresh the size cache and print them sorted by size
+func PrintSizes(w io.Writer) {
+	loadSizeCache()
+	sizeCacheMu.Lock()
+	clear(sizeCache)
+	sizeCacheMu.Unlock()
+
+	formulaCh := make(chan []*installInfo)
+	caskCh := make(chan []*installInfo)
+	go fetchInstalledFormula(true, formulaCh)
+	go fetchInstalledCask(true, caskCh)
+	formulae, casks := <-formulaCh, <-caskCh
+	saveSizeCache()
+
+	type sizeRow struct {
+		name   string
+		isCask bool
+		size   int64
+	}
+	rows := []sizeRow{}
+	var formulaeSize, casksSize int64
+	for _, info := range formulae {
+		rows = append(rows, sizeRow{info.name, false, info.size})
+		formulaeSize += info.size
+	}
+	for _, info := range casks {
+		rows = append(rows, sizeRow{info.name, true, info.size})
+		casksSize += info.size
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].size > rows[j].size
+	})
+
+	for _, r := range rows {
+		kind := "formula"
+		if r.isCask {
+			kind = "cask"
+		}
+		fmt.Fprintf(w, "%8s  %-7s  %s\n", util.FormatSize(r.size), kind, r.name)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Formulae: %d taking %s\n", len(formulae), util.FormatSize(formulaeSize))
+	fmt.Fprintf(w, "Casks: %d taking %s\n", len(casks), util.FormatSize(casksSize))
+	fmt.Fprintf(w, "Total: %s\n", util.FormatSize(formulaeSize+casksSize))
+}
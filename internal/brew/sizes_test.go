@@ -0,0 +1,40 @@
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachedDirSize(t *testing.T) {
+	useTempCacheDir(t)
+	dir := t.TempDir()
+	loadSizeCache()
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizeCacheMu.Lock()
+	sizeCache[dir] = sizeCacheEntry{Size: 12345, ModTime: info.ModTime().Unix()}
+	sizeCacheMu.Unlock()
+
+	if size := cachedDirSize(dir, false); size != 12345 {
+		t.Errorf("expected cached size 12345, got %d", size)
+	}
+
+	// Modifying the directory invalidates the cached size
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(dir, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if size := cachedDirSize(dir, false); size == 12345 {
+		t.Error("expected size to be recomputed after the directory changed")
+	}
+
+	saveSizeCache()
+	if _, err := os.Stat(filepath.Join(taproomCacheDir, sizesJson)); err != nil {
+		t.Errorf("expected size cache to be saved: %v", err)
+	}
+}
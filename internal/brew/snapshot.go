@@ -0,0 +1,228 @@
+package brew
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const (
+	snapshotsJson = "snapshots.json"
+	maxSnapshots  = 10
+	// Local tap old versions of formulae are extracted to
+	rollbackTap = "taproom/rollback"
+)
+
+// Packages installed at a point in time, taken before upgrading all
+type Snapshot struct {
+	Time     time.Time         `json:"time"`
+	Packages []SnapshotPackage `json:"packages"`
+}
+
+type SnapshotPackage struct {
+	Name    string `json:"name"` // Unique name, with the tap for packages shadowed by another one
+	IsCask  bool   `json:"isCask,omitempty"`
+	Version string `json:"version"` // Installed version with the revision
+}
+
+// What changed for a package since a snapshot and how to revert it
+type RollbackStep struct {
+	Name     string
+	IsCask   bool
+	Change   string   // "installed", "uninstalled", or the versions like "1.2 -> 1.3"
+	Commands []string // Empty when brew can't revert it
+	Note     string   // How the commands revert it, or why they can't
+}
+
+type RollbackLoadedMsg struct {
+	Snapshots []Snapshot // Newest first
+	Plans     [][]RollbackStep
+}
+
+func takeSnapshot(pkgs []*data.Package) Snapshot {
+	snapshot := Snapshot{Time: time.Now(), Packages: []SnapshotPackage{}}
+	for _, pkg := range pkgs {
+		if pkg.IsInstalled && pkg.IsManagedByBrew() {
+			snapshot.Packages = append(snapshot.Packages, SnapshotPackage{
+				Name:    pkg.UniqueName(),
+				IsCask:  pkg.IsCask,
+				Version: installedVersion(pkg),
+			})
+		}
+	}
+	return snapshot
+}
+
+// Save a snapshot of installed packages, keeping the most recent ones
+func recordSnapshot(pkgs []*data.Package) {
+	snapshots := append(loadSnapshots(), takeSnapshot(pkgs))
+	if len(snapshots) > maxSnapshots {
+		snapshots = snapshots[len(snapshots)-maxSnapshots:]
+	}
+	content, err := json.Marshal(snapshots)
+	if err != nil {
+		log.Printf("failed to encode %s: %v", snapshotsJson, err)
+		return
+	}
+	path := filepath.Join(taproomCacheDir, snapshotsJson)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("failed to create %s: %v", filepath.Dir(path), err)
+		return
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		log.Printf("failed to write %s: %v", path, err)
+	}
+}
+
+// Oldest first
+func loadSnapshots() []Snapshot {
+	snapshots := []Snapshot{}
+	content, err := os.ReadFile(filepath.Join(taproomCacheDir, snapshotsJson))
+	if err != nil {
+		return snapshots
+	}
+	if err := json.Unmarshal(content, &snapshots); err != nil {
+		log.Printf("failed to decode %s: %v", snapshotsJson, err)
+	}
+	return snapshots
+}
+
+// Load the snapshots and plan rolling back to each of them in the background
+func LoadRollback() tea.Cmd {
+	return func() tea.Msg {
+		snapshots := loadSnapshots()
+		cacheDir := ""
+		if output, err := exec.Command("brew", "--cache").Output(); err == nil {
+			cacheDir = strings.TrimSpace(string(output))
+		}
+		msg := RollbackLoadedMsg{}
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			msg.Snapshots = append(msg.Snapshots, snapshots[i])
+			msg.Plans = append(msg.Plans, planRollback(snapshots[i], allBrewPackages, cachedBottle(cacheDir), GetPackage))
+		}
+		return msg
+	}
+}
+
+// Finds the bottle of a formula version left in the download cache of brew
+func cachedBottle(cacheDir string) func(name, version string) string {
+	return func(name, version string) string {
+		if cacheDir == "" {
+			return ""
+		}
+		for _, dir := range []string{cacheDir, filepath.Join(cacheDir, "downloads")} {
+			// Downloads are named like "<sha256>--wget--1.24.5.arm64_sonoma.bottle.tar.gz", with a link without the hash
+			matches, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("*%s--%s.*bottle*.tar.gz", name, version)))
+			for _, match := range matches {
+				if cachePackageName(filepath.Base(match)) == name {
+					return match
+				}
+			}
+		}
+		return ""
+	}
+}
+
+// Steps reverting installed packages to a snapshot: reinstalling removed packages, removing new ones
+// and going back to the versions in the snapshot
+func planRollback(
+	snapshot Snapshot,
+	pkgs []*data.Package,
+	bottle func(name, version string) string,
+	lookup func(name string) *data.Package,
+) []RollbackStep {
+	installed := make(map[string]*data.Package)
+	for _, pkg := range pkgs {
+		if pkg.IsInstalled && pkg.IsManagedByBrew() {
+			installed[pkg.UniqueName()] = pkg
+		}
+	}
+	caskFlag := func(isCask bool) string {
+		if isCask {
+			return "--cask "
+		}
+		return ""
+	}
+
+	steps := []RollbackStep{}
+	inSnapshot := make(map[string]bool)
+	for _, s := range snapshot.Packages {
+		inSnapshot[s.Name] = true
+		pkg := installed[s.Name]
+		switch {
+		case pkg == nil:
+			steps = append(steps, RollbackStep{
+				Name:     s.Name,
+				IsCask:   s.IsCask,
+				Change:   "uninstalled",
+				Commands: []string{fmt.Sprintf("brew install %s%s", caskFlag(s.IsCask), s.Name)},
+				Note:     "installs the current version, " + s.Version + " was installed",
+			})
+		case installedVersion(pkg) != s.Version:
+			step := RollbackStep{Name: s.Name, IsCask: s.IsCask, Change: fmt.Sprintf("%s -> %s", s.Version, installedVersion(pkg))}
+			step.Commands, step.Note = revertVersion(pkg, s.Version, bottle, lookup)
+			steps = append(steps, step)
+		}
+	}
+	for _, pkg := range pkgs {
+		name := pkg.UniqueName()
+		if installed[name] == pkg && !inSnapshot[name] && !pkg.InstalledAsDependency {
+			steps = append(steps, RollbackStep{
+				Name:     name,
+				IsCask:   pkg.IsCask,
+				Change:   "installed",
+				Commands: []string{fmt.Sprintf("brew uninstall %s%s", caskFlag(pkg.IsCask), name)},
+			})
+		}
+	}
+	return steps
+}
+
+// Commands bringing back an older version of a formula: a versioned formula of the same major or minor version,
+// the bottle of that version if it's still in the cache, or extracting the old formula into a local tap
+func revertVersion(
+	pkg *data.Package,
+	version string,
+	bottle func(name, version string) string,
+	lookup func(name string) *data.Package,
+) ([]string, string) {
+	if pkg.IsCask {
+		return nil, "brew can't install older versions of casks"
+	}
+	name := pkg.UniqueName()
+	// Versioned and extracted formulae are named by the version without the revision
+	plain, _, _ := strings.Cut(version, "_")
+	parts := strings.Split(plain, ".")
+	for i := min(2, len(parts)); i >= 1; i-- {
+		versioned := pkg.Name + "@" + strings.Join(parts[:i], ".")
+		if p := lookup(versioned); p != nil && !p.IsCask {
+			return []string{
+				fmt.Sprintf("brew uninstall --ignore-dependencies %s", name),
+				fmt.Sprintf("brew install %s", versioned),
+			}, fmt.Sprintf("%s follows the %s releases", versioned, strings.Join(parts[:i], "."))
+		}
+	}
+	if path := bottle(pkg.Name, version); path != "" {
+		return []string{
+			fmt.Sprintf("brew uninstall --ignore-dependencies %s", name),
+			fmt.Sprintf("brew install %s", path),
+		}, "installs the bottle of " + version + " left in the download cache"
+	}
+	if pkg.Tap != coreTap {
+		return nil, "only formulae of homebrew/core can be extracted"
+	}
+	return []string{
+		fmt.Sprintf("brew tap-new --no-git %s", rollbackTap),
+		fmt.Sprintf("brew extract --version=%s %s %s", plain, pkg.Name, rollbackTap),
+		fmt.Sprintf("brew uninstall --ignore-dependencies %s", name),
+		fmt.Sprintf("brew install %s/%s@%s", rollbackTap, pkg.Name, plain),
+	}, "builds the old formula from a local tap, brew extract needs homebrew/core tapped (brew tap --force homebrew/core)"
+}
@@ -0,0 +1,90 @@
+package brew
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestRecordSnapshot(t *testing.T) {
+	useTempCacheDir(t)
+
+	wget := &data.Package{Name: "wget", IsInstalled: true, InstalledVersion: "1.24.5", InstalledRevision: 1}
+	for range maxSnapshots + 1 {
+		recordSnapshot([]*data.Package{wget, {Name: "jq"}})
+	}
+	snapshots := loadSnapshots()
+	if len(snapshots) != maxSnapshots {
+		t.Fatalf("expected %d snapshots, got %d", maxSnapshots, len(snapshots))
+	}
+	if expected := []SnapshotPackage{{Name: "wget", Version: "1.24.5_1"}}; !slices.Equal(snapshots[0].Packages, expected) {
+		t.Errorf("expected %v, got %v", expected, snapshots[0].Packages)
+	}
+}
+
+func TestPlanRollback(t *testing.T) {
+	node := &data.Package{Name: "node", Tap: coreTap, IsInstalled: true, InstalledVersion: "22.1.0"}
+	wget := &data.Package{Name: "wget", Tap: coreTap, IsInstalled: true, InstalledVersion: "1.25.0"}
+	jq := &data.Package{Name: "jq", Tap: coreTap, IsInstalled: true, InstalledVersion: "1.7.1"}
+	tool := &data.Package{Name: "tool", Tap: "user/tap", IsInstalled: true, InstalledVersion: "2.0"}
+	firefox := &data.Package{Name: "firefox", IsCask: true, IsInstalled: true, InstalledVersion: "127.0"}
+	htop := &data.Package{Name: "htop", IsInstalled: true, InstalledVersion: "3.3.0"}
+	dep := &data.Package{Name: "pcre2", IsInstalled: true, InstalledAsDependency: true}
+	node20 := &data.Package{Name: "node@20", Tap: coreTap}
+	pkgs := []*data.Package{firefox, htop, jq, node, node20, dep, tool, wget}
+
+	snapshot := Snapshot{Packages: []SnapshotPackage{
+		{Name: "firefox", IsCask: true, Version: "126.0"},
+		{Name: "git", Version: "2.45.0"},
+		{Name: "jq", Version: "1.7"},
+		{Name: "node", Version: "20.12.2"},
+		{Name: "tool", Version: "1.0"},
+		{Name: "wget", Version: "1.24.5_1"},
+	}}
+	bottle := func(name, version string) string {
+		if name == "wget" && version == "1.24.5_1" {
+			return "/cache/wget--1.24.5_1.arm64_sonoma.bottle.tar.gz"
+		}
+		return ""
+	}
+	lookup := func(name string) *data.Package {
+		for _, pkg := range pkgs {
+			if pkg.Name == name {
+				return pkg
+			}
+		}
+		return nil
+	}
+
+	steps := make(map[string]RollbackStep)
+	for _, step := range planRollback(snapshot, pkgs, bottle, lookup) {
+		steps[step.Name] = step
+	}
+	if len(steps) != 7 {
+		t.Errorf("expected 7 steps, got %v", steps)
+	}
+	for name, expected := range map[string][]string{
+		"git":  {"brew install git"},
+		"htop": {"brew uninstall htop"},
+		"node": {"brew uninstall --ignore-dependencies node", "brew install node@20"},
+		"wget": {"brew uninstall --ignore-dependencies wget", "brew install /cache/wget--1.24.5_1.arm64_sonoma.bottle.tar.gz"},
+		"jq": {
+			"brew tap-new --no-git taproom/rollback",
+			"brew extract --version=1.7 jq taproom/rollback",
+			"brew uninstall --ignore-dependencies jq",
+			"brew install taproom/rollback/jq@1.7",
+		},
+	} {
+		if !slices.Equal(steps[name].Commands, expected) {
+			t.Errorf("expected %v for %s, got %v", expected, name, steps[name].Commands)
+		}
+	}
+	if steps["firefox"].Commands != nil || steps["tool"].Commands != nil {
+		t.Errorf("expected no commands for the cask and the formula of another tap, got %v and %v",
+			steps["firefox"].Commands, steps["tool"].Commands)
+	}
+	if steps["node"].Change != "20.12.2 -> 22.1.0" {
+		t.Errorf("expected the versions as the change, got %s", steps["node"].Change)
+	}
+}
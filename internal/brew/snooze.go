@@ -0,0 +1,74 @@
+package brew
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const snoozesJson = "snoozes.json"
+
+// When the snoozes of outdated packages end, by the keys of catalog versions
+func loadSnoozes() map[string]time.Time {
+	snoozes := make(map[string]time.Time)
+	content, err := os.ReadFile(filepath.Join(taproomCacheDir, snoozesJson))
+	if err != nil {
+		return snoozes
+	}
+	if err := json.Unmarshal(content, &snoozes); err != nil {
+		log.Printf("failed to decode %s: %v", snoozesJson, err)
+		return make(map[string]time.Time)
+	}
+	return snoozes
+}
+
+func saveSnoozes(snoozes map[string]time.Time) {
+	content, err := json.Marshal(snoozes)
+	if err != nil {
+		log.Printf("failed to encode %s: %v", snoozesJson, err)
+		return
+	}
+	path := filepath.Join(taproomCacheDir, snoozesJson)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("failed to create %s: %v", filepath.Dir(path), err)
+	} else if err := os.WriteFile(path, content, 0644); err != nil {
+		log.Printf("failed to write %s: %v", path, err)
+	}
+}
+
+// Set when the snoozes of outdated packages end, forgetting the ones of packages that aren't outdated anymore
+func applySnoozes(packages []*data.Package) {
+	snoozes := loadSnoozes()
+	changed := false
+	for _, pkg := range packages {
+		until, ok := snoozes[versionKey(pkg)]
+		if ok && !pkg.IsOutdated {
+			delete(snoozes, versionKey(pkg))
+			changed = true
+			until = time.Time{}
+		}
+		pkg.SnoozedUntil = until
+	}
+	if changed {
+		saveSnoozes(snoozes)
+	}
+}
+
+// Leave an outdated package out of upgrade all and the outdated filter for some days
+func SnoozePackage(pkg *data.Package, days int) {
+	pkg.SnoozedUntil = time.Now().AddDate(0, 0, days)
+	snoozes := loadSnoozes()
+	snoozes[versionKey(pkg)] = pkg.SnoozedUntil
+	saveSnoozes(snoozes)
+}
+
+func UnsnoozePackage(pkg *data.Package) {
+	pkg.SnoozedUntil = time.Time{}
+	snoozes := loadSnoozes()
+	delete(snoozes, versionKey(pkg))
+	saveSnoozes(snoozes)
+}
@@ -0,0 +1,49 @@
+package brew
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestSnoozes(t *testing.T) {
+	useTempCacheDir(t)
+
+	snoozed := &data.Package{Name: "wget", IsInstalled: true, IsOutdated: true}
+	expired := &data.Package{Name: "jq", IsInstalled: true, IsOutdated: true}
+	SnoozePackage(snoozed, 7)
+	SnoozePackage(expired, -1)
+	if !snoozed.IsSnoozed() || snoozed.Status() != "Snoozed" {
+		t.Errorf("expected wget to be snoozed, got %s", snoozed.Status())
+	}
+
+	wget := &data.Package{Name: "wget", IsInstalled: true, IsOutdated: true}
+	jq := &data.Package{Name: "jq", IsInstalled: true, IsOutdated: true}
+	cask := &data.Package{Name: "wget", IsCask: true, IsInstalled: true, IsOutdated: true}
+	applySnoozes([]*data.Package{wget, jq, cask})
+	if !wget.IsSnoozed() || !wget.SnoozedUntil.Equal(snoozed.SnoozedUntil) {
+		t.Errorf("expected wget to be snoozed until %v, got %v", snoozed.SnoozedUntil, wget.SnoozedUntil)
+	}
+	if jq.IsSnoozed() || !jq.IsSnoozeOver() || jq.Status() != "Snooze Over" {
+		t.Errorf("expected the snooze of jq to be over, got %s", jq.Status())
+	}
+	if !cask.SnoozedUntil.IsZero() {
+		t.Errorf("expected the wget cask not to be snoozed, got %v", cask.SnoozedUntil)
+	}
+
+	// Upgraded packages forget their snoozes
+	jq.IsOutdated = false
+	applySnoozes([]*data.Package{jq})
+	if _, ok := loadSnoozes()["formula/jq"]; ok || !jq.SnoozedUntil.IsZero() {
+		t.Errorf("expected the snooze of jq to be forgotten, got %v", jq.SnoozedUntil)
+	}
+
+	UnsnoozePackage(wget)
+	if wget.IsSnoozed() || len(loadSnoozes()) != 0 {
+		t.Errorf("expected no snoozes left, got %v", loadSnoozes())
+	}
+	if time.Now().After(snoozed.SnoozedUntil) {
+		t.Errorf("expected the snooze to end in the future, got %v", snoozed.SnoozedUntil)
+	}
+}
@@ -0,0 +1,89 @@
+package brew
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+type SourceResolvedMsg struct {
+	Pkg  *data.Package
+	Path string
+	Err  error
+}
+
+type SourceEditedMsg struct {
+	Err error
+}
+
+// Locate the .rb file of a package in the background
+func ResolveSource(pkg *data.Package) tea.Cmd {
+	return func() tea.Msg {
+		if p := pkg.Provenance; p != nil && p.SourcePath != "" && fileExists(p.SourcePath) {
+			return SourceResolvedMsg{Pkg: pkg, Path: p.SourcePath}
+		}
+		if pkg.Tap == "" {
+			return SourceResolvedMsg{Pkg: pkg, Err: fmt.Errorf("the tap of %s is unknown", pkg.Name)}
+		}
+
+		output, err := exec.Command("brew", "--repository", pkg.Tap).Output()
+		if err != nil {
+			return SourceResolvedMsg{Pkg: pkg, Err: fmt.Errorf("failed to locate tap %s: %w", pkg.Tap, err)}
+		}
+		for _, path := range sourceCandidates(strings.TrimSpace(string(output)), pkg) {
+			if fileExists(path) {
+				return SourceResolvedMsg{Pkg: pkg, Path: path}
+			}
+		}
+		// brew reads core packages from the API unless their taps are cloned
+		return SourceResolvedMsg{Pkg: pkg, Err: fmt.Errorf(
+			"%s.rb is not in a local clone of %s, run 'brew tap --force %s' to clone it", pkg.Name, pkg.Tap, pkg.Tap)}
+	}
+}
+
+// Paths where a tap may keep the .rb file of a package, from the most common layout to the least
+func sourceCandidates(tapDir string, pkg *data.Package) []string {
+	file := pkg.Name + ".rb"
+	shard := file[:1]
+	if pkg.IsCask {
+		return []string{
+			filepath.Join(tapDir, "Casks", shard, file),
+			filepath.Join(tapDir, "Casks", file),
+		}
+	}
+	return []string{
+		filepath.Join(tapDir, "Formula", shard, file),
+		filepath.Join(tapDir, "Formula", file),
+		filepath.Join(tapDir, "HomebrewFormula", file),
+		filepath.Join(tapDir, file),
+	}
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// The editor brew uses for `brew edit`, which may have arguments like "code --wait"
+func editorCommand() []string {
+	editor := cmp.Or(brewSetting("HOMEBREW_EDITOR"), os.Getenv("VISUAL"), os.Getenv("EDITOR"))
+	if fields := strings.Fields(editor); len(fields) > 0 {
+		return fields
+	}
+	return []string{"vi"}
+}
+
+// Suspend the TUI and open a file in the editor, the TUI resumes once the editor exits
+func EditSource(path string) tea.Cmd {
+	editor := editorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return SourceEditedMsg{Err: err}
+	})
+}
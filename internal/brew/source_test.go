@@ -0,0 +1,33 @@
+package brew
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestSourceCandidates(t *testing.T) {
+	formula := sourceCandidates("/taps/homebrew-core", &data.Package{Name: "wget"})
+	if formula[0] != "/taps/homebrew-core/Formula/w/wget.rb" || !slices.Contains(formula, "/taps/homebrew-core/wget.rb") {
+		t.Errorf("expected sharded and root paths of wget.rb, got %v", formula)
+	}
+	cask := sourceCandidates("/taps/homebrew-cask", &data.Package{Name: "firefox", IsCask: true})
+	if want := []string{"/taps/homebrew-cask/Casks/f/firefox.rb", "/taps/homebrew-cask/Casks/firefox.rb"}; !slices.Equal(cask, want) {
+		t.Errorf("expected cask paths %v, got %v", want, cask)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("HOMEBREW_EDITOR", "")
+	t.Setenv("VISUAL", "code --wait")
+	t.Setenv("EDITOR", "nano")
+	if got, want := editorCommand(), []string{"code", "--wait"}; !slices.Equal(got, want) {
+		t.Errorf("expected editor %v, got %v", want, got)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	if got := editorCommand(); !slices.Equal(got, []string{"vi"}) {
+		t.Errorf("expected vi by default, got %v", got)
+	}
+}
@@ -0,0 +1,135 @@
+package brew
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const lastRunJson = "last-run.json"
+
+var flagLaunchSummary = Flags.Bool(
+	"launch-summary",
+	true,
+	"Show what changed since the last run on launch: newly outdated, externally upgraded and deprecated packages",
+)
+
+// Installed packages when taproom last loaded them
+type runState struct {
+	Time     time.Time             `json:"time"`
+	Packages map[string]runPackage `json:"packages"` // By the keys of catalog versions
+}
+
+type runPackage struct {
+	Version    string `json:"version"` // Installed version with the revision
+	Outdated   bool   `json:"outdated,omitempty"`
+	Deprecated bool   `json:"deprecated,omitempty"` // Deprecated or disabled
+}
+
+// What changed for packages that were already installed in the last run
+type LaunchSummary struct {
+	Since      time.Time
+	Outdated   []*data.Package // Outdated since the last run
+	Upgraded   []*data.Package // Installed versions changed by brew outside of taproom
+	Deprecated []*data.Package // Deprecated or disabled since the last run
+}
+
+func (s *LaunchSummary) IsEmpty() bool {
+	return len(s.Outdated)+len(s.Upgraded)+len(s.Deprecated) == 0
+}
+
+// The summary is only made by the first load of a session, later loads compare with it
+var launchSummaryOnce sync.Once
+
+func takeRunState(packages []*data.Package) runState {
+	state := runState{Time: time.Now(), Packages: make(map[string]runPackage)}
+	for _, pkg := range packages {
+		if pkg.IsInstalled {
+			state.Packages[versionKey(pkg)] = runPackage{
+				Version:    installedVersion(pkg),
+				Outdated:   pkg.IsOutdated,
+				Deprecated: pkg.IsDeprecated || pkg.IsDisabled,
+			}
+		}
+	}
+	return state
+}
+
+func loadRunState() *runState {
+	content, err := os.ReadFile(filepath.Join(taproomCacheDir, lastRunJson))
+	if err != nil {
+		return nil
+	}
+	state := &runState{}
+	if err := json.Unmarshal(content, state); err != nil {
+		log.Printf("failed to decode %s: %v", lastRunJson, err)
+		return nil
+	}
+	return state
+}
+
+// Compare installed packages with the last run, on the first load only, and remember them for the next run.
+// Returns nil when there's nothing to summarize.
+func recordRunState(packages []*data.Package) *LaunchSummary {
+	var summary *LaunchSummary
+	launchSummaryOnce.Do(func() {
+		if previous := loadRunState(); *flagLaunchSummary && previous != nil {
+			summary = diffRunState(*previous, packages, changedByTaproom(previous.Time))
+			if summary.IsEmpty() {
+				summary = nil
+			}
+		}
+	})
+
+	content, err := json.Marshal(takeRunState(packages))
+	if err != nil {
+		log.Printf("failed to encode %s: %v", lastRunJson, err)
+		return summary
+	}
+	path := filepath.Join(taproomCacheDir, lastRunJson)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("failed to create %s: %v", filepath.Dir(path), err)
+	} else if err := os.WriteFile(path, content, 0644); err != nil {
+		log.Printf("failed to write %s: %v", path, err)
+	}
+	return summary
+}
+
+// Packages of the commands taproom ran since then, their version changes aren't external
+func changedByTaproom(since time.Time) map[string]bool {
+	changed := make(map[string]bool)
+	for _, entry := range LoadHistory() {
+		if entry.Time.Before(since) {
+			break
+		}
+		for _, name := range entry.Pkgs {
+			changed[name] = true
+		}
+	}
+	return changed
+}
+
+func diffRunState(previous runState, packages []*data.Package, changedByTaproom map[string]bool) *LaunchSummary {
+	summary := &LaunchSummary{Since: previous.Time}
+	for _, pkg := range packages {
+		before, ok := previous.Packages[versionKey(pkg)]
+		if !pkg.IsInstalled || !ok {
+			continue
+		}
+		if pkg.IsOutdated && !before.Outdated {
+			summary.Outdated = append(summary.Outdated, pkg)
+		}
+		if installedVersion(pkg) != before.Version && !changedByTaproom[pkg.UniqueName()] {
+			summary.Upgraded = append(summary.Upgraded, pkg)
+		}
+		if (pkg.IsDeprecated || pkg.IsDisabled) && !before.Deprecated {
+			summary.Deprecated = append(summary.Deprecated, pkg)
+		}
+	}
+	return summary
+}
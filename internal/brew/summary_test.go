@@ -0,0 +1,59 @@
+package brew
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestDiffRunState(t *testing.T) {
+	outdated := &data.Package{Name: "wget", IsInstalled: true, IsOutdated: true, InstalledVersion: "1.24.5"}
+	stillOutdated := &data.Package{Name: "curl", IsInstalled: true, IsOutdated: true, InstalledVersion: "8.12.0"}
+	external := &data.Package{Name: "jq", IsInstalled: true, InstalledVersion: "1.7.1", InstalledRevision: 1}
+	byTaproom := &data.Package{Name: "git", IsInstalled: true, InstalledVersion: "2.50.0"}
+	deprecated := &data.Package{Name: "firefox", IsCask: true, IsInstalled: true, IsDeprecated: true, InstalledVersion: "139.0"}
+	added := &data.Package{Name: "new", IsInstalled: true, IsOutdated: true, IsDisabled: true, InstalledVersion: "0.1"}
+
+	previous := runState{Time: time.Now().Add(-time.Hour), Packages: map[string]runPackage{
+		"formula/wget": {Version: "1.24.5"},
+		"formula/curl": {Version: "8.12.0", Outdated: true},
+		"formula/jq":   {Version: "1.7.1"},
+		"formula/git":  {Version: "2.49.0"},
+		"cask/firefox": {Version: "139.0"},
+	}}
+	summary := diffRunState(previous, []*data.Package{outdated, stillOutdated, external, byTaproom, deprecated, added}, map[string]bool{"git": true})
+	if len(summary.Outdated) != 1 || summary.Outdated[0] != outdated {
+		t.Errorf("expected wget to be newly outdated, got %v", packageNames(summary.Outdated))
+	}
+	if len(summary.Upgraded) != 1 || summary.Upgraded[0] != external {
+		t.Errorf("expected jq to be upgraded externally, got %v", packageNames(summary.Upgraded))
+	}
+	if len(summary.Deprecated) != 1 || summary.Deprecated[0] != deprecated {
+		t.Errorf("expected firefox to be newly deprecated, got %v", packageNames(summary.Deprecated))
+	}
+}
+
+func TestRecordRunState(t *testing.T) {
+	useTempCacheDir(t)
+
+	wget := &data.Package{Name: "wget", IsInstalled: true, InstalledVersion: "1.24.5"}
+	state := takeRunState([]*data.Package{wget, {Name: "jq"}})
+	if len(state.Packages) != 1 || state.Packages["formula/wget"].Version != "1.24.5" {
+		t.Errorf("expected only wget to be remembered, got %v", state.Packages)
+	}
+
+	launchSummaryOnce = sync.Once{}
+	if summary := recordRunState([]*data.Package{wget}); summary != nil {
+		t.Errorf("expected no summary without a last run, got %v", summary)
+	}
+	// Later loads of the same session are compared with nothing
+	wget.IsOutdated = true
+	if summary := recordRunState([]*data.Package{wget}); summary != nil {
+		t.Errorf("expected no summary after the first load, got %v", summary)
+	}
+	if previous := loadRunState(); previous == nil || !previous.Packages["formula/wget"].Outdated {
+		t.Errorf("expected the last load to be remembered, got %v", previous)
+	}
+}
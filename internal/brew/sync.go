@@ -0,0 +1,234 @@
+package brew
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+var flagSyncWith = Flags.String(
+	"sync-with",
+	"",
+	"Brewfile or taproom export (CSV or Markdown) of another machine to compare installed packages with",
+)
+
+// A package installed on the other machine
+type SyncEntry struct {
+	Name    string // May have the tap, like user/tap/foo
+	Kind    string // "brew" or "cask", empty when the export has no Type column
+	Version string // Empty when unknown, Brewfiles have no versions
+}
+
+func (e SyncEntry) PackageName() string {
+	return e.Name[strings.LastIndex(e.Name, "/")+1:]
+}
+
+// A package installed on both machines in different versions
+type VersionMismatch struct {
+	Pkg     *data.Package
+	Version string // Installed on the other machine
+}
+
+// Installed packages compared with the ones of another machine, and what converging with it takes
+type SyncDiff struct {
+	Path       string
+	OnlyHere   []*data.Package // Explicitly installed here only
+	OnlyThere  []SyncEntry
+	Mismatched []VersionMismatch
+	Install    []*data.Package // Packages of the entries only there, the ones not in the catalog are left out
+	Upgrade    []*data.Package // Mismatched packages that are outdated here
+}
+
+type SyncLoadedMsg struct {
+	Diff *SyncDiff
+	Err  error
+}
+
+func SyncPath() string {
+	return *flagSyncWith
+}
+
+// Read the file of the other machine and compare it with installed packages in the background
+func LoadSync() tea.Cmd {
+	return func() tea.Msg {
+		path := *flagSyncWith
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return SyncLoadedMsg{Err: fmt.Errorf("failed to read %s: %w", path, err)}
+		}
+		entries, err := parseSyncEntries(path, content)
+		if err != nil {
+			return SyncLoadedMsg{Err: err}
+		}
+		return SyncLoadedMsg{Diff: diffSync(path, entries, allBrewPackages)}
+	}
+}
+
+// Entries of a Brewfile, or of a table exported by taproom in CSV or Markdown
+func parseSyncEntries(path string, content []byte) ([]SyncEntry, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+		if err != nil || len(records) == 0 {
+			return nil, fmt.Errorf("failed to read the CSV export %s: %v", path, err)
+		}
+		return exportEntries(path, records[0], records[1:])
+	case ".md":
+		records := parseMarkdownTable(string(content))
+		if len(records) < 2 {
+			return nil, fmt.Errorf("no table in the Markdown export %s", path)
+		}
+		// The second row separates the header from the rows
+		return exportEntries(path, records[0], records[2:])
+	default:
+		entries := []SyncEntry{}
+		for _, entry := range parseBrewfile(bytes.NewReader(content)) {
+			if entry.Kind != "tap" {
+				entries = append(entries, SyncEntry{Name: entry.Name, Kind: entry.Kind})
+			}
+		}
+		return entries, nil
+	}
+}
+
+// Cells of the lines of a Markdown table, with escaped pipes
+func parseMarkdownTable(content string) [][]string {
+	records := [][]string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+		line = strings.ReplaceAll(strings.Trim(line, "|"), `\|`, "\x00")
+		cells := strings.Split(line, "|")
+		for i, cell := range cells {
+			cells[i] = strings.ReplaceAll(strings.TrimSpace(cell), "\x00", "|")
+		}
+		records = append(records, cells)
+	}
+	return records
+}
+
+// Entries of the installed packages of an exported table, by the titles of its columns
+func exportEntries(path string, header []string, rows [][]string) ([]SyncEntry, error) {
+	nameCol, typeCol, versionCol, statusCol := -1, -1, -1, -1
+	for i, title := range header {
+		switch title {
+		case "Name":
+			nameCol = i
+		case "Type":
+			typeCol = i
+		case "Version":
+			versionCol = i
+		case "Status":
+			statusCol = i
+		}
+	}
+	if nameCol < 0 {
+		return nil, fmt.Errorf("no Name column in %s", path)
+	}
+	// Exports have all the packages of the table, installed or not
+	if statusCol < 0 {
+		return nil, fmt.Errorf("no Status column in %s to tell which packages are installed", path)
+	}
+	cell := func(row []string, col int) string {
+		if col < 0 || col >= len(row) {
+			return ""
+		}
+		return row[col]
+	}
+
+	entries := []SyncEntry{}
+	for _, row := range rows {
+		if status := cell(row, statusCol); status == "Uninstalled" || strings.HasSuffix(status, " (Uninstalled)") {
+			continue
+		}
+		entry := SyncEntry{Name: cell(row, nameCol)}
+		switch cell(row, typeCol) {
+		case "formula":
+			entry.Kind = "brew"
+		case "cask":
+			entry.Kind = "cask"
+		case "":
+		default:
+			// App Store apps and whalebrew commands aren't synced
+			continue
+		}
+		// Outdated packages show the installed version before the one they'd be upgraded to, older exports only
+		// had the new one
+		version, _, _ := strings.Cut(cell(row, versionCol), " -> ")
+		if !strings.HasSuffix(version, " (New)") {
+			entry.Version = strings.TrimSuffix(version, " (Pin)")
+		}
+		if entry.Name != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func installedVersion(pkg *data.Package) string {
+	if pkg.InstalledRevision > 0 {
+		return fmt.Sprintf("%s_%d", pkg.InstalledVersion, pkg.InstalledRevision)
+	}
+	return pkg.InstalledVersion
+}
+
+func diffSync(path string, entries []SyncEntry, pkgs []*data.Package) *SyncDiff {
+	diff := &SyncDiff{Path: path}
+	matches := func(pkg *data.Package, entry SyncEntry) bool {
+		return entry.Kind == "" || pkg.IsCask == (entry.Kind == "cask")
+	}
+	// Preferred packages come first among packages of the same name
+	byName := make(map[string][]*data.Package)
+	for _, pkg := range pkgs {
+		if pkg.IsManagedByBrew() {
+			byName[pkg.Name] = append(byName[pkg.Name], pkg)
+		}
+	}
+
+	listed := make(map[*data.Package]bool)
+	for _, entry := range entries {
+		var installed, available *data.Package
+		for _, pkg := range byName[entry.PackageName()] {
+			if !matches(pkg, entry) {
+				continue
+			}
+			if pkg.IsInstalled && installed == nil {
+				installed = pkg
+			}
+			if available == nil && (pkg.FullName() == entry.Name || !pkg.Shadowed) {
+				available = pkg
+			}
+		}
+
+		switch {
+		case installed == nil:
+			diff.OnlyThere = append(diff.OnlyThere, entry)
+			if available != nil {
+				diff.Install = append(diff.Install, available)
+			}
+		case entry.Version != "" && entry.Version != installedVersion(installed):
+			listed[installed] = true
+			diff.Mismatched = append(diff.Mismatched, VersionMismatch{Pkg: installed, Version: entry.Version})
+			if installed.IsOutdated && !installed.IsPinned {
+				diff.Upgrade = append(diff.Upgrade, installed)
+			}
+		default:
+			listed[installed] = true
+		}
+	}
+
+	for _, pkg := range pkgs {
+		if pkg.IsInstalled && !pkg.InstalledAsDependency && !listed[pkg] && pkg.IsManagedByBrew() {
+			diff.OnlyHere = append(diff.OnlyHere, pkg)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,67 @@
+package brew
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestParseSyncEntries(t *testing.T) {
+	brewfile, _ := parseSyncEntries("Brewfile", []byte("tap \"user/tap\"\nbrew \"wget\"\ncask \"firefox\"\n"))
+	if !slices.Equal(brewfile, []SyncEntry{{Name: "wget", Kind: "brew"}, {Name: "firefox", Kind: "cask"}}) {
+		t.Errorf("expected wget and firefox, got %v", brewfile)
+	}
+
+	csv, err := parseSyncEntries("setup.csv", []byte(
+		"Type,Name,Version,Status\nformula,wget,1.24.5,Installed\ncask,firefox,126.0 (New),Outdated\napp,Xcode,15.4,Installed\n"+
+			"formula,jq,1.7.1,Uninstalled\nformula,python@3.9,3.9.21,Disabled (Uninstalled)\n",
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(csv, []SyncEntry{{Name: "wget", Kind: "brew", Version: "1.24.5"}, {Name: "firefox", Kind: "cask"}}) {
+		t.Errorf("expected wget 1.24.5 and firefox without a version, got %v", csv)
+	}
+
+	markdown, err := parseSyncEntries("setup.md", []byte("| Name | Version | Status |\n| --- | --- | --- |\n| a\\|b | 1.0 (Pin) | Pinned |\n"))
+	if err != nil || !slices.Equal(markdown, []SyncEntry{{Name: "a|b", Version: "1.0"}}) {
+		t.Errorf("expected a|b 1.0, got %v, %v", markdown, err)
+	}
+
+	if _, err := parseSyncEntries("setup.csv", []byte("Type,Version\nformula,1.0\n")); err == nil {
+		t.Errorf("expected an error without a Name column")
+	}
+	if _, err := parseSyncEntries("setup.csv", []byte("Name,Version\nwget,1.0\n")); err == nil {
+		t.Errorf("expected an error without a Status column")
+	}
+}
+
+func TestDiffSync(t *testing.T) {
+	wget := &data.Package{Name: "wget", IsInstalled: true, InstalledVersion: "1.24.5"}
+	jq := &data.Package{Name: "jq", IsInstalled: true, InstalledVersion: "1.7", Version: "1.7.1", IsOutdated: true}
+	htop := &data.Package{Name: "htop", IsInstalled: true}
+	dep := &data.Package{Name: "pcre2", IsInstalled: true, InstalledAsDependency: true}
+	firefox := &data.Package{Name: "firefox", IsCask: true}
+	pkgs := []*data.Package{firefox, htop, jq, dep, wget}
+
+	diff := diffSync("setup.csv", []SyncEntry{
+		{Name: "wget", Kind: "brew", Version: "1.24.5"},
+		{Name: "jq", Kind: "brew", Version: "1.7.1"},
+		{Name: "firefox", Kind: "cask"},
+		{Name: "missing", Kind: "brew"},
+	}, pkgs)
+
+	if len(diff.OnlyThere) != 2 || !slices.Equal(diff.Install, []*data.Package{firefox}) {
+		t.Errorf("expected firefox and missing only there, firefox to install, got %v and %v", diff.OnlyThere, packageNames(diff.Install))
+	}
+	if !slices.Equal(diff.OnlyHere, []*data.Package{htop}) {
+		t.Errorf("expected htop only here, got %v", packageNames(diff.OnlyHere))
+	}
+	if len(diff.Mismatched) != 1 || diff.Mismatched[0].Pkg != jq || diff.Mismatched[0].Version != "1.7.1" {
+		t.Errorf("expected jq 1.7.1 there, got %v", diff.Mismatched)
+	}
+	if !slices.Equal(diff.Upgrade, []*data.Package{jq}) {
+		t.Errorf("expected to upgrade jq, got %v", packageNames(diff.Upgrade))
+	}
+}
@@ -1,39 +1,71 @@
 package brew
 
 import (
+	"bytes"
+	"cmp"
 	"fmt"
+	"io/fs"
+	"log"
 	"os"
+	"os/exec"
 	"path"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
-	"taproom/internal/data"
+
+	"github.com/hzqtc/taproom/internal/data"
 )
 
 const (
-	coreTap = "homebrew/core"
-	caskTap = "homebrew/cask"
+	coreTap = data.CoreTap
+	caskTap = data.CaskTap
 )
 
 var (
 	versionRegex = regexp.MustCompile(`v?(\d+(?:\.\d+)*[a-zA-Z0-9\-\.]*)`)
 	sourceExts   = []string{".tar.gz", ".tar.xz", ".tar.bz2", ".tgz", ".zip"}
+	// String interpolation, livecheck blocks and version schemes can't be parsed with regexes
+	unparsableDslRegex = regexp.MustCompile(`#\{|livecheck\s+do|version_scheme`)
+
+	caskStanzaRegex = regexp.MustCompile(`(?m)^\s*cask\s+["']`)
+	// e.g. app "Foo.app" or binary "foo.wrapper.sh", target: "foo"
+	caskArtifactRegex  = regexp.MustCompile(`(?m)^\s*(` + strings.Join(caskInstallArtifacts, "|") + `)\s+["']([^"']+)["'](?:.*target:\s*["']([^"']+)["'])?`)
+	caskDependsRegex   = regexp.MustCompile(`depends_on\s+(?:formula|cask):\s*["']([^"']+)["']`)
+	caskConflictsRegex = regexp.MustCompile(`conflicts_with\s+(?:formula|cask):\s*["']([^"']+)["']`)
+
+	// e.g. deprecate! date: "2024-01-01", because: :unmaintained, replacement_formula: "foo"
+	deprecationDslRegex = regexp.MustCompile(`(deprecate|disable)!\s+(.*)`)
+	dslDateRegex        = regexp.MustCompile(`date:\s*["']([^"']+)["']`)
+	dslBecauseRegex     = regexp.MustCompile(`because:\s*(?::(\w+)|"([^"]+)"|'([^']+)')`)
+	dslReplacementRegex = regexp.MustCompile(`replacement(?:_formula|_cask)?:\s*["']([^"']+)["']`)
 )
 
 // Get a package from locally cloned custom tap data (*.rb files)
 // Ideally this should be called after `brew update`
 func getCustomTapPackage(info *installInfo) (*data.Package, error) {
+	// This reads the .rb file located in /opt/homebrew/Library/Taps/
+	data, err := os.ReadFile(info.path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read %s: %w", info.path, err)
+	}
+	return parseCustomTapPackage(info, string(data))
+}
+
+// Parse a formula or cask from the content of its .rb file
+func parseCustomTapPackage(info *installInfo, content string) (*data.Package, error) {
+	if caskStanzaRegex.MatchString(content) {
+		return parseCustomTapCask(info, content)
+	}
+
 	pkg := data.Package{
 		Name: info.name,
 		Tap:  info.tap,
 	}
 
-	// This reads the .rb file located in /opt/homebrew/Library/Taps/
-	data, err := os.ReadFile(info.path)
-	if err != nil {
-		return nil, fmt.Errorf("can't read %s: %w", info.path, err)
+	if unparsableDslRegex.MatchString(content) {
+		return nil, fmt.Errorf("%s uses DSL that can't be parsed", info.path)
 	}
-	content := string(data)
 
 	// Version
 	if m := regexp.MustCompile(`version\s+["']([^"']+)["']`).FindStringSubmatch(content); m != nil {
@@ -53,14 +85,16 @@ func getCustomTapPackage(info *installInfo) (*data.Package, error) {
 	}
 
 	// Desc
+	var desc, homepage string
 	if m := regexp.MustCompile(`desc\s+["']([^"']+)["']`).FindStringSubmatch(content); m != nil {
-		pkg.Desc = m[1]
+		desc = m[1]
 	}
 
 	// Homepage
 	if m := regexp.MustCompile(`homepage\s+["']([^"']+)["']`).FindStringSubmatch(content); m != nil {
-		pkg.Homepage = m[1]
+		homepage = m[1]
 	}
+	pkg.SetTexts(packageTexts, desc, homepage)
 
 	// Urls
 	urlRe := regexp.MustCompile(`url\s+["']([^"']+)["']`)
@@ -109,19 +143,216 @@ func getCustomTapPackage(info *installInfo) (*data.Package, error) {
 	if strings.Contains(content, "deprecate!") {
 		pkg.IsDeprecated = true
 	}
+	pkg.Deprecation = parseDeprecationDsl(content)
 
 	// Final validation on required fields
 	if pkg.Version == "" {
 		return nil, fmt.Errorf("no version found in %s", info.path)
-	} else if pkg.Desc == "" {
+	} else if desc == "" {
 		return nil, fmt.Errorf("no desc found in %s", info.path)
-	} else if pkg.Homepage == "" {
+	} else if homepage == "" {
 		return nil, fmt.Errorf("no homepage found in %s", info.path)
 	} else {
 		return &pkg, nil
 	}
 }
 
+// Parse a cask from the content of its .rb file. Urls usually interpolate the version, which is
+// substituted, other interpolations still need evaluating by brew.
+func parseCustomTapCask(info *installInfo, content string) (*data.Package, error) {
+	pkg := data.Package{
+		Name:    info.name,
+		Tap:     info.tap,
+		IsCask:  true,
+		License: "N/A",
+	}
+
+	// Version, the first one when it differs by architecture
+	if m := regexp.MustCompile(`(?m)^\s*version\s+["']([^"']+)["']`).FindStringSubmatch(content); m != nil {
+		pkg.Version = m[1]
+	} else if regexp.MustCompile(`(?m)^\s*version\s+:latest`).MatchString(content) {
+		pkg.Version = "latest"
+	}
+	if pkg.Version == "" {
+		return nil, fmt.Errorf("no version found in %s", info.path)
+	}
+	content = strings.ReplaceAll(content, "#{version}", pkg.Version)
+	if unparsableDslRegex.MatchString(content) {
+		return nil, fmt.Errorf("%s uses DSL that can't be parsed", info.path)
+	}
+
+	// Desc, casks without one are described by their app name
+	var desc, homepage string
+	if m := regexp.MustCompile(`desc\s+["']([^"']+)["']`).FindStringSubmatch(content); m != nil {
+		desc = m[1]
+	} else if m := regexp.MustCompile(`(?m)^\s*name\s+["']([^"']+)["']`).FindStringSubmatch(content); m != nil {
+		desc = m[1]
+	}
+
+	// Homepage
+	if m := regexp.MustCompile(`homepage\s+["']([^"']+)["']`).FindStringSubmatch(content); m != nil {
+		homepage = m[1]
+	}
+	pkg.SetTexts(packageTexts, desc, homepage)
+
+	// Url, the first one when it differs by architecture
+	if m := regexp.MustCompile(`(?m)^\s*url\s+["']([^"']+)["']`).FindStringSubmatch(content); m != nil {
+		pkg.Urls = []string{m[1]}
+	}
+
+	// Artifacts
+	for _, m := range caskArtifactRegex.FindAllStringSubmatch(content, -1) {
+		pkg.Artifacts = append(pkg.Artifacts, data.CaskArtifact{Kind: m[1], Target: cmp.Or(m[3], m[2])})
+		// Packages are installed with the macOS installer, which always runs as root
+		pkg.RequiresSudo = pkg.RequiresSudo || m[1] == "pkg"
+	}
+
+	// Dependencies and conflicts
+	for _, m := range caskDependsRegex.FindAllStringSubmatch(content, -1) {
+		pkg.Dependencies = append(pkg.Dependencies, m[1])
+	}
+	for _, m := range caskConflictsRegex.FindAllStringSubmatch(content, -1) {
+		pkg.Conflicts = append(pkg.Conflicts, m[1])
+	}
+
+	// Flags
+	pkg.AutoUpdate = regexp.MustCompile(`auto_updates\s+true`).MatchString(content)
+	pkg.IsDisabled = strings.Contains(content, "disable!")
+	pkg.IsDeprecated = strings.Contains(content, "deprecate!")
+	pkg.Deprecation = parseDeprecationDsl(content)
+
+	// Final validation on required fields
+	if len(pkg.Urls) == 0 {
+		return nil, fmt.Errorf("no url found in %s", info.path)
+	} else if desc == "" {
+		return nil, fmt.Errorf("no desc or name found in %s", info.path)
+	} else if homepage == "" {
+		return nil, fmt.Errorf("no homepage found in %s", info.path)
+	}
+	pkg.InstallSupported = isInstallSupported(pkg.Urls[0]) && !pkg.RequiresSudo
+	return &pkg, nil
+}
+
+// Deprecation of a formula or cask from its deprecate! and disable! calls, nil when there are none
+func parseDeprecationDsl(content string) *data.Deprecation {
+	var d *data.Deprecation
+	for _, m := range deprecationDslRegex.FindAllStringSubmatch(content, -1) {
+		if d == nil {
+			d = &data.Deprecation{}
+		}
+		var date, reason string
+		if arg := dslDateRegex.FindStringSubmatch(m[2]); arg != nil {
+			date = arg[1]
+		}
+		if arg := dslBecauseRegex.FindStringSubmatch(m[2]); arg != nil {
+			reason = arg[1] + arg[2] + arg[3]
+		}
+		if arg := dslReplacementRegex.FindStringSubmatch(m[2]); arg != nil {
+			d.Replacement = cmp.Or(d.Replacement, arg[1])
+		}
+		if m[1] == "disable" {
+			d.DisableDate, d.DisableReason = date, reason
+		} else {
+			d.Date, d.Reason = date, reason
+		}
+	}
+	return d
+}
+
+// Evaluate packages from custom taps with brew, which understands the full formula and cask DSL.
+// This is much slower than parsing .rb files, so it's only a fallback when parsing fails.
+func evalCustomTapPackages(infos []*installInfo, isCask bool) (*localCatalog, error) {
+	if len(infos) == 0 {
+		return &localCatalog{}, nil
+	}
+	args := []string{"info", "--json=v2", "--formula"}
+	if isCask {
+		args[2] = "--cask"
+	}
+	for _, info := range infos {
+		args = append(args, info.tap+"/"+info.name)
+	}
+
+	var errOutput bytes.Buffer
+	cmd := exec.Command("brew", args...)
+	cmd.Stderr = &errOutput
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run brew info: %w: %s", err, errOutput.String())
+	}
+	return parseLocalCatalog(bytes.NewReader(output))
+}
+
+// Formulae and casks of locally cloned third-party taps, in Library/Taps/<user>/homebrew-<repo>
+func listTapPackages(tapsDir string) (formulae, casks []*installInfo) {
+	users, err := os.ReadDir(tapsDir)
+	if err != nil {
+		log.Printf("failed to list taps in %s: %v", tapsDir, err)
+		return nil, nil
+	}
+	for _, user := range users {
+		repos, _ := os.ReadDir(filepath.Join(tapsDir, user.Name()))
+		for _, repo := range repos {
+			tap := strings.ToLower(user.Name() + "/" + strings.TrimPrefix(repo.Name(), "homebrew-"))
+			dir := filepath.Join(tapsDir, user.Name(), repo.Name())
+			// Taps being developed are often symlinked
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() || tap == coreTap || tap == caskTap {
+				continue
+			}
+			formulae = append(formulae, listRubyFiles(dir, tap, "Formula", "HomebrewFormula")...)
+			casks = append(casks, listRubyFiles(dir, tap, "Casks")...)
+		}
+	}
+	return formulae, casks
+}
+
+// .rb files in the first of the directories that exists, including subdirectories like Formula/a/
+func listRubyFiles(tapDir, tap string, dirs ...string) []*installInfo {
+	infos := []*installInfo{}
+	for _, dir := range dirs {
+		root := filepath.Join(tapDir, dir)
+		if _, err := os.Stat(root); err != nil {
+			continue
+		}
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && filepath.Ext(path) == ".rb" {
+				infos = append(infos, &installInfo{name: strings.TrimSuffix(d.Name(), ".rb"), tap: tap, path: path})
+			}
+			return nil
+		})
+		break
+	}
+	return infos
+}
+
+// Packages of third-party taps that aren't installed, read from their .rb files only since evaluating
+// whole taps with brew would be too slow. Packages named like one already listed are left out.
+func uninstalledTapPackages(infos []*installInfo, listed map[string]bool, isCask bool) []*data.Package {
+	packages := []*data.Package{}
+	failed := 0
+	for _, info := range infos {
+		if listed[info.name] {
+			continue
+		}
+		pkg, err := getCustomTapPackage(info)
+		if err != nil {
+			failed++
+			continue
+		}
+		if !isCask {
+			// Casks are parsed with what they install, which tells whether they're supported
+			pkg.InstallSupported = true
+		}
+		pkg.Sources.Catalog = data.SourceTapFile
+		packages = append(packages, pkg)
+		listed[info.name] = true
+	}
+	if failed > 0 {
+		log.Printf("skipped %d uninstalled packages from third-party taps that couldn't be parsed", failed)
+	}
+	return packages
+}
+
 func parseVersionFromUrl(url string) string {
 	base := path.Base(url)
 	for _, ext := range sourceExts {
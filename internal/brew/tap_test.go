@@ -0,0 +1,234 @@
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestGetCustomTapPackage(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) *installInfo {
+		path := filepath.Join(dir, name+".rb")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return &installInfo{name: name, tap: "user/tap", path: path}
+	}
+
+	plain := write("plain", `class Plain < Formula
+  desc "A plain formula"
+  homepage "https://example.com/plain"
+  url "https://example.com/plain-1.2.3.tar.gz"
+  license "MIT"
+  depends_on "cmake" => :build
+  depends_on "openssl@3"
+end
+`)
+	pkg, err := getCustomTapPackage(plain)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if pkg.Version != "1.2.3" || pkg.Desc() != "A plain formula" || pkg.License != "MIT" {
+		t.Errorf("expected version 1.2.3, desc and license, got %q, %q, %q", pkg.Version, pkg.Desc(), pkg.License)
+	}
+	if len(pkg.Dependencies) != 1 || len(pkg.BuildDependencies) != 1 {
+		t.Errorf("expected 1 dependency and 1 build dependency, got %v and %v", pkg.Dependencies, pkg.BuildDependencies)
+	}
+
+	for name, content := range map[string]string{
+		"interpolated": `class Interpolated < Formula
+  desc "Interpolated url"
+  homepage "https://example.com"
+  version "2.0"
+  url "https://example.com/v#{version}/interpolated.tar.gz"
+end
+`,
+		"livecheck": `class Livecheck < Formula
+  desc "Has a livecheck block"
+  homepage "https://example.com"
+  url "https://example.com/livecheck-1.0.tar.gz"
+  livecheck do
+    url "https://example.com/releases"
+  end
+end
+`,
+		"incomplete": `class Incomplete < Formula
+  url "https://example.com/download"
+end
+`,
+	} {
+		if _, err := getCustomTapPackage(write(name, content)); err == nil {
+			t.Errorf("expected %s to need evaluating by brew, got no error", name)
+		}
+	}
+}
+
+func TestParseCustomTapCask(t *testing.T) {
+	info := &installInfo{name: "foo", tap: "user/tap", path: "foo.rb"}
+	pkg, err := parseCustomTapPackage(info, `cask "foo" do
+  version "1.2.3"
+  sha256 "abc"
+
+  url "https://example.com/foo-#{version}.dmg"
+  name "Foo"
+  homepage "https://example.com/foo"
+
+  auto_updates true
+  depends_on formula: "ffmpeg"
+  conflicts_with cask: "foo@beta"
+
+  app "Foo.app"
+  binary "#{appdir}/Foo.app/Contents/MacOS/foo", target: "foo"
+end
+`)
+	if err == nil {
+		t.Errorf("expected other interpolations to need evaluating by brew, got %v", pkg)
+	}
+
+	pkg, err = parseCustomTapPackage(info, `cask "foo" do
+  version "1.2.3"
+  url "https://example.com/foo-#{version}.dmg"
+  name "Foo"
+  homepage "https://example.com/foo"
+  auto_updates true
+  depends_on formula: "ffmpeg"
+  conflicts_with cask: "foo@beta"
+  app "Foo.app"
+  binary "Foo.app/Contents/MacOS/foo", target: "foo"
+end
+`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !pkg.IsCask || pkg.Version != "1.2.3" || pkg.Desc() != "Foo" || !pkg.AutoUpdate || !pkg.InstallSupported {
+		t.Errorf("expected an installable auto-updating cask 1.2.3 described by its name, got %+v", pkg)
+	}
+	if len(pkg.Urls) != 1 || pkg.Urls[0] != "https://example.com/foo-1.2.3.dmg" {
+		t.Errorf("expected the version in the url, got %v", pkg.Urls)
+	}
+	expected := []data.CaskArtifact{{Kind: "app", Target: "Foo.app"}, {Kind: "binary", Target: "foo"}}
+	if !slices.Equal(pkg.Artifacts, expected) {
+		t.Errorf("expected %v, got %v", expected, pkg.Artifacts)
+	}
+	if !slices.Equal(pkg.Dependencies, []string{"ffmpeg"}) || !slices.Equal(pkg.Conflicts, []string{"foo@beta"}) {
+		t.Errorf("expected ffmpeg dependency and foo@beta conflict, got %v and %v", pkg.Dependencies, pkg.Conflicts)
+	}
+
+	pkg, err = parseCustomTapPackage(info, `cask "foo" do
+  version :latest
+  url "https://example.com/Foo.pkg"
+  desc "Foo installer"
+  homepage "https://example.com/foo"
+  pkg "Foo.pkg"
+end
+`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if pkg.Version != "latest" || !pkg.RequiresSudo || pkg.InstallSupported {
+		t.Errorf("expected a latest pkg cask requiring sudo, got %+v", pkg)
+	}
+}
+
+func TestParseDeprecationDsl(t *testing.T) {
+	if d := parseDeprecationDsl(`class Foo < Formula
+end
+`); d != nil {
+		t.Errorf("expected no deprecation, got %+v", d)
+	}
+	d := parseDeprecationDsl(`class Foo < Formula
+  deprecate! date: "2024-01-01", because: :repo_archived, replacement_formula: "bar"
+  disable! date: "2025-01-01", because: "it's broken"
+end
+`)
+	expected := data.Deprecation{Date: "2024-01-01", Reason: "repo_archived", DisableDate: "2025-01-01", DisableReason: "it's broken", Replacement: "bar"}
+	if d == nil || *d != expected {
+		t.Errorf("expected %+v, got %+v", expected, d)
+	}
+}
+
+func FuzzParseCustomTapPackage(f *testing.F) {
+	f.Add(`class Foo < Formula
+  desc "Foo"
+  homepage "https://example.com"
+  url "https://example.com/foo-1.0.tar.gz"
+  revision 2
+  depends_on "bar" => [:build, :test]
+  conflicts_with "baz", because: "both install foo"
+  deprecate! date: "2024-01-01", because: :unmaintained
+end
+`)
+	f.Add(`cask "foo" do
+  version :latest
+  url "https://example.com/Foo.dmg"
+  name "Foo"
+  desc "Foo app"
+  homepage "https://example.com"
+end
+`)
+	f.Add(`url "https://github.com/foo/foo.git", tag: "v2.3.4", revision: "abc"`)
+	f.Add(`url "https://example.com/download/" version "" desc '' revision 99999999999999999999`)
+
+	info := &installInfo{name: "foo", tap: "user/tap", path: "foo.rb"}
+	f.Fuzz(func(t *testing.T, content string) {
+		pkg, err := parseCustomTapPackage(info, content)
+		if err != nil {
+			return
+		}
+		if pkg.Version == "" || pkg.Desc() == "" || pkg.Homepage() == "" {
+			t.Errorf("expected version, desc and home page of a parsed package, got %q, %q, %q", pkg.Version, pkg.Desc(), pkg.Homepage())
+		}
+	})
+}
+
+func TestListTapPackages(t *testing.T) {
+	taps := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(taps, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	formula := `class Foo < Formula
+  desc "Foo"
+  homepage "https://example.com"
+  url "https://example.com/foo-1.0.tar.gz"
+end
+`
+	write("user/homebrew-tools/Formula/f/foo.rb", formula)
+	write("user/homebrew-tools/Formula/wget.rb", formula)
+	write("user/homebrew-tools/Formula/README.md", "")
+	write("user/homebrew-tools/Casks/bar.rb", `cask "bar" do
+  version "2.0"
+  url "https://example.com/bar-2.0.dmg"
+  desc "Bar"
+  homepage "https://example.com/bar"
+end
+`)
+	write("homebrew/homebrew-core/Formula/jq.rb", formula)
+
+	formulae, casks := listTapPackages(taps)
+	if len(formulae) != 2 || len(casks) != 1 {
+		t.Fatalf("expected 2 formulae and 1 cask, got %d and %d", len(formulae), len(casks))
+	}
+	if casks[0].name != "bar" || casks[0].tap != "user/tools" {
+		t.Errorf("expected user/tools/bar, got %s/%s", casks[0].tap, casks[0].name)
+	}
+
+	// wget is already in the catalog
+	pkgs := uninstalledTapPackages(formulae, map[string]bool{"wget": true}, false)
+	if len(pkgs) != 1 || pkgs[0].Name != "foo" || pkgs[0].IsInstalled || !pkgs[0].InstallSupported {
+		t.Errorf("expected foo to be listed as uninstalled and installable, got %v", pkgs)
+	}
+	pkgs = uninstalledTapPackages(casks, map[string]bool{}, true)
+	if len(pkgs) != 1 || !pkgs[0].IsCask || pkgs[0].Version != "2.0" {
+		t.Errorf("expected cask bar 2.0, got %v", pkgs)
+	}
+}
@@ -0,0 +1,126 @@
+package brew
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Default interval of brew's auto-update, see `brew help update`
+const defaultAutoUpdateInterval = 24 * time.Hour
+
+// A tapped repository
+type Tap struct {
+	Name            string   `json:"name"`
+	Path            string   `json:"path"`
+	Remote          string   `json:"remote"`
+	Official        bool     `json:"official"`
+	Formulae        []string `json:"formula_names"`
+	Casks           []string `json:"cask_tokens"`
+	ForceAutoUpdate bool     `json:"-"` // Auto-update the tap even when it's not hosted on GitHub
+}
+
+// brew only auto-updates taps hosted on GitHub, unless it's forced for the tap
+func (t *Tap) IsOnGithub() bool {
+	return strings.Contains(t.Remote, "github.com")
+}
+
+type TapsLoadedMsg struct {
+	Taps []*Tap
+	Err  error
+}
+
+// Load all installed taps in the background
+func LoadTaps() tea.Cmd {
+	return func() tea.Msg {
+		taps, err := loadTaps()
+		if err != nil {
+			return TapsLoadedMsg{Err: err}
+		}
+		for _, tap := range taps {
+			tap.ForceAutoUpdate = readTapConfig(tap.Path, "forceautoupdate") == "true"
+		}
+		return TapsLoadedMsg{Taps: taps}
+	}
+}
+
+func loadTaps() ([]*Tap, error) {
+	var errOutput bytes.Buffer
+	cmd := exec.Command("brew", "tap-info", "--json", "--installed")
+	cmd.Stderr = &errOutput
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load taps: %w: %s", err, errOutput.String())
+	}
+	return parseTaps(output)
+}
+
+func parseTaps(data []byte) ([]*Tap, error) {
+	taps := []*Tap{}
+	if err := json.Unmarshal(data, &taps); err != nil {
+		return nil, fmt.Errorf("failed to decode taps: %w", err)
+	}
+	sort.Slice(taps, func(i, j int) bool {
+		return taps[i].Name < taps[j].Name
+	})
+	return taps, nil
+}
+
+// brew keeps per-tap settings in the git config of the tap repo, under the "homebrew" section
+func readTapConfig(path, key string) string {
+	output, err := exec.Command("git", "-C", path, "config", "--get", "homebrew."+key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Set whether a tap is updated by brew's auto-update, the same setting as `brew tap --force-auto-update`
+func SetTapForceAutoUpdate(tap *Tap, force bool) error {
+	var errOutput bytes.Buffer
+	cmd := exec.Command("git", "-C", tap.Path, "config", "homebrew.forceautoupdate", strconv.FormatBool(force))
+	cmd.Stderr = &errOutput
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to set auto-update of %s: %w: %s", tap.Name, err, errOutput.String())
+	}
+	tap.ForceAutoUpdate = force
+	return nil
+}
+
+// Describe how brew auto-updates based on the environment variables it reads
+func AutoUpdateSetting() string {
+	return autoUpdateSetting(brewSetting("HOMEBREW_NO_AUTO_UPDATE"), brewSetting("HOMEBREW_AUTO_UPDATE_SECS"))
+}
+
+func autoUpdateSetting(noAutoUpdate, autoUpdateSecs string) string {
+	if noAutoUpdate != "" {
+		return "disabled (HOMEBREW_NO_AUTO_UPDATE is set)"
+	}
+	if autoUpdateSecs != "" {
+		secs, err := strconv.Atoi(autoUpdateSecs)
+		if err != nil {
+			return fmt.Sprintf("invalid HOMEBREW_AUTO_UPDATE_SECS: %s", autoUpdateSecs)
+		}
+		return fmt.Sprintf("every %s (HOMEBREW_AUTO_UPDATE_SECS is customized)", formatInterval(time.Duration(secs)*time.Second))
+	}
+	return fmt.Sprintf("every %s (default)", formatInterval(defaultAutoUpdateInterval))
+}
+
+// Format an interval in its largest whole unit, e.g. 24h instead of 24h0m0s
+func formatInterval(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
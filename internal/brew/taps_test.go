@@ -0,0 +1,42 @@
+package brew
+
+import "testing"
+
+func TestParseTaps(t *testing.T) {
+	output := `[
+		{"name": "someone/tools", "path": "/opt/homebrew/Library/Taps/someone/homebrew-tools", "official": false,
+		 "formula_names": ["someone/tools/foo"], "cask_tokens": []},
+		{"name": "homebrew/core", "path": "/opt/homebrew/Library/Taps/homebrew/homebrew-core", "official": true,
+		 "formula_names": ["wget", "curl"], "cask_tokens": []}
+	]`
+	taps, err := parseTaps([]byte(output))
+	if err != nil {
+		t.Fatalf("failed to parse taps: %v", err)
+	}
+	if len(taps) != 2 || taps[0].Name != "homebrew/core" || taps[1].Name != "someone/tools" {
+		t.Fatalf("expected taps sorted by name, got %+v", taps)
+	}
+	if !taps[0].Official || len(taps[0].Formulae) != 2 {
+		t.Errorf("expected official homebrew/core with 2 formulae, got %+v", taps[0])
+	}
+}
+
+func TestAutoUpdateSetting(t *testing.T) {
+	tests := []struct {
+		noAutoUpdate   string
+		autoUpdateSecs string
+		want           string
+	}{
+		{"", "", "every 24h (default)"},
+		{"1", "300", "disabled (HOMEBREW_NO_AUTO_UPDATE is set)"},
+		{"", "300", "every 5m (HOMEBREW_AUTO_UPDATE_SECS is customized)"},
+		{"", "90", "every 1m30s (HOMEBREW_AUTO_UPDATE_SECS is customized)"},
+		{"", "soon", "invalid HOMEBREW_AUTO_UPDATE_SECS: soon"},
+	}
+
+	for _, tt := range tests {
+		if got := autoUpdateSetting(tt.noAutoUpdate, tt.autoUpdateSecs); got != tt.want {
+			t.Errorf("autoUpdateSetting(%q, %q) = %q, want %q", tt.noAutoUpdate, tt.autoUpdateSecs, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package brew
+
+import (
+	"cmp"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const (
+	upgradeTimingsJson = "upgrade-timings.json"
+
+	// Number of recent durations kept for each package
+	maxTimingSamples = 5
+	// Estimate for packages that were never installed or upgraded by taproom
+	defaultUpgradeDuration = 30 * time.Second
+)
+
+// Lines printed by brew when it starts installing or upgrading a package, in a single or batch command
+var packageStartRegex = regexp.MustCompile(`^==> (?:Upgrading|Installing)(?: Cask)? ([^\s:]+)$`)
+
+// Recent install/upgrade durations of each package, by package name
+type packageTimings map[string][]time.Duration
+
+var timingsMu sync.Mutex
+
+func loadTimings() packageTimings {
+	timings := packageTimings{}
+	data, err := os.ReadFile(filepath.Join(taproomCacheDir, upgradeTimingsJson))
+	if err != nil {
+		return timings
+	}
+	if err := json.Unmarshal(data, &timings); err != nil {
+		log.Printf("failed to decode %s: %v", upgradeTimingsJson, err)
+	}
+	return timings
+}
+
+func saveTimings(timings packageTimings) {
+	data, err := json.Marshal(timings)
+	if err != nil {
+		log.Printf("failed to encode %s: %v", upgradeTimingsJson, err)
+		return
+	}
+	path := filepath.Join(taproomCacheDir, upgradeTimingsJson)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			log.Printf("failed to write %s: %v", path, err)
+		}
+	}
+}
+
+func recordTimings(durations map[string]time.Duration) {
+	if len(durations) == 0 {
+		return
+	}
+	timingsMu.Lock()
+	defer timingsMu.Unlock()
+
+	timings := loadTimings()
+	for name, d := range durations {
+		samples := append(timings[name], d)
+		if len(samples) > maxTimingSamples {
+			samples = samples[len(samples)-maxTimingSamples:]
+		}
+		timings[name] = samples
+	}
+	saveTimings(timings)
+}
+
+// Average of past durations of the package, or of all packages when it has no history
+func (t packageTimings) estimate(name string) time.Duration {
+	if samples := t[name]; len(samples) > 0 {
+		return average(samples)
+	}
+	all := []time.Duration{}
+	for _, samples := range t {
+		all = append(all, samples...)
+	}
+	if len(all) > 0 {
+		return average(all)
+	}
+	return defaultUpgradeDuration
+}
+
+func average(durations []time.Duration) time.Duration {
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return total / time.Duration(len(durations))
+}
+
+// Estimate how long it takes to upgrade all the packages based on past timings
+func EstimateUpgradeTime(pkgs []*data.Package) time.Duration {
+	timingsMu.Lock()
+	timings := loadTimings()
+	timingsMu.Unlock()
+
+	var total time.Duration
+	for _, pkg := range pkgs {
+		total += timings.estimate(pkg.Name)
+	}
+	return total
+}
+
+// Pick the outdated packages to upgrade within a time budget, shortest estimated upgrades first
+// so that as many as possible fit, and the ones deferred to a later session
+func PlanTimeBoxedUpgrade(pkgs []*data.Package, budget time.Duration) (fit, deferred []*data.Package) {
+	timingsMu.Lock()
+	timings := loadTimings()
+	timingsMu.Unlock()
+	return planTimeBox(pkgs, budget, timings)
+}
+
+func planTimeBox(pkgs []*data.Package, budget time.Duration, timings packageTimings) (fit, deferred []*data.Package) {
+	sorted := slices.Clone(pkgs)
+	slices.SortStableFunc(sorted, func(a, b *data.Package) int {
+		return cmp.Compare(timings.estimate(a.Name), timings.estimate(b.Name))
+	})
+	var total time.Duration
+	for _, pkg := range sorted {
+		if d := timings.estimate(pkg.Name); total+d <= budget {
+			total += d
+			fit = append(fit, pkg)
+		} else {
+			deferred = append(deferred, pkg)
+		}
+	}
+	return fit, deferred
+}
+
+// commandTimer measures how long each package takes in a running brew command
+// and estimates the remaining time of the command
+type commandTimer struct {
+	mu        sync.Mutex
+	timings   packageTimings
+	pkgs      []string
+	start     time.Time
+	current   string // Package being installed or upgraded
+	currentAt time.Time
+	durations map[string]time.Duration
+}
+
+func newCommandTimer(pkgs []*data.Package) *commandTimer {
+	timingsMu.Lock()
+	timings := loadTimings()
+	timingsMu.Unlock()
+
+	return &commandTimer{
+		timings:   timings,
+		pkgs:      packageNames(pkgs),
+		start:     time.Now(),
+		durations: make(map[string]time.Duration),
+	}
+}
+
+// Check an output line for the start of a package, returns true when a new package started
+func (t *commandTimer) observe(line string) bool {
+	m := packageStartRegex.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.current != "" {
+		t.durations[t.current] = now.Sub(t.currentAt)
+	}
+	t.current = m[1]
+	t.currentAt = now
+	return true
+}
+
+// Remaining time of the command and the number of packages done
+func (t *commandTimer) eta() (time.Duration, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var remaining time.Duration
+	done := 0
+	for _, name := range t.pkgs {
+		if _, ok := t.durations[name]; ok {
+			done++
+		} else if name == t.current {
+			remaining += max(0, t.timings.estimate(name)-time.Since(t.currentAt))
+		} else {
+			remaining += t.timings.estimate(name)
+		}
+	}
+	return remaining, done
+}
+
+// Save the measured durations once the command succeeded
+func (t *commandTimer) finish() {
+	t.mu.Lock()
+	durations := t.durations
+	if len(t.pkgs) == 1 {
+		// A single package takes the whole command, including downloading
+		durations = map[string]time.Duration{t.pkgs[0]: time.Since(t.start)}
+	} else if t.current != "" {
+		durations[t.current] = time.Since(t.currentAt)
+	}
+	t.mu.Unlock()
+
+	recordTimings(durations)
+}
@@ -0,0 +1,74 @@
+package brew
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestPackageTimingsEstimate(t *testing.T) {
+	timings := packageTimings{
+		"foo": {10 * time.Second, 20 * time.Second},
+		"bar": {60 * time.Second},
+	}
+	if got := timings.estimate("foo"); got != 15*time.Second {
+		t.Errorf("expected estimate 15s for foo, got %s", got)
+	}
+	// Unknown packages use the average of all samples
+	if got := timings.estimate("baz"); got != 30*time.Second {
+		t.Errorf("expected estimate 30s for baz, got %s", got)
+	}
+	if got := (packageTimings{}).estimate("baz"); got != defaultUpgradeDuration {
+		t.Errorf("expected default estimate for baz, got %s", got)
+	}
+}
+
+func TestCommandTimer(t *testing.T) {
+	useTempCacheDir(t)
+	recordTimings(map[string]time.Duration{"a": 10 * time.Second, "b": 20 * time.Second})
+
+	pkgs := []*data.Package{{Name: "a"}, {Name: "b"}}
+	if got := EstimateUpgradeTime(pkgs); got != 30*time.Second {
+		t.Errorf("expected upgrade estimate 30s, got %s", got)
+	}
+
+	timer := newCommandTimer(pkgs)
+	if timer.observe("==> Upgrading 2 outdated packages:") {
+		t.Error("summary line should not start a package")
+	}
+	if !timer.observe("==> Upgrading a") {
+		t.Error("expected package a to start")
+	}
+	if !timer.observe("==> Upgrading b") {
+		t.Error("expected package b to start")
+	}
+	remaining, done := timer.eta()
+	if done != 1 || remaining > 20*time.Second || remaining < 19*time.Second {
+		t.Errorf("expected 1 package done and ~20s remaining, got %d and %s", done, remaining)
+	}
+
+	timer.finish()
+	timings := loadTimings()
+	if len(timings["a"]) != 2 || len(timings["b"]) != 2 {
+		t.Errorf("expected new samples recorded for a and b, got %v", timings)
+	}
+}
+
+func TestPlanTimeBox(t *testing.T) {
+	timings := packageTimings{
+		"llvm": {40 * time.Minute},
+		"node": {5 * time.Minute},
+		"wget": {time.Minute},
+		"curl": {2 * time.Minute},
+	}
+	pkgs := []*data.Package{{Name: "llvm"}, {Name: "node"}, {Name: "wget"}, {Name: "curl"}}
+	fit, deferred := planTimeBox(pkgs, 10*time.Minute, timings)
+	if got := packageNames(fit); !slices.Equal(got, []string{"wget", "curl", "node"}) {
+		t.Errorf("expected the quickest upgrades to fit first, got %v", got)
+	}
+	if got := packageNames(deferred); !slices.Equal(got, []string{"llvm"}) {
+		t.Errorf("expected llvm to be deferred, got %v", got)
+	}
+}
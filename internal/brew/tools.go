@@ -0,0 +1,230 @@
+package brew
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var flagIntegrations = Flags.Bool(
+	"integrations",
+	false,
+	"Enable the integrations screen listing tools installed globally with pipx and npm, with their outdated status",
+)
+
+const (
+	ToolManagerPipx = "pipx"
+	ToolManagerNpm  = "npm"
+)
+
+// A command line tool installed globally by another package manager
+type GlobalTool struct {
+	Name    string
+	Version string
+	Latest  string // Empty when it's up to date or the latest version is unknown
+}
+
+func (t *GlobalTool) IsOutdated() bool {
+	return t.Latest != "" && t.Latest != t.Version
+}
+
+// Tools installed globally by a package manager
+type ToolManager struct {
+	Name      string
+	Installed bool
+	Tools     []*GlobalTool
+	Err       error // Listing the tools or checking them for updates failed
+}
+
+func (m *ToolManager) OutdatedCount() int {
+	count := 0
+	for _, t := range m.Tools {
+		if t.IsOutdated() {
+			count++
+		}
+	}
+	return count
+}
+
+type GlobalToolsLoadedMsg struct {
+	Managers []*ToolManager
+}
+
+func IntegrationsEnabled() bool {
+	return *flagIntegrations
+}
+
+// List the tools installed globally with pipx and npm, and check them for updates, in the background
+func LoadGlobalTools() tea.Cmd {
+	return func() tea.Msg {
+		managers := []*ToolManager{{Name: ToolManagerPipx}, {Name: ToolManagerNpm}}
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			loadPipxTools(managers[0])
+		}()
+		go func() {
+			defer wg.Done()
+			loadNpmTools(managers[1])
+		}()
+		wg.Wait()
+		return GlobalToolsLoadedMsg{Managers: managers}
+	}
+}
+
+// Output of a command that may exit with an error and still print what's needed, like `npm outdated`
+func toolOutput(name string, args ...string) ([]byte, error) {
+	output, err := exec.Command(name, args...).Output()
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("failed to run %s %s: %w", name, args[0], err)
+	}
+	return output, nil
+}
+
+func loadPipxTools(m *ToolManager) {
+	if _, err := exec.LookPath(ToolManagerPipx); err != nil {
+		return
+	}
+	m.Installed = true
+	output, err := toolOutput(ToolManagerPipx, "list", "--json")
+	if err != nil {
+		m.Err = err
+		return
+	}
+	if m.Tools, err = parsePipxList(output); err != nil {
+		m.Err = err
+		return
+	}
+	// pipx has no outdated command, each tool is checked in its own virtual environment
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, tool := range m.Tools {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			output, err := toolOutput(ToolManagerPipx, "runpip", tool.Name, "list", "--outdated", "--format=json")
+			if err == nil {
+				tool.Latest, err = parsePipOutdated(output, tool.Name)
+			}
+			if err != nil {
+				mu.Lock()
+				m.Err = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Parse `pipx list --json`, which lists a virtual environment for each tool
+func parsePipxList(output []byte) ([]*GlobalTool, error) {
+	var list struct {
+		Venvs map[string]struct {
+			Metadata struct {
+				MainPackage struct {
+					Package        string `json:"package"`
+					PackageVersion string `json:"package_version"`
+				} `json:"main_package"`
+			} `json:"metadata"`
+		} `json:"venvs"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode pipx list: %w", err)
+	}
+	tools := []*GlobalTool{}
+	for name, venv := range list.Venvs {
+		main := venv.Metadata.MainPackage
+		if main.Package != "" {
+			name = main.Package
+		}
+		tools = append(tools, &GlobalTool{Name: name, Version: main.PackageVersion})
+	}
+	sortTools(tools)
+	return tools, nil
+}
+
+// Latest version of a package from `pip list --outdated --format=json`, empty when it's not outdated
+func parsePipOutdated(output []byte, name string) (string, error) {
+	var outdated []struct {
+		Name          string `json:"name"`
+		LatestVersion string `json:"latest_version"`
+	}
+	if err := json.Unmarshal(output, &outdated); err != nil {
+		return "", fmt.Errorf("failed to decode pip list: %w", err)
+	}
+	for _, p := range outdated {
+		if p.Name == name {
+			return p.LatestVersion, nil
+		}
+	}
+	return "", nil
+}
+
+func loadNpmTools(m *ToolManager) {
+	if _, err := exec.LookPath(ToolManagerNpm); err != nil {
+		return
+	}
+	m.Installed = true
+	output, err := toolOutput(ToolManagerNpm, "ls", "--global", "--depth=0", "--json")
+	if err != nil {
+		m.Err = err
+		return
+	}
+	if m.Tools, err = parseNpmList(output); err != nil {
+		m.Err = err
+		return
+	}
+	// Exits with an error when packages are outdated
+	if output, err = toolOutput(ToolManagerNpm, "outdated", "--global", "--json"); err == nil {
+		err = applyNpmOutdated(m.Tools, output)
+	}
+	m.Err = err
+}
+
+// Parse `npm ls --global --depth=0 --json`
+func parseNpmList(output []byte) ([]*GlobalTool, error) {
+	var list struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode npm ls: %w", err)
+	}
+	tools := []*GlobalTool{}
+	for name, dep := range list.Dependencies {
+		tools = append(tools, &GlobalTool{Name: name, Version: dep.Version})
+	}
+	sortTools(tools)
+	return tools, nil
+}
+
+// Set the latest versions from `npm outdated --global --json`, which prints nothing for no outdated packages
+func applyNpmOutdated(tools []*GlobalTool, output []byte) error {
+	if len(output) == 0 {
+		return nil
+	}
+	var outdated map[string]struct {
+		Latest string `json:"latest"`
+	}
+	if err := json.Unmarshal(output, &outdated); err != nil {
+		return fmt.Errorf("failed to decode npm outdated: %w", err)
+	}
+	for _, tool := range tools {
+		if o, ok := outdated[tool.Name]; ok {
+			tool.Latest = o.Latest
+		}
+	}
+	return nil
+}
+
+func sortTools(tools []*GlobalTool) {
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+}
@@ -0,0 +1,46 @@
+package brew
+
+import "testing"
+
+func TestParsePipxTools(t *testing.T) {
+	tools, err := parsePipxList([]byte(`{"venvs": {
+		"black": {"metadata": {"main_package": {"package": "black", "package_version": "24.1.0"}}},
+		"httpie": {"metadata": {"main_package": {"package": "httpie", "package_version": "3.2.2"}}}
+	}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "black" || tools[0].Version != "24.1.0" {
+		t.Fatalf("expected black 24.1.0 and httpie, got %+v", tools)
+	}
+
+	latest, err := parsePipOutdated([]byte(`[{"name": "click", "version": "8.1.6", "latest_version": "8.1.7"},
+		{"name": "black", "version": "24.1.0", "latest_version": "24.2.0"}]`), "black")
+	if err != nil || latest != "24.2.0" {
+		t.Errorf("expected black 24.2.0, got %s, %v", latest, err)
+	}
+	if latest, _ := parsePipOutdated([]byte(`[]`), "httpie"); latest != "" {
+		t.Errorf("expected httpie to be up to date, got %s", latest)
+	}
+}
+
+func TestParseNpmTools(t *testing.T) {
+	tools, err := parseNpmList([]byte(`{"dependencies": {"typescript": {"version": "5.3.3"}, "npm": {"version": "10.5.0"}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "npm" || tools[1].Name != "typescript" {
+		t.Fatalf("expected npm and typescript, got %+v", tools)
+	}
+
+	if err := applyNpmOutdated(tools, []byte(`{"typescript": {"current": "5.3.3", "wanted": "5.3.3", "latest": "5.4.2"}}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tools[0].IsOutdated() || !tools[1].IsOutdated() || tools[1].Latest != "5.4.2" {
+		t.Errorf("expected only typescript to be outdated, got %+v", tools)
+	}
+	manager := ToolManager{Name: ToolManagerNpm, Installed: true, Tools: tools}
+	if manager.OutdatedCount() != 1 {
+		t.Errorf("expected 1 outdated tool, got %d", manager.OutdatedCount())
+	}
+}
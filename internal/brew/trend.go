@@ -0,0 +1,111 @@
+package brew
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const (
+	installHistoryJson = "install-history.json"
+
+	// Days of 90d install counts kept, one snapshot a day
+	maxInstallSnapshots = 30
+)
+
+// 90d install counts of past days, the counts of each package line up with the dates
+type installHistory struct {
+	Dates    []string         `json:"dates"`
+	Formulae map[string][]int `json:"formulae"`
+	Casks    map[string][]int `json:"casks"`
+}
+
+// Add today's install counts to the history kept in the cache and set the trend of each package
+func recordInstallTrends(packages []*data.Package, formulaInstalls, caskInstalls map[string]int) {
+	path := filepath.Join(taproomCacheDir, installHistoryJson)
+	history := loadInstallHistory(path)
+	history.add(time.Now().Format(time.DateOnly), formulaInstalls, caskInstalls)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("failed to create %s: %v", filepath.Dir(path), err)
+	} else if content, err := json.Marshal(history); err != nil {
+		log.Printf("failed to encode install history: %v", err)
+	} else if err := os.WriteFile(path, content, 0644); err != nil {
+		log.Printf("failed to write %s: %v", path, err)
+	}
+	history.apply(packages)
+}
+
+func loadInstallHistory(path string) *installHistory {
+	history := &installHistory{}
+	if content, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(content, history); err != nil {
+			log.Printf("failed to decode %s: %v", path, err)
+			history = &installHistory{}
+		}
+	}
+	if history.Formulae == nil || history.Casks == nil {
+		history = &installHistory{Formulae: make(map[string][]int), Casks: make(map[string][]int)}
+	}
+	return history
+}
+
+// Add the counts of a day, replacing the ones of the same day, and drop the oldest day beyond the limit
+func (h *installHistory) add(date string, formulaInstalls, caskInstalls map[string]int) {
+	replace := len(h.Dates) > 0 && h.Dates[len(h.Dates)-1] == date
+	if !replace {
+		h.Dates = append(h.Dates, date)
+	}
+	drop := max(len(h.Dates)-maxInstallSnapshots, 0)
+	h.Dates = h.Dates[drop:]
+	addCounts(h.Formulae, formulaInstalls, len(h.Dates), replace, drop)
+	addCounts(h.Casks, caskInstalls, len(h.Dates), replace, drop)
+}
+
+func addCounts(series map[string][]int, installs map[string]int, days int, replace bool, drop int) {
+	for name, counts := range series {
+		if replace {
+			counts = counts[:len(counts)-1]
+		}
+		counts = append(counts[drop:], installs[name])
+		if isAllZero(counts) {
+			delete(series, name)
+		} else {
+			series[name] = counts
+		}
+	}
+	for name, count := range installs {
+		if _, ok := series[name]; !ok && count > 0 {
+			// Missing from the earlier snapshots
+			series[name] = append(make([]int, days-1), count)
+		}
+	}
+}
+
+func isAllZero(counts []int) bool {
+	for _, n := range counts {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Set the counts of the days each package has analytics for
+func (h *installHistory) apply(packages []*data.Package) {
+	for _, pkg := range packages {
+		series := h.Formulae
+		if pkg.IsCask {
+			series = h.Casks
+		}
+		counts := series[pkg.Name]
+		// Days before the package showed up in analytics
+		for len(counts) > 0 && counts[0] == 0 {
+			counts = counts[1:]
+		}
+		pkg.InstallsTrend = counts
+	}
+}
@@ -0,0 +1,48 @@
+package brew
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestInstallHistory(t *testing.T) {
+	history := loadInstallHistory(filepath.Join(t.TempDir(), installHistoryJson))
+	history.add("2025-06-01", map[string]int{"wget": 100, "old": 5}, map[string]int{"firefox": 50})
+	history.add("2025-06-01", map[string]int{"wget": 110, "old": 5}, map[string]int{"firefox": 50})
+	history.add("2025-06-02", map[string]int{"wget": 120, "new": 7}, map[string]int{"firefox": 40})
+
+	if expected := []string{"2025-06-01", "2025-06-02"}; !slices.Equal(history.Dates, expected) {
+		t.Errorf("expected dates %v, got %v", expected, history.Dates)
+	}
+	if expected := []int{110, 120}; !slices.Equal(history.Formulae["wget"], expected) {
+		t.Errorf("expected the later count of the same day to replace the earlier one, got %v", history.Formulae["wget"])
+	}
+	if expected := []int{0, 7}; !slices.Equal(history.Formulae["new"], expected) {
+		t.Errorf("expected %v, got %v", expected, history.Formulae["new"])
+	}
+
+	for day := 3; day <= maxInstallSnapshots+2; day++ {
+		history.add(fmt.Sprintf("2025-07-%02d", day), map[string]int{"wget": day}, nil)
+	}
+	if len(history.Dates) != maxInstallSnapshots || len(history.Formulae["wget"]) != maxInstallSnapshots {
+		t.Errorf("expected %d days, got %d dates and %d counts", maxInstallSnapshots, len(history.Dates), len(history.Formulae["wget"]))
+	}
+	if _, ok := history.Formulae["old"]; ok {
+		t.Errorf("expected formulae without installs left to be dropped")
+	}
+
+	history = loadInstallHistory(filepath.Join(t.TempDir(), installHistoryJson))
+	history.add("2025-06-01", map[string]int{"wget": 100}, nil)
+	history.add("2025-06-02", map[string]int{"wget": 120, "new": 7}, map[string]int{"wget": 3})
+	wget := &data.Package{Name: "wget"}
+	newPkg := &data.Package{Name: "new"}
+	cask := &data.Package{Name: "wget", IsCask: true}
+	history.apply([]*data.Package{wget, newPkg, cask})
+	if !slices.Equal(wget.InstallsTrend, []int{100, 120}) || !slices.Equal(newPkg.InstallsTrend, []int{7}) || !slices.Equal(cask.InstallsTrend, []int{3}) {
+		t.Errorf("expected trends [100 120], [7] and [3], got %v, %v and %v", wget.InstallsTrend, newPkg.InstallsTrend, cask.InstallsTrend)
+	}
+}
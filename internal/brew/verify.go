@@ -0,0 +1,237 @@
+package brew
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+var flagVerifyAttestations = Flags.Bool(
+	"verify-attestations",
+	false,
+	"Also check GitHub attestations of bottles with 'brew verify' when verifying a keg, which needs gh and network access",
+)
+
+const (
+	// brew may still write into a keg for a little while after the receipt, e.g. when running post_install
+	verifyGracePeriod = 10 * time.Minute
+	// Modified files listed in the report, the rest are only counted
+	maxModifiedFiles = 5
+)
+
+// Downloads are named like "wget--1.24.5.arm64_sonoma.bottle.tar.gz" or "wget--1.24.5_1.all.bottle.1.tar.gz"
+var bottleTagRegex = regexp.MustCompile(`\.([a-z0-9_]+)\.bottle(?:\.\d+)?\.tar\.gz$`)
+
+type PackageVerifiedMsg struct {
+	Pkg          *data.Package
+	Verification *data.Verification
+	Err          error
+}
+
+// Check an installed formula against the bottle it was poured from in the background
+func VerifyPackage(pkg *data.Package) tea.Cmd {
+	return func() tea.Msg {
+		v, err := verifyPackage(pkg)
+		if err != nil {
+			err = fmt.Errorf("failed to verify %s: %w", pkg.Name, err)
+		}
+		return PackageVerifiedMsg{Pkg: pkg, Verification: v, Err: err}
+	}
+}
+
+func verifyPackage(pkg *data.Package) (*data.Verification, error) {
+	// opt/ always points to the installed keg, even when it's keg-only or unlinked
+	keg, err := filepath.EvalSymlinks(filepath.Join(brewPrefix, "opt", pkg.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the keg: %w", err)
+	}
+
+	v := &data.Verification{Time: time.Now()}
+	receipt, _ := parseInstallReceipt(keg)
+	if receipt == nil {
+		v.Problems = append(v.Problems, "INSTALL_RECEIPT.json is missing or invalid")
+	} else {
+		if !receipt.PouredFromBottle {
+			v.Notes = append(v.Notes, "built from source, there is no bottle to compare with")
+		}
+		modified, err := modifiedFiles(keg, time.Unix(receipt.InstallTime, 0).Add(verifyGracePeriod))
+		if err != nil {
+			return nil, err
+		}
+		if len(modified) > 0 {
+			v.Problems = append(v.Problems, formatModifiedFiles(modified))
+		}
+		if !receipt.PouredFromBottle {
+			return v, nil
+		}
+	}
+
+	if pkg.IsOutdated || pkg.IsPinned && pkg.InstalledVersion != pkg.Version {
+		v.Notes = append(v.Notes, fmt.Sprintf("the catalog only has the bottle of %s, checksums weren't compared", pkg.Version))
+	} else {
+		problem, note := checkCachedBottle(pkg)
+		v.Problems = appendNonEmpty(v.Problems, problem)
+		v.Notes = appendNonEmpty(v.Notes, note)
+		v.BottleCompared = note == ""
+	}
+
+	if *flagVerifyAttestations {
+		v.Problems = appendNonEmpty(v.Problems, verifyAttestation(pkg))
+	}
+	return v, nil
+}
+
+func appendNonEmpty(list []string, s string) []string {
+	if s == "" {
+		return list
+	}
+	return append(list, s)
+}
+
+// Files in a keg changed after brew finished installing it, relative to the keg
+func modifiedFiles(keg string, after time.Time) ([]string, error) {
+	modified := []string{}
+	err := filepath.WalkDir(keg, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// brew keeps its own bookkeeping in the keg, which it updates later, e.g. when the formula is linked
+		if name := d.Name(); name == ".brew" && d.IsDir() {
+			return filepath.SkipDir
+		} else if d.IsDir() || name == "INSTALL_RECEIPT.json" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(after) {
+			rel, _ := filepath.Rel(keg, path)
+			modified = append(modified, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", keg, err)
+	}
+	return modified, nil
+}
+
+func formatModifiedFiles(files []string) string {
+	shown := files
+	if len(shown) > maxModifiedFiles {
+		shown = shown[:maxModifiedFiles]
+	}
+	s := fmt.Sprintf("%d files modified after install: %s", len(files), strings.Join(shown, ", "))
+	if len(files) > len(shown) {
+		s += fmt.Sprintf(" and %d more", len(files)-len(shown))
+	}
+	return s
+}
+
+// Compare the checksum of the bottle in the download cache with the one in the catalog.
+// Returns a problem when they don't match, or a note when there is nothing to compare.
+func checkCachedBottle(pkg *data.Package) (problem, note string) {
+	output, err := exec.Command("brew", "--cache", "--formula", pkg.Name).Output()
+	if err != nil {
+		return "", fmt.Sprintf("failed to locate the bottle in the download cache: %v", err)
+	}
+	path := strings.TrimSpace(string(output))
+	if !fileExists(path) {
+		return "", fmt.Sprintf("the bottle isn't in the download cache, run 'brew fetch %s' to compare checksums", pkg.Name)
+	}
+	m := bottleTagRegex.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return "", fmt.Sprintf("%s isn't a bottle", filepath.Base(path))
+	}
+
+	checksums, err := bottleChecksums(pkg.Name)
+	if err != nil {
+		return "", err.Error()
+	}
+	expected, ok := checksums[m[1]]
+	if !ok {
+		return "", fmt.Sprintf("the catalog has no %s bottle", m[1])
+	}
+	actual, err := fileChecksum(path)
+	if err != nil {
+		return "", err.Error()
+	}
+	return compareChecksums(filepath.Base(path), actual, expected), ""
+}
+
+func compareChecksums(name, actual, expected string) string {
+	if strings.EqualFold(actual, expected) {
+		return ""
+	}
+	return fmt.Sprintf("checksum of %s is %s, the catalog expects %s", name, actual, expected)
+}
+
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Checksums of the bottles of a formula keyed by bottle tags, read from brew so it's what brew would pour
+func bottleChecksums(name string) (map[string]string, error) {
+	var errOutput bytes.Buffer
+	cmd := exec.Command("brew", "info", "--json=v2", "--formula", name)
+	cmd.Stderr = &errOutput
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run brew info %s: %w: %s", name, err, errOutput.String())
+	}
+	catalog, err := parseLocalCatalog(bytes.NewReader(output))
+	if err != nil {
+		return nil, err
+	}
+	if len(catalog.Formulae) == 0 {
+		return nil, fmt.Errorf("brew info has no formula named %s", name)
+	}
+	return parseBottleChecksums(catalog.Formulae[0])
+}
+
+func parseBottleChecksums(f *apiFormula) (map[string]string, error) {
+	checksums := make(map[string]string, len(f.Bottle.Stable.Files))
+	for tag, raw := range f.Bottle.Stable.Files {
+		var file struct {
+			Sha256 string `json:"sha256"`
+		}
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return nil, fmt.Errorf("failed to decode the %s bottle of %s: %w", tag, f.Name, err)
+		}
+		checksums[tag] = file.Sha256
+	}
+	return checksums, nil
+}
+
+// Check the build provenance of the bottle with GitHub attestations, returns a problem if it can't be verified
+func verifyAttestation(pkg *data.Package) string {
+	output, err := exec.Command("brew", "verify", pkg.Name).CombinedOutput()
+	if err == nil {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return fmt.Sprintf("attestation of the bottle can't be verified: %s", lines[len(lines)-1])
+}
@@ -0,0 +1,96 @@
+package brew
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModifiedFiles(t *testing.T) {
+	keg := t.TempDir()
+	installed := time.Now().Add(-time.Hour)
+	for _, name := range []string{"bin/wget", "share/man/wget.1", ".brew/wget.rb", "INSTALL_RECEIPT.json"} {
+		path := filepath.Join(keg, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, installed, installed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Only files brew doesn't update itself count as modified
+	now := time.Now()
+	for _, name := range []string{"bin/wget", ".brew/wget.rb", "INSTALL_RECEIPT.json"} {
+		if err := os.Chtimes(filepath.Join(keg, name), now, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	modified, err := modifiedFiles(keg, installed.Add(verifyGracePeriod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join("bin", "wget")}; !slices.Equal(modified, want) {
+		t.Errorf("expected modified files %v, got %v", want, modified)
+	}
+}
+
+func TestFormatModifiedFiles(t *testing.T) {
+	files := []string{"a", "b", "c", "d", "e", "f", "g"}
+	if got, want := formatModifiedFiles(files), "7 files modified after install: a, b, c, d, e and 2 more"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestBottleTag(t *testing.T) {
+	cases := map[string]string{
+		"wget--1.24.5.arm64_sonoma.bottle.tar.gz":   "arm64_sonoma",
+		"ca-certificates--2024.all.bottle.1.tar.gz": "all",
+		"wget--1.24.5.tar.gz":                       "",
+	}
+	for name, want := range cases {
+		got := ""
+		if m := bottleTagRegex.FindStringSubmatch(name); m != nil {
+			got = m[1]
+		}
+		if got != want {
+			t.Errorf("expected tag %q of %s, got %q", want, name, got)
+		}
+	}
+}
+
+func TestBottleChecksums(t *testing.T) {
+	f := apiFormula{Name: "wget"}
+	f.Bottle.Stable.Files = map[string]json.RawMessage{
+		"arm64_sonoma": json.RawMessage(`{"cellar": "/opt/homebrew/Cellar", "sha256": "abc"}`),
+	}
+	checksums, err := parseBottleChecksums(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checksums["arm64_sonoma"] != "abc" {
+		t.Errorf("expected checksum abc, got %v", checksums)
+	}
+
+	path := filepath.Join(t.TempDir(), "wget.bottle.tar.gz")
+	if err := os.WriteFile(path, []byte("bottle"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := fileChecksum(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problem := compareChecksums("wget", sum, strings.ToUpper(sum)); problem != "" {
+		t.Errorf("expected matching checksums, got %q", problem)
+	}
+	if problem := compareChecksums("wget", sum, "abc"); !strings.Contains(problem, "expects abc") {
+		t.Errorf("expected a checksum mismatch, got %q", problem)
+	}
+}
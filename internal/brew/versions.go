@@ -0,0 +1,76 @@
+package brew
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const catalogVersionsJson = "catalog-versions.json"
+
+// Versions of the catalog when the previous session last loaded it, read once so that reloads in
+// this session still compare against the previous one
+var (
+	previousVersions     map[string]string
+	previousVersionsOnce sync.Once
+)
+
+func versionKey(pkg *data.Package) string {
+	if pkg.IsCask {
+		return "cask/" + pkg.UniqueName()
+	}
+	return "formula/" + pkg.UniqueName()
+}
+
+// Mark packages whose version changed since the previous session, and remember the versions for the next one
+func markUpdatedVersions(packages []*data.Package) {
+	path := filepath.Join(taproomCacheDir, catalogVersionsJson)
+	previousVersionsOnce.Do(func() {
+		previousVersions = loadVersions(path)
+	})
+	applyPreviousVersions(packages, previousVersions)
+
+	// Packages outside of a partial catalog keep their versions
+	versions := loadVersions(path)
+	for _, pkg := range packages {
+		versions[versionKey(pkg)] = pkg.Version
+	}
+	content, err := json.Marshal(versions)
+	if err != nil {
+		log.Printf("failed to encode catalog versions: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("failed to create %s: %v", filepath.Dir(path), err)
+	} else if err := os.WriteFile(path, content, 0644); err != nil {
+		log.Printf("failed to write %s: %v", path, err)
+	}
+}
+
+func loadVersions(path string) map[string]string {
+	versions := make(map[string]string)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return versions
+	}
+	if err := json.Unmarshal(content, &versions); err != nil {
+		log.Printf("failed to decode %s: %v", path, err)
+		return make(map[string]string)
+	}
+	return versions
+}
+
+// New packages aren't marked, they had no version before
+func applyPreviousVersions(packages []*data.Package, previous map[string]string) {
+	for _, pkg := range packages {
+		if v, ok := previous[versionKey(pkg)]; ok && v != pkg.Version {
+			pkg.PreviousVersion = v
+		} else {
+			pkg.PreviousVersion = ""
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package brew
+
+import (
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestApplyPreviousVersions(t *testing.T) {
+	updated := &data.Package{Name: "wget", Version: "1.25.0"}
+	same := &data.Package{Name: "jq", Version: "1.7.1"}
+	cask := &data.Package{Name: "wget", Version: "1.0", IsCask: true}
+	added := &data.Package{Name: "new", Version: "0.1"}
+	stale := &data.Package{Name: "curl", Version: "8.14.0", PreviousVersion: "8.12.0"}
+
+	applyPreviousVersions([]*data.Package{updated, same, cask, added, stale}, map[string]string{
+		"formula/wget": "1.24.5",
+		"formula/jq":   "1.7.1",
+		"cask/wget":    "1.0",
+		"formula/curl": "8.14.0",
+	})
+	if updated.PreviousVersion != "1.24.5" {
+		t.Errorf("expected previous version 1.24.5, got %q", updated.PreviousVersion)
+	}
+	for _, pkg := range []*data.Package{same, cask, added, stale} {
+		if pkg.PreviousVersion != "" {
+			t.Errorf("expected %s not to be updated, got %q", pkg.Name, pkg.PreviousVersion)
+		}
+	}
+}
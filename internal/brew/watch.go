@@ -0,0 +1,295 @@
+package brew
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+var flagWatch = Flags.Bool(
+	"watch",
+	true,
+	"Watch the Cellar, Caskroom and pinned formulae to pick up installs, uninstalls and pins done outside taproom",
+)
+
+// brew touches many files for a single package, wait for it to settle before reading them
+const watchSettleDelay = time.Second
+
+// Installed state of packages changed outside taproom, e.g. by brew in another terminal
+type InstallsChangedMsg struct {
+	changes []installChange
+}
+
+type installChange struct {
+	name   string
+	isCask bool
+	tap    string       // Of an uninstalled package, when it's known which one was installed
+	info   *installInfo // Nil when the package was uninstalled
+}
+
+var (
+	watchOnce    sync.Once
+	watchChanges chan []installChange
+)
+
+// Wait for the next change to installed packages, the first call starts watching
+func WatchInstalls() tea.Cmd {
+	if !*flagWatch {
+		return nil
+	}
+	watchOnce.Do(func() {
+		watchChanges = make(chan []installChange)
+		if err := startWatcher(watchChanges); err != nil {
+			log.Printf("failed to watch installed packages: %v", err)
+			watchChanges = nil
+		}
+	})
+	if watchChanges == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return InstallsChangedMsg{changes: <-watchChanges}
+	}
+}
+
+func startWatcher(changes chan []installChange) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	cellar := filepath.Join(brewPrefix, "Cellar")
+	caskroom := filepath.Join(brewPrefix, "Caskroom")
+	// Pins and unpins only touch the pinned directory, links and unlinks the linked one
+	for _, dir := range []string{filepath.Join(brewPrefix, "var/homebrew/pinned"), filepath.Join(brewPrefix, "var/homebrew/linked")} {
+		if err := watcher.Add(dir); err != nil {
+			log.Printf("failed to watch %s: %v", dir, err)
+		}
+	}
+	// Packages are upgraded in their own directory, e.g. Cellar/wget/<version> and Caskroom/firefox/<version>.
+	// Keg-only formulae aren't linked, so their directory is the only thing that changes.
+	for _, dir := range []string{cellar, caskroom} {
+		if err := watcher.Add(dir); err != nil {
+			log.Printf("failed to watch %s: %v", dir, err)
+		}
+		if entries, err := os.ReadDir(dir); err == nil {
+			for _, entry := range entries {
+				if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+					watcher.Add(filepath.Join(dir, entry.Name()))
+				}
+			}
+		}
+	}
+
+	go func() {
+		formulae := make(map[string]bool)
+		casks := make(map[string]bool)
+		settle := time.NewTimer(watchSettleDelay)
+		settle.Stop()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				dir, name := filepath.Split(event.Name)
+				dir = filepath.Clean(dir)
+				if (dir == cellar || dir == caskroom) && event.Has(fsnotify.Create) {
+					watcher.Add(event.Name)
+				}
+				switch {
+				case dir == caskroom:
+					casks[name] = true
+				case filepath.Dir(dir) == caskroom:
+					casks[filepath.Base(dir)] = true
+				case filepath.Dir(dir) == cellar:
+					formulae[filepath.Base(dir)] = true
+				default:
+					formulae[name] = true
+				}
+				settle.Reset(watchSettleDelay)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("failed to watch installed packages: %v", err)
+			case <-settle.C:
+				changes <- readInstallChanges(formulae, casks, cellar, caskroom)
+				formulae = make(map[string]bool)
+				casks = make(map[string]bool)
+			}
+		}
+	}()
+	return nil
+}
+
+// Read the installed state of the changed packages again
+func readInstallChanges(formulae, casks map[string]bool, cellar, caskroom string) []installChange {
+	changes := []installChange{}
+	for name := range formulae {
+		if name == "" || strings.HasPrefix(name, ".") {
+			continue
+		}
+		change := installChange{name: name}
+		if path := filepath.Join(cellar, name); isDir(path) {
+			change.info = getFormulaInstallInfo(true, path)
+			change.info.pinned = exists(filepath.Join(brewPrefix, "var/homebrew/pinned", name))
+			change.info.linked = exists(filepath.Join(brewPrefix, "var/homebrew/linked", name))
+		}
+		changes = append(changes, change)
+	}
+	for name := range casks {
+		if name == "" || strings.HasPrefix(name, ".") {
+			continue
+		}
+		change := installChange{name: name, isCask: true}
+		if path := filepath.Join(caskroom, name); isDir(path) {
+			change.info = getCaskInstallInfo(true, path)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// Update the changed packages, returning the ones whose installed version, pin or link changed.
+// Commands run by taproom already updated their packages, so they're not returned again.
+func (msg InstallsChangedMsg) Apply() []*data.Package {
+	return applyInstallChanges(msg.changes)
+}
+
+func applyInstallChanges(changes []installChange) []*data.Package {
+	updated := []*data.Package{}
+	for _, change := range changes {
+		pkg := findChangedPackage(allBrewPackages, change)
+		if pkg == nil {
+			// Not in the loaded catalog, e.g. from a tap added meanwhile
+			continue
+		}
+		before := installState(pkg)
+		if change.info == nil {
+			pkg.MarkUninstalled()
+		} else {
+			updateInstallInfo(pkg, change.info)
+		}
+		if installState(pkg) != before {
+			updated = append(updated, pkg)
+		}
+	}
+	return updated
+}
+
+// The package of a change, by the tap of its receipt. The Cellar and Caskroom have a single package
+// of a name, so changes without a tap are the installed package of that name and kind.
+func findChangedPackage(pkgs []*data.Package, change installChange) *data.Package {
+	tap := change.tap
+	if change.info != nil {
+		tap = change.info.tap
+	}
+	// pkgs are sorted by name
+	index := sort.Search(len(pkgs), func(i int) bool {
+		return pkgs[i].Name >= change.name
+	})
+	var found *data.Package
+	for ; index < len(pkgs) && pkgs[index].Name == change.name; index++ {
+		pkg := pkgs[index]
+		if pkg.IsCask != change.isCask {
+			continue
+		}
+		if tap != "" {
+			if strings.EqualFold(pkg.Tap, tap) {
+				return pkg
+			}
+		} else if pkg.IsInstalled {
+			return pkg
+		} else if found == nil {
+			found = pkg
+		}
+	}
+	return found
+}
+
+func installState(pkg *data.Package) string {
+	return fmt.Sprintf("%t %s_%d %t %t", pkg.IsInstalled, pkg.InstalledVersion, pkg.InstalledRevision, pkg.IsPinned, pkg.IsLinked)
+}
+
+// Installed state of all packages read again, without downloading the catalog or analytics
+type InstalledRefreshedMsg struct {
+	changes []installChange
+	pinned  map[string]bool
+	linked  map[string]bool
+}
+
+// Update packages whose installed state changed, returning them
+func (msg InstalledRefreshedMsg) Apply() []*data.Package {
+	// Swapped in here rather than by the refresh, which runs in the background while other loads read them
+	pinnedPackages, linkedKegs = msg.pinned, msg.linked
+	return applyInstallChanges(msg.changes)
+}
+
+// Read installed formulae and casks again in the background, a lighter refresh than LoadData
+func RefreshInstalled(fetchSize bool) tea.Cmd {
+	return func() tea.Msg {
+		pinned := loadFormulaSet("var/homebrew/pinned")
+		linked := loadFormulaSet("var/homebrew/linked")
+		formulaCh := make(chan []*installInfo)
+		caskCh := make(chan []*installInfo)
+		go fetchInstalledFormula(fetchSize, formulaCh)
+		go fetchInstalledCask(fetchSize, caskCh)
+		formulae, casks := <-formulaCh, <-caskCh
+		for _, info := range formulae {
+			info.pinned = pinned[info.name]
+			info.linked = linked[info.name]
+		}
+		return InstalledRefreshedMsg{
+			changes: refreshChanges(formulae, casks, allBrewPackages),
+			pinned:  pinned,
+			linked:  linked,
+		}
+	}
+}
+
+// Changes for all installed packages, and for the ones that were installed but are gone
+func refreshChanges(formulae, casks []*installInfo, pkgs []*data.Package) []installChange {
+	changes := []installChange{}
+	// Packages still installed, by kind and unique name, as formulae and casks or packages of different taps share names
+	installed := make(map[string]bool)
+	for _, info := range formulae {
+		change := installChange{name: info.name, info: info}
+		if pkg := findChangedPackage(pkgs, change); pkg != nil {
+			installed[versionKey(pkg)] = true
+		}
+		changes = append(changes, change)
+	}
+	for _, info := range casks {
+		change := installChange{name: info.name, isCask: true, info: info}
+		if pkg := findChangedPackage(pkgs, change); pkg != nil {
+			installed[versionKey(pkg)] = true
+		}
+		changes = append(changes, change)
+	}
+	for _, pkg := range pkgs {
+		// Apps and commands of mas and whalebrew aren't in the Cellar or Caskroom
+		if pkg.IsInstalled && !installed[versionKey(pkg)] && pkg.IsManagedByBrew() {
+			changes = append(changes, installChange{name: pkg.Name, isCask: pkg.IsCask, tap: pkg.Tap})
+		}
+	}
+	return changes
+}
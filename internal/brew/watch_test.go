@@ -0,0 +1,126 @@
+package brew
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestInstallsChanged(t *testing.T) {
+	prefix := t.TempDir()
+	defer func(original string) { brewPrefix = original }(brewPrefix)
+	brewPrefix = prefix
+	for _, dir := range []string{"Cellar/wget/1.25.0", "Caskroom", "var/homebrew/pinned"} {
+		if err := os.MkdirAll(filepath.Join(prefix, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(prefix, "var/homebrew/pinned/wget"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// wget was upgraded and pinned, curl uninstalled and firefox is still not installed
+	wget := &data.Package{Name: "wget", Version: "1.25.0", InstalledVersion: "1.24.5", IsInstalled: true, IsOutdated: true}
+	curl := &data.Package{Name: "curl", Version: "8.11.1", InstalledVersion: "8.11.1", IsInstalled: true}
+	firefox := &data.Package{Name: "firefox", IsCask: true}
+	usePackages(t, []*data.Package{curl, firefox, wget})
+
+	msg := InstallsChangedMsg{changes: readInstallChanges(
+		map[string]bool{"wget": true, "curl": true},
+		map[string]bool{"firefox": true},
+		filepath.Join(prefix, "Cellar"),
+		filepath.Join(prefix, "Caskroom"),
+	)}
+	updated := packageNames(msg.Apply())
+	if len(updated) != 2 {
+		t.Errorf("expected wget and curl to be updated, got %v", updated)
+	}
+	if wget.InstalledVersion != "1.25.0" || wget.IsOutdated || !wget.IsPinned {
+		t.Errorf("expected wget 1.25.0 to be up to date and pinned, got %s, outdated %v, pinned %v", wget.InstalledVersion, wget.IsOutdated, wget.IsPinned)
+	}
+	if curl.IsInstalled {
+		t.Errorf("expected curl to be uninstalled")
+	}
+
+	// Applying the same state again changes nothing
+	if updated := msg.Apply(); len(updated) != 0 {
+		t.Errorf("expected no more changes, got %v", packageNames(updated))
+	}
+}
+
+func TestRefreshChanges(t *testing.T) {
+	wget := &data.Package{Name: "wget", IsInstalled: true}
+	wgetCask := &data.Package{Name: "wget", Tap: caskTap, IsCask: true, Shadowed: true, IsInstalled: true}
+	curl := &data.Package{Name: "curl", IsInstalled: true}
+	firefox := &data.Package{Name: "firefox", IsCask: true}
+	changes := refreshChanges(
+		[]*installInfo{{name: "wget", version: "1.25.0"}},
+		[]*installInfo{{name: "firefox", version: "133.0"}},
+		[]*data.Package{curl, firefox, wget, wgetCask},
+	)
+	removed := []string{}
+	for _, change := range changes {
+		if change.info == nil {
+			removed = append(removed, versionKey(findChangedPackage([]*data.Package{curl, firefox, wget, wgetCask}, change)))
+		}
+	}
+	// The wget formula is still installed, the cask of the same name isn't
+	if want := []string{"formula/curl", "cask/homebrew/cask/wget"}; len(changes) != 4 || !slices.Equal(removed, want) {
+		t.Errorf("expected wget and firefox to be read and %v to be gone, got %d changes removing %v", want, len(changes), removed)
+	}
+}
+
+func TestFindChangedPackage(t *testing.T) {
+	coreFoo := &data.Package{Name: "foo", Tap: "homebrew/core"}
+	tapFoo := &data.Package{Name: "foo", Tap: "someone/tools", Shadowed: true, IsInstalled: true}
+	caskFoo := &data.Package{Name: "foo", Tap: "homebrew/cask", IsCask: true, Shadowed: true}
+	pkgs := []*data.Package{coreFoo, tapFoo, caskFoo}
+
+	tests := []struct {
+		change installChange
+		want   *data.Package
+	}{
+		{installChange{name: "foo", info: &installInfo{tap: "someone/tools"}}, tapFoo},
+		{installChange{name: "foo", info: &installInfo{tap: "homebrew/core"}}, coreFoo},
+		{installChange{name: "foo"}, tapFoo}, // Uninstalled, the installed one of the name
+		{installChange{name: "foo", isCask: true, info: &installInfo{tap: "homebrew/cask"}}, caskFoo},
+		{installChange{name: "foo", info: &installInfo{tap: "other/tap"}}, nil},
+	}
+	for _, test := range tests {
+		if got := findChangedPackage(pkgs, test.change); got != test.want {
+			t.Errorf("expected %v for %+v, got %v", test.want, test.change, got)
+		}
+	}
+}
+
+func TestWatchKegOnlyUpgrade(t *testing.T) {
+	prefix := t.TempDir()
+	defer func(original string) { brewPrefix = original }(brewPrefix)
+	brewPrefix = prefix
+	for _, dir := range []string{"Cellar/openssl@3/3.4.0", "Caskroom", "var/homebrew/pinned", "var/homebrew/linked"} {
+		if err := os.MkdirAll(filepath.Join(prefix, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changes := make(chan []installChange, 1)
+	if err := startWatcher(changes); err != nil {
+		t.Fatal(err)
+	}
+
+	// A keg-only formula is upgraded without linking it
+	if err := os.MkdirAll(filepath.Join(prefix, "Cellar/openssl@3/3.5.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-changes:
+		if len(got) != 1 || got[0].name != "openssl@3" || got[0].isCask {
+			t.Errorf("expected a change of openssl@3, got %+v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected the upgrade of openssl@3 to be picked up")
+	}
+}
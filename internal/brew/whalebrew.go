@@ -0,0 +1,84 @@
+package brew
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+const whalebrewTool = "whalebrew"
+
+var flagWhalebrew = Flags.Bool(
+	"whalebrew",
+	true,
+	"List commands installed by whalebrew alongside formulae and uninstall them with whalebrew, when whalebrew is installed",
+)
+
+type whalebrewCommand struct {
+	name  string
+	image string
+}
+
+// Parse `whalebrew list`, a table of commands and their images like "wget  whalebrew/wget" under a header
+func parseWhalebrewList(r io.Reader) []whalebrewCommand {
+	commands := []whalebrewCommand{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] == "COMMAND" {
+			continue
+		}
+		commands = append(commands, whalebrewCommand{name: fields[0], image: fields[1]})
+	}
+	return commands
+}
+
+// Installed whalebrew commands as packages, none when whalebrew is turned off or isn't installed
+func loadWhalebrewPackages() []*data.Package {
+	if !*flagWhalebrew {
+		return nil
+	}
+	if _, err := exec.LookPath(whalebrewTool); err != nil {
+		return nil
+	}
+	output, err := exec.Command(whalebrewTool, "list").Output()
+	if err != nil {
+		log.Printf("failed to run whalebrew list: %v", err)
+		return nil
+	}
+	return whalebrewPackages(parseWhalebrewList(bytes.NewReader(output)))
+}
+
+func whalebrewPackages(commands []whalebrewCommand) []*data.Package {
+	pkgs := []*data.Package{}
+	for _, c := range commands {
+		// Images are pulled by tag, or latest without one
+		version := "latest"
+		if i := strings.LastIndex(c.image, ":"); i > strings.LastIndex(c.image, "/") {
+			version = c.image[i+1:]
+		}
+		pkg := &data.Package{
+			Name:             c.name,
+			Tap:              data.WhalebrewTap,
+			WhalebrewImage:   c.image,
+			Version:          version,
+			InstalledVersion: version,
+			IsInstalled:      true,
+		}
+		pkg.SetTexts(nil, "whalebrew command running the Docker image "+c.image, pkg.BrewUrl())
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs
+}
+
+// Uninstall a whalebrew command, the Docker image is kept
+func UninstallWhalebrewPackage(pkg *data.Package) tea.Cmd {
+	// Without a terminal to confirm the removal in
+	return tea.Batch(startCommand(), execute(BrewCommandWhalebrewUninstall, []*data.Package{pkg}, "uninstall", "-y", pkg.Name))
+}
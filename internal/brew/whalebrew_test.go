@@ -0,0 +1,36 @@
+package brew
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseWhalebrewList(t *testing.T) {
+	commands := parseWhalebrewList(strings.NewReader(`COMMAND     IMAGE
+ffmpeg      whalebrew/ffmpeg:4.4
+wget        whalebrew/wget
+`))
+	if len(commands) != 2 {
+		t.Fatalf("expected 2 commands, got %v", commands)
+	}
+	if c := commands[0]; c.name != "ffmpeg" || c.image != "whalebrew/ffmpeg:4.4" {
+		t.Errorf("expected ffmpeg from whalebrew/ffmpeg:4.4, got %+v", c)
+	}
+}
+
+func TestWhalebrewPackages(t *testing.T) {
+	pkgs := whalebrewPackages([]whalebrewCommand{{"ffmpeg", "whalebrew/ffmpeg:4.4"}, {"wget", "whalebrew/wget"}})
+	if len(pkgs) != 2 {
+		t.Fatalf("expected 2 packages, got %v", packageNames(pkgs))
+	}
+	ffmpeg, wget := pkgs[0], pkgs[1]
+	if !ffmpeg.IsWhalebrew() || !ffmpeg.IsInstalled || ffmpeg.IsCask || ffmpeg.IsManagedByBrew() {
+		t.Errorf("expected an installed whalebrew command, got %+v", ffmpeg)
+	}
+	if ffmpeg.Version != "4.4" || wget.Version != "latest" {
+		t.Errorf("expected versions 4.4 and latest, got %s and %s", ffmpeg.Version, wget.Version)
+	}
+	if url := wget.BrewUrl(); url != "https://hub.docker.com/r/whalebrew/wget" {
+		t.Errorf("expected the Docker Hub url, got %s", url)
+	}
+}
@@ -0,0 +1,73 @@
+package data
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// A rough grouping of packages, derived from their descriptions and the artifacts of casks
+type Category struct {
+	Name      string
+	keywords  []string // Words of the description, plurals match too
+	artifacts []string // Cask artifact kinds
+}
+
+var Categories = []Category{
+	{Name: "browsers", keywords: []string{"browser"}},
+	{Name: "cloud", keywords: []string{"aws", "azure", "cloud", "container", "docker", "kubernetes", "terraform"}},
+	{Name: "communication", keywords: []string{"chat", "email", "irc", "mail", "messaging", "messenger", "slack"}},
+	{Name: "databases", keywords: []string{"database", "mongodb", "mysql", "nosql", "postgres", "postgresql", "redis", "sql", "sqlite"}},
+	{Name: "development", keywords: []string{"debugger", "git", "lint", "linter", "formatter", "profiler", "sdk"}},
+	{Name: "documents", keywords: []string{"document", "ebook", "latex", "markdown", "office", "pdf", "spreadsheet"}, artifacts: []string{"dictionary"}},
+	{Name: "editors", keywords: []string{"editor", "emacs", "ide", "neovim", "vim"}},
+	{Name: "fonts", keywords: []string{"font", "typeface"}, artifacts: []string{"font"}},
+	{Name: "games", keywords: []string{"emulator", "game"}},
+	{Name: "graphics", keywords: []string{"drawing", "graphics", "image", "photo", "svg"}},
+	{Name: "languages", keywords: []string{"compiler", "interpreter", "language"}},
+	{Name: "multimedia", keywords: []string{"audio", "media", "music", "player", "streaming", "video"}, artifacts: []string{"audio_unit_plugin", "vst_plugin", "vst3_plugin"}},
+	{Name: "networking", keywords: []string{"dns", "http", "network", "proxy", "ssh", "tcp", "vpn"}},
+	{Name: "science", keywords: []string{"bioinformatics", "genome", "math", "mathematics", "scientific", "statistics"}},
+	{Name: "security", keywords: []string{"encryption", "password", "security", "vulnerability"}},
+	{Name: "system", keywords: []string{"backup", "disk", "monitor", "monitoring", "process"}, artifacts: []string{"prefpane", "qlplugin", "screen_saver"}},
+	{Name: "terminals", keywords: []string{"console", "shell", "terminal", "tmux"}},
+}
+
+// Names of the categories the package is in, in the order of Categories
+func (pkg *Package) Categories() []string {
+	words := strings.FieldsFunc(strings.ToLower(pkg.Desc()), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	names := []string{}
+	for _, c := range Categories {
+		if c.matches(pkg, words) {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+func (c *Category) matches(pkg *Package, words []string) bool {
+	for _, w := range words {
+		if slices.Contains(c.keywords, w) || slices.Contains(c.keywords, strings.TrimSuffix(w, "s")) {
+			return true
+		}
+	}
+	for _, a := range pkg.Artifacts {
+		if slices.Contains(c.artifacts, a.Kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Packages in each category
+func CountCategories(pkgs []*Package) map[string]int {
+	counts := make(map[string]int)
+	for _, pkg := range pkgs {
+		for _, name := range pkg.Categories() {
+			counts[name]++
+		}
+	}
+	return counts
+}
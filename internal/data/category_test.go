@@ -0,0 +1,24 @@
+package data
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCategories(t *testing.T) {
+	pkg := &Package{Name: "postgresql@17"}
+	pkg.SetTexts(nil, "Object-relational database system", "")
+	if c := pkg.Categories(); !slices.Equal(c, []string{"databases"}) {
+		t.Errorf("expected databases, got %v", c)
+	}
+
+	font := &Package{Name: "font-fira-code", IsCask: true, Artifacts: []CaskArtifact{{Kind: "font", Target: "FiraCode.ttf"}}}
+	font.SetTexts(nil, "", "")
+	if c := font.Categories(); !slices.Equal(c, []string{"fonts"}) {
+		t.Errorf("expected fonts from the artifact, got %v", c)
+	}
+
+	if !pkg.MatchKeywords([]string{"cat:data"}) || pkg.MatchKeywords([]string{"cat:fonts"}) || !font.MatchKeywords([]string{"-cat:editors"}) {
+		t.Errorf("expected cat: to match category names")
+	}
+}
@@ -0,0 +1,53 @@
+package data
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const fontArtifact = "font"
+
+// Styles of a font family installed by a font cask, like Fira Code in Bold and Regular
+type FontFamily struct {
+	Name   string
+	Styles []string
+}
+
+// Casks of fonts, named font-* in the cask tap, used to be in homebrew/cask-fonts
+func (pkg *Package) IsFont() bool {
+	if !pkg.IsCask {
+		return false
+	}
+	if strings.HasPrefix(pkg.Name, "font-") {
+		return true
+	}
+	return slices.ContainsFunc(pkg.Artifacts, func(a CaskArtifact) bool { return a.Kind == fontArtifact })
+}
+
+// Font families and their styles from the names of the font files, like FiraCode-Bold.ttf
+func (pkg *Package) FontFamilies() []FontFamily {
+	families := []FontFamily{}
+	for _, a := range pkg.Artifacts {
+		if a.Kind != fontArtifact {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(a.Target), filepath.Ext(a.Target))
+		style := "Regular"
+		if i := strings.Index(name, "["); i > 0 {
+			// Variable fonts list their axes, like RobotoFlex[GRAD,XOPQ,wght].ttf
+			name, style = name[:i], "Variable"
+		} else if i := strings.LastIndex(name, "-"); i > 0 {
+			name, style = name[:i], name[i+1:]
+		}
+		i := slices.IndexFunc(families, func(f FontFamily) bool { return f.Name == name })
+		if i < 0 {
+			families = append(families, FontFamily{Name: name})
+			i = len(families) - 1
+		}
+		if !slices.Contains(families[i].Styles, style) {
+			families[i].Styles = append(families[i].Styles, style)
+		}
+	}
+	return families
+}
@@ -0,0 +1,34 @@
+package data
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFontFamilies(t *testing.T) {
+	pkg := &Package{Name: "font-fira-code", IsCask: true, Artifacts: []CaskArtifact{
+		{Kind: "font", Target: "FiraCode-Bold.ttf"},
+		{Kind: "font", Target: "FiraCode-Regular.ttf"},
+		{Kind: "font", Target: "FiraCode-Bold.ttf"},
+		{Kind: "font", Target: "FiraCode[wght].ttf"},
+		{Kind: "font", Target: "FiraMono.otf"},
+		{Kind: "app", Target: "Fonts.app"},
+	}}
+	if !pkg.IsFont() {
+		t.Errorf("expected a font")
+	}
+	families := pkg.FontFamilies()
+	if len(families) != 2 {
+		t.Fatalf("expected 2 families, got %v", families)
+	}
+	if f := families[0]; f.Name != "FiraCode" || !slices.Equal(f.Styles, []string{"Bold", "Regular", "Variable"}) {
+		t.Errorf("expected FiraCode in Bold, Regular and Variable, got %v", f)
+	}
+	if f := families[1]; f.Name != "FiraMono" || !slices.Equal(f.Styles, []string{"Regular"}) {
+		t.Errorf("expected FiraMono in Regular, got %v", f)
+	}
+
+	if (&Package{Name: "font-tools"}).IsFont() {
+		t.Errorf("expected formulae not to be fonts")
+	}
+}
@@ -4,25 +4,100 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/hzqtc/taproom/internal/util"
 )
 
+type AnalyticsCounts struct {
+	Days30  int
+	Days90  int
+	Days365 int
+}
+
+// Analytics of a single package, loaded when it's viewed
+type Analytics struct {
+	Installs          AnalyticsCounts // macOS only
+	InstallsOnRequest AnalyticsCounts
+	InstallsLinux     AnalyticsCounts
+	BuildErrors       AnalyticsCounts
+}
+
 type ReleaseInfo struct {
 	Date    time.Time
 	Version string
 	Url     string
 }
 
+// Metadata of the upstream GitHub repository
+type RepoInfo struct {
+	Url        string
+	Stars      int
+	OpenIssues int
+	Archived   bool
+	LastCommit time.Time // Of the default branch
+}
+
+// Something a cask installs, e.g. an app named Firefox.app
+type CaskArtifact struct {
+	Kind   string // Artifact stanza like app, binary, pkg or installer, launchctl for launch agents and daemons
+	Target string
+}
+
+// Why and when a package was deprecated or disabled, and what to use instead
+type Deprecation struct {
+	Date          string // When it was deprecated, like 2024-01-01
+	Reason        string // A symbol like repo_archived, or a custom explanation
+	DisableDate   string // When it was or will be disabled
+	DisableReason string
+	Replacement   string // Name of the formula or cask to use instead
+}
+
+// Source of a piece of package data
+type Source string
+
+const (
+	SourceApi        Source = "API"               // formula.json or cask.json from formulae.brew.sh
+	SourceBrewInfo   Source = "brew info"         // Evaluated by brew from local taps
+	SourceTapFile    Source = ".rb file"          // Parsed from the formula or cask file of a third-party tap
+	SourceReceipt    Source = "install receipt"   // INSTALL_RECEIPT.json of the installation
+	SourceInstallDir Source = "install directory" // Name or modification time of the directory in Cellar or Caskroom
+	SourceAnalytics  Source = "analytics API"
+	SourceGitHub     Source = "GitHub"
+	SourceCommand    Source = "finished command" // Updated by taproom after a command finished, until reloading
+)
+
+// Sources of the package data merged from multiple places
+type Sources struct {
+	Catalog          Source // Version, tap and the rest of the package metadata
+	InstalledVersion Source
+	InstalledDate    Source
+}
+
+// Where the installation info of a package was read from, to verify it's attributed to the right package
+type Provenance struct {
+	Receipt    string // Path of INSTALL_RECEIPT.json, empty when it's missing
+	Tap        string // Tap recorded in the receipt
+	SourcePath string // Formula or cask file recorded in the receipt
+}
+
+// Result of checking an installed keg against the bottle it was poured from
+type Verification struct {
+	Time           time.Time
+	Problems       []string // Signs of a tampered or manually modified install
+	Notes          []string // Checks that were skipped and why
+	BottleCompared bool     // Checksums of the cached bottle were compared with the catalog
+}
+
 // Package holds all combined information for a formula or cask.
 type Package struct {
-	Name                  string // Used as a unique key
+	Name                  string // Unique with the tap, see UniqueName
 	Aliases               []string
 	Tap                   string
 	Version               string
 	Revision              int
 	InstalledVersion      string
 	InstalledRevision     int
-	Desc                  string
-	Homepage              string
+	PreviousVersion       string // Version in the previous session, only set when it changed since
 	Urls                  []string
 	License               string
 	Dependencies          []string
@@ -30,38 +105,115 @@ type Package struct {
 	Dependents            []string
 	Conflicts             []string
 	Installs90d           int
+	InstallsTrend         []int // Installs90d of earlier days with analytics snapshots, oldest first
+	BuildErrors90d        int   // Formulae only
 	AutoUpdate            bool
 	IsCask                bool
 	IsInstalled           bool
 	IsOutdated            bool
 	IsPinned              bool
+	IsKegOnly             bool // Formula isn't linked into the prefix by default, e.g. it shadows a system library
+	IsLinked              bool // Formula's keg is symlinked into the prefix
+	ProvidedByMacOS       bool // Formula is keg-only because macOS ships the same tool or library, e.g. curl or sqlite
 	IsDeprecated          bool
 	IsDisabled            bool
+	Shadowed              bool         // Another package of the same name is preferred, like brew prefers homebrew/core
+	Deprecation           *Deprecation // Only set when the package is deprecated or disabled
 	InstalledAsDependency bool
-	Size                  int64  // Size in kbs
-	FormattedSize         string // Formated size like 24.5MB, 230KB
-	InstallSupported      bool   // Whether installing the package is supported in taproom
+	Size                  int64 // Size in kbs
+	InstallSupported      bool  // Whether installing the package is supported in taproom
 	InstalledDate         string
-	ReleaseInfo           *ReleaseInfo // Only set when package is outdated
+	SnoozedUntil          time.Time      // Upgrading the outdated package was postponed until then
+	BrokenInstall         string         // Why the installation looks broken, like a missing version directory
+	HealthProblems        []string       // Missing dependencies and broken links found by the health check
+	ZapPaths              []string       // Files removed by 'brew uninstall --zap', casks only
+	Artifacts             []CaskArtifact // What a cask installs, like apps, binaries and launch agents
+	RequiresSudo          bool           // Installing or uninstalling the cask asks for the password of an admin
+	Languages             []string       // Localized builds of a cask
+	Variants              []string       // macOS versions and architectures with a different build of a cask
+	MasId                 int            // App Store id of apps managed by mas, zero for brew packages
+	WhalebrewImage        string         // Docker image of commands installed by whalebrew, empty for brew packages
+	Options               []string       // Install options supported by a formula, like --HEAD or --with-foo
+	Platforms             []string       // Tags of available bottles like arm64_sonoma or x86_64_linux, formulae only
+	RequiresMacOS         bool           // Doesn't run on Linux
+	MinMacOSVersion       string         // Like 12 or 10.15, empty when there's no minimum
+	Vulnerabilities       []string       // IDs of known vulnerabilities affecting the installed version
+	ReleaseInfo           *ReleaseInfo   // Only set when package is outdated
+	Repo                  *RepoInfo      // Only set once the GitHub repository has been fetched
+	Analytics             *Analytics     // Only set once the package has been viewed
+	Provenance            *Provenance    // Only set when package is installed
+	Verification          *Verification  // Only set once the installed keg has been verified
+	Sources               Sources
+
+	// Descriptions are searched and shown in the table, so they're kept in memory. Home pages are
+	// rarely used, they're loaded from the store on demand.
+	desc     string
+	texts    *TextStore
+	homepage textRef
 }
 
+// Official taps, whose packages are referred to by their short names
+const (
+	CoreTap = "homebrew/core"
+	CaskTap = "homebrew/cask"
+	// Shown as the tap of App Store apps
+	MasTap = "mas"
+	// Shown as the tap of whalebrew commands
+	WhalebrewTap = "whalebrew"
+)
+
 const (
 	formulaSymbol = ""
+	masSymbol     = ""
+	dockerSymbol  = ""
 	caskSymbol    = ""
 )
 
 const (
+	statusUnhealthy      = "Unhealthy"
 	statusDisabled       = "Disabled"
 	statusDeprecated     = "Deprecated"
 	statusPinned         = "Pinned"
+	statusSnoozed        = "Snoozed"
+	statusSnoozeOver     = "Snooze Over"
 	statusOutdated       = "Outdated"
 	statusInstalledAsDep = "Installed (Dep)"
 	statusInstalled      = "Installed"
 	statusUninstalled    = "Uninstalled"
 )
 
+// Set the description and home page, keeping the home page in the given store
+func (pkg *Package) SetTexts(store *TextStore, desc, homepage string) {
+	if store == nil {
+		store = memoryTexts
+	}
+	pkg.desc = desc
+	pkg.texts = store
+	pkg.homepage = store.put(homepage)
+}
+
+func (pkg *Package) Desc() string {
+	return pkg.desc
+}
+
+func (pkg *Package) Homepage() string {
+	if pkg.texts == nil {
+		return ""
+	}
+	return pkg.texts.get(pkg.homepage)
+}
+
+// Formatted size like 24.5MB, 230KB, formatted when shown rather than kept for every package
+func (pkg *Package) FormattedSize() string {
+	return util.FormatSize(pkg.Size)
+}
+
 func (pkg *Package) Symbol() string {
-	if pkg.IsCask {
+	if pkg.IsMasApp() {
+		return masSymbol
+	} else if pkg.IsWhalebrew() {
+		return dockerSymbol
+	} else if pkg.IsCask {
 		return caskSymbol
 	} else {
 		return formulaSymbol
@@ -69,12 +221,18 @@ func (pkg *Package) Symbol() string {
 }
 
 func (pkg *Package) Status() string {
-	if pkg.IsDisabled {
+	if pkg.IsUnhealthy() {
+		return statusUnhealthy
+	} else if pkg.IsDisabled {
 		return statusDisabled
 	} else if pkg.IsDeprecated {
 		return statusDeprecated
 	} else if pkg.IsPinned {
 		return statusPinned
+	} else if pkg.IsSnoozed() {
+		return statusSnoozed
+	} else if pkg.IsSnoozeOver() {
+		return statusSnoozeOver
 	} else if pkg.IsOutdated {
 		return statusOutdated
 	} else if pkg.InstalledAsDependency {
@@ -86,14 +244,80 @@ func (pkg *Package) Status() string {
 	}
 }
 
+// An outdated package whose upgrade was postponed, left out of the outdated ones for now
+func (pkg *Package) IsSnoozed() bool {
+	return pkg.IsOutdated && time.Now().Before(pkg.SnoozedUntil)
+}
+
+// An outdated package whose postponed upgrade is due
+func (pkg *Package) IsSnoozeOver() bool {
+	return pkg.IsOutdated && !pkg.SnoozedUntil.IsZero() && !time.Now().Before(pkg.SnoozedUntil)
+}
+
+// An app installed from the App Store, listed by mas rather than brew
+func (pkg *Package) IsMasApp() bool {
+	return pkg.MasId != 0
+}
+
+// A command installed by whalebrew, which runs it in a Docker container
+func (pkg *Package) IsWhalebrew() bool {
+	return pkg.WhalebrewImage != ""
+}
+
+// Installed and managed by brew, rather than by mas or whalebrew
+func (pkg *Package) IsManagedByBrew() bool {
+	return !pkg.IsMasApp() && !pkg.IsWhalebrew()
+}
+
+// An installed package whose installation is broken or corrupted, reinstalling it is the fix
+func (pkg *Package) IsUnhealthy() bool {
+	return pkg.IsInstalled && (pkg.BrokenInstall != "" || len(pkg.HealthProblems) > 0)
+}
+
+// Name with the tap, like user/tap/foo, brew's short name for packages of the official taps
+func (pkg *Package) FullName() string {
+	if pkg.Tap == "" || pkg.Tap == CoreTap || pkg.Tap == CaskTap {
+		return pkg.Name
+	}
+	return pkg.Tap + "/" + pkg.Name
+}
+
+// Name that refers to this package only, in brew commands and lookups. Packages named like
+// a preferred one are referred to by their tap and name.
+func (pkg *Package) UniqueName() string {
+	if pkg.Shadowed {
+		return pkg.Tap + "/" + pkg.Name
+	}
+	return pkg.Name
+}
+
 func (pkg *Package) BrewUrl() string {
-	if pkg.IsCask {
+	if pkg.IsMasApp() {
+		return fmt.Sprintf("https://apps.apple.com/app/id%d", pkg.MasId)
+	} else if pkg.IsWhalebrew() {
+		return dockerImageUrl(pkg.WhalebrewImage)
+	} else if pkg.IsCask {
 		return fmt.Sprintf("https://formulae.brew.sh/cask/%s", pkg.Name)
 	} else {
 		return fmt.Sprintf("https://formulae.brew.sh/formula/%s", pkg.Name)
 	}
 }
 
+// Page of a Docker image, on Docker Hub unless the image names its registry like ghcr.io/owner/name
+func dockerImageUrl(image string) string {
+	repo, _, _ := strings.Cut(image, "@")
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	if host, _, ok := strings.Cut(repo, "/"); ok && strings.ContainsAny(host, ".:") {
+		return "https://" + repo
+	} else if !ok {
+		// Official images
+		return "https://hub.docker.com/_/" + repo
+	}
+	return "https://hub.docker.com/r/" + repo
+}
+
 func (pkg *Package) versionWithRev() string {
 	if pkg.Revision > 0 {
 		return fmt.Sprintf("%s_%d", pkg.Version, pkg.Revision)
@@ -134,7 +358,25 @@ func (pkg *Package) MarkInstalled() {
 	pkg.IsInstalled = true
 	pkg.IsOutdated = false
 	pkg.InstalledVersion = pkg.Version
+	pkg.InstalledRevision = pkg.Revision
 	pkg.InstalledDate = time.Now().Format(time.DateOnly)
+	pkg.SnoozedUntil = time.Time{}
+	pkg.Sources.InstalledVersion = pkg.Sources.Catalog
+	pkg.Sources.InstalledDate = SourceCommand
+	// The keg was replaced, an earlier verification and health check no longer apply
+	pkg.Verification = nil
+	pkg.BrokenInstall = ""
+	pkg.HealthProblems = nil
+	// brew links formulae when installing them, unless they're keg-only
+	pkg.IsLinked = !pkg.IsCask && !pkg.IsKegOnly
+}
+
+func (pkg *Package) MarkLinked() {
+	pkg.IsLinked = true
+}
+
+func (pkg *Package) MarkUnlinked() {
+	pkg.IsLinked = false
 }
 
 func (pkg *Package) MarkInstalledAsDep() {
@@ -147,7 +389,11 @@ func (pkg *Package) MarkUninstalled() {
 	pkg.InstalledVersion = ""
 	pkg.IsOutdated = false
 	pkg.IsPinned = false
+	pkg.IsLinked = false
 	pkg.InstalledAsDependency = false
+	pkg.Verification = nil
+	pkg.BrokenInstall = ""
+	pkg.HealthProblems = nil
 }
 
 func (pkg *Package) MarkPinned() {
@@ -158,6 +404,21 @@ func (pkg *Package) MarkUnpinned() {
 	pkg.IsPinned = false
 }
 
+// Build failures reported in analytics above this share of installs are worth a warning
+const highBuildErrorRate = 0.05
+
+// Build errors per install in the last 90 days
+func (pkg *Package) BuildErrorRate() float64 {
+	if pkg.Installs90d == 0 {
+		return 0
+	}
+	return float64(pkg.BuildErrors90d) / float64(pkg.Installs90d)
+}
+
+func (pkg *Package) HasHighBuildErrorRate() bool {
+	return pkg.BuildErrors90d > 0 && (pkg.Installs90d == 0 || pkg.BuildErrorRate() >= highBuildErrorRate)
+}
+
 const (
 	negativeKwPrefix = "-"
 
@@ -165,6 +426,7 @@ const (
 	kwPrefixDesc     = "d:"
 	kwPrefixTap      = "t:"
 	kwPrefixHomePage = "h:"
+	kwPrefixCategory = "cat:"
 )
 
 // Test if a package matches the keywords
@@ -191,6 +453,8 @@ func (pkg *Package) matchKeyword(kw string) bool {
 		return pkg.matchKeywordInTap(kw)
 	} else if kw, hasPrefix := strings.CutPrefix(kw, kwPrefixHomePage); hasPrefix {
 		return pkg.matchKeywordInHomePage(kw)
+	} else if kw, hasPrefix := strings.CutPrefix(kw, kwPrefixCategory); hasPrefix {
+		return pkg.matchKeywordInCategories(kw)
 	}
 	return pkg.matchKeywordInName(kw) || pkg.matchKeywordInDesc(kw)
 }
@@ -209,7 +473,7 @@ func (pkg *Package) matchKeywordInName(kw string) bool {
 }
 
 func (pkg *Package) matchKeywordInDesc(kw string) bool {
-	return strings.Contains(strings.ToLower(pkg.Desc), kw)
+	return strings.Contains(strings.ToLower(pkg.Desc()), kw)
 }
 
 func (pkg *Package) matchKeywordInTap(kw string) bool {
@@ -217,5 +481,14 @@ func (pkg *Package) matchKeywordInTap(kw string) bool {
 }
 
 func (pkg *Package) matchKeywordInHomePage(kw string) bool {
-	return strings.Contains(strings.ToLower(pkg.Homepage), kw)
+	return strings.Contains(strings.ToLower(pkg.Homepage()), kw)
+}
+
+func (pkg *Package) matchKeywordInCategories(kw string) bool {
+	for _, c := range pkg.Categories() {
+		if strings.Contains(c, kw) {
+			return true
+		}
+	}
+	return false
 }
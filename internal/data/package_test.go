@@ -0,0 +1,74 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHasHighBuildErrorRate(t *testing.T) {
+	tests := []struct {
+		installs    int
+		buildErrors int
+		want        bool
+	}{
+		{1000, 0, false},
+		{1000, 10, false},
+		{1000, 50, true},
+		{0, 3, true},
+		{0, 0, false},
+	}
+
+	for _, tt := range tests {
+		pkg := Package{Installs90d: tt.installs, BuildErrors90d: tt.buildErrors}
+		if got := pkg.HasHighBuildErrorRate(); got != tt.want {
+			t.Errorf("HasHighBuildErrorRate() with %d installs and %d build errors = %v, want %v", tt.installs, tt.buildErrors, got, tt.want)
+		}
+	}
+}
+
+func FuzzMatchKeywords(f *testing.F) {
+	for _, query := range []string{"wget", "n:wget", "-d:file", "t:homebrew h:gnu", "cat:net", "-", "n:", "-n:", "--", "W\u0130GET", "\xff"} {
+		f.Add(query)
+	}
+
+	pkg := Package{Name: "wget", Aliases: []string{"gnu-wget"}, Tap: "homebrew/core"}
+	pkg.SetTexts(nil, "Internet file retriever", "https://www.gnu.org/software/wget/")
+	f.Fuzz(func(t *testing.T, query string) {
+		kws := strings.Fields(query)
+		pkg.MatchKeywords(kws)
+		for _, kw := range kws {
+			if strings.HasPrefix(kw, negativeKwPrefix) {
+				continue
+			}
+			// A keyword and its negation never match at the same time
+			if pkg.MatchKeywords([]string{kw}) && pkg.MatchKeywords([]string{negativeKwPrefix + kw}) {
+				t.Errorf("expected %q and its negation not to both match", kw)
+			}
+		}
+	})
+}
+
+func TestUniqueName(t *testing.T) {
+	core := Package{Name: "goku", Tap: CoreTap}
+	tapped := Package{Name: "goku", Tap: "yqrashawn/goku", Shadowed: true}
+	if core.FullName() != "goku" || core.UniqueName() != "goku" {
+		t.Errorf("expected goku, got %q and %q", core.FullName(), core.UniqueName())
+	}
+	if tapped.FullName() != "yqrashawn/goku/goku" || tapped.UniqueName() != "yqrashawn/goku/goku" {
+		t.Errorf("expected yqrashawn/goku/goku, got %q and %q", tapped.FullName(), tapped.UniqueName())
+	}
+}
+
+func TestDockerImageUrl(t *testing.T) {
+	for image, expected := range map[string]string{
+		"whalebrew/wget":            "https://hub.docker.com/r/whalebrew/wget",
+		"whalebrew/ffmpeg:4.4":      "https://hub.docker.com/r/whalebrew/ffmpeg",
+		"alpine":                    "https://hub.docker.com/_/alpine",
+		"ghcr.io/owner/tool:latest": "https://ghcr.io/owner/tool",
+		"localhost:5000/tool":       "https://localhost:5000/tool",
+	} {
+		if url := dockerImageUrl(image); url != expected {
+			t.Errorf("expected %s for %s, got %s", expected, image, url)
+		}
+	}
+}
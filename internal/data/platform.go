@@ -0,0 +1,115 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	OSMacOS = "macos"
+	OSLinux = "linux"
+
+	bottleTagAll = "all"
+)
+
+// Platform is the OS, architecture and macOS version packages are installed on
+type Platform struct {
+	OS      string
+	Arch    string // arm64 or x86_64
+	Version string // macOS version like 14.5, empty on Linux
+}
+
+// macOS versions by the code names used in bottle tags
+var macOSCodeNames = map[string]string{
+	"tahoe":       "26",
+	"sequoia":     "15",
+	"sonoma":      "14",
+	"ventura":     "13",
+	"monterey":    "12",
+	"big_sur":     "11",
+	"catalina":    "10.15",
+	"mojave":      "10.14",
+	"high_sierra": "10.13",
+	"sierra":      "10.12",
+	"el_capitan":  "10.11",
+}
+
+func (p Platform) String() string {
+	if p.OS == OSMacOS {
+		return fmt.Sprintf("macOS %s (%s)", p.Version, p.Arch)
+	}
+	return fmt.Sprintf("Linux (%s)", p.Arch)
+}
+
+// Whether a bottle with the tag, e.g. arm64_sonoma, sequoia or x86_64_linux, can be poured on the platform.
+// Bottles built for older macOS versions work on newer ones.
+func (p Platform) canPour(tag string) bool {
+	if tag == bottleTagAll {
+		return true
+	}
+	if p.OS == OSLinux {
+		return tag == p.Arch+"_linux"
+	}
+
+	codeName := tag
+	if p.Arch == "arm64" {
+		var ok bool
+		if codeName, ok = strings.CutPrefix(tag, "arm64_"); !ok {
+			return false
+		}
+	} else if strings.Contains(tag, "_") && macOSCodeNames[tag] == "" {
+		// Tags of other architectures, code names like big_sur have underscores too
+		return false
+	}
+	version, ok := macOSCodeNames[codeName]
+	return ok && CompareVersions(version, p.Version) <= 0
+}
+
+// Compare dotted version numbers like 10.15 and 14.5
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
+// Why the package can't be installed on the platform, empty when it can
+func (pkg *Package) IncompatibleReason(p Platform) string {
+	if pkg.RequiresMacOS || pkg.MinMacOSVersion != "" {
+		if p.OS != OSMacOS {
+			return "requires macOS"
+		}
+		if pkg.MinMacOSVersion != "" && CompareVersions(pkg.MinMacOSVersion, p.Version) > 0 {
+			return fmt.Sprintf("requires macOS %s or newer", pkg.MinMacOSVersion)
+		}
+	}
+	if !pkg.IsCask && !pkg.HasBottle(p) {
+		return fmt.Sprintf("no bottle for %s", p)
+	}
+	return ""
+}
+
+// Whether installing the formula on the platform pours a prebuilt bottle instead of building from source
+func (pkg *Package) HasBottle(p Platform) bool {
+	for _, tag := range pkg.Platforms {
+		if p.canPour(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (pkg *Package) IsCompatible(p Platform) bool {
+	return pkg.IncompatibleReason(p) == ""
+}
@@ -0,0 +1,33 @@
+package data
+
+import "testing"
+
+func TestIncompatibleReason(t *testing.T) {
+	sonomaArm := Platform{OS: OSMacOS, Arch: "arm64", Version: "14.5"}
+	sonomaIntel := Platform{OS: OSMacOS, Arch: "x86_64", Version: "14.5"}
+	linux := Platform{OS: OSLinux, Arch: "x86_64"}
+
+	tests := []struct {
+		name     string
+		pkg      Package
+		platform Platform
+		want     string
+	}{
+		{"older arm bottle", Package{Platforms: []string{"arm64_ventura"}}, sonomaArm, ""},
+		{"newer arm bottle only", Package{Platforms: []string{"arm64_sequoia"}}, sonomaArm, "no bottle for macOS 14.5 (arm64)"},
+		{"arm bottle on intel", Package{Platforms: []string{"arm64_sonoma"}}, sonomaIntel, "no bottle for macOS 14.5 (x86_64)"},
+		{"intel code name with underscore", Package{Platforms: []string{"big_sur"}}, sonomaIntel, ""},
+		{"bottle for all", Package{Platforms: []string{"all"}}, linux, ""},
+		{"linux bottle", Package{Platforms: []string{"sonoma", "x86_64_linux"}}, linux, ""},
+		{"no bottles", Package{}, linux, "no bottle for Linux (x86_64)"},
+		{"macOS only formula", Package{Platforms: []string{"all"}, RequiresMacOS: true}, linux, "requires macOS"},
+		{"cask on old macOS", Package{IsCask: true, MinMacOSVersion: "15"}, sonomaArm, "requires macOS 15 or newer"},
+		{"cask on new macOS", Package{IsCask: true, MinMacOSVersion: "10.15"}, sonomaArm, ""},
+		{"cask on linux", Package{IsCask: true, MinMacOSVersion: "12"}, linux, "requires macOS"},
+	}
+	for _, tt := range tests {
+		if got := tt.pkg.IncompatibleReason(tt.platform); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// UpgradePriority ranks how urgently an outdated package should be upgraded, higher is more urgent
+type UpgradePriority int
+
+const (
+	PriorityNone       UpgradePriority = iota // Not outdated
+	PriorityDeprecated                        // Deprecated or disabled, better replaced than upgraded
+	PriorityRebuild                           // Same version, new revision
+	PriorityPatch
+	PriorityMinor
+	PriorityMajor
+	PrioritySecurity // Installed version has known vulnerabilities
+)
+
+var versionNumberRegex = regexp.MustCompile(`\d+`)
+
+func (p UpgradePriority) String() string {
+	switch p {
+	case PrioritySecurity:
+		return "Security"
+	case PriorityMajor:
+		return "Major"
+	case PriorityMinor:
+		return "Minor"
+	case PriorityPatch:
+		return "Patch"
+	case PriorityRebuild:
+		return "Rebuild"
+	case PriorityDeprecated:
+		return "Deprecated"
+	default:
+		return ""
+	}
+}
+
+func (pkg *Package) UpgradePriority() UpgradePriority {
+	if !pkg.IsOutdated {
+		return PriorityNone
+	} else if len(pkg.Vulnerabilities) > 0 {
+		return PrioritySecurity
+	} else if pkg.IsDeprecated || pkg.IsDisabled {
+		return PriorityDeprecated
+	} else if pkg.InstalledVersion == pkg.Version {
+		return PriorityRebuild
+	}
+
+	installed := versionNumberRegex.FindAllString(pkg.InstalledVersion, 3)
+	latest := versionNumberRegex.FindAllString(pkg.Version, 3)
+	for i := range min(len(installed), len(latest)) {
+		a, _ := strconv.Atoi(installed[i])
+		b, _ := strconv.Atoi(latest[i])
+		if a != b && i == 0 {
+			return PriorityMajor
+		} else if a != b && i == 1 {
+			return PriorityMinor
+		} else if a != b {
+			break
+		}
+	}
+	return PriorityPatch
+}
+
+// Explain why a package got its upgrade priority
+func (pkg *Package) UpgradePriorityReason() string {
+	switch pkg.UpgradePriority() {
+	case PrioritySecurity:
+		return fmt.Sprintf("%d known vulnerabilities in %s", len(pkg.Vulnerabilities), pkg.installedVersionWithRev())
+	case PriorityMajor:
+		return "new major version"
+	case PriorityMinor:
+		return "new minor version"
+	case PriorityPatch:
+		return "patch release"
+	case PriorityRebuild:
+		return "rebuilt with a new revision"
+	case PriorityDeprecated:
+		return "package is deprecated, consider a replacement"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,25 @@
+package data
+
+import "testing"
+
+func TestUpgradePriority(t *testing.T) {
+	tests := []struct {
+		pkg  Package
+		want UpgradePriority
+	}{
+		{Package{InstalledVersion: "1.2.3", Version: "1.2.3"}, PriorityNone},
+		{Package{IsOutdated: true, InstalledVersion: "1.2.3", Version: "2.0.0"}, PriorityMajor},
+		{Package{IsOutdated: true, InstalledVersion: "1.2.3", Version: "1.3.0"}, PriorityMinor},
+		{Package{IsOutdated: true, InstalledVersion: "1.2.3", Version: "1.2.4"}, PriorityPatch},
+		{Package{IsOutdated: true, InstalledVersion: "2024-01-01", Version: "2024-01-15"}, PriorityPatch},
+		{Package{IsOutdated: true, InstalledVersion: "1.2.3", Version: "1.2.3", Revision: 1}, PriorityRebuild},
+		{Package{IsOutdated: true, InstalledVersion: "1.2.3", Version: "2.0.0", IsDeprecated: true}, PriorityDeprecated},
+		{Package{IsOutdated: true, InstalledVersion: "1.2.3", Version: "1.2.4", Vulnerabilities: []string{"CVE-2025-1"}}, PrioritySecurity},
+	}
+
+	for _, tt := range tests {
+		if got := tt.pkg.UpgradePriority(); got != tt.want {
+			t.Errorf("UpgradePriority() of %s -> %s = %s, want %s", tt.pkg.InstalledVersion, tt.pkg.Version, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package data
+
+import (
+	"log"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// Reference to a string in a TextStore, packed as offset << 16 | length
+type textRef uint64
+
+const (
+	maxTextLen     = 1<<16 - 1
+	textFlushBytes = 64 * 1024
+)
+
+func (r textRef) offset() int64 {
+	return int64(r >> 16)
+}
+
+func (r textRef) len() int {
+	return int(r & maxTextLen)
+}
+
+// TextStore keeps rarely used text of packages, like home pages, in a file on disk
+// and reads it back on demand, so thousands of them don't take up memory
+type TextStore struct {
+	mu      sync.Mutex
+	file    *os.File // Nil when text is kept in memory
+	pending []byte   // Text not yet written to the file, or all text when kept in memory
+	written int64    // Number of bytes in the file
+}
+
+// Fallback for packages created without a store
+var memoryTexts = NewMemoryTextStore()
+
+// Create a store backed by an anonymous file in dir, which is removed once the store is released
+func NewTextStore(dir string) (*TextStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(dir, "texts-*")
+	if err != nil {
+		return nil, err
+	}
+	// The open file stays readable after being unlinked
+	if err := os.Remove(file.Name()); err != nil {
+		log.Printf("failed to remove %s: %v", file.Name(), err)
+	}
+	store := &TextStore{file: file}
+	runtime.SetFinalizer(store, func(s *TextStore) {
+		s.file.Close()
+	})
+	return store, nil
+}
+
+func NewMemoryTextStore() *TextStore {
+	return &TextStore{}
+}
+
+func (s *TextStore) put(text string) textRef {
+	if text == "" {
+		return 0
+	}
+	if len(text) > maxTextLen {
+		text = text[:maxTextLen]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ref := textRef((s.written+int64(len(s.pending)))<<16 | int64(len(text)))
+	s.pending = append(s.pending, text...)
+	if s.file != nil && len(s.pending) >= textFlushBytes {
+		s.flush()
+	}
+	return ref
+}
+
+func (s *TextStore) flush() {
+	n, err := s.file.WriteAt(s.pending, s.written)
+	if err != nil {
+		// Keep the rest in memory, reads of it are served from pending
+		log.Printf("failed to write text store: %v", err)
+	}
+	s.written += int64(n)
+	s.pending = s.pending[n:]
+}
+
+func (s *TextStore) get(ref textRef) string {
+	if ref.len() == 0 {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if off := ref.offset() - s.written; off >= 0 {
+		return string(s.pending[off : off+int64(ref.len())])
+	}
+	buf := make([]byte, ref.len())
+	if _, err := s.file.ReadAt(buf, ref.offset()); err != nil {
+		log.Printf("failed to read text store: %v", err)
+		return ""
+	}
+	return string(buf)
+}
@@ -0,0 +1,46 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTextStore(t *testing.T) {
+	store, err := NewTextStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create text store: %v", err)
+	}
+
+	// Write enough text to be flushed to the file, with the rest still pending
+	texts := []string{}
+	refs := []textRef{}
+	for i := range 3000 {
+		text := fmt.Sprintf("package %d %s", i, strings.Repeat("x", i%50))
+		texts = append(texts, text)
+		refs = append(refs, store.put(text))
+	}
+	if store.written == 0 || len(store.pending) == 0 {
+		t.Fatalf("expected text both in the file and pending, got %d written and %d pending", store.written, len(store.pending))
+	}
+	for i, ref := range refs {
+		if got := store.get(ref); got != texts[i] {
+			t.Fatalf("expected %q, got %q", texts[i], got)
+		}
+	}
+}
+
+func TestPackageTexts(t *testing.T) {
+	pkg := Package{}
+	if pkg.Desc() != "" || pkg.Homepage() != "" {
+		t.Error("expected empty description and home page without texts")
+	}
+
+	pkg.SetTexts(nil, "Internet file retriever", "https://www.gnu.org/software/wget/")
+	if pkg.Desc() != "Internet file retriever" || pkg.Homepage() != "https://www.gnu.org/software/wget/" {
+		t.Errorf("unexpected description %q and home page %q", pkg.Desc(), pkg.Homepage())
+	}
+	if !pkg.MatchKeywords([]string{"retriever", "h:gnu.org"}) {
+		t.Error("expected keywords to match the description and home page")
+	}
+}
@@ -0,0 +1,140 @@
+package gh
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const httpTimeout = 30 * time.Second
+
+// Overridden in tests
+var githubApiUrl = "https://api.github.com"
+
+var httpClient = &http.Client{Timeout: httpTimeout}
+
+var (
+	errNoGraphql = errors.New("github graphql api needs GITHUB_TOKEN or gh")
+	errNotFound  = errors.New("not found")
+)
+
+// When the REST API allows requests again after running out of them, anonymous requests only get 60 an hour
+var (
+	restResetAt   time.Time
+	restResetAtMu sync.Mutex
+)
+
+// Token for the GitHub API, read from the same variables gh reads
+func githubToken() string {
+	return cmp.Or(os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN"))
+}
+
+// Whether requests go through gh: only without a token, which is used directly
+func useGh() bool {
+	return githubToken() == "" && isGhInstalled()
+}
+
+// Run a GraphQL query with the token, or with gh, the GraphQL API doesn't take anonymous requests
+func runGraphql(query string) ([]byte, error) {
+	if token := githubToken(); token != "" {
+		return postGraphql(token, query)
+	}
+	if !isGhInstalled() {
+		return nil, errNoGraphql
+	}
+	// gh exits with an error when some repositories can't be resolved but still prints the data
+	body, err := exec.Command(gh, "api", "graphql", "-f", "query="+query).Output()
+	if err != nil && len(body) == 0 {
+		if e, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("gh api graphql failed: %s", e.Stderr)
+		}
+		return nil, fmt.Errorf("gh api graphql failed: %w", err)
+	}
+	return body, nil
+}
+
+func postGraphql(token, query string) ([]byte, error) {
+	url := githubApiUrl + "/graphql"
+	payload, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body from %s: %w", url, err)
+	}
+	// Rate limits are reported in the body like other GraphQL errors
+	if resp.StatusCode != http.StatusOK && len(body) == 0 {
+		return nil, fmt.Errorf("bad HTTP status posting %s: %s", url, resp.Status)
+	}
+	return body, nil
+}
+
+// Get a REST API path like /repos/cli/cli and decode the JSON response,
+// with the token when there's one and anonymously otherwise
+func getRest(path string, v any) error {
+	restResetAtMu.Lock()
+	resetAt := restResetAt
+	restResetAtMu.Unlock()
+	if time.Now().Before(resetAt) {
+		return errRateLimited
+	}
+
+	url := githubApiUrl + path
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := githubToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			restResetAtMu.Lock()
+			restResetAt = time.Unix(reset, 0)
+			restResetAtMu.Unlock()
+			log.Printf("GitHub REST API rate limit exhausted until %s, set GITHUB_TOKEN for a higher limit", time.Unix(reset, 0).Format(time.TimeOnly))
+		}
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errNotFound
+	case (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		resp.Header.Get("X-RateLimit-Remaining") == "0":
+		return errRateLimited
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("bad HTTP status getting %s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode json from %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package gh
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func withApiServer(t *testing.T, handler http.HandlerFunc) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	original := githubApiUrl
+	githubApiUrl = server.URL
+	t.Cleanup(func() {
+		githubApiUrl = original
+		restResetAt = time.Time{}
+	})
+}
+
+func TestPostGraphqlWithToken(t *testing.T) {
+	withApiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("expected an authorized post to /graphql, got %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"query":"query { viewer { login } }"`) {
+			t.Errorf("expected the query in the body, got %s", body)
+		}
+		fmt.Fprint(w, `{"data": {"viewer": {"login": "octocat"}}}`)
+	})
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	body, err := runGraphql("query { viewer { login } }")
+	if err != nil || !strings.Contains(string(body), "octocat") {
+		t.Errorf("expected the response body, got %s, %v", body, err)
+	}
+}
+
+func TestFetchLatestReleaseAnonymously(t *testing.T) {
+	requests := 0
+	withApiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("expected an anonymous request, got %s", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/repos/cli/cli/releases/latest":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+			fmt.Fprint(w, `{"published_at": "2025-06-01T12:00:00Z", "tag_name": "v2.74.0", "html_url": "https://github.com/cli/cli/releases/tag/v2.74.0"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("PATH", "")
+
+	if _, err := runGraphql("query {}"); err != errNoGraphql {
+		t.Errorf("expected GraphQL to be unavailable without a token and gh, got %v", err)
+	}
+	release := fetchLatestRelease("cli", "cli")
+	if release == nil || release.Version != "v2.74.0" {
+		t.Errorf("expected release v2.74.0, got %+v", release)
+	}
+	// The rate limit ran out with the first request
+	if release := fetchLatestRelease("junegunn", "fzf"); release != nil || requests != 1 {
+		t.Errorf("expected no request until the rate limit resets, got %d requests and %+v", requests, release)
+	}
+}
+
+func TestFetchReposRest(t *testing.T) {
+	withApiServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/cli/cli" {
+			fmt.Fprint(w, `{"html_url": "https://github.com/cli/cli", "stargazers_count": 40000, "open_issues_count": 900,
+				"archived": false, "pushed_at": "2025-06-01T12:00:00Z"}`)
+		} else {
+			http.NotFound(w, r)
+		}
+	})
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("PATH", "")
+
+	repos := []githubRepo{{"cli", "cli"}, {"nobody", "missing"}}
+	infos, err := fetchRepos(repos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r := infos[repos[0]]; r == nil || r.Stars != 40000 || r.OpenIssues != 900 {
+		t.Errorf("expected 40000 stars and 900 open issues, got %+v", r)
+	}
+	if _, ok := infos[repos[1]]; ok {
+		t.Errorf("expected no info for the missing repository")
+	}
+}
@@ -2,12 +2,15 @@ package gh
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os/exec"
 	"regexp"
-	"taproom/internal/data"
+	"strings"
 	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
 )
 
 type ghReleaseInfo struct {
@@ -19,6 +22,13 @@ type ghReleaseInfo struct {
 const (
 	gh            = "gh"
 	releaseFields = "publishedAt,tagName,url"
+
+	// Number of repositories resolved by a single GraphQL request
+	graphqlBatchSize = 50
+	// Longest time to wait for the GraphQL rate limit to reset before giving up
+	maxRateLimitWait = 5 * time.Minute
+	// Time between requests when repositories are looked up one by one
+	oneByOneInterval = 250 * time.Millisecond
 )
 
 var (
@@ -26,26 +36,86 @@ var (
 	githubPageUrl = regexp.MustCompile(`^https://([^.\s]+).github.io/([^/\s]+)`)
 )
 
-func GetGithubReleaseInfo(pkg *data.Package) *data.ReleaseInfo {
-	if !isGhInstalled() {
-		return nil
+type githubRepo struct {
+	owner string
+	name  string
+}
+
+func (r githubRepo) String() string {
+	return fmt.Sprintf("%s/%s", r.owner, r.name)
+}
+
+// Fetch release info for all packages, fetched is called with the releases of the packages of each batch.
+// Packages aren't changed here, their releases are set by the caller where packages are safe to write.
+// Repositories are resolved in batches with the GraphQL API; when a batch fails, or without
+// a token or gh for GraphQL, the repositories in it are looked up one by one instead.
+func FetchGithubReleaseInfo(pkgs []*data.Package, fetched func(map[*data.Package]*data.ReleaseInfo)) {
+	pkgsByRepo := make(map[githubRepo][]*data.Package)
+	repos := []githubRepo{}
+	for _, pkg := range pkgs {
+		if repo, ok := getGithubRepo(pkg); ok {
+			if _, seen := pkgsByRepo[repo]; !seen {
+				repos = append(repos, repo)
+			}
+			pkgsByRepo[repo] = append(pkgsByRepo[repo], pkg)
+		}
+	}
+
+	for start := 0; start < len(repos); start += graphqlBatchSize {
+		batch := repos[start:min(start+graphqlBatchSize, len(repos))]
+		releases, err := fetchLatestReleases(batch)
+		if err != nil {
+			if err != errNoGraphql {
+				log.Printf("Failed to batch fetch release info, falling back to fetching one by one: %v", err)
+			}
+			releases = make(map[githubRepo]*data.ReleaseInfo)
+			limiter := time.NewTicker(oneByOneInterval)
+			for _, repo := range batch {
+				releases[repo] = fetchLatestRelease(repo.owner, repo.name)
+				<-limiter.C
+			}
+			limiter.Stop()
+		}
+		batchReleases := make(map[*data.Package]*data.ReleaseInfo)
+		for repo, release := range releases {
+			for _, pkg := range pkgsByRepo[repo] {
+				batchReleases[pkg] = release
+			}
+		}
+		fetched(batchReleases)
+	}
+}
+
+// Whether the package's url or home page points to a GitHub repository
+func HasGithubRepo(pkg *data.Package) bool {
+	_, ok := getGithubRepo(pkg)
+	return ok
+}
+
+// The package's GitHub repository as owner/name, empty when it has none
+func GithubRepoName(pkg *data.Package) string {
+	if repo, ok := getGithubRepo(pkg); ok {
+		return repo.String()
 	}
+	return ""
+}
 
+func getGithubRepo(pkg *data.Package) (githubRepo, bool) {
 	for _, url := range pkg.Urls {
 		if matches := githubRepoUrl.FindStringSubmatch(url); len(matches) > 0 {
 			// Package url matches a github repo
-			return fetchLatestRelease(matches[1], matches[2])
+			return githubRepo{matches[1], matches[2]}, true
 		}
 	}
 
-	if matches := githubRepoUrl.FindStringSubmatch(pkg.Homepage); len(matches) > 0 {
+	if matches := githubRepoUrl.FindStringSubmatch(pkg.Homepage()); len(matches) > 0 {
 		// Package home page matches a github repo
-		return fetchLatestRelease(matches[1], matches[2])
-	} else if matches := githubPageUrl.FindStringSubmatch(pkg.Homepage); len(matches) > 0 {
+		return githubRepo{matches[1], matches[2]}, true
+	} else if matches := githubPageUrl.FindStringSubmatch(pkg.Homepage()); len(matches) > 0 {
 		// Package home page matches a github page
-		return fetchLatestRelease(matches[1], matches[2])
+		return githubRepo{matches[1], matches[2]}, true
 	} else {
-		return nil
+		return githubRepo{}, false
 	}
 }
 
@@ -57,7 +127,25 @@ func isGhInstalled() bool {
 	}
 }
 
+// Latest release of a repository from the REST API
+type restReleaseInfo struct {
+	PublishDate time.Time `json:"published_at"`
+	TagName     string    `json:"tag_name"`
+	Url         string    `json:"html_url"`
+}
+
 func fetchLatestRelease(ghOwner, ghRepo string) *data.ReleaseInfo {
+	if !useGh() {
+		var release restReleaseInfo
+		if err := getRest(fmt.Sprintf("/repos/%s/%s/releases/latest", ghOwner, ghRepo), &release); err != nil {
+			if err != errNotFound && err != errRateLimited {
+				log.Printf("Failed to get release info for %s/%s: %v", ghOwner, ghRepo, err)
+			}
+			return nil
+		}
+		return toReleaseInfo(&ghReleaseInfo{PublishDate: release.PublishDate, TagName: release.TagName, Url: release.Url})
+	}
+
 	var note ghReleaseInfo
 	cmd := exec.Command(gh, "release", "view", "--repo", fmt.Sprintf("%s/%s", ghOwner, ghRepo), "--json", releaseFields)
 
@@ -77,6 +165,113 @@ func fetchLatestRelease(ghOwner, ghRepo string) *data.ReleaseInfo {
 	}
 }
 
+// Response of the batched GraphQL query, each repository is aliased as r0, r1, ...
+type graphqlReleasesResponse struct {
+	Data struct {
+		RateLimit struct {
+			Cost      int       `json:"cost"`
+			Remaining int       `json:"remaining"`
+			ResetAt   time.Time `json:"resetAt"`
+		} `json:"rateLimit"`
+	} `json:"data"`
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+var errRateLimited = errors.New("github graphql rate limit exceeded")
+
+func buildReleasesQuery(repos []githubRepo) string {
+	var b strings.Builder
+	b.WriteString("query {\n")
+	b.WriteString("  rateLimit { cost remaining resetAt }\n")
+	for i, repo := range repos {
+		b.WriteString(fmt.Sprintf(
+			"  r%d: repository(owner: %q, name: %q) { latestRelease { publishedAt tagName url } }\n",
+			i, repo.owner, repo.name))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func parseReleasesResponse(body []byte, repos []githubRepo) (*graphqlReleasesResponse, map[githubRepo]*data.ReleaseInfo, error) {
+	var resp graphqlReleasesResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode graphql response %s: %w", body, err)
+	}
+	// Repositories are dynamic aliases next to rateLimit, decode them separately
+	var raw struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode graphql response %s: %w", body, err)
+	}
+
+	for _, e := range resp.Errors {
+		if e.Type == "RATE_LIMITED" {
+			return &resp, nil, errRateLimited
+		}
+	}
+	if raw.Data == nil && len(resp.Errors) > 0 {
+		return &resp, nil, fmt.Errorf("graphql query failed: %s", resp.Errors[0].Message)
+	}
+
+	releases := make(map[githubRepo]*data.ReleaseInfo)
+	for i, repo := range repos {
+		var r *struct {
+			LatestRelease *ghReleaseInfo `json:"latestRelease"`
+		}
+		if msg, ok := raw.Data[fmt.Sprintf("r%d", i)]; ok {
+			if err := json.Unmarshal(msg, &r); err != nil {
+				log.Printf("Failed to decode release info for %s: %v", repo, err)
+			}
+		}
+		if r != nil && r.LatestRelease != nil {
+			releases[repo] = toReleaseInfo(r.LatestRelease)
+		} else {
+			// Missing repositories and repositories without releases are reported as errors, don't retry them
+			releases[repo] = nil
+		}
+	}
+	return &resp, releases, nil
+}
+
+func fetchLatestReleases(repos []githubRepo) (map[githubRepo]*data.ReleaseInfo, error) {
+	query := buildReleasesQuery(repos)
+	for {
+		body, err := runGraphql(query)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, releases, err := parseReleasesResponse(body, repos)
+		if err == errRateLimited {
+			wait := time.Until(resp.Data.RateLimit.ResetAt)
+			if wait <= 0 {
+				wait = time.Minute
+			}
+			if wait > maxRateLimitWait {
+				return nil, fmt.Errorf("rate limited until %s", resp.Data.RateLimit.ResetAt.Format(time.TimeOnly))
+			}
+			log.Printf("GitHub GraphQL rate limited, retrying in %s", wait)
+			time.Sleep(wait)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if rl := resp.Data.RateLimit; rl.Remaining < rl.Cost {
+			// Next batch would exceed the limit, wait for it to reset
+			if wait := time.Until(rl.ResetAt); wait > 0 && wait <= maxRateLimitWait {
+				log.Printf("GitHub GraphQL rate limit almost exhausted, waiting %s", wait)
+				time.Sleep(wait)
+			}
+		}
+		return releases, nil
+	}
+}
+
 func toReleaseInfo(info *ghReleaseInfo) *data.ReleaseInfo {
 	return &data.ReleaseInfo{
 		Date:    info.PublishDate,
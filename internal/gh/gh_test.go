@@ -0,0 +1,87 @@
+package gh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildReleasesQuery(t *testing.T) {
+	query := buildReleasesQuery([]githubRepo{{"cli", "cli"}, {"junegunn", "fzf"}})
+	for _, want := range []string{
+		"rateLimit { cost remaining resetAt }",
+		`r0: repository(owner: "cli", name: "cli")`,
+		`r1: repository(owner: "junegunn", name: "fzf")`,
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("expected query to contain %q, got:\n%s", want, query)
+		}
+	}
+}
+
+func TestParseReleasesResponse(t *testing.T) {
+	repos := []githubRepo{{"cli", "cli"}, {"nobody", "missing"}, {"junegunn", "fzf"}}
+	body := `{
+		"data": {
+			"rateLimit": {"cost": 1, "remaining": 4999, "resetAt": "2025-01-01T00:00:00Z"},
+			"r0": {"latestRelease": {"publishedAt": "2025-06-01T12:00:00Z", "tagName": "v2.74.0", "url": "https://github.com/cli/cli/releases/tag/v2.74.0"}},
+			"r1": null,
+			"r2": {"latestRelease": null}
+		},
+		"errors": [{"type": "NOT_FOUND", "message": "Could not resolve to a Repository"}]
+	}`
+
+	resp, releases, err := parseReleasesResponse([]byte(body), repos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Data.RateLimit.Remaining != 4999 {
+		t.Errorf("expected remaining rate limit 4999, got %d", resp.Data.RateLimit.Remaining)
+	}
+	if r := releases[repos[0]]; r == nil || r.Version != "v2.74.0" {
+		t.Errorf("expected release v2.74.0 for %s, got %+v", repos[0], r)
+	}
+	for _, repo := range repos[1:] {
+		if r, ok := releases[repo]; !ok || r != nil {
+			t.Errorf("expected nil release for %s, got %+v", repo, r)
+		}
+	}
+}
+
+func TestParseReleasesResponseRateLimited(t *testing.T) {
+	body := `{"data": null, "errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`
+	if _, _, err := parseReleasesResponse([]byte(body), []githubRepo{{"cli", "cli"}}); err != errRateLimited {
+		t.Errorf("expected rate limit error, got %v", err)
+	}
+}
+
+func TestParseReposResponse(t *testing.T) {
+	repos := []githubRepo{{"cli", "cli"}, {"nobody", "missing"}, {"old", "archived"}}
+	body := `{
+		"data": {
+			"r0": {"url": "https://github.com/cli/cli", "stargazerCount": 40000, "isArchived": false,
+				"issues": {"totalCount": 800}, "defaultBranchRef": {"target": {"committedDate": "2025-06-01T12:00:00Z"}}},
+			"r1": null,
+			"r2": {"url": "https://github.com/old/archived", "stargazerCount": 12, "isArchived": true,
+				"issues": {"totalCount": 0}, "defaultBranchRef": null}
+		},
+		"errors": [{"type": "NOT_FOUND", "message": "Could not resolve to a Repository"}]
+	}`
+
+	infos, err := parseReposResponse([]byte(body), repos)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r := infos[repos[0]]; r == nil || r.Stars != 40000 || r.OpenIssues != 800 || r.LastCommit.Year() != 2025 {
+		t.Errorf("expected 40000 stars, 800 issues and a commit in 2025 for %s, got %+v", repos[0], r)
+	}
+	if _, ok := infos[repos[1]]; ok {
+		t.Errorf("expected no info for the missing repository %s", repos[1])
+	}
+	if r := infos[repos[2]]; r == nil || !r.Archived || !r.LastCommit.IsZero() {
+		t.Errorf("expected an archived repository without commits for %s, got %+v", repos[2], r)
+	}
+
+	if !strings.Contains(buildReposQuery(repos), `r2: repository(owner: "old", name: "archived") { url stargazerCount`) {
+		t.Errorf("expected the query to alias each repository")
+	}
+}
@@ -0,0 +1,181 @@
+package gh
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// Sent when the repositories of packages have been fetched, packages without a repository are left out
+type RepoInfoMsg struct {
+	Pkgs []*data.Package
+	Err  error
+}
+
+var (
+	requestedRepos   = make(map[githubRepo]bool) // Fetched or being fetched, each repository is fetched once
+	requestedReposMu sync.Mutex
+)
+
+type ghRepoInfo struct {
+	Url            string `json:"url"`
+	StargazerCount int    `json:"stargazerCount"`
+	IsArchived     bool   `json:"isArchived"`
+	Issues         struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"issues"`
+	DefaultBranchRef *struct {
+		Target struct {
+			CommittedDate time.Time `json:"committedDate"`
+		} `json:"target"`
+	} `json:"defaultBranchRef"`
+}
+
+// Fetch stars, open issues, archived status and the last commit of the GitHub repositories of packages,
+// repositories already fetched or being fetched are skipped. Returns nil when there's nothing to fetch.
+func FetchRepoInfo(pkgs []*data.Package) tea.Cmd {
+	requestedReposMu.Lock()
+	defer requestedReposMu.Unlock()
+	pkgsByRepo := make(map[githubRepo][]*data.Package)
+	repos := []githubRepo{}
+	for _, pkg := range pkgs {
+		repo, ok := getGithubRepo(pkg)
+		if !ok || pkg.Repo != nil {
+			continue
+		}
+		if !requestedRepos[repo] {
+			requestedRepos[repo] = true
+			repos = append(repos, repo)
+		}
+		if slices.Contains(repos, repo) {
+			pkgsByRepo[repo] = append(pkgsByRepo[repo], pkg)
+		}
+	}
+	if len(repos) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		fetched := []*data.Package{}
+		for start := 0; start < len(repos); start += graphqlBatchSize {
+			batch := repos[start:min(start+graphqlBatchSize, len(repos))]
+			infos, err := fetchRepos(batch)
+			if err != nil {
+				log.Printf("Failed to fetch GitHub repositories: %v", err)
+				// Let them be tried again later
+				requestedReposMu.Lock()
+				for _, repo := range batch {
+					delete(requestedRepos, repo)
+				}
+				requestedReposMu.Unlock()
+				return RepoInfoMsg{Pkgs: fetched, Err: err}
+			}
+			for repo, info := range infos {
+				for _, pkg := range pkgsByRepo[repo] {
+					pkg.Repo = info
+					fetched = append(fetched, pkg)
+				}
+			}
+		}
+		return RepoInfoMsg{Pkgs: fetched}
+	}
+}
+
+func buildReposQuery(repos []githubRepo) string {
+	var b strings.Builder
+	b.WriteString("query {\n")
+	for i, repo := range repos {
+		b.WriteString(fmt.Sprintf(
+			"  r%d: repository(owner: %q, name: %q) { url stargazerCount isArchived issues(states: OPEN) { totalCount } "+
+				"defaultBranchRef { target { ... on Commit { committedDate } } } }\n",
+			i, repo.owner, repo.name))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func fetchRepos(repos []githubRepo) (map[githubRepo]*data.RepoInfo, error) {
+	body, err := runGraphql(buildReposQuery(repos))
+	if err == errNoGraphql {
+		return fetchReposRest(repos)
+	} else if err != nil {
+		return nil, err
+	}
+	return parseReposResponse(body, repos)
+}
+
+// Repository from the REST API, open issues include pull requests there
+type restRepoInfo struct {
+	Url             string    `json:"html_url"`
+	StargazersCount int       `json:"stargazers_count"`
+	OpenIssuesCount int       `json:"open_issues_count"`
+	Archived        bool      `json:"archived"`
+	PushedAt        time.Time `json:"pushed_at"`
+}
+
+// Fetch repositories one by one anonymously, stops when the rate limit runs out
+func fetchReposRest(repos []githubRepo) (map[githubRepo]*data.RepoInfo, error) {
+	infos := make(map[githubRepo]*data.RepoInfo)
+	for _, repo := range repos {
+		var r restRepoInfo
+		if err := getRest(fmt.Sprintf("/repos/%s/%s", repo.owner, repo.name), &r); err == errNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		infos[repo] = &data.RepoInfo{
+			Url:        r.Url,
+			Stars:      r.StargazersCount,
+			OpenIssues: r.OpenIssuesCount,
+			Archived:   r.Archived,
+			LastCommit: r.PushedAt,
+		}
+	}
+	return infos, nil
+}
+
+func parseReposResponse(body []byte, repos []githubRepo) (map[githubRepo]*data.RepoInfo, error) {
+	var resp struct {
+		Data   map[string]*ghRepoInfo `json:"data"`
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode graphql response %s: %w", body, err)
+	}
+	for _, e := range resp.Errors {
+		if e.Type == "RATE_LIMITED" {
+			return nil, errRateLimited
+		}
+	}
+	if resp.Data == nil && len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("graphql query failed: %s", resp.Errors[0].Message)
+	}
+
+	infos := make(map[githubRepo]*data.RepoInfo)
+	for i, repo := range repos {
+		// Missing repositories are reported as errors and left out
+		if r := resp.Data[fmt.Sprintf("r%d", i)]; r != nil {
+			info := &data.RepoInfo{
+				Url:        r.Url,
+				Stars:      r.StargazerCount,
+				OpenIssues: r.Issues.TotalCount,
+				Archived:   r.IsArchived,
+			}
+			if r.DefaultBranchRef != nil {
+				info.LastCommit = r.DefaultBranchRef.Target.CommittedDate
+			}
+			infos[repo] = info
+		}
+	}
+	return infos, nil
+}
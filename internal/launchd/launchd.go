@@ -0,0 +1,223 @@
+package launchd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const labelPrefix = "com.github.hzqtc.taproom."
+
+// Subcommands that can run in the background
+var tasks = []string{"prewarm"}
+
+var flagAgentInterval = pflag.Duration("agent-interval", 4*time.Hour, "How often a launchd agent installed with 'taproom agent install' runs")
+
+type Agent struct {
+	Task     string
+	Program  string // Absolute path of the taproom executable
+	Args     []string
+	Interval time.Duration
+}
+
+func (a *Agent) Label() string {
+	return labelPrefix + a.Task
+}
+
+var plistTemplate = template.Must(template.New("plist").Funcs(template.FuncMap{
+	"xml": func(s string) (string, error) {
+		var b bytes.Buffer
+		err := xml.EscapeText(&b, []byte(s))
+		return b.String(), err
+	},
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{xml .Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{xml .Program}}</string>
+		{{- range .Args}}
+		<string>{{xml .}}</string>
+		{{- end}}
+		<string>{{xml .Task}}</string>
+	</array>
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>PATH</key>
+		<string>{{xml .Path}}</string>
+	</dict>
+	<key>StartInterval</key>
+	<integer>{{.Seconds}}</integer>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>LowPriorityIO</key>
+	<true/>
+	<key>ProcessType</key>
+	<string>Background</string>
+</dict>
+</plist>
+`))
+
+// Write the property list of the agent
+func (a *Agent) WritePlist(w io.Writer) error {
+	return plistTemplate.Execute(w, struct {
+		*Agent
+		Label   string
+		Path    string
+		Seconds int64
+	}{
+		Agent: a,
+		Label: a.Label(),
+		// launchd starts agents with a minimal PATH, taproom needs to find brew, du and gh
+		Path:    os.Getenv("PATH"),
+		Seconds: int64(a.Interval.Seconds()),
+	})
+}
+
+func plistPath(label string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", label+".plist"), nil
+}
+
+// launchd domain of the agents of the current user
+func guiDomain() string {
+	return "gui/" + strconv.Itoa(os.Getuid())
+}
+
+func launchctl(args ...string) error {
+	output, err := exec.Command("launchctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("launchctl %v: %w: %s", args, err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// Write the plist to ~/Library/LaunchAgents and load it, replacing the agent if it's already installed
+func Install(a *Agent) (string, error) {
+	path, err := plistPath(a.Label())
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := a.WritePlist(&b); err != nil {
+		return "", fmt.Errorf("failed to generate %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	// Unload the old agent first, otherwise launchd keeps running it
+	_ = launchctl("bootout", guiDomain()+"/"+a.Label())
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := launchctl("bootstrap", guiDomain(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Unload the agent of a task and delete its plist
+func Remove(task string) (string, error) {
+	label := labelPrefix + task
+	path, err := plistPath(label)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", fmt.Errorf("agent %s is not installed", label)
+	}
+	if err := launchctl("bootout", guiDomain()+"/"+label); err != nil {
+		// Still remove the plist of an agent that isn't loaded
+		log.Printf("failed to unload %s: %v", label, err)
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// Whether the agent of a task is installed and loaded
+func Status(task string) (installed, loaded bool) {
+	label := labelPrefix + task
+	if path, err := plistPath(label); err == nil {
+		_, err := os.Stat(path)
+		installed = err == nil
+	}
+	loaded = launchctl("print", guiDomain()+"/"+label) == nil
+	return installed, loaded
+}
+
+// Flags set on the command line, passed on to the task so it loads the same data
+func taskArgs(flags *pflag.FlagSet) []string {
+	args := []string{}
+	flags.Visit(func(f *pflag.Flag) {
+		if f.Name == "agent-interval" {
+			return
+		}
+		// Slices print like [a,b], which they don't parse back from
+		value := f.Value.String()
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			value = strings.Join(slice.GetSlice(), ",")
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", f.Name, value))
+	})
+	return args
+}
+
+// Handle `taproom agent install|remove|status [task]`
+func Run(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: taproom agent install|remove|status [%s]", strings.Join(tasks, "|"))
+	}
+	task := tasks[0]
+	if len(args) > 1 {
+		task = args[1]
+	}
+	if !slices.Contains(tasks, task) {
+		return fmt.Errorf("unknown task %s, expected one of: %s", task, strings.Join(tasks, ", "))
+	}
+
+	switch args[0] {
+	case "install":
+		program, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate taproom: %w", err)
+		}
+		path, err := Install(&Agent{Task: task, Program: program, Args: taskArgs(pflag.CommandLine), Interval: *flagAgentInterval})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Installed %s, running taproom %s every %s\n", path, task, *flagAgentInterval)
+	case "remove":
+		path, err := Remove(task)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Removed %s\n", path)
+	case "status":
+		installed, loaded := Status(task)
+		fmt.Fprintf(w, "%s: installed %v, loaded %v\n", labelPrefix+task, installed, loaded)
+	default:
+		return fmt.Errorf("unknown agent command %s, expected install, remove or status", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package launchd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestWritePlist(t *testing.T) {
+	t.Setenv("PATH", "/opt/homebrew/bin:/usr/bin")
+	agent := Agent{
+		Task:     "prewarm",
+		Program:  "/opt/homebrew/bin/taproom",
+		Args:     []string{"--fetch-build-errors=true"},
+		Interval: 4 * time.Hour,
+	}
+	var b bytes.Buffer
+	if err := agent.WritePlist(&b); err != nil {
+		t.Fatalf("failed to write plist: %v", err)
+	}
+
+	// The plist must be well-formed XML with the arguments in order
+	var plist struct {
+		Strings  []string `xml:"dict>array>string"`
+		Integers []int    `xml:"dict>integer"`
+	}
+	if err := xml.Unmarshal(b.Bytes(), &plist); err != nil {
+		t.Fatalf("failed to parse plist: %v\n%s", err, b.String())
+	}
+	want := []string{"/opt/homebrew/bin/taproom", "--fetch-build-errors=true", "prewarm"}
+	if strings.Join(plist.Strings, " ") != strings.Join(want, " ") {
+		t.Errorf("expected program arguments %v, got %v", want, plist.Strings)
+	}
+	if len(plist.Integers) != 1 || plist.Integers[0] != 4*60*60 {
+		t.Errorf("expected an interval of 14400 seconds, got %v", plist.Integers)
+	}
+	if !strings.Contains(b.String(), "<string>com.github.hzqtc.taproom.prewarm</string>") {
+		t.Errorf("expected the label of the prewarm agent, got\n%s", b.String())
+	}
+}
+
+func TestTaskArgs(t *testing.T) {
+	flags := pflag.NewFlagSet("taproom", pflag.ContinueOnError)
+	flags.StringSlice("filters", nil, "")
+	flags.Bool("fetch-build-errors", false, "")
+	flags.Duration("agent-interval", time.Hour, "")
+	flags.String("unset", "", "")
+	if err := flags.Parse([]string{"--filters=installed,outdated", "--fetch-build-errors", "--agent-interval=2h"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags are visited in lexicographical order, leaving out the ones not set and the agent interval
+	want := []string{"--fetch-build-errors=true", "--filters=installed,outdated"}
+	if got := taskArgs(flags); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("expected task arguments %v, got %v", want, got)
+	}
+}
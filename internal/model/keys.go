@@ -5,23 +5,56 @@ import "github.com/charmbracelet/bubbles/key"
 // keyMap defines the keybindings for the application.
 type keyMap struct {
 	// General
-	SwitchFocus key.Binding
-	FocusSearch key.Binding
-	Enter       key.Binding
-	Esc         key.Binding
-	Refresh     key.Binding
-	Quit        key.Binding
+	SwitchFocus      key.Binding
+	FocusSearch      key.Binding
+	Enter            key.Binding
+	NextItem         key.Binding
+	PrevItem         key.Binding
+	JumpBack         key.Binding
+	QuickJump        key.Binding
+	Esc              key.Binding
+	Refresh          key.Binding
+	RefreshInstalled key.Binding
+	FocusQueue       key.Binding
+	History          key.Binding
+	Rollback         key.Binding
+	FullOutput       key.Binding
+	Taps             key.Binding
+	MigrateTaps      key.Binding
+	Categories       key.Binding
+	Brewfile         key.Binding
+	Sync             key.Binding
+	Doctor           key.Binding
+	Integrations     key.Binding
+	Cache            key.Binding
+	Caveats          key.Binding
+	DiskUsage        key.Binding
+	Export           key.Binding
+	FullCatalog      key.Binding
+	Provenance       key.Binding
+	Inspect          key.Binding
+	WhyInstalled     key.Binding
+	Quit             key.Binding
 
 	// Package Commands
 	OpenHomePage key.Binding
 	OpenBrewUrl  key.Binding
 	OpenRelease  key.Binding
+	EditSource   key.Binding
+	Shell        key.Binding
+	Verify       key.Binding
 	Upgrade      key.Binding
 	UpgradeAll   key.Binding
+	TimeBox      key.Binding
 	Install      key.Binding
+	Reinstall    key.Binding
+	WithOptions  key.Binding
 	Remove       key.Binding
+	Zap          key.Binding
 	Pin          key.Binding
 	Unpin        key.Binding
+	Snooze       key.Binding
+	Link         key.Binding
 	CleanUp      key.Binding
 }
 
@@ -29,23 +62,56 @@ type keyMap struct {
 func defaultKeyMap() keyMap {
 	return keyMap{
 		// General
-		SwitchFocus: key.NewBinding(key.WithKeys("tab")),
-		FocusSearch: key.NewBinding(key.WithKeys("/")),
-		Enter:       key.NewBinding(key.WithKeys("enter")),
-		Esc:         key.NewBinding(key.WithKeys("esc")),
-		Refresh:     key.NewBinding(key.WithKeys("R")),
-		Quit:        key.NewBinding(key.WithKeys("q", "ctrl+c")),
+		SwitchFocus:      key.NewBinding(key.WithKeys("tab")),
+		FocusSearch:      key.NewBinding(key.WithKeys("/")),
+		Enter:            key.NewBinding(key.WithKeys("enter")),
+		NextItem:         key.NewBinding(key.WithKeys("]")),
+		PrevItem:         key.NewBinding(key.WithKeys("[")),
+		JumpBack:         key.NewBinding(key.WithKeys("backspace")),
+		QuickJump:        key.NewBinding(key.WithKeys("'")),
+		Esc:              key.NewBinding(key.WithKeys("esc")),
+		Refresh:          key.NewBinding(key.WithKeys("R")),
+		RefreshInstalled: key.NewBinding(key.WithKeys("ctrl+r")),
+		FocusQueue:       key.NewBinding(key.WithKeys("Q")),
+		History:          key.NewBinding(key.WithKeys("H")),
+		Rollback:         key.NewBinding(key.WithKeys("ctrl+t")),
+		FullOutput:       key.NewBinding(key.WithKeys("O")),
+		Taps:             key.NewBinding(key.WithKeys("T")),
+		MigrateTaps:      key.NewBinding(key.WithKeys("m")),
+		Categories:       key.NewBinding(key.WithKeys("#")),
+		Brewfile:         key.NewBinding(key.WithKeys("B")),
+		Sync:             key.NewBinding(key.WithKeys("ctrl+s")),
+		Doctor:           key.NewBinding(key.WithKeys("D")),
+		Integrations:     key.NewBinding(key.WithKeys("ctrl+g")),
+		Cache:            key.NewBinding(key.WithKeys("A")),
+		Caveats:          key.NewBinding(key.WithKeys("M")),
+		DiskUsage:        key.NewBinding(key.WithKeys("d")),
+		Export:           key.NewBinding(key.WithKeys("ctrl+e")),
+		FullCatalog:      key.NewBinding(key.WithKeys("C")),
+		Provenance:       key.NewBinding(key.WithKeys("w")),
+		Inspect:          key.NewBinding(key.WithKeys("X")),
+		WhyInstalled:     key.NewBinding(key.WithKeys("W")),
+		Quit:             key.NewBinding(key.WithKeys("q", "ctrl+c")),
 
 		// Package Commands
 		OpenHomePage: key.NewBinding(key.WithKeys("h")),
 		OpenBrewUrl:  key.NewBinding(key.WithKeys("b")),
 		OpenRelease:  key.NewBinding(key.WithKeys("r")),
+		EditSource:   key.NewBinding(key.WithKeys("E")),
+		Shell:        key.NewBinding(key.WithKeys("!")),
+		Verify:       key.NewBinding(key.WithKeys("V")),
 		Upgrade:      key.NewBinding(key.WithKeys("u")),
 		UpgradeAll:   key.NewBinding(key.WithKeys("U")),
+		TimeBox:      key.NewBinding(key.WithKeys("F")),
 		Install:      key.NewBinding(key.WithKeys("t")),
+		Reinstall:    key.NewBinding(key.WithKeys("Y")),
+		WithOptions:  key.NewBinding(key.WithKeys("I")),
 		Remove:       key.NewBinding(key.WithKeys("x")),
+		Zap:          key.NewBinding(key.WithKeys("z")),
 		Pin:          key.NewBinding(key.WithKeys("p")),
 		Unpin:        key.NewBinding(key.WithKeys("P")),
+		Snooze:       key.NewBinding(key.WithKeys("Z")),
+		Link:         key.NewBinding(key.WithKeys("l")),
 		CleanUp:      key.NewBinding(key.WithKeys("L")),
 	}
 }
@@ -1,16 +1,23 @@
 package model
 
 import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
 	"strings"
-	"taproom/internal/brew"
-	"taproom/internal/data"
-	"taproom/internal/ui"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/stopwatch"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/brew"
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/gh"
+	"github.com/hzqtc/taproom/internal/ui"
+	"github.com/hzqtc/taproom/internal/util"
 	"github.com/pkg/browser"
 )
 
@@ -21,6 +28,7 @@ const (
 	focusTable focusMode = iota
 	focusDetail
 	focusSearch
+	focusQueue
 )
 
 type model struct {
@@ -36,19 +44,44 @@ type model struct {
 	statsView   ui.StatsModel
 	outputView  ui.OutputModel
 	loadingView ui.LoadingScreenModel
+	prompt      ui.PromptModel
+	queue       ui.QueueModel
+	historyView ui.HistoryModel
+	pager       ui.PagerModel
+	tapsView    ui.TapsModel
+	categories  ui.CategoriesModel
+	brewfile    ui.BrewfileModel
+	syncView    ui.SyncModel
+	rollback    ui.RollbackModel
+	doctor      ui.DoctorModel
+	toolsView   ui.IntegrationsModel
+	cacheView   ui.CacheModel
+	caveatsView ui.CaveatsModel
+	diskUsage   ui.DiskUsageModel
+	dashboard   ui.DashboardModel
+	summary     ui.SummaryModel
+	options     ui.OptionsModel
 
 	// State
-	isExecuting bool
-	focusMode   focusMode
-	width       int
-	height      int
+	isExecuting      bool
+	partialCatalog   bool            // Only installed packages are loaded
+	lastOutput       []string        // Complete output of the last finished command
+	jumpStack        []string        // Packages jumped away from in the details panel, the last one is the most recent
+	deferredUpgrades []*data.Package // Outdated packages left out of a time-boxed upgrade
+	dashboardOpened  bool            // The dashboard of --dashboard is only opened by the first load
+	journalChecked   bool            // A batch interrupted in a previous run is only offered once
+	watching         bool            // Watching installed packages starts once the first load set them
+	jumpMode         bool            // The next key picks the first letter to jump to
+	focusMode        focusMode
+	width            int
+	height           int
 
 	// Keybindings
 	keys keyMap
 }
 
 func InitialModel() model {
-	return model{
+	m := model{
 		table:       ui.NewPackageTableModel(),
 		detailPanel: ui.NewDetailsPanelModel(),
 		search:      ui.NewSearchInputModel(),
@@ -57,8 +90,31 @@ func InitialModel() model {
 		statsView:   ui.NewStatsModel(),
 		outputView:  ui.NewOutputModel(),
 		loadingView: ui.NewLoadingScreenModel(),
+		prompt:      ui.NewPromptModel(),
+		queue:       ui.NewQueueModel(),
+		historyView: ui.NewHistoryModel(),
+		pager:       ui.NewPagerModel(),
+		tapsView:    ui.NewTapsModel(),
+		categories:  ui.NewCategoriesModel(),
+		brewfile:    ui.NewBrewfileModel(),
+		syncView:    ui.NewSyncModel(),
+		rollback:    ui.NewRollbackModel(),
+		doctor:      ui.NewDoctorModel(),
+		toolsView:   ui.NewIntegrationsModel(),
+		cacheView:   ui.NewCacheModel(),
+		caveatsView: ui.NewCaveatsModel(),
+		diskUsage:   ui.NewDiskUsageModel(),
+		dashboard:   ui.NewDashboardModel(),
+		summary:     ui.NewSummaryModel(),
+		options:     ui.NewOptionsModel(),
 		keys:        defaultKeyMap(),
 	}
+	if warning := brew.CacheDirWarning(); warning != "" {
+		m.outputView.Append(warning)
+	}
+	// Init can't keep state changes, so decide here what loadData will load first
+	m.partialCatalog = ui.IsInstalledOnly(m.filterView.Value())
+	return m
 }
 
 func (m model) Init() tea.Cmd {
@@ -66,9 +122,11 @@ func (m model) Init() tea.Cmd {
 }
 
 func (m *model) loadData() tea.Cmd {
+	// Only installed packages can be shown, the full catalog is loaded once the filter is cleared
+	m.partialCatalog = ui.IsInstalledOnly(m.filterView.Value())
 	return tea.Batch(
 		m.loadingView.StartLoading(),
-		brew.LoadData(m.table.ShowPackageInstalls(), m.table.ShowPackageSizes(), m.loadingView.Progress()),
+		brew.LoadData(m.table.ShowPackageInstalls(), m.table.ShowPackageSizes(), m.partialCatalog, m.loadingView.Progress()),
 	)
 }
 
@@ -86,9 +144,137 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case brew.DataLoadedMsg:
 		m.allPackages = msg.Packages
-		cmds = append(cmds, m.loadingView.StopLoading(), m.filterPackages())
+		m.table.SetInstallsUnavailable(slices.Contains(msg.Unavailable, brew.SourceAnalytics))
+		warnings := []string{}
+		for _, source := range msg.Unavailable {
+			warnings = append(warnings, fmt.Sprintf("%s unavailable", source))
+		}
+		m.statsView.SetWarnings(warnings)
+		cmds = append(cmds, m.loadingView.StopLoading(), m.filterPackages(), brew.CheckHealth(), brew.FetchReleaseInfo())
+		m.refreshDashboard()
+		if *flagDashboard && !m.dashboardOpened {
+			m.dashboardOpened = true
+			m.dashboard.Show(brew.GetUpgradablePackages(), len(brew.GetPinnedOutdatedPackages()))
+		}
+		if msg.Summary != nil {
+			m.summary.Show(msg.Summary)
+		}
+		if !brew.IsCatalogScopeChosen() && !m.prompt.IsActive() {
+			m.askCatalogScope()
+		}
+		if len(msg.BrokenTapPackages) > 0 && !m.prompt.IsActive() {
+			m.warnBrokenTaps(msg.BrokenTapPackages)
+		}
+		if !m.journalChecked && !m.prompt.IsActive() {
+			m.journalChecked = true
+			m.offerInterruptedBatch()
+		}
+		if !m.watching {
+			// Changes are applied to the loaded packages, so they aren't picked up while loading them
+			m.watching = true
+			cmds = append(cmds, brew.WatchInstalls())
+		}
+		m.remindSnoozeOver()
+		m.updateLayout()
+
+	case brew.HealthCheckedMsg:
+		if pkgs := msg.Apply(); len(pkgs) > 0 {
+			unhealthy := []*data.Package{}
+			for _, pkg := range pkgs {
+				if pkg.IsUnhealthy() {
+					unhealthy = append(unhealthy, pkg)
+				}
+			}
+			if len(unhealthy) > 0 {
+				m.outputView.Append(fmt.Sprintf("Health check found problems with: %s, press Y on them to reinstall", strings.Join(packageNames(unhealthy), ", ")))
+			}
+			m.table.UpdateRows()
+			m.updateLayout()
+		}
+
+	case brew.InstallsChangedMsg:
+		// Also sent for commands run by taproom, which update packages themselves
+		if pkgs := msg.Apply(); len(pkgs) > 0 && !m.isExecuting {
+			m.outputView.Append(fmt.Sprintf("Picked up changes made outside taproom: %s", strings.Join(packageNames(pkgs), ", ")))
+			m.refreshDashboard()
+			m.detailPanel.Refresh()
+			cmds = append(cmds, m.filterPackages())
+			m.updateLayout()
+		}
+		cmds = append(cmds, brew.WatchInstalls())
+
+	case brew.InstalledRefreshedMsg:
+		pkgs := msg.Apply()
+		if len(pkgs) == 0 {
+			m.outputView.Append("Refreshed installed packages, nothing changed")
+		} else {
+			m.outputView.Append(fmt.Sprintf("Refreshed installed packages, %d changed: %s", len(pkgs), strings.Join(packageNames(pkgs), ", ")))
+		}
+		m.refreshDashboard()
+		m.detailPanel.Refresh()
+		cmds = append(cmds, m.filterPackages())
 		m.updateLayout()
 
+	case catalogScopeChangedMsg:
+		cmds = append(cmds, m.loadData())
+
+	case snoozedMsg:
+		m.table.UpdateRows()
+		m.refreshDashboard()
+		cmds = append(cmds, m.filterPackages())
+		m.updateLayout()
+
+	case upgradePinnedMsg:
+		names := strings.Join(packageNames(msg.pkgs), ", ")
+		cmds = append(cmds, m.runCommand("Upgrade pinned "+names, brew.UpgradePinnedPackages(msg.pkgs)))
+
+	case upgradeBudgetMsg:
+		fit, deferred := brew.PlanTimeBoxedUpgrade(brew.GetUpgradablePackages(), msg.budget)
+		if len(fit) == 0 {
+			m.outputView.Append(fmt.Sprintf("No upgrade is expected to finish within %s", formatBudget(msg.budget)))
+			m.updateLayout()
+			break
+		}
+		m.deferredUpgrades = deferred
+		cmds = append(cmds, m.runChecked(
+			fmt.Sprintf(
+				"Upgrade %d packages within %s (~%s, %d deferred)",
+				len(fit), formatBudget(msg.budget), brew.EstimateUpgradeTime(fit).Round(time.Second), len(deferred),
+			),
+			fit,
+			brew.UpgradeAllOf(fit),
+			brew.PowerWarnings()...,
+		))
+
+	case resumeBatchMsg:
+		brew.DiscardInterruptedBatch()
+		cmds = append(cmds, m.runCommand(
+			fmt.Sprintf("Resume interrupted %s (%d packages)", msg.journal.Description(), len(msg.pkgs)),
+			msg.journal.Resume(msg.pkgs),
+		))
+	case discardBatchMsg:
+		brew.DiscardInterruptedBatch()
+
+	case runSyncMsg:
+		cmds = append(cmds, m.runSync(msg.diff, msg.uninstall))
+		m.updateLayout()
+
+	case resolveConflictsMsg:
+		names := strings.Join(packageNames(msg.conflicts), ", ")
+		if msg.uninstall {
+			cmds = append(cmds, m.runCommand("Uninstall "+names, brew.UninstallPackages(msg.conflicts, false)))
+		} else {
+			cmds = append(cmds, m.runCommand("Unlink "+names, brew.UnlinkPackages(msg.conflicts)))
+		}
+		// Queued behind the command above, which pauses the queue if it fails
+		cmds = append(cmds, m.runChecked(msg.label, []*data.Package{msg.pkg}, msg.install))
+
+	case resumeUpgradeMsg:
+		cmds = append(cmds, m.runCommand(
+			fmt.Sprintf("Resume upgrade all (%d packages)", len(msg.pkgs)),
+			brew.UpgradeAllOf(msg.pkgs),
+		))
+
 	case brew.DataLoadingErrMsg:
 		cmds = append(cmds, m.loadingView.SetError(msg.Err.Error()))
 
@@ -107,30 +293,231 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		cmds = append(cmds, brew.StreamOutput(msg.Ch))
 
+	case brew.CommandProgressMsg:
+		m.outputView.SetProgress(msg.Percent)
+		m.updateLayout()
+		cmds = append(cmds, brew.StreamOutput(msg.Ch))
+
+	case brew.CommandEtaMsg:
+		m.outputView.SetEta(msg.Remaining, msg.Done, msg.Total)
+		m.updateLayout()
+		cmds = append(cmds, brew.StreamOutput(msg.Ch))
+
 	case brew.CommandFinishMsg:
 		m.isExecuting = false
+		// Keep the complete output around for the pager, even when it's cleared from view
+		m.lastOutput = m.outputView.Lines()
 		if msg.Err == nil {
 			// Command was successful, clear output and update package state
 			m.outputView.Clear()
 			brew.UpdatePackageForAction(msg.Command, msg.Pkgs)
 			m.table.UpdateRows()
+			m.detailPanel.Refresh()
+			if msg.Command == brew.BrewCommandUpgradeAll {
+				m.reportDeferred()
+				m.reportSkippedPinned()
+			}
+			switch msg.Command {
+			case brew.BrewCommandInstall, brew.BrewCommandUpgrade, brew.BrewCommandUpgradeAll:
+				m.rememberCaveats(msg.Pkgs)
+			}
+			if msg.Command == brew.BrewCommandUninstall {
+				// Other formulae may have lost their dependencies
+				cmds = append(cmds, brew.CheckHealth())
+			}
+			if msg.Command == brew.BrewCommandTapRepair || msg.Command == brew.BrewCommandMigrate {
+				// Packages of repaired taps can be loaded now, and migrated packages from their new taps
+				cmds = append(cmds, m.loadData())
+			}
+			cmds = append(cmds, m.runNextQueued())
 		} else {
 			m.outputView.SetError()
+			// Keep the error visible, queued commands need to be resumed manually
+			m.queue.SetPaused(m.queue.Len() > 0)
+			if msg.Command == brew.BrewCommandUpgradeAll {
+				m.reportDeferred()
+				m.offerResumeUpgrade(msg.Pkgs, msg.Failed)
+			} else if len(msg.Failed) > 0 {
+				// Packages run one by one, keep the state of the ones that succeeded
+				brew.UpdatePackageForAction(msg.Command, withoutPackages(msg.Pkgs, msg.Failed))
+				m.table.UpdateRows()
+			}
 		}
 		// If there are error, it should already be displayed in the output
 		m.updateLayout()
 
+	case brew.TapsLoadedMsg:
+		m.tapsView.SetTaps(msg.Taps, msg.Err)
+	case brew.TapMigrationsFoundMsg:
+		m.confirmTapMigrations(msg.Plan, msg.Err)
+
+	case brew.BrewfileLoadedMsg:
+		m.brewfile.SetDiff(msg.Diff, msg.Err)
+	case brew.CleanupPreviewMsg:
+		m.confirmCleanup(msg.Preview, msg.Err)
+	case brew.SourceResolvedMsg:
+		if msg.Err != nil {
+			m.outputView.Append(msg.Err.Error())
+			m.updateLayout()
+		} else {
+			cmds = append(cmds, brew.EditSource(msg.Path))
+		}
+	case brew.SourceEditedMsg:
+		if msg.Err != nil {
+			m.outputView.Append(fmt.Sprintf("Failed to run the editor: %v", msg.Err))
+			m.updateLayout()
+		}
+	case brew.PackageVerifiedMsg:
+		if msg.Err != nil {
+			m.outputView.Append(msg.Err.Error())
+			m.updateLayout()
+		} else {
+			msg.Pkg.Verification = msg.Verification
+			if m.table.Selected() == msg.Pkg {
+				m.detailPanel.SetPackage(msg.Pkg)
+			}
+		}
+	case brew.ShellExitedMsg:
+		if msg.Err != nil {
+			m.outputView.Append(msg.Err.Error())
+			m.updateLayout()
+		}
+	case brew.DiagnosticsLoadedMsg:
+		m.doctor.SetDiagnostics(msg.Diagnostics)
+	case brew.RollbackLoadedMsg:
+		m.rollback.SetPlans(msg.Snapshots, msg.Plans)
+	case brew.SyncLoadedMsg:
+		m.syncView.SetDiff(msg.Diff, msg.Err)
+	case brew.GlobalToolsLoadedMsg:
+		m.toolsView.SetManagers(msg.Managers)
+	case ui.ExportedMsg:
+		if msg.Err != nil {
+			m.outputView.Append(msg.Err.Error())
+		} else {
+			m.outputView.Append(fmt.Sprintf("Exported %d packages to %s", msg.Count, msg.Path))
+		}
+		m.updateLayout()
+	case brew.CacheLoadedMsg:
+		m.cacheView.SetReport(msg.Report, msg.Err)
+	case brew.CacheEntryDeletedMsg:
+		m.cacheView.Remove(msg.Entry, msg.Err)
+
 	case ui.TableSelectionChangedMsg:
 		m.detailPanel.SetPackage(msg.Selected)
+		if pkg := msg.Selected; pkg != nil && pkg.Analytics == nil && m.table.ShowPackageInstalls() {
+			// Wait for the selection to settle so scrolling through the table doesn't fetch every package
+			cmds = append(cmds, tea.Tick(analyticsFetchDelay, func(time.Time) tea.Msg {
+				return analyticsDelayMsg{pkg: pkg}
+			}))
+		}
+		if pkg := msg.Selected; pkg != nil && (pkg.Repo == nil || m.table.ShowStars()) {
+			cmds = append(cmds, tea.Tick(analyticsFetchDelay, func(time.Time) tea.Msg {
+				return repoInfoDelayMsg{pkg: pkg}
+			}))
+		}
+
+	case analyticsDelayMsg:
+		if m.table.Selected() == msg.pkg {
+			cmds = append(cmds, brew.FetchPackageAnalytics(msg.pkg))
+		}
+
+	case repoInfoDelayMsg:
+		if m.table.Selected() == msg.pkg {
+			pkgs := []*data.Package{msg.pkg}
+			if m.table.ShowStars() {
+				pkgs = append(pkgs, m.table.PackagesInView()...)
+			}
+			cmds = append(cmds, gh.FetchRepoInfo(pkgs))
+		}
+
+	case gh.RepoInfoMsg:
+		if msg.Err != nil {
+			log.Printf("failed to fetch github repositories: %v", msg.Err)
+		}
+		if m.table.ShowStars() {
+			m.table.UpdateRows()
+		}
+		if selected := m.table.Selected(); slices.Contains(msg.Pkgs, selected) {
+			m.detailPanel.SetPackage(selected)
+		}
+
+	case brew.ReleaseInfoMsg:
+		if selected := m.table.Selected(); slices.Contains(msg.Apply(), selected) {
+			m.detailPanel.SetPackage(selected)
+		}
+		cmds = append(cmds, msg.Next())
+
+	case brew.PackageInspectedMsg:
+		m.pager.Show(msg.Lines)
+
+	case brew.PackageAnalyticsMsg:
+		if msg.Err != nil {
+			log.Printf("failed to fetch analytics of %s: %v", msg.Pkg.Name, msg.Err)
+		} else if m.table.Selected() == msg.Pkg {
+			m.detailPanel.SetPackage(msg.Pkg)
+		}
 
 	case ui.SearchMsg:
-		cmds = append(cmds, m.filterPackages())
+		// A search typed while loading is applied once the data arrives
+		if !m.loadingView.IsLoading() {
+			cmds = append(cmds, m.filterPackages())
+		}
 
 	case ui.FilterChangedMsg:
-		cmds = append(cmds, m.filterPackages())
+		if m.partialCatalog && !ui.IsInstalledOnly(m.filterView.Value()) {
+			cmds = append(cmds, m.loadData())
+		} else {
+			cmds = append(cmds, m.filterPackages())
+		}
 
 	case tea.KeyMsg:
-		if m.focusMode == focusSearch {
+		if m.prompt.IsActive() {
+			// A pending prompt takes all key presses until it's answered or dismissed
+			label := strings.TrimSuffix(m.prompt.Title(), "?")
+			isChoice := m.prompt.IsChoice()
+			m.prompt, cmd = m.prompt.Update(msg)
+			if cmd != nil && !isChoice {
+				cmd = m.runCommand(label, cmd)
+			}
+			cmds = append(cmds, cmd)
+			m.updateLayout()
+		} else if m.loadingView.HasError() {
+			cmds = append(cmds, m.handleLoadingErrorKeys(msg))
+		} else if m.loadingView.IsLoading() {
+			cmds = append(cmds, m.handleLoadingKeys(msg))
+		} else if m.options.IsActive() {
+			cmds = append(cmds, m.handleOptionsKeys(msg))
+		} else if m.pager.IsVisible() {
+			cmds = append(cmds, m.handlePagerKeys(msg))
+		} else if m.historyView.IsVisible() {
+			cmds = append(cmds, m.handleHistoryKeys(msg))
+		} else if m.tapsView.IsVisible() {
+			cmds = append(cmds, m.handleTapsKeys(msg))
+		} else if m.categories.IsVisible() {
+			cmds = append(cmds, m.handleCategoriesKeys(msg))
+		} else if m.brewfile.IsVisible() {
+			cmds = append(cmds, m.handleBrewfileKeys(msg))
+		} else if m.rollback.IsVisible() {
+			cmds = append(cmds, m.handleRollbackKeys(msg))
+		} else if m.syncView.IsVisible() {
+			cmds = append(cmds, m.handleSyncKeys(msg))
+		} else if m.doctor.IsVisible() {
+			cmds = append(cmds, m.handleDoctorKeys(msg))
+		} else if m.toolsView.IsVisible() {
+			cmds = append(cmds, m.handleIntegrationsKeys(msg))
+		} else if m.cacheView.IsVisible() {
+			cmds = append(cmds, m.handleCacheKeys(msg))
+		} else if m.caveatsView.IsVisible() {
+			cmds = append(cmds, m.handleCaveatsKeys(msg))
+		} else if m.diskUsage.IsVisible() {
+			cmds = append(cmds, m.handleDiskUsageKeys(msg))
+		} else if m.summary.IsVisible() {
+			cmds = append(cmds, m.handleSummaryKeys(msg))
+		} else if m.dashboard.IsVisible() {
+			cmds = append(cmds, m.handleDashboardKeys(msg))
+		} else if m.jumpMode {
+			cmds = append(cmds, m.handleJumpKeys(msg))
+		} else if m.focusMode == focusSearch {
 			cmds = append(cmds, m.handleSearchInputKeys(msg))
 		} else {
 			// General keys when focus is not on search
@@ -140,7 +527,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				switch m.focusMode {
 				case focusTable:
 					m.focusMode = focusDetail
-				case focusDetail:
+				case focusDetail, focusQueue:
 					m.focusMode = focusTable
 				}
 				m.updateFocusBorder()
@@ -150,10 +537,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, textinput.Blink)
 			case key.Matches(msg, m.keys.Refresh):
 				cmds = append(cmds, m.loadData())
+			case key.Matches(msg, m.keys.RefreshInstalled):
+				cmds = append(cmds, brew.RefreshInstalled(m.table.ShowPackageSizes()))
 			case key.Matches(msg, m.keys.Quit):
 				return m, tea.Quit
 			default:
 				switch m.focusMode {
+				case focusQueue:
+					cmds = append(cmds, m.handleQueueKeys(msg))
 				case focusDetail:
 					cmds = append(cmds, m.handleDetailsPanelKeys(msg))
 				case focusTable:
@@ -182,10 +573,39 @@ func (m *model) handleSearchInputKeys(msg tea.KeyMsg) tea.Cmd {
 	return cmd
 }
 
+// The key after the jump key picks the first letter, the table jumps at most once
+func (m *model) handleJumpKeys(msg tea.KeyMsg) tea.Cmd {
+	m.jumpMode = false
+	if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 {
+		return nil
+	}
+	cmd, ok := m.table.JumpTo(msg.Runes[0])
+	if !ok {
+		m.outputView.Append(fmt.Sprintf("No package starts with %q", msg.Runes[0]))
+		m.updateLayout()
+	}
+	return cmd
+}
+
 func (m *model) handleTableKeys(msg tea.KeyMsg) tea.Cmd {
 	var cmd tea.Cmd
 	selectedPkg := m.table.Selected()
 
+	if selectedPkg != nil && selectedPkg.IsMasApp() && key.Matches(msg, m.keys.EditSource, m.keys.Shell, m.keys.Verify,
+		m.keys.Install, m.keys.Reinstall, m.keys.WithOptions, m.keys.Remove, m.keys.Zap, m.keys.Pin, m.keys.Unpin,
+		m.keys.Link, m.keys.Inspect, m.keys.WhyInstalled) {
+		m.outputView.Append(fmt.Sprintf("%s is an App Store app, mas can only upgrade it", selectedPkg.Name))
+		m.updateLayout()
+		return nil
+	}
+	if selectedPkg != nil && selectedPkg.IsWhalebrew() && key.Matches(msg, m.keys.EditSource, m.keys.Shell, m.keys.Verify,
+		m.keys.Upgrade, m.keys.Install, m.keys.Reinstall, m.keys.WithOptions, m.keys.Zap, m.keys.Pin, m.keys.Unpin,
+		m.keys.Link, m.keys.Inspect, m.keys.WhyInstalled) {
+		m.outputView.Append(fmt.Sprintf("%s is a whalebrew command, whalebrew can only uninstall it", selectedPkg.Name))
+		m.updateLayout()
+		return nil
+	}
+
 	switch {
 	case key.Matches(msg, m.keys.Enter):
 		m.focusMode = focusDetail
@@ -198,8 +618,8 @@ func (m *model) handleTableKeys(msg tea.KeyMsg) tea.Cmd {
 
 	// Commands
 	case key.Matches(msg, m.keys.OpenHomePage):
-		if selectedPkg != nil && selectedPkg.Homepage != "" {
-			browser.OpenURL(selectedPkg.Homepage)
+		if selectedPkg != nil && selectedPkg.Homepage() != "" {
+			browser.OpenURL(selectedPkg.Homepage())
 		}
 	case key.Matches(msg, m.keys.OpenBrewUrl):
 		if selectedPkg != nil {
@@ -209,36 +629,168 @@ func (m *model) handleTableKeys(msg tea.KeyMsg) tea.Cmd {
 		if selectedPkg != nil && selectedPkg.ReleaseInfo != nil {
 			browser.OpenURL(selectedPkg.ReleaseInfo.Url)
 		}
+	case key.Matches(msg, m.keys.EditSource):
+		if selectedPkg != nil {
+			cmd = brew.ResolveSource(selectedPkg)
+		}
+	case key.Matches(msg, m.keys.Shell):
+		if selectedPkg != nil && selectedPkg.IsInstalled && !selectedPkg.IsCask {
+			cmd = brew.PackageShell(selectedPkg)
+		}
+	case key.Matches(msg, m.keys.Verify):
+		if selectedPkg != nil && selectedPkg.IsInstalled && !selectedPkg.IsCask {
+			cmd = brew.VerifyPackage(selectedPkg)
+		}
 	case key.Matches(msg, m.keys.UpgradeAll):
-		outdatedPkgs := brew.GetOutdatedPackages()
-		if !m.isExecuting && len(outdatedPkgs) > 0 {
-			cmd = brew.UpgradeAllPackages(outdatedPkgs)
+		outdatedPkgs := brew.GetUpgradablePackages()
+		cmds := []tea.Cmd{}
+		if len(outdatedPkgs) > 0 {
+			// Nothing is deferred anymore, e.g. when an earlier time-boxed upgrade was aborted
+			m.deferredUpgrades = nil
+			cmds = append(cmds, m.runChecked(
+				fmt.Sprintf("Upgrade all (%d packages, ~%s)", len(outdatedPkgs), brew.EstimateUpgradeTime(outdatedPkgs).Round(time.Second)),
+				outdatedPkgs,
+				brew.UpgradeAllPackages(outdatedPkgs),
+				brew.PowerWarnings()...,
+			))
+		}
+		if apps := brew.GetOutdatedMasApps(); len(apps) > 0 {
+			cmds = append(cmds, m.runCommand(fmt.Sprintf("Upgrade %d App Store apps", len(apps)), brew.UpgradeMasApps(apps)))
+		}
+		cmd = tea.Batch(cmds...)
+	case key.Matches(msg, m.keys.TimeBox):
+		if outdatedPkgs := brew.GetUpgradablePackages(); len(outdatedPkgs) > 0 {
+			m.askUpgradeBudget(outdatedPkgs)
 		}
 	case key.Matches(msg, m.keys.Upgrade):
-		if !m.isExecuting && selectedPkg != nil && selectedPkg.IsOutdated && !selectedPkg.IsPinned {
-			cmd = brew.UpgradePackage(selectedPkg)
+		if selectedPkg != nil && selectedPkg.IsMasApp() && selectedPkg.IsOutdated {
+			cmd = m.runCommand("Upgrade "+selectedPkg.Name, brew.UpgradeMasApps([]*data.Package{selectedPkg}))
+		} else if selectedPkg != nil && selectedPkg.IsOutdated && !selectedPkg.IsPinned {
+			cmd = m.runChecked("Upgrade "+selectedPkg.Name, []*data.Package{selectedPkg}, brew.UpgradePackage(selectedPkg))
 		}
 	case key.Matches(msg, m.keys.Install):
-		if !m.isExecuting && selectedPkg != nil && !selectedPkg.IsInstalled {
-			cmd = brew.InstallPackage(selectedPkg)
+		if selectedPkg != nil && !selectedPkg.IsInstalled {
+			cmd = m.installPackage("Install "+selectedPkg.Name, selectedPkg, brew.InstallPackage(selectedPkg))
+		}
+	case key.Matches(msg, m.keys.Reinstall):
+		if selectedPkg != nil && selectedPkg.IsInstalled {
+			cmd = m.runChecked("Reinstall "+selectedPkg.Name, []*data.Package{selectedPkg}, brew.ReinstallPackage(selectedPkg))
+		}
+	case key.Matches(msg, m.keys.WithOptions):
+		if selectedPkg != nil && (!selectedPkg.IsInstalled || (selectedPkg.IsOutdated && !selectedPkg.IsPinned)) {
+			cmd = m.options.Show(selectedPkg)
+			m.updateLayout()
 		}
 	case key.Matches(msg, m.keys.Remove):
-		if !m.isExecuting && selectedPkg != nil && selectedPkg.IsInstalled {
-			cmd = brew.UninstallPackage(selectedPkg)
+		if selectedPkg != nil && selectedPkg.IsWhalebrew() && selectedPkg.IsInstalled {
+			cmd = m.runCommand("Uninstall "+selectedPkg.Name, brew.UninstallWhalebrewPackage(selectedPkg))
+		} else if selectedPkg != nil && selectedPkg.IsInstalled {
+			cmd = m.uninstallPackage(selectedPkg)
+		}
+	case key.Matches(msg, m.keys.Zap):
+		if selectedPkg != nil && selectedPkg.IsInstalled && selectedPkg.IsCask {
+			m.zapPackage(selectedPkg)
 		}
 	case key.Matches(msg, m.keys.Pin):
-		if !m.isExecuting && selectedPkg != nil && selectedPkg.IsInstalled && !selectedPkg.IsCask && !selectedPkg.IsPinned {
-			cmd = brew.PinPackage(selectedPkg)
+		if selectedPkg != nil && selectedPkg.IsInstalled && !selectedPkg.IsCask && !selectedPkg.IsPinned {
+			cmd = m.runCommand("Pin "+selectedPkg.Name, brew.PinPackage(selectedPkg))
 		}
 	case key.Matches(msg, m.keys.Unpin):
-		if !m.isExecuting && selectedPkg != nil && selectedPkg.IsPinned {
-			cmd = brew.UnpinPackage(selectedPkg)
+		if selectedPkg != nil && selectedPkg.IsPinned {
+			cmd = m.runCommand("Unpin "+selectedPkg.Name, brew.UnpinPackage(selectedPkg))
+		}
+	case key.Matches(msg, m.keys.Snooze):
+		if selectedPkg != nil && selectedPkg.IsOutdated && !selectedPkg.IsPinned {
+			m.confirmSnooze(selectedPkg)
+		}
+	case key.Matches(msg, m.keys.Link):
+		if selectedPkg != nil && selectedPkg.IsInstalled && !selectedPkg.IsCask {
+			m.confirmLink(selectedPkg)
 		}
 	case key.Matches(msg, m.keys.CleanUp):
-		cmd = brew.Cleanup()
+		cmd = brew.PreviewCleanup()
+	case key.Matches(msg, m.keys.History):
+		m.historyView.Show(brew.LoadHistory())
+	case key.Matches(msg, m.keys.Taps):
+		m.tapsView.Show()
+		cmd = brew.LoadTaps()
+	case key.Matches(msg, m.keys.Categories):
+		m.categories.Show(m.allPackages)
+	case key.Matches(msg, m.keys.Brewfile):
+		m.brewfile.Show()
+		cmd = brew.LoadBrewfile()
+	case key.Matches(msg, m.keys.Rollback):
+		m.rollback.Show()
+		cmd = brew.LoadRollback()
+	case key.Matches(msg, m.keys.Sync):
+		if brew.SyncPath() != "" {
+			m.syncView.Show()
+			cmd = brew.LoadSync()
+		} else {
+			m.outputView.Append("Start with --sync-with pointing to a Brewfile or taproom export of another machine to compare with it")
+			m.updateLayout()
+		}
+	case key.Matches(msg, m.keys.Doctor):
+		m.doctor.Show()
+		cmd = brew.LoadDiagnostics()
+	case key.Matches(msg, m.keys.Integrations):
+		if brew.IntegrationsEnabled() {
+			m.toolsView.Show()
+			cmd = brew.LoadGlobalTools()
+		} else {
+			m.outputView.Append("Integrations are turned off, start with --integrations to list pipx and npm tools")
+			m.updateLayout()
+		}
+	case key.Matches(msg, m.keys.Cache):
+		m.cacheView.Show()
+		cmd = brew.LoadCache()
+	case key.Matches(msg, m.keys.Caveats):
+		m.caveatsView.Show(brew.LoadCaveats())
+	case key.Matches(msg, m.keys.DiskUsage):
+		m.diskUsage.Show(m.allPackages)
+	case key.Matches(msg, m.keys.Export):
+		m.confirmExport()
+	case key.Matches(msg, m.keys.Provenance):
+		m.detailPanel.ToggleProvenance()
+	case key.Matches(msg, m.keys.Inspect):
+		if selectedPkg != nil {
+			m.outputView.Append(fmt.Sprintf("Inspecting the data of %s...", selectedPkg.Name))
+			m.updateLayout()
+			cmd = brew.InspectPackage(selectedPkg)
+		}
+	case key.Matches(msg, m.keys.WhyInstalled):
+		if selectedPkg != nil && selectedPkg.IsInstalled {
+			m.explainInstalled(selectedPkg)
+		}
+	case key.Matches(msg, m.keys.FullCatalog):
+		if brew.GetCatalogScope() == brew.CatalogTrimmed {
+			brew.LoadFullCatalog()
+			cmd = m.loadData()
+		}
+	case key.Matches(msg, m.keys.FullOutput):
+		// Output of the running command, or of the last one
+		if lines := m.outputView.Lines(); len(lines) > 0 {
+			m.pager.Show(lines)
+		} else if len(m.lastOutput) > 0 {
+			m.pager.Show(m.lastOutput)
+		}
+	case key.Matches(msg, m.keys.FocusQueue):
+		if m.queue.Len() > 0 {
+			m.focusMode = focusQueue
+			m.updateFocusBorder()
+		}
+	case key.Matches(msg, m.keys.QuickJump):
+		m.jumpMode = true
+		m.outputView.Append("Jump: press the first letter of a package, any other key cancels")
+		m.updateLayout()
 
 	default:
+		wasOutdated := slices.Contains(m.filterView.Value(), ui.FilterOutdated)
 		m.filterView, cmd = m.filterView.Update(msg)
+		if !wasOutdated && slices.Contains(m.filterView.Value(), ui.FilterOutdated) {
+			// Show what should be upgraded first when switching to outdated packages
+			m.table.SortByPriority()
+		}
 		if cmd == nil {
 			m.table, cmd = m.table.Update(msg)
 		}
@@ -247,21 +799,1052 @@ func (m *model) handleTableKeys(msg tea.KeyMsg) tea.Cmd {
 	return cmd
 }
 
+// Tell which packages installed on request pulled in a package, through the shortest dependency chains
+func (m *model) explainInstalled(pkg *data.Package) {
+	const maxChainsShown = 5
+
+	if !pkg.InstalledAsDependency {
+		m.outputView.Append(fmt.Sprintf("%s was installed on request", pkg.Name))
+	} else if chains := brew.WhyInstalled(pkg); len(chains) == 0 {
+		m.outputView.Append(fmt.Sprintf("%s was installed as a dependency, but nothing installed requires it any more (see brew autoremove)", pkg.Name))
+	} else {
+		m.outputView.Append(fmt.Sprintf("%s is installed as a dependency of:", pkg.Name))
+		chainLines := make([]string, len(chains))
+		for i, chain := range chains {
+			chainLines[i] = strings.Join(chain, " -> ")
+		}
+		for _, line := range truncatedLines(chainLines, maxChainsShown) {
+			m.outputView.Append(line)
+		}
+	}
+	m.updateLayout()
+}
+
+// Ask before linking or unlinking a formula, linking can overwrite files of other formulae
+func (m *model) confirmLink(pkg *data.Package) {
+	if pkg.IsLinked {
+		m.prompt.Show(
+			fmt.Sprintf("Unlink %s?", pkg.Name),
+			[]string{fmt.Sprintf("Its executables and libraries will be removed from %s, the keg stays installed", brew.Prefix())},
+			ui.PromptOption{Key: "a", Desc: "abort"},
+			ui.PromptOption{Key: "y", Desc: "unlink", Action: func() tea.Cmd { return brew.UnlinkPackage(pkg) }},
+		)
+	} else {
+		lines := []string{fmt.Sprintf("Its executables and libraries will be symlinked into %s", brew.Prefix())}
+		if pkg.IsKegOnly {
+			lines = append(lines, fmt.Sprintf("%s is keg-only, linking it may shadow the version provided by the system", pkg.Name))
+		}
+		lines = append(lines, "Linking fails when files of other formulae are in the way, overwriting replaces them")
+		m.prompt.Show(
+			fmt.Sprintf("Link %s?", pkg.Name),
+			lines,
+			ui.PromptOption{Key: "a", Desc: "abort"},
+			ui.PromptOption{Key: "y", Desc: "link", Action: func() tea.Cmd { return brew.LinkPackage(pkg, false) }},
+			ui.PromptOption{Key: "o", Desc: "link with --overwrite", Action: func() tea.Cmd { return brew.LinkPackage(pkg, true) }},
+		)
+	}
+	m.updateLayout()
+}
+
+// Sent when a package was snoozed or unsnoozed, which moves it in or out of the outdated ones
+type snoozedMsg struct{}
+
+// Ask for how long to postpone upgrading an outdated package
+func (m *model) confirmSnooze(pkg *data.Package) {
+	snooze := func(days int) func() tea.Cmd {
+		return func() tea.Cmd {
+			brew.SnoozePackage(pkg, days)
+			return func() tea.Msg { return snoozedMsg{} }
+		}
+	}
+	options := []ui.PromptOption{
+		{Key: "a", Desc: "abort"},
+		{Key: "d", Desc: "1 day", Action: snooze(1)},
+		{Key: "w", Desc: "1 week", Action: snooze(7)},
+		{Key: "m", Desc: "30 days", Action: snooze(30)},
+	}
+	lines := []string{"It's left out of upgrade all and the outdated filter until then"}
+	if pkg.IsSnoozed() {
+		lines = []string{fmt.Sprintf("It's snoozed until %s", pkg.SnoozedUntil.Format(time.DateOnly))}
+		options = append(options, ui.PromptOption{Key: "u", Desc: "unsnooze", Action: func() tea.Cmd {
+			brew.UnsnoozePackage(pkg)
+			return func() tea.Msg { return snoozedMsg{} }
+		}})
+	}
+	m.prompt.ShowChoice(fmt.Sprintf("Snooze upgrading %s?", pkg.Name), lines, options...)
+	m.updateLayout()
+}
+
+// Remind of outdated packages whose snooze is over
+func (m *model) remindSnoozeOver() {
+	over := []*data.Package{}
+	for _, pkg := range brew.GetOutdatedPackages() {
+		if pkg.IsSnoozeOver() {
+			over = append(over, pkg)
+		}
+	}
+	if len(over) > 0 {
+		m.outputView.Append(fmt.Sprintf("Snooze over for %s, press Z on them to snooze again", strings.Join(packageNames(over), ", ")))
+	}
+}
+
+// Uninstall a package directly, or ask how to proceed when other installed packages depend on it
+// or when it would leave orphaned dependencies behind
+func (m *model) uninstallPackage(pkg *data.Package) tea.Cmd {
+	dependents, orphans, cascade := brew.UninstallCascade(pkg)
+	if len(dependents) == 0 && len(orphans) == 0 {
+		return m.runCommand("Uninstall "+pkg.Name, brew.UninstallPackage(pkg))
+	}
+
+	lines := []string{}
+	options := []ui.PromptOption{{Key: "a", Desc: "abort"}}
+	if len(dependents) > 0 {
+		lines = append(lines, fmt.Sprintf("%s is required by: %s", pkg.Name, strings.Join(dependents, ", ")))
+		options = append(options, ui.PromptOption{
+			Key:    "i",
+			Desc:   "uninstall anyway (ignore dependencies)",
+			Action: func() tea.Cmd { return brew.UninstallPackages([]*data.Package{pkg}, true) },
+		})
+	} else {
+		options = append(options, ui.PromptOption{
+			Key:    "i",
+			Desc:   "uninstall only " + pkg.Name,
+			Action: func() tea.Cmd { return brew.UninstallPackage(pkg) },
+		})
+	}
+	if len(orphans) > 0 {
+		lines = append(lines, fmt.Sprintf("No longer needed dependencies: %s", strings.Join(orphans, ", ")))
+	}
+
+	lines = append(lines, fmt.Sprintf("Cascade order: %s", strings.Join(packageNames(cascade), " -> ")))
+	options = append(options, ui.PromptOption{
+		Key:    "c",
+		Desc:   fmt.Sprintf("cascade (uninstall %d packages)", len(cascade)),
+		Action: func() tea.Cmd { return brew.UninstallPackages(cascade, false) },
+	})
+
+	m.prompt.Show(fmt.Sprintf("Uninstall %s?", pkg.Name), lines, options...)
+	m.updateLayout()
+	return nil
+}
+
+// Let the user know some installed packages are missing because their taps couldn't be read
+func (m *model) warnBrokenTaps(broken []string) {
+	const maxBrokenShown = 10
+
+	lines := []string{"These installed packages failed to load, their taps may be shallow clones or broken:"}
+	lines = append(lines, truncatedLines(broken, maxBrokenShown)...)
+	m.prompt.Show(
+		"Repair taps?",
+		lines,
+		ui.PromptOption{Key: "a", Desc: "ignore"},
+		ui.PromptOption{Key: "r", Desc: "run brew tap --repair", Action: func() tea.Cmd { return brew.RepairTaps() }},
+	)
+}
+
+// Ask which format to export the packages in the table to
+func (m *model) confirmExport() {
+	count := len(m.table.Packages())
+	if count == 0 {
+		return
+	}
+	m.prompt.ShowChoice(
+		fmt.Sprintf("Export %d packages?", count),
+		[]string{"The table is written to the current directory, in its order and with its columns"},
+		ui.PromptOption{Key: "a", Desc: "abort"},
+		ui.PromptOption{Key: "m", Desc: "Markdown", Action: func() tea.Cmd { return m.table.Export(ui.ExportMarkdown) }},
+		ui.PromptOption{Key: "h", Desc: "HTML", Action: func() tea.Cmd { return m.table.Export(ui.ExportHtml) }},
+		ui.PromptOption{Key: "c", Desc: "CSV", Action: func() tea.Cmd { return m.table.Export(ui.ExportCsv) }},
+	)
+	m.updateLayout()
+}
+
+// Ask before moving installed packages to the taps they migrated to
+func (m *model) confirmTapMigrations(plan *brew.TapMigrationPlan, err error) {
+	if err != nil {
+		m.tapsView.SetError(err)
+		return
+	}
+	if len(plan.Migrations) == 0 {
+		m.prompt.ShowChoice(
+			"Migrate packages?",
+			[]string{"None of the installed packages were moved to another tap"},
+			ui.PromptOption{Key: "a", Desc: "close"},
+		)
+		m.updateLayout()
+		return
+	}
+
+	lines := []string{"Each package is uninstalled and installed again from its new tap, dependencies first:"}
+	for _, tap := range plan.Taps {
+		lines = append(lines, "  tap "+tap)
+	}
+	for _, migration := range plan.Migrations {
+		lines = append(lines, fmt.Sprintf("  %s: %s -> %s/%s", migration.Pkg.Name, migration.From, migration.To, migration.NewName))
+	}
+	for _, tap := range plan.Untaps {
+		lines = append(lines, "  untap "+tap)
+	}
+	m.prompt.Show(
+		fmt.Sprintf("Migrate %d packages?", len(plan.Migrations)),
+		lines,
+		ui.PromptOption{Key: "a", Desc: "cancel"},
+		ui.PromptOption{Key: "m", Desc: "migrate", Action: func() tea.Cmd { return brew.MigratePackages(plan) }},
+	)
+	m.updateLayout()
+}
+
+// Sent to upgrade the outdated packages that fit in a time budget
+type upgradeBudgetMsg struct {
+	budget time.Duration
+}
+
+// Ask how long to spend upgrading, packages that don't fit are left for another session
+func (m *model) askUpgradeBudget(outdated []*data.Package) {
+	options := []ui.PromptOption{{Key: "a", Desc: "cancel"}}
+	for i, budget := range []time.Duration{5 * time.Minute, 15 * time.Minute, 30 * time.Minute, time.Hour} {
+		options = append(options, ui.PromptOption{
+			Key:    strconv.Itoa(i + 1),
+			Desc:   formatBudget(budget),
+			Action: func() tea.Cmd { return func() tea.Msg { return upgradeBudgetMsg{budget} } },
+		})
+	}
+	m.prompt.ShowChoice(
+		"Upgrade for at most how long?",
+		[]string{
+			fmt.Sprintf("Upgrading all %d outdated packages takes ~%s", len(outdated), brew.EstimateUpgradeTime(outdated).Round(time.Second)),
+			"The quickest upgrades run first based on past timings, the ones that don't fit are deferred",
+		},
+		options...,
+	)
+	m.updateLayout()
+}
+
+// Budgets like 15m or 1h, without the zero seconds of time.Duration
+func formatBudget(budget time.Duration) string {
+	if budget%time.Hour == 0 {
+		return fmt.Sprintf("%dh", budget/time.Hour)
+	}
+	return fmt.Sprintf("%dm", budget/time.Minute)
+}
+
+// Tell which packages didn't fit in the time budget of the last upgrade
+func (m *model) reportDeferred() {
+	if len(m.deferredUpgrades) == 0 {
+		return
+	}
+	m.outputView.Append(fmt.Sprintf(
+		"Deferred %d packages to a later session: %s",
+		len(m.deferredUpgrades),
+		strings.Join(packageNames(m.deferredUpgrades), ", "),
+	))
+	m.deferredUpgrades = nil
+}
+
+// Sent to unpin, upgrade and repin pinned packages skipped by upgrade all
+type upgradePinnedMsg struct {
+	pkgs []*data.Package
+}
+
+// Tell which pinned packages were left outdated by upgrade all, and offer to upgrade them anyway
+func (m *model) reportSkippedPinned() {
+	pinned := brew.GetPinnedOutdatedPackages()
+	if len(pinned) == 0 {
+		return
+	}
+	names := strings.Join(packageNames(pinned), ", ")
+	m.outputView.Append(fmt.Sprintf("Skipped %d pinned packages: %s", len(pinned), names))
+	if m.prompt.IsActive() {
+		return
+	}
+	m.prompt.ShowChoice(
+		"Upgrade pinned packages?",
+		[]string{fmt.Sprintf("%s will be unpinned, upgraded and pinned again", names)},
+		ui.PromptOption{Key: "a", Desc: "keep them"},
+		ui.PromptOption{
+			Key:    "y",
+			Desc:   fmt.Sprintf("upgrade %d pinned packages", len(pinned)),
+			Action: func() tea.Cmd { return func() tea.Msg { return upgradePinnedMsg{pkgs: pinned} } },
+		},
+	)
+}
+
+// Sent to retry packages left by a failed upgrade all
+type resumeUpgradeMsg struct {
+	pkgs []*data.Package
+}
+
+// Keep packages upgraded before upgrade all failed, and offer to retry the rest.
+// Failed packages are known when packages were upgraded one by one, otherwise they're found in the output.
+func (m *model) offerResumeUpgrade(pkgs, failed []*data.Package) {
+	var finished, unfinished []*data.Package
+	if len(failed) > 0 {
+		finished, unfinished = withoutPackages(pkgs, failed), failed
+	} else {
+		finished, unfinished = brew.SplitFinishedPackages(pkgs, m.lastOutput)
+	}
+	if len(finished) > 0 {
+		brew.UpdatePackageForAction(brew.BrewCommandUpgrade, finished)
+		m.table.UpdateRows()
+	}
+	if len(unfinished) == 0 || m.prompt.IsActive() {
+		return
+	}
+
+	m.offerResume(
+		"Resume upgrade all?",
+		[]string{fmt.Sprintf("%d of %d packages were upgraded, %d are left:", len(finished), len(pkgs), len(unfinished))},
+		unfinished,
+		ui.PromptOption{Key: "a", Desc: "stop here"},
+		resumeUpgradeMsg{pkgs: unfinished},
+	)
+}
+
+// Sent to run packages left by a batch a previous run of taproom didn't finish
+type resumeBatchMsg struct {
+	journal *brew.BatchJournal
+	pkgs    []*data.Package
+}
+
+type discardBatchMsg struct{}
+
+// Offer to resume a batch left behind when taproom or the terminal died in the middle of it,
+// with the packages that still need to run according to their current state
+func (m *model) offerInterruptedBatch() {
+	journal := brew.InterruptedBatch()
+	if journal == nil {
+		return
+	}
+	unfinished := journal.Unfinished()
+	if len(unfinished) == 0 {
+		brew.DiscardInterruptedBatch()
+		m.outputView.Append(fmt.Sprintf(
+			"The %s interrupted on %s had finished all of its %d packages",
+			journal.Description(),
+			journal.Started.Format(time.DateTime),
+			len(journal.Pkgs),
+		))
+		return
+	}
+
+	m.offerResume(
+		fmt.Sprintf("Resume interrupted %s?", journal.Description()),
+		[]string{
+			fmt.Sprintf("taproom stopped during %s started on %s.", journal.Description(), journal.Started.Format(time.DateTime)),
+			fmt.Sprintf("%d of %d packages were done, %d are left:", len(journal.Pkgs)-len(unfinished), len(journal.Pkgs), len(unfinished)),
+		},
+		unfinished,
+		ui.PromptOption{
+			Key:    "d",
+			Desc:   "discard",
+			Action: func() tea.Cmd { return func() tea.Msg { return discardBatchMsg{} } },
+		},
+		resumeBatchMsg{journal: journal, pkgs: unfinished},
+	)
+}
+
+// Ask whether to run the packages left pending by an upgrade or batch, listed under the lines telling what happened.
+// Resuming sends resume, the other choice is what to do with the pending packages otherwise.
+func (m *model) offerResume(title string, lines []string, pending []*data.Package, other ui.PromptOption, resume tea.Msg) {
+	const maxPendingShown = 10
+
+	m.prompt.ShowChoice(
+		title,
+		append(lines, truncatedLines(packageNames(pending), maxPendingShown)...),
+		other,
+		ui.PromptOption{
+			Key:    "r",
+			Desc:   fmt.Sprintf("resume %d packages", len(pending)),
+			Action: func() tea.Cmd { return func() tea.Msg { return resume } },
+		},
+	)
+	m.updateLayout()
+}
+
+func withoutPackages(pkgs, excluded []*data.Package) []*data.Package {
+	kept := []*data.Package{}
+	for _, pkg := range pkgs {
+		if !slices.Contains(excluded, pkg) {
+			kept = append(kept, pkg)
+		}
+	}
+	return kept
+}
+
+// Items indented under the line introducing them, the ones past max are only counted
+func truncatedLines(items []string, max int) []string {
+	lines := []string{}
+	for i, item := range items {
+		if i == max {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(items)-max))
+			break
+		}
+		lines = append(lines, "  "+item)
+	}
+	return lines
+}
+
+func packageNames(pkgs []*data.Package) []string {
+	names := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		names[i] = pkg.Name
+	}
+	return names
+}
+
+const analyticsFetchDelay = 300 * time.Millisecond
+
+// Sent when a package stayed selected long enough to fetch its analytics
+type analyticsDelayMsg struct {
+	pkg *data.Package
+}
+
+// Sent when a package stayed selected long enough to fetch its GitHub repository, and the ones of the rows in view
+type repoInfoDelayMsg struct {
+	pkg *data.Package
+}
+
+// Sent when the catalog scope changed and packages need to be reloaded
+type catalogScopeChangedMsg struct{}
+
+// On the first run, offer to load fewer packages on low-memory machines
+func (m *model) askCatalogScope() {
+	m.prompt.ShowChoice(
+		"Trim the package catalog?",
+		[]string{
+			fmt.Sprintf("A trimmed catalog only loads installed packages, the top %d formulae and casks,", brew.CatalogTop()),
+			"packages from third-party taps and their dependencies, which uses much less memory.",
+			"Press C to load the full catalog any time, or use --catalog to override this choice.",
+		},
+		ui.PromptOption{
+			Key:    "f",
+			Desc:   "keep the full catalog",
+			Action: func() tea.Cmd { brew.SaveCatalogScope(brew.CatalogFull); return nil },
+		},
+		ui.PromptOption{
+			Key:  "t",
+			Desc: "trim the catalog",
+			Action: func() tea.Cmd {
+				brew.SaveCatalogScope(brew.CatalogTrimmed)
+				return func() tea.Msg { return catalogScopeChangedMsg{} }
+			},
+		},
+	)
+}
+
+// Ask for confirmation before zapping a cask, listing the files that will be removed
+func (m *model) zapPackage(pkg *data.Package) {
+	const maxZapPathsShown = 10
+
+	lines := []string{}
+	if len(pkg.ZapPaths) == 0 {
+		lines = append(lines, "No zap stanza, only the app itself will be removed")
+	} else {
+		lines = append(lines, "These files will also be removed:")
+		lines = append(lines, truncatedLines(pkg.ZapPaths, maxZapPathsShown)...)
+	}
+
+	m.prompt.Show(
+		fmt.Sprintf("Zap %s?", pkg.Name),
+		lines,
+		ui.PromptOption{Key: "a", Desc: "abort"},
+		ui.PromptOption{Key: "y", Desc: "uninstall and zap", Action: func() tea.Cmd { return brew.ZapPackage(pkg) }},
+	)
+	m.updateLayout()
+}
+
+// Ask before cleaning up, listing what would be removed and how much space it frees
+func (m *model) confirmCleanup(preview *brew.CleanupPreview, err error) {
+	const maxCleanupItemsShown = 10
+
+	lines := []string{}
+	if err != nil {
+		lines = append(lines, fmt.Sprintf("Failed to preview cleanup: %v", err))
+	} else if len(preview.Items) == 0 {
+		lines = append(lines, "Nothing would be removed")
+	} else {
+		lines = append(lines, fmt.Sprintf("%d old versions, downloads and logs would be removed:", len(preview.Items)))
+		items := make([]string, len(preview.Items))
+		for i, item := range preview.Items {
+			items[i] = fmt.Sprintf("%8s %s", util.FormatSize(item.Size), item.Path)
+		}
+		lines = append(lines, truncatedLines(items, maxCleanupItemsShown)...)
+	}
+
+	title := "Cleanup?"
+	if preview != nil && preview.Total > 0 {
+		title = fmt.Sprintf("Cleanup (free %s)?", util.FormatSize(preview.Total))
+	}
+	m.prompt.Show(
+		title,
+		lines,
+		ui.PromptOption{Key: "a", Desc: "abort"},
+		ui.PromptOption{Key: "y", Desc: "clean up", Action: brew.Cleanup},
+	)
+	m.updateLayout()
+}
+
+// Sent to get conflicting packages out of the way before installing a package
+type resolveConflictsMsg struct {
+	label     string
+	pkg       *data.Package
+	install   tea.Cmd
+	conflicts []*data.Package
+	uninstall bool // Uninstall the conflicting packages instead of unlinking them
+}
+
+// Install a package, asking first how to deal with installed packages it conflicts with,
+// rather than letting brew fail partway through
+func (m *model) installPackage(label string, pkg *data.Package, cmd tea.Cmd) tea.Cmd {
+	conflicts := brew.GetInstalledConflicts(pkg)
+	if len(conflicts) == 0 {
+		return m.runChecked(label, []*data.Package{pkg}, cmd)
+	}
+
+	names := strings.Join(packageNames(conflicts), ", ")
+	lines := []string{fmt.Sprintf("%s conflicts with installed packages: %s", pkg.Name, names)}
+	options := []ui.PromptOption{{Key: "a", Desc: "abort"}}
+	resolve := func(uninstall bool) func() tea.Cmd {
+		return func() tea.Cmd {
+			return func() tea.Msg {
+				return resolveConflictsMsg{label: label, pkg: pkg, install: cmd, conflicts: conflicts, uninstall: uninstall}
+			}
+		}
+	}
+	// Only formulae can be unlinked, casks have to go
+	if !slices.ContainsFunc(conflicts, func(p *data.Package) bool { return p.IsCask }) {
+		lines = append(lines, "Unlinking keeps them installed, link them again after uninstalling "+pkg.Name+" to switch back")
+		options = append(options, ui.PromptOption{Key: "l", Desc: "unlink them first", Action: resolve(false)})
+	}
+	for _, p := range conflicts {
+		if dependents := brew.GetInstalledDependents(p.UniqueName()); len(dependents) > 0 {
+			lines = append(lines, fmt.Sprintf("%s is required by: %s", p.UniqueName(), strings.Join(dependents, ", ")))
+		}
+	}
+	options = append(options, ui.PromptOption{Key: "u", Desc: "uninstall them first", Action: resolve(true)})
+
+	m.prompt.ShowChoice(label+"?", lines, options...)
+	m.updateLayout()
+	return nil
+}
+
+// Run a brew command, or queue it when another command is running
+func (m *model) runCommand(label string, cmd tea.Cmd) tea.Cmd {
+	if m.isExecuting || m.queue.Len() > 0 {
+		m.queue.Push(ui.QueueItem{Label: label, Cmd: cmd})
+		m.updateLayout()
+		if !m.isExecuting && !m.queue.IsPaused() {
+			return m.runNextQueued()
+		}
+		return nil
+	}
+	// Set immediately rather than waiting for CommandStartMsg so quick key presses are queued too
+	m.isExecuting = true
+	return cmd
+}
+
+// Run an install or upgrade, asking first when free disk space looks too short for it
+// rather than letting brew fail partway through, or when there are other warnings
+func (m *model) runChecked(label string, pkgs []*data.Package, cmd tea.Cmd, warnings ...string) tea.Cmd {
+	if shortage := brew.CheckDiskSpace(pkgs); shortage != nil {
+		warning := fmt.Sprintf(
+			"Only %s of disk space is free, %s needs about %s; free some first, e.g. with brew cleanup",
+			util.FormatSize(shortage.Free),
+			label,
+			util.FormatSize(shortage.Required),
+		)
+		if brew.DiskSpaceWarnOnly() {
+			m.outputView.Append(warning)
+		} else {
+			warnings = append(warnings, warning)
+		}
+	}
+	if len(warnings) == 0 {
+		return m.runCommand(label, cmd)
+	}
+	m.prompt.Show(
+		label+"?",
+		warnings,
+		ui.PromptOption{Key: "a", Desc: "abort"},
+		ui.PromptOption{Key: "y", Desc: "run anyway", Action: func() tea.Cmd { return cmd }},
+	)
+	m.updateLayout()
+	return nil
+}
+
+func (m *model) runNextQueued() tea.Cmd {
+	item, ok := m.queue.Pop()
+	if !ok {
+		return nil
+	}
+	if m.queue.Len() == 0 && m.focusMode == focusQueue {
+		m.focusMode = focusTable
+	}
+	m.queue.SetPaused(false)
+	m.isExecuting = true
+	m.updateLayout()
+	return item.Cmd
+}
+
+func (m *model) handleQueueKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.FocusQueue):
+		m.focusMode = focusTable
+		m.updateFocusBorder()
+	case key.Matches(msg, m.keys.Enter):
+		if !m.isExecuting {
+			cmd = m.runNextQueued()
+		}
+	default:
+		m.queue, cmd = m.queue.Update(msg)
+		if m.queue.Len() == 0 {
+			m.focusMode = focusTable
+			m.queue.SetPaused(false)
+		}
+		m.updateLayout()
+	}
+	return cmd
+}
+
+func (m *model) handleHistoryKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.History):
+		m.historyView.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	case key.Matches(msg, m.keys.Enter):
+		// Re-run the selected command
+		if entry := m.historyView.Selected(); entry != nil {
+			m.historyView.Hide()
+			cmd = m.runCommand("Re-run "+entry.CommandLine(), brew.RerunHistory(*entry))
+		}
+	default:
+		m.historyView, cmd = m.historyView.Update(msg)
+	}
+	return cmd
+}
+
+func (m *model) handleTapsKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Taps):
+		m.tapsView.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	case key.Matches(msg, m.keys.Enter):
+		if tap := m.tapsView.Selected(); tap != nil {
+			m.tapsView.SetError(brew.SetTapForceAutoUpdate(tap, !tap.ForceAutoUpdate))
+		}
+	case key.Matches(msg, m.keys.MigrateTaps):
+		cmd = brew.FindTapMigrations()
+	default:
+		m.tapsView, cmd = m.tapsView.Update(msg)
+	}
+	return cmd
+}
+
+// Search the packages of the category picked
+func (m *model) handleCategoriesKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Categories):
+		m.categories.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	case key.Matches(msg, m.keys.Enter):
+		m.categories.Hide()
+		cmd = m.search.SetValue("cat:" + m.categories.Selected())
+	default:
+		m.categories, cmd = m.categories.Update(msg)
+	}
+	return cmd
+}
+
+func (m *model) handleBrewfileKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Brewfile):
+		m.brewfile.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	case key.Matches(msg, m.keys.Enter):
+		if diff := m.brewfile.Diff(); diff != nil && len(diff.Missing) > 0 {
+			m.brewfile.Hide()
+			cmd = m.runCommand(
+				fmt.Sprintf("Install %d missing Brewfile entries", len(diff.Missing)),
+				brew.InstallBrewfile(diff),
+			)
+		}
+	default:
+		m.brewfile, cmd = m.brewfile.Update(msg)
+	}
+	return cmd
+}
+
+func (m *model) handleRollbackKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Rollback):
+		m.rollback.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	case key.Matches(msg, m.keys.NextItem):
+		m.rollback.Older()
+	case key.Matches(msg, m.keys.PrevItem):
+		m.rollback.Newer()
+	default:
+		m.rollback, cmd = m.rollback.Update(msg)
+	}
+	return cmd
+}
+
+func (m *model) handleSyncKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Sync):
+		m.syncView.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	case key.Matches(msg, m.keys.Enter):
+		if diff := m.syncView.Diff(); diff != nil && len(diff.Install)+len(diff.Upgrade)+len(diff.OnlyHere) > 0 {
+			m.confirmSync(diff)
+		}
+	default:
+		m.syncView, cmd = m.syncView.Update(msg)
+	}
+	return cmd
+}
+
+// Ask whether converging with the other machine also uninstalls the packages it doesn't have
+func (m *model) confirmSync(diff *brew.SyncDiff) {
+	lines := []string{}
+	if len(diff.Install) > 0 {
+		lines = append(lines, fmt.Sprintf("Install: %s", strings.Join(packageNames(diff.Install), ", ")))
+	}
+	if len(diff.Upgrade) > 0 {
+		lines = append(lines, fmt.Sprintf("Upgrade: %s", strings.Join(packageNames(diff.Upgrade), ", ")))
+	}
+	if len(diff.OnlyHere) > 0 {
+		lines = append(lines, fmt.Sprintf("Uninstall: %s", strings.Join(packageNames(diff.OnlyHere), ", ")))
+	}
+	options := []ui.PromptOption{{Key: "a", Desc: "abort"}}
+	if len(diff.Install)+len(diff.Upgrade) > 0 {
+		options = append(options, ui.PromptOption{
+			Key:    "i",
+			Desc:   "install and upgrade only",
+			Action: func() tea.Cmd { return func() tea.Msg { return runSyncMsg{diff: diff} } },
+		})
+	}
+	if len(diff.OnlyHere) > 0 {
+		options = append(options, ui.PromptOption{
+			Key:    "y",
+			Desc:   "also uninstall",
+			Action: func() tea.Cmd { return func() tea.Msg { return runSyncMsg{diff: diff, uninstall: true} } },
+		})
+	}
+	m.prompt.ShowChoice("Converge with the other machine?", lines, options...)
+	m.updateLayout()
+}
+
+// Sent to converge with the other machine, also uninstalling packages it doesn't have when uninstall is set
+type runSyncMsg struct {
+	diff      *brew.SyncDiff
+	uninstall bool
+}
+
+// Queue the commands converging with the other machine
+func (m *model) runSync(diff *brew.SyncDiff, uninstall bool) tea.Cmd {
+	m.syncView.Hide()
+	cmds := []tea.Cmd{}
+	if len(diff.Install) > 0 {
+		cmds = append(cmds, m.runCommand(
+			fmt.Sprintf("Install %d packages of the other machine", len(diff.Install)),
+			brew.InstallPackages(diff.Install),
+		))
+	}
+	if len(diff.Upgrade) > 0 {
+		cmds = append(cmds, m.runCommand(
+			fmt.Sprintf("Upgrade %d packages", len(diff.Upgrade)),
+			brew.UpgradePackages(diff.Upgrade),
+		))
+	}
+	if uninstall && len(diff.OnlyHere) > 0 {
+		cmds = append(cmds, m.runCommand(
+			fmt.Sprintf("Uninstall %d packages the other machine doesn't have", len(diff.OnlyHere)),
+			brew.UninstallPackages(diff.OnlyHere, false),
+		))
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *model) handleDoctorKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Doctor):
+		m.doctor.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	default:
+		m.doctor, cmd = m.doctor.Update(msg)
+	}
+	return cmd
+}
+
+func (m *model) handleIntegrationsKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Integrations):
+		m.toolsView.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	default:
+		m.toolsView, cmd = m.toolsView.Update(msg)
+	}
+	return cmd
+}
+
+func (m *model) handleCacheKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Cache):
+		m.cacheView.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	case key.Matches(msg, m.keys.Remove):
+		if entry := m.cacheView.Selected(); entry != nil {
+			m.prompt.ShowChoice(
+				fmt.Sprintf("Delete cached files of %s?", entry.Name),
+				[]string{fmt.Sprintf("%d files (%s) will be deleted", entry.Files, util.FormatSize(entry.Size))},
+				ui.PromptOption{Key: "a", Desc: "abort"},
+				ui.PromptOption{Key: "y", Desc: "delete", Action: func() tea.Cmd { return brew.DeleteCacheEntry(entry) }},
+			)
+			m.updateLayout()
+		}
+	default:
+		m.cacheView, cmd = m.cacheView.Update(msg)
+	}
+	return cmd
+}
+
+func (m *model) handleCaveatsKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Caveats):
+		m.caveatsView.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	case key.Matches(msg, m.keys.Remove):
+		if caveat := m.caveatsView.Selected(); caveat != nil {
+			brew.DismissCaveat(caveat.Pkg)
+			m.caveatsView.Remove(caveat.Pkg)
+		}
+	default:
+		m.caveatsView, cmd = m.caveatsView.Update(msg)
+	}
+	return cmd
+}
+
+// The search typed while loading is applied when the data arrives, other commands wait until then
+func (m *model) handleLoadingKeys(msg tea.KeyMsg) tea.Cmd {
+	if m.focusMode == focusSearch {
+		return m.handleSearchInputKeys(msg)
+	}
+	switch {
+	case key.Matches(msg, m.keys.FocusSearch):
+		m.focusMode = focusSearch
+		m.updateFocusBorder()
+		return textinput.Blink
+	case key.Matches(msg, m.keys.Quit):
+		return tea.Quit
+	}
+	return nil
+}
+
+// Loading failed, e.g. offline, only retrying or quitting makes sense
+func (m *model) handleLoadingErrorKeys(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.keys.Refresh):
+		return m.loadData()
+	case key.Matches(msg, m.keys.Quit):
+		return tea.Quit
+	}
+	return nil
+}
+
+func (m *model) handleDiskUsageKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.DiskUsage):
+		m.diskUsage.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	case key.Matches(msg, m.keys.Enter):
+		if pkg := m.diskUsage.Selected(); pkg != nil {
+			m.diskUsage.Hide()
+			cmd = m.jumpToPackage(pkg)
+		}
+	default:
+		m.diskUsage, cmd = m.diskUsage.Update(msg)
+	}
+	return cmd
+}
+
+// Update the outdated packages in the dashboard after packages were reloaded or changed
+func (m *model) refreshDashboard() {
+	if m.dashboard.IsVisible() {
+		m.dashboard.SetPackages(brew.GetUpgradablePackages(), len(brew.GetPinnedOutdatedPackages()))
+	}
+}
+
+// The summary of what changed since the last run is read and dismissed before anything else
+func (m *model) handleSummaryKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Enter):
+		m.summary.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	default:
+		m.summary, cmd = m.summary.Update(msg)
+	}
+	return cmd
+}
+
+// Upgrade or skip outdated packages one by one, enter or esc switches to the full view
+func (m *model) handleDashboardKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.Enter):
+		m.dashboard.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	case key.Matches(msg, m.keys.Upgrade):
+		if pkg := m.dashboard.Selected(); pkg != nil && pkg.IsOutdated {
+			cmd = m.runChecked("Upgrade "+pkg.Name, []*data.Package{pkg}, brew.UpgradePackage(pkg))
+		}
+	case key.Matches(msg, m.keys.UpgradeAll):
+		if pkgs := m.dashboard.Pending(); len(pkgs) > 0 {
+			cmd = m.runChecked(
+				fmt.Sprintf("Upgrade %d packages (~%s)", len(pkgs), brew.EstimateUpgradeTime(pkgs).Round(time.Second)),
+				pkgs,
+				brew.UpgradePackages(pkgs),
+				brew.PowerWarnings()...,
+			)
+		}
+	default:
+		m.dashboard, cmd = m.dashboard.Update(msg)
+	}
+	return cmd
+}
+
+// Keep caveats printed by an install or upgrade, which would otherwise scroll away with the output
+func (m *model) rememberCaveats(pkgs []*data.Package) {
+	caveats := brew.ParseCaveats(m.lastOutput, pkgs)
+	if len(caveats) == 0 {
+		return
+	}
+	brew.AddCaveats(caveats)
+	names := make([]string, len(caveats))
+	for i, c := range caveats {
+		names[i] = c.Pkg
+	}
+	m.outputView.Append(fmt.Sprintf("Caveats of %s saved, press M to read them", strings.Join(names, ", ")))
+}
+
+func (m *model) handleOptionsKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keys.Esc):
+		m.options.Dismiss()
+	case key.Matches(msg, m.keys.Enter):
+		options, err := m.options.Options()
+		if err != nil {
+			break
+		}
+		pkg := m.options.Package()
+		label := strings.Join(append([]string{pkg.Name}, options...), " ")
+		if m.options.IsUpgrade() {
+			cmd = m.runChecked("Upgrade "+label, []*data.Package{pkg}, brew.UpgradePackage(pkg, options...))
+		} else {
+			cmd = m.installPackage("Install "+label, pkg, brew.InstallPackage(pkg, options...))
+		}
+		m.options.Dismiss()
+	default:
+		m.options, cmd = m.options.Update(msg)
+	}
+	m.updateLayout()
+	return cmd
+}
+
+func (m *model) handlePagerKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	switch {
+	case m.pager.IsSearching():
+		// Search input takes all keys until it's submitted or cancelled
+		m.pager, cmd = m.pager.Update(msg)
+	case key.Matches(msg, m.keys.Esc), key.Matches(msg, m.keys.FullOutput):
+		m.pager.Hide()
+	case key.Matches(msg, m.keys.Quit):
+		cmd = tea.Quit
+	default:
+		m.pager, cmd = m.pager.Update(msg)
+	}
+	return cmd
+}
+
 func (m *model) handleDetailsPanelKeys(msg tea.KeyMsg) tea.Cmd {
 	var cmd tea.Cmd
 	switch {
 	case key.Matches(msg, m.keys.Esc):
 		m.focusMode = focusTable
 		m.updateFocusBorder()
+	case key.Matches(msg, m.keys.Enter):
+		if pkg := brew.GetPackage(m.detailPanel.SelectedLink()); pkg != nil {
+			if selected := m.table.Selected(); selected != nil {
+				m.jumpStack = append(m.jumpStack, selected.UniqueName())
+			}
+			cmd = m.jumpToPackage(pkg)
+		} else {
+			m.detailPanel.ToggleSection()
+		}
+	case key.Matches(msg, m.keys.JumpBack):
+		if n := len(m.jumpStack); n > 0 {
+			// Look up by name, packages are loaded again on refresh
+			pkg := brew.GetPackage(m.jumpStack[n-1])
+			m.jumpStack = m.jumpStack[:n-1]
+			if pkg != nil {
+				cmd = m.jumpToPackage(pkg)
+			}
+		}
+	case key.Matches(msg, m.keys.NextItem):
+		m.detailPanel.NextItem()
+	case key.Matches(msg, m.keys.PrevItem):
+		m.detailPanel.PrevItem()
 	default:
 		m.detailPanel, cmd = m.detailPanel.Update(msg)
 	}
 	return cmd
 }
 
+// Select a package in the table, clearing the search and filters when they hide it
+func (m *model) jumpToPackage(pkg *data.Package) tea.Cmd {
+	if cmd, ok := m.table.Select(pkg); ok {
+		return cmd
+	}
+	// Packages are filtered right away, so the search message is not needed
+	m.search.Clear()
+	m.filterView.Reset()
+	m.filterPackages()
+	cmd, _ := m.table.Select(pkg)
+	return cmd
+}
+
 // filterAndSortPackages updates the viewPackages based on current filters and sort mode.
 func (m *model) filterPackages() tea.Cmd {
 	viewPackages := []*data.Package{}
+	searched := []*data.Package{} // Matching the search, for the counts of each filter
 
 	searchQuery := strings.ToLower(m.search.Value())
 	keywords := strings.Fields(searchQuery)
@@ -273,24 +1856,12 @@ func (m *model) filterPackages() tea.Cmd {
 			continue
 		}
 
+		searched = append(searched, pkg)
 		passesFilter := true
 		for _, f := range m.filterView.Value() {
-			switch f {
-			case ui.FilterFormulae:
-				passesFilter = !pkg.IsCask
-			case ui.FilterCasks:
-				passesFilter = pkg.IsCask
-			case ui.FilterInstalled:
-				passesFilter = pkg.IsInstalled
-			case ui.FilterOutdated:
-				passesFilter = pkg.IsOutdated
-			case ui.FilterExplicitlyInstalled:
-				passesFilter = pkg.IsInstalled && !pkg.InstalledAsDependency
-			case ui.FilterActive:
-				passesFilter = !pkg.IsDisabled && !pkg.IsDeprecated
-			}
 			// A package needs to pass all filters, so break early when it doesn't pass any filter
-			if !passesFilter {
+			if !f.Matches(pkg) {
+				passesFilter = false
 				break
 			}
 		}
@@ -301,5 +1872,6 @@ func (m *model) filterPackages() tea.Cmd {
 	}
 
 	m.statsView.SetPackages(viewPackages)
+	m.filterView.SetCounts(searched)
 	return m.table.SetPackages(viewPackages)
 }
@@ -1,9 +1,8 @@
 package model
 
 import (
-	"taproom/internal/ui"
-
 	"github.com/charmbracelet/lipgloss"
+	"github.com/hzqtc/taproom/internal/ui"
 	"github.com/spf13/pflag"
 )
 
@@ -13,19 +12,65 @@ const (
 )
 
 var (
-	flagHideHelp = pflag.Bool("hide-help", false, "Hide the help text")
+	flagHideHelp  = pflag.Bool("hide-help", false, "Hide the help text")
+	flagDashboard = pflag.BoolP("dashboard", "o", false, "Open a view of only outdated packages to upgrade or skip each of them")
 )
 
 func (m model) View() string {
 	if loading := m.loadingView.View(); loading != "" {
+		if m.loadingView.IsLoading() {
+			return lipgloss.JoinVertical(lipgloss.Left, loading, m.search.View())
+		}
 		return loading
 	}
+	if pager := m.pager.View(); pager != "" {
+		return pager
+	}
 
 	mainContent := lipgloss.JoinHorizontal(
 		lipgloss.Top,
 		m.table.View(),
 		m.detailPanel.View(),
 	)
+	if history := m.historyView.View(); history != "" {
+		mainContent = history
+	}
+	if taps := m.tapsView.View(); taps != "" {
+		mainContent = taps
+	}
+	if categories := m.categories.View(); categories != "" {
+		mainContent = categories
+	}
+	if brewfile := m.brewfile.View(); brewfile != "" {
+		mainContent = brewfile
+	}
+	if rollback := m.rollback.View(); rollback != "" {
+		mainContent = rollback
+	}
+	if sync := m.syncView.View(); sync != "" {
+		mainContent = sync
+	}
+	if doctor := m.doctor.View(); doctor != "" {
+		mainContent = doctor
+	}
+	if integrations := m.toolsView.View(); integrations != "" {
+		mainContent = integrations
+	}
+	if cache := m.cacheView.View(); cache != "" {
+		mainContent = cache
+	}
+	if caveats := m.caveatsView.View(); caveats != "" {
+		mainContent = caveats
+	}
+	if diskUsage := m.diskUsage.View(); diskUsage != "" {
+		mainContent = diskUsage
+	}
+	if dashboard := m.dashboard.View(); dashboard != "" {
+		mainContent = dashboard
+	}
+	if summary := m.summary.View(); summary != "" {
+		mainContent = summary
+	}
 
 	topContent := lipgloss.JoinHorizontal(
 		lipgloss.Top,
@@ -41,6 +86,15 @@ func (m model) View() string {
 	if output := m.outputView.View(); output != "" {
 		views = append(views, output)
 	}
+	if queue := m.queue.View(); queue != "" {
+		views = append(views, queue)
+	}
+	if prompt := m.prompt.View(); prompt != "" {
+		views = append(views, prompt)
+	}
+	if options := m.options.View(); options != "" {
+		views = append(views, options)
+	}
 	if !*flagHideHelp {
 		views = append(views, m.helpView.View())
 	}
@@ -54,14 +108,22 @@ func (m *model) updateFocusBorder() {
 		m.search.SetFocused(true)
 		m.table.SetFocused(false)
 		m.detailPanel.SetFocused(false)
+		m.queue.SetFocused(false)
 	case focusTable:
 		m.search.SetFocused(false)
 		m.table.SetFocused(true)
 		m.detailPanel.SetFocused(false)
+		m.queue.SetFocused(false)
 	case focusDetail:
 		m.search.SetFocused(false)
 		m.table.SetFocused(false)
 		m.detailPanel.SetFocused(true)
+		m.queue.SetFocused(false)
+	case focusQueue:
+		m.search.SetFocused(false)
+		m.table.SetFocused(false)
+		m.detailPanel.SetFocused(false)
+		m.queue.SetFocused(true)
 	}
 }
 
@@ -73,6 +135,9 @@ func (m *model) updateLayout() {
 	m.outputView.SetWidth(m.width - 2)
 	m.statsView.SetWidth(m.width - 2)
 	m.helpView.SetWidth(m.width - 2)
+	m.prompt.SetWidth(m.width - 2)
+	m.options.SetWidth(m.width - 2)
+	m.queue.SetWidth(m.width - 2)
 
 	sidePanelWidth := max(sidePanelWidthMin, m.width-ui.MaxTableWidth-4)
 	tableWidth := m.width - sidePanelWidth - 4
@@ -86,6 +151,15 @@ func (m *model) updateLayout() {
 	if output := m.outputView.View(); output != "" {
 		mainHeight -= lipgloss.Height(output)
 	}
+	if queue := m.queue.View(); queue != "" {
+		mainHeight -= lipgloss.Height(queue)
+	}
+	if prompt := m.prompt.View(); prompt != "" {
+		mainHeight -= lipgloss.Height(prompt)
+	}
+	if options := m.options.View(); options != "" {
+		mainHeight -= lipgloss.Height(options)
+	}
 
 	m.filterView.SetWidth(sidePanelWidth)
 	searchWidth := m.width - sidePanelWidth - 8
@@ -95,4 +169,18 @@ func (m *model) updateLayout() {
 	m.search.SetWidth(searchWidth)
 	m.table.SetDimensions(tableWidth, mainHeight)
 	m.detailPanel.SetDimension(sidePanelWidth-2, mainHeight)
+	m.historyView.SetDimensions(m.width-2, mainHeight)
+	m.tapsView.SetDimensions(m.width-2, mainHeight)
+	m.categories.SetDimensions(m.width-2, mainHeight)
+	m.brewfile.SetDimensions(m.width-2, mainHeight)
+	m.doctor.SetDimensions(m.width-2, mainHeight)
+	m.syncView.SetDimensions(m.width-2, mainHeight)
+	m.rollback.SetDimensions(m.width-2, mainHeight)
+	m.toolsView.SetDimensions(m.width-2, mainHeight)
+	m.cacheView.SetDimensions(m.width-2, mainHeight)
+	m.caveatsView.SetDimensions(m.width-2, mainHeight)
+	m.diskUsage.SetDimensions(m.width-2, mainHeight)
+	m.dashboard.SetDimensions(m.width-2, mainHeight)
+	m.summary.SetDimensions(m.width-2, mainHeight)
+	m.pager.SetDimensions(m.width-2, m.height-2)
 }
@@ -0,0 +1,130 @@
+package osv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/util"
+)
+
+const (
+	osvQueryBatchUrl = "https://api.osv.dev/v1/querybatch"
+	ecosystemGit     = "GIT"
+
+	// OSV accepts up to 1000 queries per batch
+	queryBatchSize = 1000
+	httpTimeout    = 30 * time.Second
+)
+
+// Upstream git repository of a package, OSV tracks vulnerabilities of git repos by their tags
+var gitRepoUrl = regexp.MustCompile(`^https://(?:github\.com|gitlab\.com)/[^/\s]+/[^/?#\s]+`)
+
+type osvQuery struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Version string `json:"version"`
+}
+
+type osvBatchRequest struct {
+	Queries []osvQuery `json:"queries"`
+}
+
+type osvBatchResponse struct {
+	Results []struct {
+		Vulns []struct {
+			Id string `json:"id"`
+		} `json:"vulns"`
+	} `json:"results"`
+}
+
+var httpClient = &http.Client{Timeout: httpTimeout}
+
+func getRepoUrl(pkg *data.Package) string {
+	for _, url := range append(append([]string{}, pkg.Urls...), pkg.Homepage()) {
+		// Repository names may have dots, like socket.io, but not the .git of clone urls
+		if m := gitRepoUrl.FindString(url); m != "" {
+			return strings.TrimSuffix(m, ".git")
+		}
+	}
+	return ""
+}
+
+// Query OSV for known vulnerabilities affecting the installed version of each package
+// and set them on the package. Packages without a known upstream git repo are skipped.
+func FetchVulnerabilities(pkgs []*data.Package) {
+	queried := []*data.Package{}
+	queries := []osvQuery{}
+	for _, pkg := range pkgs {
+		repo := getRepoUrl(pkg)
+		if repo == "" || pkg.InstalledVersion == "" {
+			continue
+		}
+		// Tags are usually either the plain version or prefixed with 'v', query both
+		for _, version := range []string{pkg.InstalledVersion, "v" + pkg.InstalledVersion} {
+			q := osvQuery{Version: version}
+			q.Package.Name = repo
+			q.Package.Ecosystem = ecosystemGit
+			queries = append(queries, q)
+			queried = append(queried, pkg)
+		}
+	}
+
+	for start := 0; start < len(queries); start += queryBatchSize {
+		end := min(start+queryBatchSize, len(queries))
+		vulns, err := queryBatch(queries[start:end])
+		if err != nil {
+			log.Printf("Failed to query OSV: %v", err)
+			return
+		}
+		for i, ids := range vulns {
+			pkg := queried[start+i]
+			pkg.Vulnerabilities = util.SortAndUniq(append(pkg.Vulnerabilities, ids...))
+		}
+	}
+}
+
+func queryBatch(queries []osvQuery) ([][]string, error) {
+	body, err := json.Marshal(osvBatchRequest{Queries: queries})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Post(osvQueryBatchUrl, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to post %s: %w", osvQueryBatchUrl, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad HTTP status posting %s: %s", osvQueryBatchUrl, resp.Status)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body from %s: %w", osvQueryBatchUrl, err)
+	}
+	return parseBatchResponse(respBody)
+}
+
+func parseBatchResponse(body []byte) ([][]string, error) {
+	var resp osvBatchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode osv response: %w", err)
+	}
+	vulns := make([][]string, len(resp.Results))
+	for i, r := range resp.Results {
+		for _, v := range r.Vulns {
+			vulns[i] = append(vulns[i], v.Id)
+		}
+	}
+	return vulns, nil
+}
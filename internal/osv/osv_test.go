@@ -0,0 +1,37 @@
+package osv
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestGetRepoUrl(t *testing.T) {
+	pkg := &data.Package{
+		Urls: []string{"https://ftp.gnu.org/foo.tar.gz", "https://github.com/owner/repo.git"},
+	}
+	pkg.SetTexts(nil, "", "https://example.org")
+	if got, want := getRepoUrl(pkg), "https://github.com/owner/repo"; got != want {
+		t.Errorf("expected repo %q, got %q", want, got)
+	}
+	pkg.Urls = []string{"https://github.com/socketio/socket.io/archive/refs/tags/4.8.1.tar.gz"}
+	if got, want := getRepoUrl(pkg), "https://github.com/socketio/socket.io"; got != want {
+		t.Errorf("expected repo %q, got %q", want, got)
+	}
+	pkg.Urls = nil
+	if got := getRepoUrl(pkg); got != "" {
+		t.Errorf("expected no repo, got %q", got)
+	}
+}
+
+func TestParseBatchResponse(t *testing.T) {
+	body := `{"results": [{"vulns": [{"id": "CVE-2025-0001", "modified": "2025-01-01T00:00:00Z"}, {"id": "GHSA-xxxx"}]}, {}]}`
+	vulns, err := parseBatchResponse([]byte(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vulns) != 2 || !slices.Equal(vulns[0], []string{"CVE-2025-0001", "GHSA-xxxx"}) || len(vulns[1]) != 0 {
+		t.Errorf("unexpected vulnerabilities %v", vulns)
+	}
+}
@@ -0,0 +1,53 @@
+package release
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/gh"
+)
+
+// Latest releases by upstream and package version, a release doesn't change until the package does
+type releaseCache map[string]*data.ReleaseInfo
+
+// Key of the package's release in the cache like github.com/cli/cli@2.74.0, empty when its releases can't be fetched
+func cacheKey(rules []resolverRule, pkg *data.Package) string {
+	if repo := gh.GithubRepoName(pkg); repo != "" {
+		return "github.com/" + repo + "@" + pkg.Version
+	}
+	if r, u := findResolver(rules, pkg); r != nil {
+		return u.Host + u.Path + "@" + pkg.Version
+	}
+	return ""
+}
+
+func loadCache(path string) releaseCache {
+	cache := make(releaseCache)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(content, &cache); err != nil {
+		log.Printf("failed to decode %s: %v", path, err)
+		return make(releaseCache)
+	}
+	return cache
+}
+
+func saveCache(path string, cache releaseCache) {
+	content, err := json.Marshal(cache)
+	if err != nil {
+		log.Printf("failed to encode release cache: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("failed to create %s: %v", filepath.Dir(path), err)
+		return
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		log.Printf("failed to write %s: %v", path, err)
+	}
+}
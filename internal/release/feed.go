@@ -0,0 +1,181 @@
+package release
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// Resolve the latest release from an RSS or Atom feed linked from the package's home page
+type feedResolver struct{}
+
+// Resolve the latest release from a SourceForge project's file feed
+type sourceforgeResolver struct{}
+
+// Structs for parsing RSS and Atom feeds, only fields used for release info are included
+type rssFeed struct {
+	Items []struct {
+		Title   string `xml:"title"`
+		Link    string `xml:"link"`
+		PubDate string `xml:"pubDate"`
+	} `xml:"channel>item"`
+}
+
+type atomFeed struct {
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Updated   string `xml:"updated"`
+		Published string `xml:"published"`
+	} `xml:"entry"`
+}
+
+type feedItem struct {
+	title string
+	link  string
+	date  time.Time
+}
+
+const maxFeedSize = 4 << 20
+
+var (
+	// <link rel="alternate" type="application/rss+xml" href="...">, attributes can be in any order
+	feedLinkRegex = regexp.MustCompile(`(?i)<link[^>]+type=["']application/(?:rss|atom)\+xml["'][^>]*>`)
+	hrefRegex     = regexp.MustCompile(`(?i)href=["']([^"']+)["']`)
+	// Version number in a feed item title or file path, e.g. "Release 1.2.3" or "/foo/1.2/foo-1.2.tar.gz"
+	feedVersionRegex = regexp.MustCompile(`\d+(?:\.\d+)+[a-zA-Z0-9\-]*`)
+
+	// SourceForge project in urls like sourceforge.net/projects/foo, downloads.sourceforge.net/project/foo
+	// or foo.sourceforge.io
+	sourceforgeProjectRegex = regexp.MustCompile(`/projects?/([^/]+)`)
+	sourceforgeIoRegex      = regexp.MustCompile(`^([^.]+)\.sourceforge\.(?:io|net)$`)
+
+	feedDateLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 -0700"}
+)
+
+func (feedResolver) resolve(pkg *data.Package, _ *url.URL) (*data.ReleaseInfo, error) {
+	homepage, err := url.Parse(pkg.Homepage())
+	if err != nil || homepage.Host == "" {
+		return nil, fmt.Errorf("invalid home page %q", pkg.Homepage())
+	}
+	page, err := fetchBody(pkg.Homepage())
+	if err != nil {
+		return nil, err
+	}
+	feedUrl := findFeedUrl(homepage, string(page))
+	if feedUrl == "" {
+		return nil, nil
+	}
+	return fetchFeedRelease(feedUrl)
+}
+
+func (sourceforgeResolver) resolve(_ *data.Package, pkgUrl *url.URL) (*data.ReleaseInfo, error) {
+	var project string
+	if m := sourceforgeIoRegex.FindStringSubmatch(pkgUrl.Hostname()); m != nil && m[1] != "www" && m[1] != "downloads" {
+		project = m[1]
+	} else if m := sourceforgeProjectRegex.FindStringSubmatch(pkgUrl.Path); m != nil {
+		project = m[1]
+	} else {
+		return nil, fmt.Errorf("no sourceforge project in %s", pkgUrl)
+	}
+	return fetchFeedRelease(fmt.Sprintf("https://sourceforge.net/projects/%s/rss?path=/", project))
+}
+
+func fetchBody(u string) ([]byte, error) {
+	resp, err := httpGet(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body from %s: %w", u, err)
+	}
+	return body, nil
+}
+
+// Find the RSS or Atom feed advertised in an html page, relative links are resolved against the page url
+func findFeedUrl(pageUrl *url.URL, page string) string {
+	tag := feedLinkRegex.FindString(page)
+	if tag == "" {
+		return ""
+	}
+	m := hrefRegex.FindStringSubmatch(tag)
+	if m == nil {
+		return ""
+	}
+	href, err := url.Parse(m[1])
+	if err != nil {
+		return ""
+	}
+	return pageUrl.ResolveReference(href).String()
+}
+
+func fetchFeedRelease(feedUrl string) (*data.ReleaseInfo, error) {
+	body, err := fetchBody(feedUrl)
+	if err != nil {
+		return nil, err
+	}
+	return parseFeedRelease(body)
+}
+
+// Parse an RSS or Atom feed and use the most recent item as the latest release
+func parseFeedRelease(body []byte) (*data.ReleaseInfo, error) {
+	items := []feedItem{}
+
+	var rss rssFeed
+	if err := xml.Unmarshal(body, &rss); err == nil && len(rss.Items) > 0 {
+		for _, item := range rss.Items {
+			items = append(items, feedItem{title: item.Title, link: item.Link, date: parseFeedDate(item.PubDate)})
+		}
+	} else {
+		var atom atomFeed
+		if err := xml.Unmarshal(body, &atom); err != nil {
+			return nil, fmt.Errorf("failed to decode feed: %w", err)
+		}
+		for _, entry := range atom.Entries {
+			item := feedItem{title: entry.Title, date: parseFeedDate(entry.Published)}
+			if item.date.IsZero() {
+				item.date = parseFeedDate(entry.Updated)
+			}
+			if len(entry.Links) > 0 {
+				item.link = entry.Links[0].Href
+			}
+			items = append(items, item)
+		}
+	}
+
+	var latest *feedItem
+	for i := range items {
+		if latest == nil || items[i].date.After(latest.date) {
+			latest = &items[i]
+		}
+	}
+	if latest == nil || latest.date.IsZero() {
+		return nil, nil
+	}
+
+	return &data.ReleaseInfo{
+		Date:    latest.date,
+		Version: feedVersionRegex.FindString(latest.title),
+		Url:     latest.link,
+	}, nil
+}
+
+func parseFeedDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
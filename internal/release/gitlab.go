@@ -0,0 +1,69 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// Resolve the latest release with the GitLab releases API, works for gitlab.com and self-hosted instances
+type gitlabResolver struct{}
+
+type gitlabRelease struct {
+	TagName    string    `json:"tag_name"`
+	ReleasedAt time.Time `json:"released_at"`
+	Links      struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+}
+
+func (gitlabResolver) resolve(_ *data.Package, pkgUrl *url.URL) (*data.ReleaseInfo, error) {
+	project := gitlabProjectPath(pkgUrl.Path)
+	if project == "" {
+		return nil, fmt.Errorf("no gitlab project in %s", pkgUrl)
+	}
+
+	apiUrl := fmt.Sprintf("https://%s/api/v4/projects/%s/releases?per_page=1", pkgUrl.Host, url.PathEscape(project))
+	resp, err := httpGet(apiUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body from %s: %w", apiUrl, err)
+	}
+	return parseGitlabReleases(body)
+}
+
+// Extract the project path (group/subgroup/project) from a url path like
+// /group/project, /group/project/-/archive/v1.0/project-v1.0.tar.gz or /group/project.git
+func gitlabProjectPath(path string) string {
+	path, _, _ = strings.Cut(path, "/-/")
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if strings.Count(path, "/") < 1 {
+		return ""
+	}
+	return path
+}
+
+func parseGitlabReleases(body []byte) (*data.ReleaseInfo, error) {
+	var releases []gitlabRelease
+	if err := json.Unmarshal(body, &releases); err != nil {
+		return nil, fmt.Errorf("failed to decode gitlab releases: %w", err)
+	}
+	if len(releases) == 0 {
+		return nil, nil
+	}
+	return &data.ReleaseInfo{
+		Date:    releases[0].ReleasedAt,
+		Version: releases[0].TagName,
+		Url:     releases[0].Links.Self,
+	}, nil
+}
@@ -0,0 +1,202 @@
+package release
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/gh"
+	"github.com/spf13/pflag"
+)
+
+// Flags of release resolvers, added to the command line by taproom
+var Flags = pflag.NewFlagSet("release", pflag.ExitOnError)
+
+// A resolver finds the latest release of a package hosted outside of GitHub,
+// pkgUrl is the package url or home page matched by the resolver's host pattern
+type resolver interface {
+	resolve(pkg *data.Package, pkgUrl *url.URL) (*data.ReleaseInfo, error)
+}
+
+type resolverRule struct {
+	hostPattern *regexp.Regexp
+	resolver    resolver
+}
+
+const (
+	resolverGitlab      = "gitlab"
+	resolverSourceforge = "sourceforge"
+	resolverFeed        = "feed"
+
+	// Number of packages resolved concurrently
+	resolverWorkers = 4
+	// Time between resolver requests, shared by all workers
+	resolverInterval = 200 * time.Millisecond
+	httpTimeout      = 15 * time.Second
+)
+
+var flagReleaseResolvers = Flags.StringSlice(
+	"release-resolvers",
+	[]string{
+		`^gitlab\.=gitlab`,
+		`^(.+\.)?sourceforge\.(net|io)$=sourceforge`,
+	},
+	"Release resolvers for non-GitHub upstreams as host-regex=resolver (comma separated no space).\n"+
+		"Resolvers: gitlab (GitLab releases API), sourceforge (project RSS feed), feed (RSS/Atom feed linked from the home page)",
+)
+
+var httpClient = &http.Client{Timeout: httpTimeout}
+
+func parseResolverRules(specs []string) ([]resolverRule, error) {
+	rules := []resolverRule{}
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid release resolver %q, expected host-regex=resolver", spec)
+		}
+		pattern, err := regexp.Compile(spec[:i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid host pattern in release resolver %q: %w", spec, err)
+		}
+		var r resolver
+		switch spec[i+1:] {
+		case resolverGitlab:
+			r = gitlabResolver{}
+		case resolverSourceforge:
+			r = sourceforgeResolver{}
+		case resolverFeed:
+			r = feedResolver{}
+		default:
+			return nil, fmt.Errorf("unknown release resolver %q in %q", spec[i+1:], spec)
+		}
+		rules = append(rules, resolverRule{hostPattern: pattern, resolver: r})
+	}
+	return rules, nil
+}
+
+// Find the first resolver whose host pattern matches any of the package's urls or home page
+func findResolver(rules []resolverRule, pkg *data.Package) (resolver, *url.URL) {
+	candidates := append([]string{}, pkg.Urls...)
+	candidates = append(candidates, pkg.Homepage())
+	for _, rule := range rules {
+		for _, candidate := range candidates {
+			u, err := url.Parse(candidate)
+			if err != nil || u.Host == "" {
+				continue
+			}
+			if rule.hostPattern.MatchString(u.Hostname()) {
+				return rule.resolver, u
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Fetch release info for all packages, fetched is called with the releases as packages get them.
+// Packages aren't changed here, fetched runs in other goroutines so their releases are set by the caller.
+// Packages on GitHub are resolved with the GitHub API, others with the resolver configured for their domain.
+// Releases found before for the same upstream and version are read from the cache at cachePath instead.
+func FetchReleaseInfo(pkgs []*data.Package, cachePath string, fetched func(map[*data.Package]*data.ReleaseInfo)) {
+	rules, err := parseResolverRules(*flagReleaseResolvers)
+	if err != nil {
+		log.Printf("Ignoring release resolvers: %v", err)
+	}
+
+	cache := loadCache(cachePath)
+	keys := make(map[*data.Package]string)
+	cachedReleases := make(map[*data.Package]*data.ReleaseInfo)
+	githubPkgs := []*data.Package{}
+	otherPkgs := []*data.Package{}
+	for _, pkg := range pkgs {
+		key := cacheKey(rules, pkg)
+		if key == "" {
+			// Neither on GitHub nor matched by a resolver
+			continue
+		}
+		keys[pkg] = key
+		if release, ok := cache[key]; ok {
+			cachedReleases[pkg] = release
+		} else if gh.HasGithubRepo(pkg) {
+			githubPkgs = append(githubPkgs, pkg)
+		} else {
+			otherPkgs = append(otherPkgs, pkg)
+		}
+	}
+	if len(cachedReleases) > 0 {
+		fetched(cachedReleases)
+	}
+
+	var cacheMu sync.Mutex
+	record := func(releases map[*data.Package]*data.ReleaseInfo) {
+		cacheMu.Lock()
+		for pkg, release := range releases {
+			// Packages without a release are looked up again next time
+			if release != nil {
+				cache[keys[pkg]] = release
+			}
+		}
+		cacheMu.Unlock()
+		if len(releases) > 0 {
+			fetched(releases)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		gh.FetchGithubReleaseInfo(githubPkgs, record)
+	}()
+
+	limiter := time.NewTicker(resolverInterval)
+	defer limiter.Stop()
+	pkgCh := make(chan *data.Package)
+	for range resolverWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pkg := range pkgCh {
+				r, u := findResolver(rules, pkg)
+				<-limiter.C
+				release, err := r.resolve(pkg, u)
+				if err != nil {
+					log.Printf("Failed to get release info for %s from %s: %v", pkg.Name, u, err)
+					continue
+				}
+				record(map[*data.Package]*data.ReleaseInfo{pkg: release})
+			}
+		}()
+	}
+	for _, pkg := range otherPkgs {
+		pkgCh <- pkg
+	}
+	close(pkgCh)
+	wg.Wait()
+
+	// Drop releases of packages that were uninstalled or upgraded since
+	kept := make(releaseCache)
+	for _, key := range keys {
+		if release, ok := cache[key]; ok {
+			kept[key] = release
+		}
+	}
+	saveCache(cachePath, kept)
+}
+
+func httpGet(u string) (*http.Response, error) {
+	resp, err := httpClient.Get(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", u, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("bad HTTP status fetching %s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,133 @@
+package release
+
+import (
+	"maps"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestParseResolverRules(t *testing.T) {
+	rules, err := parseResolverRules([]string{`^gitlab\.=gitlab`, `^example\.org$=feed`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pkg := &data.Package{
+		Urls: []string{"https://gitlab.gnome.org/GNOME/glib/-/archive/2.84.0/glib-2.84.0.tar.gz"},
+	}
+	pkg.SetTexts(nil, "", "https://example.org")
+	r, u := findResolver(rules, pkg)
+	if _, ok := r.(gitlabResolver); !ok || u.Host != "gitlab.gnome.org" {
+		t.Errorf("expected gitlab resolver for gitlab.gnome.org, got %T for %v", r, u)
+	}
+
+	pkg.Urls = []string{"https://ftp.example.com/foo-1.0.tar.gz"}
+	if r, _ := findResolver(rules, pkg); r == nil {
+		t.Error("expected feed resolver for home page example.org")
+	} else if _, ok := r.(feedResolver); !ok {
+		t.Errorf("expected feed resolver, got %T", r)
+	}
+
+	for _, spec := range []string{"gitlab", "gitlab.com=unknown", "[=gitlab"} {
+		if _, err := parseResolverRules([]string{spec}); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestGitlabProjectPath(t *testing.T) {
+	tests := map[string]string{
+		"/GNOME/glib":            "GNOME/glib",
+		"/group/sub/project.git": "group/sub/project",
+		"/inkscape/inkscape/-/archive/1.0/x.tar.gz": "inkscape/inkscape",
+		"/":       "",
+		"/lonely": "",
+	}
+	for path, want := range tests {
+		if got := gitlabProjectPath(path); got != want {
+			t.Errorf("gitlabProjectPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestParseGitlabReleases(t *testing.T) {
+	body := `[{"tag_name": "v1.4.0", "released_at": "2025-03-01T10:00:00Z", "_links": {"self": "https://gitlab.com/a/b/-/releases/v1.4.0"}}]`
+	release, err := parseGitlabReleases([]byte(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if release.Version != "v1.4.0" || release.Url != "https://gitlab.com/a/b/-/releases/v1.4.0" || release.Date.Year() != 2025 {
+		t.Errorf("unexpected release %+v", release)
+	}
+}
+
+func TestParseFeedRelease(t *testing.T) {
+	rss := `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+	<item><title>/foo/1.1/foo-1.1.tar.gz</title><link>https://sf.net/foo-1.1</link><pubDate>Mon, 03 Feb 2025 10:00:00 UT</pubDate></item>
+	<item><title>/foo/1.2/foo-1.2.tar.gz</title><link>https://sf.net/foo-1.2</link><pubDate>Tue, 04 Mar 2025 10:00:00 +0000</pubDate></item>
+</channel></rss>`
+	release, err := parseFeedRelease([]byte(rss))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if release == nil || release.Version != "1.2" || release.Url != "https://sf.net/foo-1.2" {
+		t.Errorf("unexpected release from rss %+v", release)
+	}
+
+	atom := `<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<entry><title>Release 2.0.1</title><link href="https://example.org/2.0.1"/><updated>2025-05-01T00:00:00Z</updated></entry>
+	<entry><title>Release 2.0.0</title><link href="https://example.org/2.0.0"/><updated>2025-04-01T00:00:00Z</updated></entry>
+</feed>`
+	release, err = parseFeedRelease([]byte(atom))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if release == nil || release.Version != "2.0.1" || release.Url != "https://example.org/2.0.1" {
+		t.Errorf("unexpected release from atom %+v", release)
+	}
+}
+
+func TestFindFeedUrl(t *testing.T) {
+	page, _ := url.Parse("https://example.org/project/")
+	html := `<head><link href="news.atom" rel="alternate" type="application/atom+xml"></head>`
+	if got, want := findFeedUrl(page, html), "https://example.org/project/news.atom"; got != want {
+		t.Errorf("expected feed url %q, got %q", want, got)
+	}
+	if got := findFeedUrl(page, "<html></html>"); got != "" {
+		t.Errorf("expected no feed url, got %q", got)
+	}
+}
+
+func TestFetchReleaseInfoFromCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "release_info.json")
+	released := &data.ReleaseInfo{Date: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), Version: "v2.74.0", Url: "https://github.com/cli/cli/releases/tag/v2.74.0"}
+	saveCache(path, releaseCache{
+		"github.com/cli/cli@2.74.0": released,
+		"github.com/cli/cli@2.73.0": {Version: "v2.73.0"},
+	})
+
+	cached := &data.Package{Name: "gh", Version: "2.74.0", Urls: []string{"https://github.com/cli/cli/archive/refs/tags/v2.74.0.tar.gz"}}
+	unknown := &data.Package{Name: "local", Version: "1.0", Urls: []string{"https://example.com/local-1.0.tar.gz"}}
+	fetched := make(map[*data.Package]*data.ReleaseInfo)
+	FetchReleaseInfo([]*data.Package{cached, unknown}, path, func(releases map[*data.Package]*data.ReleaseInfo) {
+		maps.Copy(fetched, releases)
+	})
+
+	if len(fetched) != 1 || fetched[cached] == nil {
+		t.Errorf("expected only gh to be reported, got %v", fetched)
+	} else if *fetched[cached] != *released {
+		t.Errorf("expected the cached release, got %+v", fetched[cached])
+	}
+	if cached.ReleaseInfo != nil {
+		t.Errorf("expected the package to be left for the caller to update, got %+v", cached.ReleaseInfo)
+	}
+	if cache := loadCache(path); len(cache) != 1 || cache["github.com/cli/cli@2.74.0"] == nil {
+		t.Errorf("expected the release of the previous version to be dropped, got %v", cache)
+	}
+}
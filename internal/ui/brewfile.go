@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/brew"
+)
+
+// BrewfileModel compares installed packages with a Brewfile
+type BrewfileModel struct {
+	diff    *brew.BrewfileDiff
+	err     error
+	visible bool
+	vp      viewport.Model
+}
+
+var brewfileStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+func NewBrewfileModel() BrewfileModel {
+	return BrewfileModel{}
+}
+
+// Show the view while the Brewfile is being loaded
+func (m *BrewfileModel) Show() {
+	m.diff = nil
+	m.err = nil
+	m.visible = true
+	m.updateContent()
+}
+
+func (m *BrewfileModel) SetDiff(diff *brew.BrewfileDiff, err error) {
+	m.diff = diff
+	m.err = err
+	m.updateContent()
+}
+
+func (m *BrewfileModel) Diff() *brew.BrewfileDiff {
+	return m.diff
+}
+
+func (m *BrewfileModel) Hide() {
+	m.visible = false
+	m.diff = nil
+}
+
+func (m *BrewfileModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *BrewfileModel) SetDimensions(width, height int) {
+	m.vp.Width = width - 2
+	m.vp.Height = height
+	brewfileStyle = brewfileStyle.
+		BorderStyle(getRoundedBorderWithTitle("Brewfile", width)).
+		Width(width)
+}
+
+func (m BrewfileModel) Update(msg tea.Msg) (BrewfileModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.vp, cmd = m.vp.Update(msg)
+	return m, cmd
+}
+
+func (m *BrewfileModel) updateContent() {
+	if m.err != nil {
+		m.vp.SetContent(deprecatedStyle.Render(m.err.Error()))
+		return
+	}
+	if m.diff == nil {
+		m.vp.SetContent("Loading Brewfile...")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s\n", headerStyle.UnsetWidth().Render("Brewfile:"), m.diff.Path))
+	if len(m.diff.Missing) > 0 {
+		b.WriteString(keyStyle.Render("enter") + ": install missing entries with brew bundle\n")
+	}
+
+	b.WriteString("\n" + headerStyle.UnsetWidth().Render(fmt.Sprintf("Missing (%d):", len(m.diff.Missing))) + "\n")
+	for _, entry := range m.diff.Missing {
+		b.WriteString(fmt.Sprintf("  %s %s %s\n", uninstalledStyle.Render(uninstalledSymbol), entry.Kind, entry.Name))
+	}
+
+	if len(m.diff.Unsupported) > 0 {
+		b.WriteString("\n" + headerStyle.UnsetWidth().Render(fmt.Sprintf("Skipped, install in a terminal (%d):", len(m.diff.Unsupported))) + "\n")
+		for _, entry := range m.diff.Unsupported {
+			b.WriteString(fmt.Sprintf("  %s %s %s %s\n", uninstalledStyle.Render(uninstalledSymbol), entry.Kind, entry.Name, uninstalledStyle.Render("(may need sudo)")))
+		}
+	}
+
+	b.WriteString("\n" + headerStyle.UnsetWidth().Render(fmt.Sprintf("Not in Brewfile (%d):", len(m.diff.Extra))) + "\n")
+	for _, pkg := range m.diff.Extra {
+		kind := "brew"
+		if pkg.IsCask {
+			kind = "cask"
+		}
+		b.WriteString(fmt.Sprintf("  %s %s %s\n", formatStatusSymbol(pkg), kind, pkg.Name))
+	}
+
+	b.WriteString("\n" + headerStyle.UnsetWidth().Render(fmt.Sprintf("Installed (%d):", len(m.diff.Installed))) + "\n")
+	for _, entry := range m.diff.Installed {
+		b.WriteString(fmt.Sprintf("  %s %s %s\n", installedStyle.Render(installedSymbol), entry.Kind, entry.Name))
+	}
+
+	m.vp.SetContent(b.String())
+	m.vp.GotoTop()
+}
+
+func (m BrewfileModel) View() string {
+	if !m.visible {
+		return ""
+	}
+	return brewfileStyle.Render(m.vp.View())
+}
@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hzqtc/taproom/internal/brew"
+	"github.com/hzqtc/taproom/internal/util"
+)
+
+// CacheModel lists the download cache of brew grouped by package
+type CacheModel struct {
+	report  *brew.CacheReport
+	err     error
+	loading bool
+	bySize  bool
+	cursor  int
+	visible bool
+	width   int
+	height  int
+
+	up     key.Binding
+	down   key.Binding
+	top    key.Binding
+	bottom key.Binding
+	sort   key.Binding
+}
+
+var cacheStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+const cacheNameWidth = 40
+
+func NewCacheModel() CacheModel {
+	return CacheModel{
+		bySize: true,
+		up:     key.NewBinding(key.WithKeys("k", "up")),
+		down:   key.NewBinding(key.WithKeys("j", "down")),
+		top:    key.NewBinding(key.WithKeys("g", "home")),
+		bottom: key.NewBinding(key.WithKeys("G", "end")),
+		sort:   key.NewBinding(key.WithKeys("s")),
+	}
+}
+
+// Show the view while the cache is being scanned
+func (m *CacheModel) Show() {
+	m.report = nil
+	m.err = nil
+	m.cursor = 0
+	m.loading = true
+	m.visible = true
+}
+
+func (m *CacheModel) SetReport(report *brew.CacheReport, err error) {
+	m.report = report
+	m.err = err
+	m.loading = false
+	if report != nil {
+		brew.SortCacheEntries(report.Entries, m.bySize)
+	}
+}
+
+// Remove a deleted entry from the list
+func (m *CacheModel) Remove(entry *brew.CacheEntry, err error) {
+	m.err = err
+	if err != nil || m.report == nil {
+		return
+	}
+	if i := slices.Index(m.report.Entries, entry); i >= 0 {
+		m.report.Entries = slices.Delete(m.report.Entries, i, i+1)
+		m.report.Total -= entry.Size
+		m.cursor = max(0, min(m.cursor, len(m.report.Entries)-1))
+	}
+}
+
+func (m *CacheModel) Hide() {
+	m.visible = false
+	m.report = nil
+}
+
+func (m *CacheModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *CacheModel) Selected() *brew.CacheEntry {
+	if m.report != nil && m.cursor >= 0 && m.cursor < len(m.report.Entries) {
+		return m.report.Entries[m.cursor]
+	}
+	return nil
+}
+
+func (m *CacheModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	cacheStyle = cacheStyle.
+		BorderStyle(getRoundedBorderWithTitle("Cache", width)).
+		Width(width)
+}
+
+func (m CacheModel) Update(msg tea.Msg) (CacheModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || m.report == nil || len(m.report.Entries) == 0 {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(keyMsg, m.down):
+		m.cursor = min(len(m.report.Entries)-1, m.cursor+1)
+	case key.Matches(keyMsg, m.top):
+		m.cursor = 0
+	case key.Matches(keyMsg, m.bottom):
+		m.cursor = len(m.report.Entries) - 1
+	case key.Matches(keyMsg, m.sort):
+		m.bySize = !m.bySize
+		brew.SortCacheEntries(m.report.Entries, m.bySize)
+		m.cursor = 0
+	}
+	return m, nil
+}
+
+func formatCacheEntry(entry *brew.CacheEntry) string {
+	return fmt.Sprintf(
+		"%s  %5d files  %8s",
+		fitCell(entry.Name, cacheNameWidth, false),
+		entry.Files,
+		util.FormatSize(entry.Size),
+	)
+}
+
+func (m CacheModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	sortBy := "size"
+	if m.bySize {
+		sortBy = "name"
+	}
+	header := []string{}
+	if m.report != nil {
+		header = append(header, fmt.Sprintf(
+			"%s %s (%s in %d entries)",
+			headerStyle.UnsetWidth().Render("Cache:"),
+			m.report.Path,
+			util.FormatSize(m.report.Total),
+			len(m.report.Entries),
+		))
+	}
+	header = append(header,
+		fmt.Sprintf("%s: sort by %s  %s: delete", keyStyle.Render("s"), sortBy, keyStyle.Render("x")),
+		"",
+	)
+	if m.err != nil {
+		header = append(header, deprecatedStyle.Render(m.err.Error()), "")
+	}
+	if m.loading {
+		header = append(header, "Scanning cache...")
+	}
+
+	rows := []string{}
+	if m.report != nil {
+		entries := m.report.Entries
+		listHeight := max(1, m.height-len(header))
+		start := max(0, min(m.cursor-listHeight/2, len(entries)-listHeight))
+		end := min(len(entries), start+listHeight)
+		for i := start; i < end; i++ {
+			row := fitCell(formatCacheEntry(entries[i]), m.width-2, false)
+			if i == m.cursor {
+				row = queueSelectedStyle.Render(row)
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, strings.Join(header, "\n"), strings.Join(rows, "\n"))
+	return cacheStyle.Height(m.height).MaxHeight(m.height + 2).Render(content)
+}
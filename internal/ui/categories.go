@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// CategoriesModel lists the categories of packages to search one of them
+type CategoriesModel struct {
+	counts  map[string]int
+	cursor  int
+	visible bool
+	width   int
+	height  int
+
+	up     key.Binding
+	down   key.Binding
+	top    key.Binding
+	bottom key.Binding
+}
+
+var categoriesStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+const categoryNameWidth = 20
+
+func NewCategoriesModel() CategoriesModel {
+	return CategoriesModel{
+		up:     key.NewBinding(key.WithKeys("k", "up")),
+		down:   key.NewBinding(key.WithKeys("j", "down")),
+		top:    key.NewBinding(key.WithKeys("g", "home")),
+		bottom: key.NewBinding(key.WithKeys("G", "end")),
+	}
+}
+
+// Show the categories with how many of the packages are in each
+func (m *CategoriesModel) Show(pkgs []*data.Package) {
+	m.counts = data.CountCategories(pkgs)
+	m.visible = true
+}
+
+func (m *CategoriesModel) Hide() {
+	m.visible = false
+}
+
+func (m *CategoriesModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *CategoriesModel) Selected() string {
+	return data.Categories[m.cursor].Name
+}
+
+func (m *CategoriesModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	categoriesStyle = categoriesStyle.
+		BorderStyle(getRoundedBorderWithTitle("Categories", width)).
+		Width(width)
+}
+
+func (m CategoriesModel) Update(msg tea.Msg) (CategoriesModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(keyMsg, m.down):
+		m.cursor = min(len(data.Categories)-1, m.cursor+1)
+	case key.Matches(keyMsg, m.top):
+		m.cursor = 0
+	case key.Matches(keyMsg, m.bottom):
+		m.cursor = len(data.Categories) - 1
+	}
+	return m, nil
+}
+
+func (m CategoriesModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	header := []string{
+		keyStyle.Render("enter") + ": search packages of the selected category, like " + keyStyle.Render("cat:fonts"),
+		"",
+	}
+	listHeight := max(1, m.height-len(header))
+	start := max(0, min(m.cursor-listHeight/2, len(data.Categories)-listHeight))
+	end := min(len(data.Categories), start+listHeight)
+	rows := []string{}
+	for i := start; i < end; i++ {
+		name := data.Categories[i].Name
+		row := fitCell(fmt.Sprintf("%s  %5d packages", fitCell(name, categoryNameWidth, false), m.counts[name]), m.width-2, false)
+		if i == m.cursor {
+			row = queueSelectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, strings.Join(header, "\n"), strings.Join(rows, "\n"))
+	return categoriesStyle.Height(m.height).MaxHeight(m.height + 2).Render(content)
+}
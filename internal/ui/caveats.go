@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hzqtc/taproom/internal/brew"
+)
+
+// CaveatsModel lists caveats of installed packages that haven't been dismissed, with the text of the selected one
+type CaveatsModel struct {
+	caveats []brew.Caveat
+	cursor  int
+	visible bool
+	width   int
+	height  int
+
+	up     key.Binding
+	down   key.Binding
+	top    key.Binding
+	bottom key.Binding
+}
+
+var caveatsStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+func NewCaveatsModel() CaveatsModel {
+	return CaveatsModel{
+		up:     key.NewBinding(key.WithKeys("k", "up")),
+		down:   key.NewBinding(key.WithKeys("j", "down")),
+		top:    key.NewBinding(key.WithKeys("g", "home")),
+		bottom: key.NewBinding(key.WithKeys("G", "end")),
+	}
+}
+
+func (m *CaveatsModel) Show(caveats []brew.Caveat) {
+	m.caveats = caveats
+	m.cursor = 0
+	m.visible = true
+}
+
+func (m *CaveatsModel) Hide() {
+	m.visible = false
+	m.caveats = nil
+}
+
+func (m *CaveatsModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *CaveatsModel) Selected() *brew.Caveat {
+	if m.cursor >= 0 && m.cursor < len(m.caveats) {
+		return &m.caveats[m.cursor]
+	}
+	return nil
+}
+
+// Remove a dismissed caveat from the list
+func (m *CaveatsModel) Remove(pkg string) {
+	m.caveats = slices.DeleteFunc(m.caveats, func(c brew.Caveat) bool { return c.Pkg == pkg })
+	m.cursor = max(0, min(m.cursor, len(m.caveats)-1))
+}
+
+func (m *CaveatsModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	caveatsStyle = caveatsStyle.
+		BorderStyle(getRoundedBorderWithTitle("Caveats", width)).
+		Width(width)
+}
+
+func (m CaveatsModel) Update(msg tea.Msg) (CaveatsModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || len(m.caveats) == 0 {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(keyMsg, m.down):
+		m.cursor = min(len(m.caveats)-1, m.cursor+1)
+	case key.Matches(keyMsg, m.top):
+		m.cursor = 0
+	case key.Matches(keyMsg, m.bottom):
+		m.cursor = len(m.caveats) - 1
+	}
+	return m, nil
+}
+
+func (m CaveatsModel) View() string {
+	if !m.visible {
+		return ""
+	}
+	if len(m.caveats) == 0 {
+		return caveatsStyle.Height(m.height).Render("No caveats to remember, they're saved here after installing packages.")
+	}
+
+	// List takes the top third, text of the selected caveat takes the rest
+	listHeight := max(1, m.height/3)
+	start := max(0, min(m.cursor-listHeight/2, len(m.caveats)-listHeight))
+	end := min(len(m.caveats), start+listHeight)
+	rows := []string{}
+	for i := start; i < end; i++ {
+		c := m.caveats[i]
+		row := fitCell(fmt.Sprintf("%s  %s", c.Time.Format(historyTimeFormat), c.Pkg), m.width-2, false)
+		if i == m.cursor {
+			row = queueSelectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	selected := m.caveats[m.cursor]
+	textHeight := max(0, m.height-len(rows)-3)
+	text := selected.Text
+	if len(text) > textHeight {
+		text = text[:textHeight]
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		keyStyle.Render("x")+": dismiss the selected caveat",
+		strings.Join(rows, "\n"),
+		"",
+		headerStyle.UnsetWidth().Render("Caveats of "+selected.Pkg),
+		strings.Join(text, "\n"),
+	)
+	return caveatsStyle.Height(m.height).MaxHeight(m.height + 2).Render(content)
+}
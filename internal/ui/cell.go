@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+const cellTail = "…"
+
+// Characters that take no space on their own but make terminals and width calculations
+// disagree on how wide an emoji sequence is
+var zeroWidthReplacer = strings.NewReplacer(
+	"\u200b", "", // zero width space
+	"\u200c", "", // zero width non-joiner
+	"\u200d", "", // zero width joiner
+	"\ufe0e", "", // text presentation selector
+	"\ufe0f", "", // emoji presentation selector
+	"\t", " ",
+	"\n", " ",
+	"\r", "",
+)
+
+// Normalize a cell's content and pad or truncate it to exactly width terminal cells,
+// so wide characters like emoji and CJK don't shift the following columns
+func fitCell(s string, width int, rightAligned bool) string {
+	if width <= 0 {
+		return ""
+	}
+
+	s = zeroWidthReplacer.Replace(s)
+	if ansi.StringWidth(s) > width {
+		s = ansi.Truncate(s, width, cellTail)
+	}
+
+	padding := strings.Repeat(" ", max(0, width-ansi.StringWidth(s)))
+	if rightAligned {
+		return padding + s
+	} else {
+		return s + padding
+	}
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestFitCell(t *testing.T) {
+	tests := []struct {
+		in           string
+		width        int
+		rightAligned bool
+		want         string
+	}{
+		{"abc", 5, false, "abc  "},
+		{"abc", 5, true, "  abc"},
+		{"abcdef", 4, false, "abc…"},
+		{"日本語", 7, false, "日本語 "},
+		{"日本語", 4, false, "日… "},
+		{"🍺 beer", 8, false, "🍺 beer "},
+		{"❤\ufe0f love", 7, false, "❤ love "},
+		{"a\tb", 3, false, "a b"},
+	}
+
+	for _, tt := range tests {
+		got := fitCell(tt.in, tt.width, tt.rightAligned)
+		if got != tt.want {
+			t.Errorf("fitCell(%q, %d, %v) = %q, want %q", tt.in, tt.width, tt.rightAligned, got, tt.want)
+		}
+		if w := ansi.StringWidth(got); w > tt.width {
+			t.Errorf("fitCell(%q, %d, %v) has width %d", tt.in, tt.width, tt.rightAligned, w)
+		}
+	}
+}
@@ -2,7 +2,8 @@ package ui
 
 import (
 	"fmt"
-	"taproom/internal/data"
+
+	"github.com/hzqtc/taproom/internal/data"
 )
 
 type packageTableColumn int
@@ -20,6 +21,8 @@ const (
 	colInstalls                              // Number of installs in the last 90 days
 	colSize                                  // Size of the package on disk
 	colStatus                                // Calculated status such as deprecated, installed, outdated, pinned
+	colPriority                              // Upgrade priority of outdated packages
+	colStars                                 // Stars of the GitHub repository, fetched for the rows in view
 
 	totalNumColumns
 )
@@ -33,6 +36,8 @@ var colWidthMap = map[packageTableColumn]int{
 	colInstalls:    10,
 	colSize:        8,
 	colStatus:      15,
+	colPriority:    10,
+	colStars:       7,
 }
 
 func (c packageTableColumn) String() string {
@@ -53,6 +58,10 @@ func (c packageTableColumn) String() string {
 		return "Size"
 	case colStatus:
 		return "Status"
+	case colPriority:
+		return "Priority"
+	case colStars:
+		return "Stars"
 	default:
 		return "Unknown"
 	}
@@ -74,6 +83,10 @@ func parseColumnName(name string) (packageTableColumn, error) {
 		return colSize, nil
 	case "Status":
 		return colStatus, nil
+	case "Priority":
+		return colPriority, nil
+	case "Stars":
+		return colStars, nil
 	default:
 		return colUnknown, fmt.Errorf("Unknown column: %s", name)
 	}
@@ -84,15 +97,15 @@ func (c packageTableColumn) hideable() bool {
 }
 
 func (c packageTableColumn) sortable() bool {
-	return c == colName || c == colTap || c == colInstalls || c == colSize || c == colStatus
+	return c == colName || c == colTap || c == colInstalls || c == colSize || c == colStatus || c == colPriority || c == colStars
 }
 
 func (c packageTableColumn) reverseSort() bool {
-	return c == colInstalls || c == colSize
+	return c == colInstalls || c == colSize || c == colPriority || c == colStars
 }
 
 func (c packageTableColumn) rightAligned() bool {
-	return c == colInstalls || c == colSize
+	return c == colInstalls || c == colSize || c == colStars
 }
 
 func (c packageTableColumn) width() int {
@@ -112,23 +125,30 @@ func (c packageTableColumn) getColumnData(pkg *data.Package) string {
 	case colSymbol:
 		return pkg.Symbol()
 	case colName:
-		return pkg.Name
+		return pkg.UniqueName()
 	case colVersion:
 		return pkg.ShortVersion()
 	case colTap:
 		return pkg.Tap
 	case colDescription:
-		return pkg.Desc
+		return pkg.Desc()
 	case colInstalls:
 		return fmt.Sprintf("%d", pkg.Installs90d)
 	case colSize:
 		if pkg.IsInstalled {
-			return pkg.FormattedSize
+			return pkg.FormattedSize()
 		} else {
 			return "N/A"
 		}
 	case colStatus:
 		return pkg.Status()
+	case colPriority:
+		return pkg.UpgradePriority().String()
+	case colStars:
+		if pkg.Repo == nil {
+			return ""
+		}
+		return fmt.Sprintf("%d", pkg.Repo.Stars)
 	default:
 		return ""
 	}
@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// DashboardModel lists only outdated packages, to upgrade or skip each of them without the full view
+type DashboardModel struct {
+	pkgs    []*data.Package
+	skipped map[string]bool // Kept by unique name as packages are reloaded after each upgrade
+	pinned  int
+	cursor  int
+	visible bool
+	width   int
+	height  int
+
+	up     key.Binding
+	down   key.Binding
+	top    key.Binding
+	bottom key.Binding
+	skip   key.Binding
+}
+
+var dashboardStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+const dashboardNameWidth = 30
+
+func NewDashboardModel() DashboardModel {
+	return DashboardModel{
+		skipped: make(map[string]bool),
+		up:      key.NewBinding(key.WithKeys("k", "up")),
+		down:    key.NewBinding(key.WithKeys("j", "down")),
+		top:     key.NewBinding(key.WithKeys("g", "home")),
+		bottom:  key.NewBinding(key.WithKeys("G", "end")),
+		skip:    key.NewBinding(key.WithKeys("s")),
+	}
+}
+
+// Show outdated packages, pinned ones are only counted since they can't be upgraded from here
+func (m *DashboardModel) Show(pkgs []*data.Package, pinned int) {
+	m.SetPackages(pkgs, pinned)
+	m.cursor = 0
+	m.visible = true
+}
+
+// Replace packages after a reload, keeping the selection and the ones that were skipped
+func (m *DashboardModel) SetPackages(pkgs []*data.Package, pinned int) {
+	selected := m.Selected()
+	m.pkgs = pkgs
+	m.pinned = pinned
+	m.cursor = max(0, min(m.cursor, len(pkgs)-1))
+	for i, pkg := range pkgs {
+		if selected != nil && pkg.UniqueName() == selected.UniqueName() {
+			m.cursor = i
+		}
+	}
+}
+
+func (m *DashboardModel) Hide() {
+	m.visible = false
+	m.pkgs = nil
+}
+
+func (m *DashboardModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *DashboardModel) Selected() *data.Package {
+	if m.cursor >= 0 && m.cursor < len(m.pkgs) {
+		return m.pkgs[m.cursor]
+	}
+	return nil
+}
+
+// Outdated packages that weren't skipped
+func (m *DashboardModel) Pending() []*data.Package {
+	pending := []*data.Package{}
+	for _, pkg := range m.pkgs {
+		if pkg.IsOutdated && !m.skipped[pkg.UniqueName()] {
+			pending = append(pending, pkg)
+		}
+	}
+	return pending
+}
+
+func (m *DashboardModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	dashboardStyle = dashboardStyle.
+		BorderStyle(getRoundedBorderWithTitle("Outdated", width)).
+		Width(width)
+}
+
+func (m DashboardModel) Update(msg tea.Msg) (DashboardModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || len(m.pkgs) == 0 {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(keyMsg, m.down):
+		m.cursor = min(len(m.pkgs)-1, m.cursor+1)
+	case key.Matches(keyMsg, m.top):
+		m.cursor = 0
+	case key.Matches(keyMsg, m.bottom):
+		m.cursor = len(m.pkgs) - 1
+	case key.Matches(keyMsg, m.skip):
+		// Skipping again brings the package back, then move on to the next one
+		name := m.pkgs[m.cursor].UniqueName()
+		m.skipped[name] = !m.skipped[name]
+		m.cursor = min(len(m.pkgs)-1, m.cursor+1)
+	}
+	return m, nil
+}
+
+func (m DashboardModel) formatRow(pkg *data.Package) string {
+	state := ""
+	switch {
+	case !pkg.IsOutdated:
+		state = installedStyle.Render("upgraded")
+	case m.skipped[pkg.UniqueName()]:
+		state = uninstalledStyle.Render("skipped")
+	}
+	return fmt.Sprintf(
+		"%s  %s -> %s  %s",
+		fitCell(pkg.Name, dashboardNameWidth, false),
+		pkg.InstalledVersion,
+		pkg.Version,
+		state,
+	)
+}
+
+func (m DashboardModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	summary := fmt.Sprintf("%d packages to upgrade", len(m.Pending()))
+	if m.pinned > 0 {
+		summary += fmt.Sprintf(", %d pinned ones left as they are", m.pinned)
+	}
+	header := []string{
+		headerStyle.UnsetWidth().Render(summary),
+		fmt.Sprintf(
+			"%s: upgrade  %s: skip  %s: upgrade all not skipped  %s: full view  %s: quit",
+			keyStyle.Render("u"),
+			keyStyle.Render("s"),
+			keyStyle.Render("U"),
+			keyStyle.Render("enter"),
+			keyStyle.Render("q"),
+		),
+		"",
+	}
+
+	rows := []string{}
+	listHeight := max(1, m.height-len(header))
+	start := max(0, min(m.cursor-listHeight/2, len(m.pkgs)-listHeight))
+	end := min(len(m.pkgs), start+listHeight)
+	for i := start; i < end; i++ {
+		row := m.formatRow(m.pkgs[i])
+		if i == m.cursor {
+			row = queueSelectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+	if len(m.pkgs) == 0 {
+		rows = append(rows, "Everything is up to date.")
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, strings.Join(header, "\n"), strings.Join(rows, "\n"))
+	return dashboardStyle.Height(m.height).MaxHeight(m.height + 2).Render(content)
+}
@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestDashboardSkip(t *testing.T) {
+	pkgs := []*data.Package{
+		{Name: "curl", IsOutdated: true},
+		{Name: "node", IsOutdated: true},
+		{Name: "wget", IsOutdated: true},
+	}
+	m := NewDashboardModel()
+	m.Show(pkgs, 0)
+	skip := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")}
+	m, _ = m.Update(skip)
+	if got := m.Selected(); got.Name != "node" {
+		t.Errorf("expected skipping to move on to node, got %s", got.Name)
+	}
+
+	// An upgraded package isn't pending anymore, skipped ones stay skipped after a reload
+	pkgs[1].IsOutdated = false
+	m.SetPackages([]*data.Package{{Name: "curl", IsOutdated: true}, pkgs[2]}, 0)
+	pending := m.Pending()
+	if len(pending) != 1 || pending[0].Name != "wget" {
+		t.Errorf("expected only wget pending, got %v", pending)
+	}
+}
@@ -1,23 +1,58 @@
 package ui
 
 import (
+	"cmp"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
-	"taproom/internal/brew"
-	"taproom/internal/data"
-	"taproom/internal/util"
 	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
+	"github.com/hzqtc/taproom/internal/brew"
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/util"
+	"github.com/spf13/pflag"
 )
 
+var flagShowSources = pflag.Bool("show-sources", false, "Annotate fields in the details panel with where their data came from")
+
+// Sections of the details panel that can be collapsed, a collapsed one only shows how many entries it has
+const (
+	sectionReplacement       = "Replacement"
+	sectionConflicts         = "Conflicts"
+	sectionDependencies      = "Dependencies"
+	sectionBuildDependencies = "Build dependencies"
+	sectionRequiredBy        = "Required By"
+	sectionRelated           = "Related"
+)
+
+const (
+	collapsedSymbol = "▸"
+	expandedSymbol  = "▾"
+)
+
+// A line the cursor can be on: the header of a collapsible section, or a package listed in it
+type detailsItem struct {
+	section string
+	pkg     string // Empty for the header
+	offset  int    // Where the line starts in the content
+}
+
 type DetailsPanelModel struct {
-	pkg     *data.Package
-	content string
-	vp      viewport.Model
+	pkg            *data.Package
+	content        string
+	showProvenance bool
+	focused        bool
+	expanded       map[string]bool  // Sections expanded in this session, all are collapsed by default
+	items          []detailsItem    // Headers and packages of the sections of the current package
+	cursor         detailsItem      // Item under the cursor, kept when another package is selected
+	removalCost    brew.RemovalCost // Walks all dependents, so it's computed per package instead of per render
+	vp             viewport.Model
 }
 
 var (
@@ -45,6 +80,7 @@ var (
 )
 
 const (
+	unhealthySymbol           = ""
 	disabledSymbol            = "󰜺"
 	deprecatedSymbol          = "󰀦"
 	uninstalledSymbol         = "󰅖"
@@ -52,10 +88,13 @@ const (
 	explicitlyInstalledSymbol = "󰄭"
 	outdatedSymbol            = "󰓦"
 	pinnedSymbol              = "󰐃"
+	snoozedSymbol             = "󰒲"
+	snoozeOverSymbol          = "󰂞"
 )
 
 func NewDetailsPanelModel() DetailsPanelModel {
-	return DetailsPanelModel{}
+	// What to use instead of a deprecated package is worth seeing right away
+	return DetailsPanelModel{expanded: map[string]bool{sectionReplacement: true}}
 }
 
 func (m *DetailsPanelModel) SetDimension(width, height int) {
@@ -66,10 +105,107 @@ func (m *DetailsPanelModel) SetDimension(width, height int) {
 
 func (m *DetailsPanelModel) SetPackage(pkg *data.Package) {
 	m.pkg = pkg
+	m.updateRemovalCost()
 	m.updatePanel()
 }
 
+// Show the current state of the package after it was changed, e.g. by a command, staying at the same position
+func (m *DetailsPanelModel) Refresh() {
+	m.updateRemovalCost()
+	m.render()
+}
+
+func (m *DetailsPanelModel) updateRemovalCost() {
+	if m.pkg != nil && m.pkg.IsInstalled {
+		m.removalCost = brew.GetRemovalCost(m.pkg)
+	} else {
+		m.removalCost = brew.RemovalCost{}
+	}
+}
+
+// Expand or collapse the install provenance section
+func (m *DetailsPanelModel) ToggleProvenance() {
+	m.showProvenance = !m.showProvenance
+	m.updatePanel()
+}
+
+// Move the cursor to the next section header or package in a section
+func (m *DetailsPanelModel) NextItem() {
+	m.moveCursor(1)
+}
+
+// Move the cursor to the previous section header or package in a section
+func (m *DetailsPanelModel) PrevItem() {
+	m.moveCursor(-1)
+}
+
+func (m *DetailsPanelModel) moveCursor(delta int) {
+	if len(m.items) == 0 {
+		return
+	}
+	i := m.cursorIndex()
+	if i < 0 {
+		i = 0
+	} else {
+		i = max(0, min(len(m.items)-1, i+delta))
+	}
+	m.cursor = m.items[i]
+	m.render()
+	m.scrollToCursor()
+}
+
+// The package under the cursor, empty when the cursor is on a section header
+func (m *DetailsPanelModel) SelectedLink() string {
+	if m.cursorIndex() < 0 {
+		return ""
+	}
+	return m.cursor.pkg
+}
+
+// Expand or collapse the section under the cursor, or the first section when the cursor isn't on one
+func (m *DetailsPanelModel) ToggleSection() {
+	if len(m.items) == 0 {
+		return
+	}
+	if m.cursorIndex() < 0 {
+		m.cursor = m.items[0]
+	}
+	// Collapsing a section from one of its packages moves the cursor to the header
+	m.cursor.pkg = ""
+	m.expanded[m.cursor.section] = !m.expanded[m.cursor.section]
+	m.render()
+	m.scrollToCursor()
+}
+
+func (m *DetailsPanelModel) cursorIndex() int {
+	for i, item := range m.items {
+		if item.section == m.cursor.section && item.pkg == m.cursor.pkg {
+			return i
+		}
+	}
+	return -1
+}
+
+// Scroll the item under the cursor into view
+func (m *DetailsPanelModel) scrollToCursor() {
+	i := m.cursorIndex()
+	if i < 0 {
+		return
+	}
+	// Lines before the item, after wrapping them like the viewport does
+	before := lipgloss.NewStyle().Width(m.vp.Width).Render(m.content[:m.items[i].offset])
+	line := strings.Count(before, "\n")
+	if line < m.vp.YOffset || line >= m.vp.YOffset+m.vp.Height {
+		m.vp.SetYOffset(line)
+	}
+}
+
 func (m *DetailsPanelModel) SetFocused(focused bool) {
+	if m.focused != focused {
+		m.focused = focused
+		// The cursor is only shown when the panel is focused
+		m.render()
+	}
 	if focused {
 		detailPanelStyle = detailPanelStyle.BorderForeground(focusedBorderColor)
 	} else {
@@ -94,12 +230,18 @@ func formatStatus(pkg *data.Package) string {
 }
 
 func formatStatusSymbol(pkg *data.Package) string {
-	if pkg.IsDisabled {
+	if pkg.IsUnhealthy() {
+		return deprecatedStyle.Render(unhealthySymbol)
+	} else if pkg.IsDisabled {
 		return deprecatedStyle.Render(disabledSymbol)
 	} else if pkg.IsDeprecated {
 		return deprecatedStyle.Render(deprecatedSymbol)
 	} else if pkg.IsPinned {
 		return pinnedStyle.Render(pinnedSymbol)
+	} else if pkg.IsSnoozed() {
+		return pinnedStyle.Render(snoozedSymbol)
+	} else if pkg.IsSnoozeOver() {
+		return outdatedStyle.Render(snoozeOverSymbol)
 	} else if pkg.IsOutdated {
 		return outdatedStyle.Render(outdatedSymbol)
 	} else if pkg.IsInstalled {
@@ -113,95 +255,509 @@ func formatStatusSymbol(pkg *data.Package) string {
 	}
 }
 
+// Stars, open issues and the last commit of the upstream repository, archived repositories are no longer maintained
+func formatRepoInfo(repo *data.RepoInfo) string {
+	info := fmt.Sprintf("%d stars, %d open issues", repo.Stars, repo.OpenIssues)
+	if !repo.LastCommit.IsZero() {
+		info += ", last commit on " + repo.LastCommit.Format(time.DateOnly)
+	}
+	if repo.Archived {
+		info += deprecatedStyle.Render(fmt.Sprintf(" %s archived", deprecatedSymbol))
+	}
+	return info
+}
+
+// Reasons brew gives for deprecating or disabling packages, custom reasons are shown as they are
+var deprecationReasons = map[string]string{
+	"checksum_mismatch":        "the source changed after it was released",
+	"deprecated_upstream":      "deprecated upstream",
+	"discontinued":             "discontinued upstream",
+	"does_not_build":           "doesn't build",
+	"fails_gatekeeper_check":   "fails the macOS Gatekeeper check",
+	"moved_to_mas":             "moved to the Mac App Store",
+	"no_license":               "no license",
+	"no_longer_available":      "no longer available upstream",
+	"no_longer_meets_criteria": "no longer meets the criteria for casks",
+	"repo_archived":            "upstream repository archived",
+	"repo_removed":             "upstream repository removed",
+	"unmaintained":             "not maintained upstream",
+	"unsigned":                 "unsigned app",
+	"unsupported":              "not supported upstream",
+	"versioned_formula":        "versioned formula",
+}
+
+func formatDeprecationReason(reason string) string {
+	reason = strings.TrimPrefix(reason, ":")
+	if text, ok := deprecationReasons[reason]; ok {
+		return text
+	}
+	return reason
+}
+
+// Why and when the package was deprecated or disabled, and what to use instead
+func formatDeprecation(pkg *data.Package, d *data.Deprecation) string {
+	symbol, text, date, reason := deprecatedSymbol, "Deprecated", d.Date, d.Reason
+	if pkg.IsDisabled {
+		symbol, text, date, reason = disabledSymbol, "Disabled", d.DisableDate, cmp.Or(d.DisableReason, d.Reason)
+	}
+	text = symbol + " " + text
+	if date != "" {
+		text += " on " + date
+	}
+	if reason != "" {
+		text += ": " + formatDeprecationReason(reason)
+	}
+	lines := []string{deprecatedStyle.Render(text)}
+	if !pkg.IsDisabled && d.DisableDate != "" {
+		lines = append(lines, deprecatedStyle.Render("To be disabled on "+d.DisableDate))
+	}
+	if d.Replacement != "" {
+		lines = append(lines, "Use instead: "+keyStyle.Render(d.Replacement))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// Bars of the values scaled between their minimum and maximum, flat values are drawn at mid height
+func sparkline(values []int) string {
+	lo, hi := slices.Min(values), slices.Max(values)
+	bars := make([]rune, len(values))
+	for i, v := range values {
+		if hi == lo {
+			bars[i] = sparkBars[len(sparkBars)/2-1]
+		} else {
+			bars[i] = sparkBars[(v-lo)*(len(sparkBars)-1)/(hi-lo)]
+		}
+	}
+	return string(bars)
+}
+
+const (
+	fontSample = "The quick brown fox jumps over the lazy dog 0O 1lI {}[]"
+	// Icons of Nerd Fonts, which only show up when the terminal uses one
+	nerdFontSample = "\uf09b \ue7a8 \uf121 \uf07c \ue795"
+)
+
+// Families and styles of a font cask, and a sample line once it's installed
+func formatFont(pkg *data.Package) string {
+	var b strings.Builder
+	b.WriteString("\nFont:\n")
+	for _, f := range pkg.FontFamilies() {
+		b.WriteString(fmt.Sprintf("  %s: %s\n", keyStyle.Render(f.Name), strings.Join(f.Styles, ", ")))
+	}
+	if pkg.IsInstalled {
+		sample := fontSample
+		if strings.Contains(pkg.Name, "nerd-font") {
+			sample += " " + nerdFontSample
+		}
+		// Terminals draw everything in one font, the sample shows the font when the terminal is set to use it
+		b.WriteString(fmt.Sprintf("  Sample: %s\n", sample))
+		b.WriteString("  Set the terminal's font to one of the families above to preview it\n")
+	}
+	return b.String()
+}
+
+func formatAnalyticsCounts(c data.AnalyticsCounts) string {
+	return fmt.Sprintf("%d / %d / %d", c.Days30, c.Days90, c.Days365)
+}
+
 // Use OSC8 to wrap a string in a hyperlink. The id lets terminals underline the
 // whole link on hover even when it wraps across multiple lines.
 func hyperLink(url, text string) string {
 	return ansi.SetHyperlink(url, "id=link") + text + ansi.ResetHyperlink()
 }
 
+// Annotate a field with where its data came from, when enabled with --show-sources
+func withSource(text string, sources ...data.Source) string {
+	if !*flagShowSources || len(sources) == 0 {
+		return text
+	}
+	names := make([]string, len(sources))
+	for i, source := range sources {
+		names[i] = string(source)
+	}
+	return text + uninstalledStyle.Render(" ["+strings.Join(names, ", ")+"]")
+}
+
+// Sources of the version line, which shows the installed version too when it's different
+func versionSources(pkg *data.Package) []data.Source {
+	if pkg.IsOutdated {
+		return []data.Source{pkg.Sources.InstalledVersion, pkg.Sources.Catalog}
+	} else if pkg.IsPinned {
+		return []data.Source{pkg.Sources.InstalledVersion}
+	}
+	return []data.Source{pkg.Sources.Catalog}
+}
+
+func formatLinked(pkg *data.Package) string {
+	linked := "no"
+	if pkg.IsLinked {
+		linked = "yes"
+	}
+	if pkg.IsKegOnly {
+		linked += " (keg-only)"
+	}
+	return linked
+}
+
+// Packages named in a summary line, the rest are only counted
+const maxRemovalNames = 5
+
+func formatRemovalCost(cost brew.RemovalCost) string {
+	if len(cost.Dependents) > 0 {
+		return outdatedStyle.Render(fmt.Sprintf("Removing: required by %d installed packages", len(cost.Dependents))) + "\n"
+	}
+	if len(cost.Orphans) == 0 {
+		return ""
+	}
+	names := cost.Orphans
+	if len(names) > maxRemovalNames {
+		names = names[:maxRemovalNames]
+	}
+	orphans := strings.Join(names, ", ")
+	if len(cost.Orphans) > len(names) {
+		orphans += fmt.Sprintf(" and %d more", len(cost.Orphans)-len(names))
+	}
+	removing := fmt.Sprintf("Removing: orphans %d packages (%s)", len(cost.Orphans), orphans)
+	// Sizes are unknown when the size column is hidden
+	if cost.Size > 0 {
+		removing += ", frees " + util.FormatSize(cost.Size)
+	}
+	return removing + "\n"
+}
+
+// Explain why installing a formula macOS already provides seems to do nothing
+func formatProvidedByMacOS(pkg *data.Package) string {
+	bin := filepath.Join(brew.Prefix(), "opt", pkg.Name, "bin")
+	return outdatedStyle.Render("Provided by macOS: installing it doesn't replace the copy shipped with macOS") + "\n" +
+		fmt.Sprintf("  brew keeps it keg-only so the system one stays first in PATH, only formulae depending on it use it;\n"+
+			"  run it from %s or prepend that to PATH\n", keyStyle.Render(bin))
+}
+
+func formatArtifactKind(kind string) string {
+	switch kind {
+	case "launchctl":
+		return "launch agent"
+	case "pkg":
+		return "package installer"
+	default:
+		return strings.ReplaceAll(kind, "_", " ")
+	}
+}
+
+func formatProvenance(pkg *data.Package, p *data.Provenance, expanded bool) string {
+	if !expanded {
+		return fmt.Sprintf("Install provenance: %s to show\n", keyStyle.Render("w"))
+	}
+	orMissing := func(s string) string {
+		if s == "" {
+			return uninstalledStyle.Render("(none)")
+		}
+		return s
+	}
+	tap := orMissing(p.Tap)
+	if p.Tap != "" && p.Tap != pkg.Tap {
+		tap = deprecatedStyle.Render(fmt.Sprintf("%s %s (catalog says %s)", deprecatedSymbol, p.Tap, pkg.Tap))
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Install provenance (%s to hide):\n", keyStyle.Render("w")))
+	b.WriteString(fmt.Sprintf("  Receipt: %s\n", orMissing(p.Receipt)))
+	b.WriteString(fmt.Sprintf("  Tap: %s\n", tap))
+	b.WriteString(fmt.Sprintf("  Source: %s\n", orMissing(p.SourcePath)))
+	return b.String()
+}
+
+func formatVerification(v *data.Verification) string {
+	var b strings.Builder
+	if len(v.Problems) == 0 && v.BottleCompared {
+		b.WriteString(fmt.Sprintf("Verified: %s\n", installedStyle.Render(installedSymbol+" matches the bottle")))
+	} else if len(v.Problems) == 0 {
+		b.WriteString(fmt.Sprintf("Verified: %s\n", installedStyle.Render(installedSymbol+" no problems found (bottle not compared)")))
+	} else {
+		b.WriteString(deprecatedStyle.Render(fmt.Sprintf("%s Verified: the keg may be tampered with", deprecatedSymbol)) + "\n")
+		for _, problem := range v.Problems {
+			b.WriteString(deprecatedStyle.Render("  "+problem) + "\n")
+		}
+	}
+	for _, note := range v.Notes {
+		b.WriteString(uninstalledStyle.Render("  "+note) + "\n")
+	}
+	return b.String()
+}
+
 func (m *DetailsPanelModel) updatePanel() {
+	m.render()
+	m.vp.GotoTop()
+}
+
+func (m *DetailsPanelModel) render() {
 	if m.pkg == nil {
+		m.items = nil
 		m.vp.SetContent("No packages selected.")
 		return
 	}
 
 	var b strings.Builder
 	b.WriteString(headerStyle.Render(fmt.Sprintf("%s %s", m.pkg.Symbol(), m.pkg.Name)))
-	b.WriteString(fmt.Sprintf("\n%s\n\n", m.pkg.Desc))
-	b.WriteString(fmt.Sprintf("Version: %s\n", m.pkg.LongVersion()))
-	b.WriteString(fmt.Sprintf("Tap: %s\n", m.pkg.Tap))
-	b.WriteString(fmt.Sprintf("Homepage: %s\n", hyperLink(m.pkg.Homepage, m.pkg.Homepage)))
+	b.WriteString(fmt.Sprintf("\n%s\n\n", m.pkg.Desc()))
+	if d := m.pkg.Deprecation; d != nil {
+		b.WriteString(formatDeprecation(m.pkg, d) + "\n")
+	}
+	version := withSource(m.pkg.LongVersion(), versionSources(m.pkg)...)
+	if m.pkg.PreviousVersion != "" {
+		version += fmt.Sprintf(" (%s in the last session)", m.pkg.PreviousVersion)
+	}
+	b.WriteString(fmt.Sprintf("Version: %s\n", version))
+	b.WriteString(fmt.Sprintf("Tap: %s\n", withSource(m.pkg.Tap, m.pkg.Sources.Catalog)))
+	b.WriteString(fmt.Sprintf("Homepage: %s\n", hyperLink(m.pkg.Homepage(), m.pkg.Homepage())))
 	b.WriteString(fmt.Sprintf("License: %s\n", m.pkg.License))
-	b.WriteString(fmt.Sprintf("Installs (90d): %d\n", m.pkg.Installs90d))
+	if repo := m.pkg.Repo; repo != nil {
+		b.WriteString(fmt.Sprintf("GitHub: %s\n", withSource(formatRepoInfo(repo), data.SourceGitHub)))
+	}
+	installs := strconv.Itoa(m.pkg.Installs90d)
+	if len(m.pkg.InstallsTrend) > 1 {
+		installs += " " + sparkline(m.pkg.InstallsTrend)
+	}
+	b.WriteString(fmt.Sprintf("Installs (90d): %s\n", withSource(installs, data.SourceAnalytics)))
+	if m.pkg.BuildErrors90d > 0 {
+		buildErrors := fmt.Sprintf("Build errors (90d): %d", m.pkg.BuildErrors90d)
+		if m.pkg.Installs90d > 0 {
+			buildErrors += fmt.Sprintf(" (%.1f%% of installs)", m.pkg.BuildErrorRate()*100)
+		}
+		if m.pkg.HasHighBuildErrorRate() {
+			buildErrors = deprecatedStyle.Render(fmt.Sprintf("%s %s, building from source often fails", deprecatedSymbol, buildErrors))
+		}
+		b.WriteString(buildErrors + "\n")
+	}
+
+	if reason := m.pkg.IncompatibleReason(brew.CurrentPlatform()); reason != "" {
+		b.WriteString(deprecatedStyle.Render(fmt.Sprintf("%s Incompatible: %s", deprecatedSymbol, reason)) + "\n")
+	}
+
+	if m.pkg.RequiresSudo {
+		b.WriteString(deprecatedStyle.Render(fmt.Sprintf("%s Requires sudo: installing asks for an admin password, run it in a terminal", deprecatedSymbol)) + "\n")
+	}
+
+	if !m.pkg.IsCask {
+		if m.pkg.HasBottle(brew.CurrentPlatform()) {
+			b.WriteString(fmt.Sprintf("Installs from: %s\n", installedStyle.Render("bottle")))
+		} else {
+			pain := brew.EstimateCompilePain(m.pkg)
+			source := fmt.Sprintf("source, compile pain: %s", pain.Level)
+			if len(pain.MissingBuildDeps) > 0 {
+				source += fmt.Sprintf(" (%d build dependencies to install)", len(pain.MissingBuildDeps))
+			}
+			if pain.Level == brew.CompilePainHigh {
+				source = deprecatedStyle.Render(source)
+			} else {
+				source = outdatedStyle.Render(source)
+			}
+			b.WriteString(fmt.Sprintf("Installs from: %s\n", source))
+		}
+		if m.pkg.ProvidedByMacOS && brew.CurrentPlatform().OS == data.OSMacOS {
+			b.WriteString(formatProvidedByMacOS(m.pkg))
+		}
+	}
 
 	b.WriteString(fmt.Sprintf("\nStatus: %s\n", formatStatus(m.pkg)))
+	if m.pkg.IsSnoozed() {
+		b.WriteString(fmt.Sprintf("Snoozed until %s (press %s)\n", m.pkg.SnoozedUntil.Format(time.DateOnly), keyStyle.Render("Z")))
+	} else if m.pkg.IsSnoozeOver() {
+		b.WriteString(outdatedStyle.Render(fmt.Sprintf("%s Snooze over since %s", snoozeOverSymbol, m.pkg.SnoozedUntil.Format(time.DateOnly))) + "\n")
+	}
+	if m.pkg.BrokenInstall != "" {
+		b.WriteString(deprecatedStyle.Render(fmt.Sprintf("%s Broken install: %s", deprecatedSymbol, m.pkg.BrokenInstall)) + "\n")
+		b.WriteString(fmt.Sprintf("Repair with: %s\n", keyStyle.Render(brew.RepairSuggestion(m.pkg))))
+	} else if len(m.pkg.HealthProblems) > 0 {
+		for _, problem := range m.pkg.HealthProblems {
+			b.WriteString(deprecatedStyle.Render(fmt.Sprintf("%s %s", unhealthySymbol, problem)) + "\n")
+		}
+		b.WriteString(fmt.Sprintf("Repair with: %s (press %s)\n", keyStyle.Render(brew.RepairSuggestion(m.pkg)), keyStyle.Render("Y")))
+	}
+	if priority := m.pkg.UpgradePriority(); priority != data.PriorityNone {
+		b.WriteString(fmt.Sprintf("Upgrade priority: %s (%s)\n", outdatedStyle.Render(priority.String()), m.pkg.UpgradePriorityReason()))
+	}
 	if m.pkg.IsInstalled {
-		b.WriteString(fmt.Sprintf("Size: %s\n", m.pkg.FormattedSize))
-		b.WriteString(fmt.Sprintf("Installed on: %s\n", m.pkg.InstalledDate))
+		b.WriteString(fmt.Sprintf("Size: %s\n", m.pkg.FormattedSize()))
+		b.WriteString(formatRemovalCost(m.removalCost))
+		if !m.pkg.IsCask {
+			b.WriteString(fmt.Sprintf("Linked: %s\n", formatLinked(m.pkg)))
+		}
+		b.WriteString(fmt.Sprintf("Installed on: %s\n", withSource(m.pkg.InstalledDate, m.pkg.Sources.InstalledDate)))
 		if release := m.pkg.ReleaseInfo; release != nil {
-			b.WriteString(fmt.Sprintf("Released on: %s\n", release.Date.Format(time.DateOnly)))
+			b.WriteString(fmt.Sprintf("Released on: %s\n", withSource(release.Date.Format(time.DateOnly), data.SourceGitHub)))
+		}
+		if p := m.pkg.Provenance; p != nil {
+			b.WriteString(formatProvenance(m.pkg, p, m.showProvenance))
+		}
+		if v := m.pkg.Verification; v != nil {
+			b.WriteString(formatVerification(v))
 		}
 	}
 
-	if len(m.pkg.Conflicts) > 0 {
-		b.WriteString("\nConflicts:\n")
-		for _, c := range m.pkg.Conflicts {
-			if p := brew.GetPackage(c); p != nil {
-				b.WriteString(fmt.Sprintf("  %s %s\n", formatStatusSymbol(p), c))
+	if len(m.pkg.Options) > 0 {
+		b.WriteString(fmt.Sprintf("Options: %s\n", strings.Join(m.pkg.Options, ", ")))
+	}
+	if len(m.pkg.Languages) > 0 {
+		b.WriteString(fmt.Sprintf("Languages: %s\n", strings.Join(m.pkg.Languages, ", ")))
+		if lang := brew.CaskLanguage(m.pkg); lang != "" {
+			b.WriteString(fmt.Sprintf("Installs in: %s\n", keyStyle.Render(lang)))
+		}
+	}
+	if len(m.pkg.Variants) > 0 {
+		b.WriteString(fmt.Sprintf("Variants: %s\n", strings.Join(m.pkg.Variants, ", ")))
+	}
+
+	if m.pkg.IsFont() {
+		b.WriteString(formatFont(m.pkg))
+	}
+
+	if len(m.pkg.Artifacts) > 0 {
+		b.WriteString("\nInstalls:\n")
+		for _, a := range m.pkg.Artifacts {
+			b.WriteString(fmt.Sprintf("  %s: %s\n", formatArtifactKind(a.Kind), a.Target))
+		}
+	}
+
+	if a := m.pkg.Analytics; a != nil {
+		b.WriteString("\nAnalytics (30d / 90d / 365d):\n")
+		b.WriteString(fmt.Sprintf("  Installs on macOS: %s\n", formatAnalyticsCounts(a.Installs)))
+		if !m.pkg.IsCask {
+			b.WriteString(fmt.Sprintf("  Installs on Linux: %s\n", formatAnalyticsCounts(a.InstallsLinux)))
+			b.WriteString(fmt.Sprintf("  Installs on request: %s\n", formatAnalyticsCounts(a.InstallsOnRequest)))
+			buildErrors := fmt.Sprintf("  Build errors: %s", formatAnalyticsCounts(a.BuildErrors))
+			if a.BuildErrors.Days30 > 0 {
+				buildErrors = deprecatedStyle.Render(buildErrors)
 			}
+			b.WriteString(buildErrors + "\n")
 		}
 	}
 
-	if len(m.pkg.Dependencies) > 0 {
-		b.WriteString("\nDependencies:\n")
-		for _, dep := range m.pkg.Dependencies {
-			depPkg := brew.GetPackage(dep)
-			if depPkg == nil {
-				continue
+	if len(m.pkg.Vulnerabilities) > 0 {
+		b.WriteString("\nSecurity:\n")
+		b.WriteString(fmt.Sprintf("  %d known vulnerabilities in the installed version %s\n", len(m.pkg.Vulnerabilities), m.pkg.InstalledVersion))
+		for _, id := range m.pkg.Vulnerabilities {
+			b.WriteString(fmt.Sprintf("  %s %s\n", deprecatedStyle.Render(deprecatedSymbol), hyperLink("https://osv.dev/vulnerability/"+id, id)))
+		}
+		if m.pkg.IsOutdated {
+			b.WriteString(fmt.Sprintf("  Upgrading to %s may fix them\n", m.pkg.Version))
+		}
+	}
+
+	m.items = nil
+	if d := m.pkg.Deprecation; d != nil && d.Replacement != "" {
+		if p := brew.GetPackage(d.Replacement); p != nil {
+			m.writeSection(&b, sectionReplacement, 1, func() {
+				m.writeEntry(&b, sectionReplacement, p, 1)
+			})
+		}
+	}
+	if len(m.pkg.Conflicts) > 0 {
+		m.writeSection(&b, sectionConflicts, len(m.pkg.Conflicts), func() {
+			for _, c := range m.pkg.Conflicts {
+				if p := brew.GetPackage(c); p != nil {
+					m.writeEntry(&b, sectionConflicts, p, 1)
+				}
 			}
-			b.WriteString(fmt.Sprintf("  %s %s\n", formatStatusSymbol(depPkg), dep))
-			if !depPkg.IsInstalled {
-				// For uninstalled dependencies, show all recursive uninstalled dependencies
-				recursiveDeps := util.SortAndUniq(brew.GetRecursiveMissingDeps(dep))
-				for _, d := range recursiveDeps {
-					if p := brew.GetPackage(d); p != nil && !p.IsInstalled {
-						b.WriteString(fmt.Sprintf("    %s %s\n", formatStatusSymbol(p), d))
+		})
+	}
+
+	if len(m.pkg.Dependencies) > 0 {
+		m.writeSection(&b, sectionDependencies, len(m.pkg.Dependencies), func() {
+			for _, dep := range m.pkg.Dependencies {
+				depPkg := brew.GetPackage(dep)
+				if depPkg == nil {
+					continue
+				}
+				m.writeEntry(&b, sectionDependencies, depPkg, 1)
+				if !depPkg.IsInstalled {
+					// For uninstalled dependencies, show all recursive uninstalled dependencies
+					recursiveDeps := util.SortAndUniq(brew.GetRecursiveMissingDeps(dep))
+					for _, d := range recursiveDeps {
+						if p := brew.GetPackage(d); p != nil && !p.IsInstalled {
+							m.writeEntry(&b, sectionDependencies, p, 2)
+						}
 					}
 				}
 			}
-		}
+		})
 	}
 
 	if len(m.pkg.BuildDependencies) > 0 {
-		b.WriteString("\nBuild dependencies:\n")
-		for _, dep := range m.pkg.BuildDependencies {
-			if p := brew.GetPackage(dep); p != nil {
-				b.WriteString(fmt.Sprintf("  %s %s\n", formatStatusSymbol(p), dep))
+		m.writeSection(&b, sectionBuildDependencies, len(m.pkg.BuildDependencies), func() {
+			for _, dep := range m.pkg.BuildDependencies {
+				if p := brew.GetPackage(dep); p != nil {
+					m.writeEntry(&b, sectionBuildDependencies, p, 1)
+				}
 			}
-		}
+		})
 	}
 
 	if len(m.pkg.Dependents) > 0 {
-		b.WriteString("\nRequired By:\n")
-		for _, dep := range m.pkg.Dependents {
-			depPkg := brew.GetPackage(dep)
-			if depPkg == nil {
-				continue
-			}
-			b.WriteString(fmt.Sprintf("  %s %s\n", formatStatusSymbol(depPkg), dep))
-			if depPkg.IsInstalled {
-				// For installed dependents, show all recursive explicitly installed dependents
-				recursiveDependents := util.SortAndUniq(brew.GetRecursiveInstalledDependents(dep))
-				for _, d := range recursiveDependents {
-					if p := brew.GetPackage(d); p != nil && p.IsInstalled && !p.InstalledAsDependency {
-						b.WriteString(fmt.Sprintf("    %s %s\n", formatStatusSymbol(p), d))
+		m.writeSection(&b, sectionRequiredBy, len(m.pkg.Dependents), func() {
+			for _, dep := range m.pkg.Dependents {
+				depPkg := brew.GetPackage(dep)
+				if depPkg == nil {
+					continue
+				}
+				m.writeEntry(&b, sectionRequiredBy, depPkg, 1)
+				if depPkg.IsInstalled {
+					// For installed dependents, show all recursive explicitly installed dependents
+					recursiveDependents := util.SortAndUniq(brew.GetRecursiveInstalledDependents(dep))
+					for _, d := range recursiveDependents {
+						if p := brew.GetPackage(d); p != nil && p.IsInstalled && !p.InstalledAsDependency {
+							m.writeEntry(&b, sectionRequiredBy, p, 2)
+						}
 					}
 				}
 			}
-		}
+		})
+	}
+
+	if related := brew.RelatedPackages(m.pkg); len(related) > 0 {
+		m.writeSection(&b, sectionRelated, len(related), func() {
+			for _, p := range related {
+				m.writeEntry(&b, sectionRelated, p, 1)
+			}
+		})
 	}
 
 	m.content = b.String()
 	m.vp.SetContent(lipgloss.NewStyle().Width(m.vp.Width).Render(m.content))
-	m.vp.GotoTop()
+}
+
+// Write the header of a collapsible section, and its entries when it's expanded
+func (m *DetailsPanelModel) writeSection(b *strings.Builder, name string, count int, writeEntries func()) {
+	b.WriteString("\n")
+	item := detailsItem{section: name, offset: b.Len()}
+	m.items = append(m.items, item)
+	symbol := collapsedSymbol
+	if m.expanded[name] {
+		symbol = expandedSymbol
+	}
+	header := fmt.Sprintf("%s %s (%d)", symbol, name, count)
+	if m.isCursor(item) {
+		header = keyStyle.Render(header)
+	}
+	b.WriteString(header + "\n")
+	if m.expanded[name] {
+		writeEntries()
+	}
+}
+
+// Write a package listed in a section, which the cursor can select to jump to it
+func (m *DetailsPanelModel) writeEntry(b *strings.Builder, section string, pkg *data.Package, depth int) {
+	item := detailsItem{section: section, pkg: pkg.UniqueName(), offset: b.Len()}
+	m.items = append(m.items, item)
+	name := pkg.UniqueName()
+	if m.isCursor(item) {
+		name = keyStyle.Render(name)
+	}
+	b.WriteString(fmt.Sprintf("%s%s %s\n", strings.Repeat("  ", depth), formatStatusSymbol(pkg), name))
+}
+
+func (m *DetailsPanelModel) isCursor(item detailsItem) bool {
+	return m.focused && item.section == m.cursor.section && item.pkg == m.cursor.pkg
 }
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestCollapsibleSections(t *testing.T) {
+	m := NewDetailsPanelModel()
+	m.SetDimension(80, 40)
+	m.SetPackage(&data.Package{Name: "wget", Dependencies: []string{"openssl@3", "libidn2"}, Dependents: []string{"curl"}})
+	if !strings.Contains(m.content, collapsedSymbol+" Dependencies (2)") || !strings.Contains(m.content, collapsedSymbol+" Required By (1)") {
+		t.Errorf("expected sections to be collapsed by default, got %q", m.content)
+	}
+
+	m.NextItem()
+	m.NextItem()
+	m.ToggleSection()
+	if m.cursor.section != sectionRequiredBy || !m.expanded[sectionRequiredBy] {
+		t.Errorf("expected Required By to be expanded, got section %q expanded %v", m.cursor.section, m.expanded)
+	}
+	if link := m.SelectedLink(); link != "" {
+		t.Errorf("expected no link on a section header, got %q", link)
+	}
+
+	// Collapse state and the cursor carry over to other packages
+	m.SetPackage(&data.Package{Name: "curl", Dependents: []string{"git"}})
+	if !strings.Contains(m.content, expandedSymbol+" Required By (1)") {
+		t.Errorf("expected Required By to stay expanded, got %q", m.content)
+	}
+	m.ToggleSection()
+	if m.expanded[sectionRequiredBy] {
+		t.Errorf("expected Required By to be collapsed again")
+	}
+}
+
+func TestCursorOnEntries(t *testing.T) {
+	m := NewDetailsPanelModel()
+	m.SetDimension(80, 40)
+	m.expanded[sectionConflicts] = true
+	m.SetPackage(&data.Package{Name: "wget", Conflicts: []string{"wget2"}})
+
+	// Packages missing from the catalog aren't listed, so there's nothing to jump to
+	m.NextItem()
+	m.NextItem()
+	if len(m.items) != 1 || m.SelectedLink() != "" {
+		t.Errorf("expected only the header to be selectable, got %+v", m.items)
+	}
+
+	var b strings.Builder
+	m.cursor = detailsItem{section: sectionConflicts, pkg: "wget2"}
+	m.writeEntry(&b, sectionConflicts, &data.Package{Name: "wget2"}, 1)
+	if link := m.SelectedLink(); link != "wget2" {
+		t.Errorf("expected the cursor on wget2, got %q", link)
+	}
+}
+
+func TestFormatDeprecation(t *testing.T) {
+	pkg := &data.Package{Name: "foo", IsDeprecated: true}
+	d := &data.Deprecation{Date: "2024-01-01", Reason: "repo_archived", DisableDate: "2025-01-01", Replacement: "bar"}
+	text := formatDeprecation(pkg, d)
+	for _, want := range []string{"Deprecated on 2024-01-01: upstream repository archived", "To be disabled on 2025-01-01", "bar"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q, got %q", want, text)
+		}
+	}
+
+	pkg.IsDisabled = true
+	d.DisableReason = "Use the official cask instead"
+	if text := formatDeprecation(pkg, d); !strings.Contains(text, "Disabled on 2025-01-01: Use the official cask instead") || strings.Contains(text, "To be disabled") {
+		t.Errorf("expected the disable date and custom reason, got %q", text)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if s := sparkline([]int{10, 20, 30, 80}); s != "▁▂▃█" {
+		t.Errorf("expected ▁▂▃█, got %s", s)
+	}
+	if s := sparkline([]int{5, 5}); s != "▄▄" {
+		t.Errorf("expected flat values at mid height, got %s", s)
+	}
+}
+
+func TestFormatFont(t *testing.T) {
+	pkg := &data.Package{Name: "font-hack-nerd-font", IsCask: true, Artifacts: []data.CaskArtifact{
+		{Kind: "font", Target: "HackNerdFont-Bold.ttf"},
+		{Kind: "font", Target: "HackNerdFont-Regular.ttf"},
+	}}
+	if s := formatFont(pkg); !strings.Contains(s, "HackNerdFont") || !strings.Contains(s, ": Bold, Regular") || strings.Contains(s, "Sample") {
+		t.Errorf("expected the family and its styles without a sample, got %q", s)
+	}
+	pkg.IsInstalled = true
+	if s := formatFont(pkg); !strings.Contains(s, "Sample: "+fontSample+" "+nerdFontSample) {
+		t.Errorf("expected a sample with Nerd Font icons, got %q", s)
+	}
+}
+
+func TestFormatVerification(t *testing.T) {
+	tests := []struct {
+		v    data.Verification
+		want string
+	}{
+		{data.Verification{BottleCompared: true}, "matches the bottle"},
+		{data.Verification{Notes: []string{"built from source, there is no bottle to compare with"}}, "no problems found (bottle not compared)"},
+		{data.Verification{Problems: []string{"INSTALL_RECEIPT.json is missing or invalid"}}, "the keg may be tampered with"},
+	}
+	for _, test := range tests {
+		if got := formatVerification(&test.v); !strings.Contains(got, test.want) {
+			t.Errorf("expected %q in %q", test.want, got)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/util"
+)
+
+type diskUsageScope int
+
+const (
+	diskUsageAll diskUsageScope = iota
+	diskUsageCellar
+	diskUsageCaskroom
+)
+
+func (s diskUsageScope) String() string {
+	switch s {
+	case diskUsageCellar:
+		return "Cellar"
+	case diskUsageCaskroom:
+		return "Caskroom"
+	default:
+		return "all"
+	}
+}
+
+// A package in the disk usage list, with its share of the total and the running share up to it
+type diskUsageRow struct {
+	pkg        *data.Package
+	percent    float64
+	cumulative float64
+}
+
+// DiskUsageModel lists installed packages by size, largest first, like ncdu for Homebrew
+type DiskUsageModel struct {
+	pkgs     []*data.Package
+	rows     []diskUsageRow
+	cellar   int64
+	caskroom int64
+	scope    diskUsageScope
+	cursor   int
+	visible  bool
+	width    int
+	height   int
+
+	up       key.Binding
+	down     key.Binding
+	top      key.Binding
+	bottom   key.Binding
+	scopeKey key.Binding
+}
+
+var diskUsageStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+const (
+	diskUsageNameWidth = 30
+	diskUsageBarWidth  = 30
+)
+
+func NewDiskUsageModel() DiskUsageModel {
+	return DiskUsageModel{
+		up:       key.NewBinding(key.WithKeys("k", "up")),
+		down:     key.NewBinding(key.WithKeys("j", "down")),
+		top:      key.NewBinding(key.WithKeys("g", "home")),
+		bottom:   key.NewBinding(key.WithKeys("G", "end")),
+		scopeKey: key.NewBinding(key.WithKeys("c")),
+	}
+}
+
+func (m *DiskUsageModel) Show(pkgs []*data.Package) {
+	m.pkgs = pkgs
+	m.cellar, m.caskroom = 0, 0
+	for _, pkg := range pkgs {
+		if !pkg.IsInstalled {
+			continue
+		}
+		if pkg.IsCask {
+			m.caskroom += pkg.Size
+		} else {
+			m.cellar += pkg.Size
+		}
+	}
+	m.scope = diskUsageAll
+	m.rows = diskUsageRows(pkgs, m.scope)
+	m.cursor = 0
+	m.visible = true
+}
+
+func (m *DiskUsageModel) Hide() {
+	m.visible = false
+	m.pkgs = nil
+	m.rows = nil
+}
+
+func (m *DiskUsageModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *DiskUsageModel) Selected() *data.Package {
+	if m.cursor >= 0 && m.cursor < len(m.rows) {
+		return m.rows[m.cursor].pkg
+	}
+	return nil
+}
+
+func (m *DiskUsageModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	diskUsageStyle = diskUsageStyle.
+		BorderStyle(getRoundedBorderWithTitle("Disk Usage", width)).
+		Width(width)
+}
+
+// Installed packages in the scope sorted by size, with the percentages of the scope's total
+func diskUsageRows(pkgs []*data.Package, scope diskUsageScope) []diskUsageRow {
+	rows := []diskUsageRow{}
+	var total int64
+	for _, pkg := range pkgs {
+		if !pkg.IsInstalled || (scope == diskUsageCellar && pkg.IsCask) || (scope == diskUsageCaskroom && !pkg.IsCask) {
+			continue
+		}
+		rows = append(rows, diskUsageRow{pkg: pkg})
+		total += pkg.Size
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].pkg.Size != rows[j].pkg.Size {
+			return rows[i].pkg.Size > rows[j].pkg.Size
+		}
+		return rows[i].pkg.Name < rows[j].pkg.Name
+	})
+	if total == 0 {
+		return rows
+	}
+	var sum int64
+	for i := range rows {
+		sum += rows[i].pkg.Size
+		rows[i].percent = float64(rows[i].pkg.Size) * 100 / float64(total)
+		rows[i].cumulative = float64(sum) * 100 / float64(total)
+	}
+	return rows
+}
+
+func (m DiskUsageModel) Update(msg tea.Msg) (DiskUsageModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.scopeKey):
+		m.scope = (m.scope + 1) % 3
+		m.rows = diskUsageRows(m.pkgs, m.scope)
+		m.cursor = 0
+	case len(m.rows) == 0:
+	case key.Matches(keyMsg, m.up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(keyMsg, m.down):
+		m.cursor = min(len(m.rows)-1, m.cursor+1)
+	case key.Matches(keyMsg, m.top):
+		m.cursor = 0
+	case key.Matches(keyMsg, m.bottom):
+		m.cursor = len(m.rows) - 1
+	}
+	return m, nil
+}
+
+// A bar of the given width filled in proportion to the percentage
+func diskUsageBar(percent float64, width int) string {
+	filled := min(width, int(percent*float64(width)/100+0.5))
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func formatDiskUsageRow(row diskUsageRow) string {
+	kind := "formula"
+	if row.pkg.IsCask {
+		kind = "cask"
+	}
+	return fmt.Sprintf(
+		"%s  %-7s  %8s  %5.1f%%  %5.1f%%  %s",
+		fitCell(row.pkg.Name, diskUsageNameWidth, false),
+		kind,
+		util.FormatSize(row.pkg.Size),
+		row.percent,
+		row.cumulative,
+		diskUsageBar(row.percent, diskUsageBarWidth),
+	)
+}
+
+func (m DiskUsageModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	total := m.cellar + m.caskroom
+	split := ""
+	if total > 0 {
+		cellarPercent := float64(m.cellar) * 100 / float64(total)
+		split = fmt.Sprintf(
+			"Cellar %s %s %s Caskroom",
+			util.FormatSize(m.cellar),
+			diskUsageBar(cellarPercent, diskUsageBarWidth),
+			util.FormatSize(m.caskroom),
+		)
+	}
+	header := []string{
+		fmt.Sprintf("%s %s installed", headerStyle.UnsetWidth().Render("Disk usage:"), util.FormatSize(total)),
+		split,
+		fmt.Sprintf("%s: showing %s (switch between all, Cellar and Caskroom)  %s: go to package",
+			keyStyle.Render("c"), m.scope, keyStyle.Render("enter")),
+		"",
+		fmt.Sprintf(
+			"%s  %-7s  %8s  %6s  %6s",
+			fitCell("Name", diskUsageNameWidth, false), "Type", "Size", "Share", "Cumul.",
+		),
+	}
+
+	rows := []string{}
+	listHeight := max(1, m.height-len(header))
+	start := max(0, min(m.cursor-listHeight/2, len(m.rows)-listHeight))
+	end := min(len(m.rows), start+listHeight)
+	for i := start; i < end; i++ {
+		row := fitCell(formatDiskUsageRow(m.rows[i]), m.width-2, false)
+		if i == m.cursor {
+			row = queueSelectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+	if len(m.rows) == 0 {
+		rows = append(rows, "No installed packages here.")
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, strings.Join(header, "\n"), strings.Join(rows, "\n"))
+	return diskUsageStyle.Height(m.height).MaxHeight(m.height + 2).Render(content)
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestDiskUsageRows(t *testing.T) {
+	pkgs := []*data.Package{
+		{Name: "small", Size: 100, IsInstalled: true},
+		{Name: "app", Size: 600, IsInstalled: true, IsCask: true},
+		{Name: "big", Size: 300, IsInstalled: true},
+		{Name: "available", Size: 5000},
+	}
+
+	rows := diskUsageRows(pkgs, diskUsageAll)
+	names := []string{}
+	for _, row := range rows {
+		names = append(names, row.pkg.Name)
+	}
+	if len(rows) != 3 || names[0] != "app" || names[1] != "big" || names[2] != "small" {
+		t.Fatalf("expected installed packages largest first, got %v", names)
+	}
+	if rows[0].percent != 60 || rows[1].cumulative != 90 || rows[2].cumulative != 100 {
+		t.Errorf("expected 60%% share and 90%%, 100%% cumulative, got %v, %v, %v", rows[0].percent, rows[1].cumulative, rows[2].cumulative)
+	}
+
+	rows = diskUsageRows(pkgs, diskUsageCellar)
+	if len(rows) != 2 || rows[0].pkg.Name != "big" || rows[0].percent != 75 {
+		t.Errorf("expected big to take 75%% of Cellar, got %+v", rows)
+	}
+}
+
+func TestDiskUsageBar(t *testing.T) {
+	if got := diskUsageBar(50, 4); got != "██░░" {
+		t.Errorf("expected a half filled bar, got %q", got)
+	}
+}
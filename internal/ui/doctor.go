@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/brew"
+)
+
+// DoctorModel shows diagnostics of the Homebrew installation
+type DoctorModel struct {
+	diagnostics *brew.Diagnostics
+	visible     bool
+	vp          viewport.Model
+}
+
+var doctorStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+func NewDoctorModel() DoctorModel {
+	return DoctorModel{}
+}
+
+// Show the view while diagnostics are being collected
+func (m *DoctorModel) Show() {
+	m.diagnostics = nil
+	m.visible = true
+	m.updateContent()
+}
+
+func (m *DoctorModel) SetDiagnostics(d *brew.Diagnostics) {
+	m.diagnostics = d
+	m.updateContent()
+}
+
+func (m *DoctorModel) Hide() {
+	m.visible = false
+	m.diagnostics = nil
+}
+
+func (m *DoctorModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *DoctorModel) SetDimensions(width, height int) {
+	m.vp.Width = width - 2
+	m.vp.Height = height
+	doctorStyle = doctorStyle.
+		BorderStyle(getRoundedBorderWithTitle("Doctor", width)).
+		Width(width)
+}
+
+func (m DoctorModel) Update(msg tea.Msg) (DoctorModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.vp, cmd = m.vp.Update(msg)
+	return m, cmd
+}
+
+func formatConfigEntries(entries []brew.ConfigEntry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(fmt.Sprintf("  %s: %s\n", e.Key, e.Value))
+	}
+	return b.String()
+}
+
+func (m *DoctorModel) updateContent() {
+	d := m.diagnostics
+	if d == nil {
+		m.vp.SetContent("Running brew doctor...")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s\n", headerStyle.UnsetWidth().Render("Version:"), d.Version))
+	mode := fmt.Sprintf("API (formulae and casks are downloaded from %s)", brew.ApiDomain())
+	if !d.ApiMode {
+		mode = "Taps (formulae and casks are read from local tap clones)"
+	}
+	b.WriteString(fmt.Sprintf("%s %s\n", headerStyle.UnsetWidth().Render("Mode:"), mode))
+	for _, err := range d.Errors {
+		b.WriteString(deprecatedStyle.Render(err) + "\n")
+	}
+
+	b.WriteString("\n" + headerStyle.UnsetWidth().Render(fmt.Sprintf("Warnings (%d):", len(d.Warnings))) + "\n")
+	if len(d.Warnings) == 0 && len(d.Errors) == 0 {
+		b.WriteString(fmt.Sprintf("  %s Your system is ready to brew.\n", installedStyle.Render(installedSymbol)))
+	}
+	for _, w := range d.Warnings {
+		b.WriteString(fmt.Sprintf("  %s %s\n", deprecatedStyle.Render(deprecatedSymbol), w.Title))
+		for _, line := range w.Details {
+			b.WriteString("    " + line + "\n")
+		}
+	}
+
+	b.WriteString("\n" + headerStyle.UnsetWidth().Render("Environment:") + "\n")
+	b.WriteString(formatConfigEntries(d.Env))
+	b.WriteString("\n" + headerStyle.UnsetWidth().Render("System:") + "\n")
+	b.WriteString(formatConfigEntries(d.System))
+
+	m.vp.SetContent(b.String())
+	m.vp.GotoTop()
+}
+
+func (m DoctorModel) View() string {
+	if !m.visible {
+		return ""
+	}
+	return doctorStyle.Render(m.vp.View())
+}
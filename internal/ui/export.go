@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// Formats the package table can be exported to
+type ExportFormat int
+
+const (
+	ExportMarkdown ExportFormat = iota
+	ExportHtml
+	ExportCsv
+)
+
+func (f ExportFormat) extension() string {
+	switch f {
+	case ExportHtml:
+		return "html"
+	case ExportCsv:
+		return "csv"
+	default:
+		return "md"
+	}
+}
+
+// Sent when the table has been written to a file
+type ExportedMsg struct {
+	Path  string
+	Count int
+	Err   error
+}
+
+// Rows of the table as plain text, the symbol column is written out as the kind of package.
+// Cells that read the same for installed and uninstalled packages tell them apart, for syncing with the export.
+type exportTable struct {
+	header       []string
+	rightAligned []bool
+	rows         [][]string
+	urls         []string // Page of the package of each row
+}
+
+func packageKind(pkg *data.Package) string {
+	switch {
+	case pkg.IsMasApp():
+		return "app"
+	case pkg.IsWhalebrew():
+		return "whalebrew"
+	case pkg.IsCask:
+		return "cask"
+	default:
+		return "formula"
+	}
+}
+
+func (m *PackageTableModel) exportTable() exportTable {
+	t := exportTable{}
+	for _, col := range m.columns {
+		title := col.String()
+		if col == colSymbol {
+			title = "Type"
+		}
+		t.header = append(t.header, title)
+		t.rightAligned = append(t.rightAligned, col.rightAligned())
+	}
+	for _, pkg := range m.packages {
+		row := make([]string, 0, len(m.columns))
+		for _, col := range m.columns {
+			cell := col.getColumnData(pkg)
+			switch {
+			case col == colSymbol:
+				cell = packageKind(pkg)
+			case col == colInstalls && m.noInstalls:
+				cell = "N/A"
+			case col == colVersion && pkg.IsOutdated:
+				// The table only shows the new version
+				cell = pkg.LongVersion()
+			case col == colStatus && !pkg.IsInstalled && cell != "Uninstalled":
+				cell += " (Uninstalled)"
+			}
+			row = append(row, cell)
+		}
+		t.rows = append(t.rows, row)
+		t.urls = append(t.urls, pkg.BrewUrl())
+	}
+	return t
+}
+
+// Write the packages of the table, in its order and with its columns, to a file in the current directory
+func (m *PackageTableModel) Export(format ExportFormat) tea.Cmd {
+	// Rendered now, before the table changes
+	t := m.exportTable()
+	return func() tea.Msg {
+		var content []byte
+		var err error
+		switch format {
+		case ExportHtml:
+			content = []byte(t.html())
+		case ExportCsv:
+			content, err = t.csv()
+		default:
+			content = []byte(t.markdown())
+		}
+		if err != nil {
+			return ExportedMsg{Err: fmt.Errorf("failed to export packages: %w", err)}
+		}
+		path := fmt.Sprintf("taproom-%s.%s", time.Now().Format("20060102-150405"), format.extension())
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return ExportedMsg{Err: fmt.Errorf("failed to write %s: %w", path, err)}
+		}
+		return ExportedMsg{Path: path, Count: len(t.rows)}
+	}
+}
+
+func (t exportTable) markdown() string {
+	escape := func(cell string) string {
+		return strings.ReplaceAll(cell, "|", `\|`)
+	}
+	var b strings.Builder
+	line := func(cells []string) {
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	line(t.header)
+	separators := make([]string, len(t.header))
+	for i := range t.header {
+		separators[i] = "---"
+		if t.rightAligned[i] {
+			separators[i] = "---:"
+		}
+	}
+	line(separators)
+	for _, row := range t.rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = escape(cell)
+		}
+		line(cells)
+	}
+	return b.String()
+}
+
+func (t exportTable) html() string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Homebrew packages</title>\n</head>\n<body>\n")
+	b.WriteString(fmt.Sprintf("<p>%d packages, exported by taproom on %s</p>\n", len(t.rows), time.Now().Format(time.DateOnly)))
+	b.WriteString("<table>\n<thead>\n<tr>")
+	for _, title := range t.header {
+		b.WriteString("<th>" + html.EscapeString(title) + "</th>")
+	}
+	b.WriteString("</tr>\n</thead>\n<tbody>\n")
+	for i, row := range t.rows {
+		b.WriteString("<tr>")
+		for j, cell := range row {
+			cell = html.EscapeString(cell)
+			if t.header[j] == colName.String() {
+				// Names link to the package pages
+				cell = fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(t.urls[i]), cell)
+			}
+			if t.rightAligned[j] {
+				b.WriteString("<td align=\"right\">" + cell + "</td>")
+			} else {
+				b.WriteString("<td>" + cell + "</td>")
+			}
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</tbody>\n</table>\n</body>\n</html>\n")
+	return b.String()
+}
+
+func (t exportTable) csv() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(t.header); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(t.rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/brew"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestExportTable(t *testing.T) {
+	m := NewPackageTableModel()
+	m.SetDimensions(MaxTableWidth, 20)
+	m.columns = []packageTableColumn{colSymbol, colName, colInstalls}
+	m.SetPackages([]*data.Package{
+		{Name: "wget", Installs90d: 100},
+		{Name: "a|b", Installs90d: 200, IsCask: true},
+	})
+	m.sortColumn = colInstalls
+	m.sortRows()
+
+	table := m.exportTable()
+	markdown := table.markdown()
+	expected := "| Type | Name | Installs |\n| --- | --- | ---: |\n| cask | a\\|b | 200 |\n| formula | wget | 100 |\n"
+	if markdown != expected {
+		t.Errorf("expected %q, got %q", expected, markdown)
+	}
+
+	csv, err := table.csv()
+	if err != nil || string(csv) != "Type,Name,Installs\ncask,a|b,200\nformula,wget,100\n" {
+		t.Errorf("expected the rows as CSV, got %q, %v", csv, err)
+	}
+
+	html := table.html()
+	for _, want := range []string{
+		"<th>Type</th><th>Name</th><th>Installs</th>",
+		`<td><a href="https://formulae.brew.sh/formula/wget">wget</a></td><td align="right">100</td>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in the HTML, got %q", want, html)
+		}
+	}
+}
+
+func TestExportSyncsInstalledPackages(t *testing.T) {
+	m := NewPackageTableModel()
+	m.SetDimensions(MaxTableWidth, 20)
+	m.columns = []packageTableColumn{colSymbol, colName, colVersion, colStatus}
+	m.SetPackages([]*data.Package{
+		{Name: "wget", Version: "1.25.0", IsInstalled: true, InstalledVersion: "1.25.0"},
+		{Name: "curl", Version: "8.14.0", IsInstalled: true, InstalledVersion: "8.12.0", IsOutdated: true, IsPinned: true},
+		{Name: "jq", Version: "1.7.1"},
+		{Name: "python@3.9", Version: "3.9.21", IsDisabled: true},
+	})
+	content, err := m.exportTable().csv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "other.csv")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { brew.Flags.Set("sync-with", "") })
+	brew.Flags.Set("sync-with", path)
+
+	msg := brew.LoadSync()().(brew.SyncLoadedMsg)
+	if msg.Err != nil {
+		t.Fatalf("unexpected error: %v", msg.Err)
+	}
+	expected := []brew.SyncEntry{{Name: "curl", Kind: "brew", Version: "8.12.0"}, {Name: "wget", Kind: "brew", Version: "1.25.0"}}
+	if !slices.Equal(msg.Diff.OnlyThere, expected) {
+		t.Errorf("expected %v only there, got %v", expected, msg.Diff.OnlyThere)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"math/bits"
 	"strings"
+
+	"github.com/hzqtc/taproom/internal/brew"
+	"github.com/hzqtc/taproom/internal/data"
 )
 
 // Filter defines which subset of packages is currently being viewed.
@@ -24,6 +27,11 @@ const (
 	FilterOutdated                               // 0000 1000
 	FilterExplicitlyInstalled                    // 0001 0000
 	FilterActive                                 // 0010 0000
+	FilterCompatible                             // 0100 0000
+	FilterBottled                                // 1000 0000
+	FilterVulnerable                             // 1 0000 0000
+	FilterUpdated                                // 10 0000 0000
+	FilterFonts                                  // 100 0000 0000
 
 	filterMax
 	filterUnknown
@@ -32,10 +40,52 @@ const (
 // Mutually exclusive filter groups
 // Filters from different groups can co-exist
 var conflictFilters = []filterGroup{
-	filterGroup(FilterFormulae | FilterCasks),
+	filterGroup(FilterFormulae | FilterCasks | FilterFonts),
 	filterGroup(FilterInstalled | FilterOutdated | FilterExplicitlyInstalled | FilterActive),
 }
 
+// Whether the filters only show installed packages
+func IsInstalledOnly(filters []Filter) bool {
+	for _, f := range filters {
+		if f == FilterInstalled || f == FilterOutdated || f == FilterExplicitlyInstalled || f == FilterVulnerable {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether the package is shown with the filter on
+func (f Filter) Matches(pkg *data.Package) bool {
+	switch f {
+	case FilterFormulae:
+		return !pkg.IsCask
+	case FilterCasks:
+		return pkg.IsCask
+	case FilterInstalled:
+		return pkg.IsInstalled
+	case FilterOutdated:
+		// Snoozed packages come back when their snooze is over
+		return pkg.IsOutdated && !pkg.IsSnoozed()
+	case FilterExplicitlyInstalled:
+		return pkg.IsInstalled && !pkg.InstalledAsDependency
+	case FilterActive:
+		return !pkg.IsDisabled && !pkg.IsDeprecated
+	case FilterCompatible:
+		return pkg.IsCompatible(brew.CurrentPlatform())
+	case FilterBottled:
+		// Casks are always prebuilt
+		return pkg.IsCask || pkg.HasBottle(brew.CurrentPlatform())
+	case FilterVulnerable:
+		return len(pkg.Vulnerabilities) > 0
+	case FilterUpdated:
+		return pkg.PreviousVersion != ""
+	case FilterFonts:
+		return pkg.IsFont()
+	default:
+		return true
+	}
+}
+
 func (f Filter) getConflictFilters() filterGroup {
 	for _, fg := range conflictFilters {
 		if fg.isFilterEnabled(f) {
@@ -100,6 +150,16 @@ func (f Filter) String() string {
 		return "Expl. Installed"
 	case FilterActive:
 		return "Active"
+	case FilterCompatible:
+		return "Compatible"
+	case FilterBottled:
+		return "Bottled"
+	case FilterVulnerable:
+		return "Vulnerable"
+	case FilterUpdated:
+		return "Updated"
+	case FilterFonts:
+		return "Fonts"
 	default:
 		return "Unknown"
 	}
@@ -119,6 +179,16 @@ func parseFilter(s string) (Filter, error) {
 		return FilterExplicitlyInstalled, nil
 	case "Active":
 		return FilterActive, nil
+	case "Compatible":
+		return FilterCompatible, nil
+	case "Bottled":
+		return FilterBottled, nil
+	case "Vulnerable":
+		return FilterVulnerable, nil
+	case "Updated":
+		return FilterUpdated, nil
+	case "Fonts":
+		return FilterFonts, nil
 	default:
 		return filterUnknown, fmt.Errorf("Unknown filter: %s", s)
 	}
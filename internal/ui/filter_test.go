@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestIsInstalledOnly(t *testing.T) {
+	tests := []struct {
+		filters []Filter
+		want    bool
+	}{
+		{[]Filter{}, false},
+		{[]Filter{FilterFormulae}, false},
+		{[]Filter{FilterCasks, FilterActive}, false},
+		{[]Filter{FilterFormulae, FilterInstalled}, true},
+		{[]Filter{FilterOutdated}, true},
+		{[]Filter{FilterExplicitlyInstalled}, true},
+		{[]Filter{FilterVulnerable}, true},
+	}
+
+	for _, tt := range tests {
+		if got := IsInstalledOnly(tt.filters); got != tt.want {
+			t.Errorf("IsInstalledOnly(%v) = %v, want %v", tt.filters, got, tt.want)
+		}
+	}
+}
+
+func TestFilterCounts(t *testing.T) {
+	m := NewFilterViewModel()
+	m.SetWidth(120)
+	m.SetCounts([]*data.Package{
+		{Name: "wget", IsInstalled: true, IsOutdated: true, Vulnerabilities: []string{"CVE-2024-38428"}},
+		{Name: "jq", IsInstalled: true, InstalledAsDependency: true},
+		{Name: "firefox", IsCask: true, PreviousVersion: "139.0"},
+		{Name: "font-fira-code", IsCask: true},
+	})
+	if m.counts[FilterFormulae] != 2 || m.counts[FilterCasks] != 2 || m.counts[FilterOutdated] != 1 {
+		t.Errorf("expected 2 formulae, 2 casks and 1 outdated, got %v", m.counts)
+	}
+	if m.counts[FilterFonts] != 1 {
+		t.Errorf("expected 1 font, got %d", m.counts[FilterFonts])
+	}
+	if m.counts[FilterVulnerable] != 1 {
+		t.Errorf("expected 1 vulnerable package, got %d", m.counts[FilterVulnerable])
+	}
+	if m.counts[FilterUpdated] != 1 {
+		t.Errorf("expected 1 updated package, got %d", m.counts[FilterUpdated])
+	}
+	if m.counts[FilterExplicitlyInstalled] != 1 {
+		t.Errorf("expected 1 explicitly installed, got %d", m.counts[FilterExplicitlyInstalled])
+	}
+
+	view := m.View()
+	for _, want := range []string{"All 4", "Formulae 2", "Casks 2", "Installed 2", "Outdated 1"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the filters, got %q", want, view)
+		}
+	}
+	if strings.Contains(view, "Expl. Installed") {
+		t.Errorf("expected filters that are off to be left out, got %q", view)
+	}
+}
+
+func TestFilterOutdatedSnoozed(t *testing.T) {
+	snoozed := &data.Package{Name: "wget", IsInstalled: true, IsOutdated: true, SnoozedUntil: time.Now().AddDate(0, 0, 1)}
+	over := &data.Package{Name: "jq", IsInstalled: true, IsOutdated: true, SnoozedUntil: time.Now().AddDate(0, 0, -1)}
+	if FilterOutdated.Matches(snoozed) {
+		t.Errorf("expected snoozed packages to be left out of outdated ones")
+	}
+	if !FilterOutdated.Matches(over) {
+		t.Errorf("expected packages whose snooze is over to be outdated")
+	}
+}
@@ -1,8 +1,13 @@
 package ui
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/data"
 	"github.com/spf13/pflag"
 )
 
@@ -11,8 +16,10 @@ type FilterChangedMsg struct {
 }
 
 type FilterViewModel struct {
-	fg    filterGroup
-	width int
+	fg     filterGroup
+	width  int
+	all    int            // Packages matching the search
+	counts map[Filter]int // Packages matching the search shown by each filter on its own
 
 	filterAll       key.Binding
 	filterFormulae  key.Binding
@@ -21,6 +28,11 @@ type FilterViewModel struct {
 	filterOutdated  key.Binding
 	filterExplicit  key.Binding
 	filterActive    key.Binding
+	filterCompat    key.Binding
+	filterBottled   key.Binding
+	filterVuln      key.Binding
+	filterUpdated   key.Binding
+	filterFonts     key.Binding
 }
 
 var flagFilters = pflag.StringSliceP(
@@ -28,7 +40,7 @@ var flagFilters = pflag.StringSliceP(
 	"f",
 	[]string{},
 	"Filters to enable (comma separated no space).\n"+
-		"Pick 0 or 1 filter from each group: (Formulae, Casks), (Installed, Outdated, Expl. Installed, Active)",
+		"Pick 0 or 1 filter from each group: (Formulae, Casks, Fonts), (Installed, Outdated, Expl. Installed, Active), (Compatible), (Bottled), (Vulnerable), (Updated)",
 )
 
 var filterStyle = baseStyle.
@@ -50,6 +62,11 @@ func NewFilterViewModel() FilterViewModel {
 		filterOutdated:  key.NewBinding(key.WithKeys("o")),
 		filterExplicit:  key.NewBinding(key.WithKeys("e")),
 		filterActive:    key.NewBinding(key.WithKeys("v")),
+		filterCompat:    key.NewBinding(key.WithKeys("m")),
+		filterBottled:   key.NewBinding(key.WithKeys("n")),
+		filterVuln:      key.NewBinding(key.WithKeys("y")),
+		filterUpdated:   key.NewBinding(key.WithKeys("N")),
+		filterFonts:     key.NewBinding(key.WithKeys("ctrl+f")),
 	}
 }
 
@@ -72,6 +89,16 @@ func (m FilterViewModel) Update(msg tea.Msg) (FilterViewModel, tea.Cmd) {
 			m.fg.toggleFilter(FilterExplicitlyInstalled)
 		case key.Matches(msg, m.filterActive):
 			m.fg.toggleFilter(FilterActive)
+		case key.Matches(msg, m.filterCompat):
+			m.fg.toggleFilter(FilterCompatible)
+		case key.Matches(msg, m.filterBottled):
+			m.fg.toggleFilter(FilterBottled)
+		case key.Matches(msg, m.filterVuln):
+			m.fg.toggleFilter(FilterVulnerable)
+		case key.Matches(msg, m.filterUpdated):
+			m.fg.toggleFilter(FilterUpdated)
+		case key.Matches(msg, m.filterFonts):
+			m.fg.toggleFilter(FilterFonts)
 		}
 	}
 
@@ -90,16 +117,59 @@ func (m *FilterViewModel) sendFilterChangedMsg() tea.Cmd {
 	}
 }
 
+// Filters listed with their counts even when they're off, the others only when they're on
+var countedFilters = []Filter{FilterFormulae, FilterCasks, FilterInstalled, FilterOutdated}
+
+// Count the packages each filter would show, so choosing one isn't blind
+func (m *FilterViewModel) SetCounts(pkgs []*data.Package) {
+	m.all = len(pkgs)
+	m.counts = make(map[Filter]int)
+	for _, pkg := range pkgs {
+		for f := Filter(1); f < filterMax; f <<= 1 {
+			if f.Matches(pkg) {
+				m.counts[f]++
+			}
+		}
+	}
+}
+
 func (m FilterViewModel) View() string {
-	return filterStyle.Render(m.fg.String())
+	if m.counts == nil {
+		return filterStyle.Render(m.fg.String())
+	}
+	segments := []string{}
+	all := fmt.Sprintf("All %d", m.all)
+	if m.fg == emptyFilterGroup {
+		all = keyStyle.Render(all)
+	}
+	segments = append(segments, all)
+	for f := Filter(1); f < filterMax; f <<= 1 {
+		enabled := m.fg.isFilterEnabled(f)
+		if !enabled && !slices.Contains(countedFilters, f) {
+			continue
+		}
+		segment := fmt.Sprintf("%s %d", f.String(), m.counts[f])
+		if enabled {
+			segment = keyStyle.Render(segment)
+		}
+		segments = append(segments, segment)
+	}
+	// Kept on a single line next to the search box, what doesn't fit is cut off
+	return filterStyle.Render(fitCell(strings.Join(segments, " | "), m.width-filterStyle.GetHorizontalPadding(), false))
 }
 
 func (m *FilterViewModel) SetWidth(w int) {
+	m.width = w
 	filterStyle = filterStyle.
 		BorderStyle(getRoundedBorderWithTitle("Filters", w)).
 		Width(w)
 }
 
+// Turn off all filters without sending a message
+func (m *FilterViewModel) Reset() {
+	m.fg.reset()
+}
+
 func (m *FilterViewModel) Value() []Filter {
 	return m.fg.split()
 }
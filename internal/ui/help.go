@@ -28,6 +28,42 @@ func (m HelpModel) View() string {
 	b.WriteString(": quit ")
 	b.WriteString(keyStyle.Render("R"))
 	b.WriteString(": refresh ")
+	b.WriteString(keyStyle.Render("ctrl+r"))
+	b.WriteString(": refresh installed ")
+	b.WriteString(keyStyle.Render("H"))
+	b.WriteString(": history ")
+	b.WriteString(keyStyle.Render("ctrl+t"))
+	b.WriteString(": rollback ")
+	b.WriteString(keyStyle.Render("T"))
+	b.WriteString(": taps ")
+	b.WriteString(keyStyle.Render("#"))
+	b.WriteString(": categories ")
+	b.WriteString(keyStyle.Render("B"))
+	b.WriteString(": Brewfile ")
+	b.WriteString(keyStyle.Render("ctrl+s"))
+	b.WriteString(": sync ")
+	b.WriteString(keyStyle.Render("D"))
+	b.WriteString(": doctor ")
+	b.WriteString(keyStyle.Render("ctrl+g"))
+	b.WriteString(": integrations ")
+	b.WriteString(keyStyle.Render("A"))
+	b.WriteString(": download cache ")
+	b.WriteString(keyStyle.Render("M"))
+	b.WriteString(": caveats ")
+	b.WriteString(keyStyle.Render("d"))
+	b.WriteString(": disk usage ")
+	b.WriteString(keyStyle.Render("ctrl+e"))
+	b.WriteString(": export ")
+	b.WriteString(keyStyle.Render("C"))
+	b.WriteString(": full catalog ")
+	b.WriteString(keyStyle.Render("w"))
+	b.WriteString(": install provenance ")
+	b.WriteString(keyStyle.Render("X"))
+	b.WriteString(": inspect raw data ")
+	b.WriteString(keyStyle.Render("W"))
+	b.WriteString(": why installed ")
+	b.WriteString(keyStyle.Render("O"))
+	b.WriteString(": full output ")
 	b.WriteString(keyStyle.Render("tab"))
 	b.WriteString(": switch focus ")
 	b.WriteString(keyStyle.Render("/"))
@@ -51,7 +87,15 @@ func (m HelpModel) View() string {
 	b.WriteString(keyStyle.Render("g"))
 	b.WriteString(": go to top ")
 	b.WriteString(keyStyle.Render("G"))
-	b.WriteString(": go to bottom")
+	b.WriteString(": go to bottom ")
+	b.WriteString(keyStyle.Render("'"))
+	b.WriteString(": jump by first letter ")
+	b.WriteString(keyStyle.Render("[") + "/" + keyStyle.Render("]"))
+	b.WriteString(": details cursor ")
+	b.WriteString(keyStyle.Render("enter"))
+	b.WriteString(": expand/collapse or jump ")
+	b.WriteString(keyStyle.Render("backspace"))
+	b.WriteString(": jump back")
 	b.WriteString("\n")
 	b.WriteString("Filter    : ")
 	b.WriteString(keyStyle.Render("a"))
@@ -60,6 +104,8 @@ func (m HelpModel) View() string {
 	b.WriteString(": formulae ")
 	b.WriteString(keyStyle.Render("c"))
 	b.WriteString(": casks ")
+	b.WriteString(keyStyle.Render("ctrl+f"))
+	b.WriteString(": fonts ")
 	b.WriteString(keyStyle.Render("i"))
 	b.WriteString(": installed ")
 	b.WriteString(keyStyle.Render("o"))
@@ -67,7 +113,15 @@ func (m HelpModel) View() string {
 	b.WriteString(keyStyle.Render("e"))
 	b.WriteString(": explicitly installed ")
 	b.WriteString(keyStyle.Render("v"))
-	b.WriteString(": active")
+	b.WriteString(": active ")
+	b.WriteString(keyStyle.Render("m"))
+	b.WriteString(": compatible ")
+	b.WriteString(keyStyle.Render("n"))
+	b.WriteString(": bottled (no compiling) ")
+	b.WriteString(keyStyle.Render("y"))
+	b.WriteString(": vulnerable ")
+	b.WriteString(keyStyle.Render("N"))
+	b.WriteString(": updated since last session")
 	b.WriteString("\n")
 	b.WriteString("Commands  : ")
 	b.WriteString(keyStyle.Render("h"))
@@ -76,20 +130,44 @@ func (m HelpModel) View() string {
 	b.WriteString(": brew.sh ")
 	b.WriteString(keyStyle.Render("r"))
 	b.WriteString(": release page ")
+	b.WriteString(keyStyle.Render("E"))
+	b.WriteString(": edit source ")
+	b.WriteString(keyStyle.Render("!"))
+	b.WriteString(": shell with package ")
+	b.WriteString(keyStyle.Render("V"))
+	b.WriteString(": verify keg ")
 	b.WriteString(keyStyle.Render("U"))
 	b.WriteString(": upgrade all ")
+	b.WriteString(keyStyle.Render("F"))
+	b.WriteString(": upgrade within a time budget ")
 	b.WriteString(keyStyle.Render("u"))
 	b.WriteString(": upgrade ")
 	b.WriteString(keyStyle.Render("t"))
 	b.WriteString(": install ")
+	b.WriteString(keyStyle.Render("I"))
+	b.WriteString(": install/upgrade with options ")
+	b.WriteString(keyStyle.Render("Y"))
+	b.WriteString(": reinstall ")
 	b.WriteString(keyStyle.Render("x"))
 	b.WriteString(": uninstall ")
+	b.WriteString(keyStyle.Render("z"))
+	b.WriteString(": zap ")
 	b.WriteString(keyStyle.Render("p"))
 	b.WriteString(": pin ")
 	b.WriteString(keyStyle.Render("P"))
 	b.WriteString(": unpin ")
+	b.WriteString(keyStyle.Render("Z"))
+	b.WriteString(": snooze upgrade ")
+	b.WriteString(keyStyle.Render("l"))
+	b.WriteString(": link/unlink ")
 	b.WriteString(keyStyle.Render("L"))
-	b.WriteString(": cleanup")
+	b.WriteString(": cleanup ")
+	b.WriteString(keyStyle.Render("Q"))
+	b.WriteString(": queue (")
+	b.WriteString(keyStyle.Render("J") + "/" + keyStyle.Render("K"))
+	b.WriteString(": move ")
+	b.WriteString(keyStyle.Render("x"))
+	b.WriteString(": remove)")
 
 	return helpStyle.Render(b.String())
 }
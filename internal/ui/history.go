@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hzqtc/taproom/internal/brew"
+)
+
+// HistoryModel lists brew commands run by taproom with the output of the selected one
+type HistoryModel struct {
+	entries []brew.HistoryEntry
+	cursor  int
+	visible bool
+	width   int
+	height  int
+
+	up     key.Binding
+	down   key.Binding
+	top    key.Binding
+	bottom key.Binding
+}
+
+var historyStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+const historyTimeFormat = "2006-01-02 15:04"
+
+func NewHistoryModel() HistoryModel {
+	return HistoryModel{
+		up:     key.NewBinding(key.WithKeys("k", "up")),
+		down:   key.NewBinding(key.WithKeys("j", "down")),
+		top:    key.NewBinding(key.WithKeys("g", "home")),
+		bottom: key.NewBinding(key.WithKeys("G", "end")),
+	}
+}
+
+func (m *HistoryModel) Show(entries []brew.HistoryEntry) {
+	m.entries = entries
+	m.cursor = 0
+	m.visible = true
+}
+
+func (m *HistoryModel) Hide() {
+	m.visible = false
+	m.entries = nil
+}
+
+func (m *HistoryModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *HistoryModel) Selected() *brew.HistoryEntry {
+	if m.cursor >= 0 && m.cursor < len(m.entries) {
+		return &m.entries[m.cursor]
+	}
+	return nil
+}
+
+func (m *HistoryModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	historyStyle = historyStyle.
+		BorderStyle(getRoundedBorderWithTitle("History", width)).
+		Width(width)
+}
+
+func (m HistoryModel) Update(msg tea.Msg) (HistoryModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || len(m.entries) == 0 {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(keyMsg, m.down):
+		m.cursor = min(len(m.entries)-1, m.cursor+1)
+	case key.Matches(keyMsg, m.top):
+		m.cursor = 0
+	case key.Matches(keyMsg, m.bottom):
+		m.cursor = len(m.entries) - 1
+	}
+	return m, nil
+}
+
+func formatHistoryEntry(e *brew.HistoryEntry) string {
+	status := installedStyle.Render(installedSymbol)
+	if e.ExitCode != 0 {
+		status = deprecatedStyle.Render(fmt.Sprintf("%s exit %d", disabledSymbol, e.ExitCode))
+	}
+	return fmt.Sprintf("%s  %8s  %s  %s", e.Time.Format(historyTimeFormat), e.Duration.Round(time.Second), e.CommandLine(), status)
+}
+
+func (m HistoryModel) View() string {
+	if !m.visible {
+		return ""
+	}
+	if len(m.entries) == 0 {
+		return historyStyle.Height(m.height).Render("No commands have been run by taproom yet.")
+	}
+
+	// List takes the top half, output of the selected entry takes the rest
+	listHeight := max(1, m.height/2)
+	start := max(0, min(m.cursor-listHeight/2, len(m.entries)-listHeight))
+	end := min(len(m.entries), start+listHeight)
+	rows := []string{}
+	for i := start; i < end; i++ {
+		row := fitCell(formatHistoryEntry(&m.entries[i]), m.width-2, false)
+		if i == m.cursor {
+			row = queueSelectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	selected := m.entries[m.cursor]
+	outputHeight := max(0, m.height-len(rows)-2)
+	output := selected.Output
+	if len(output) > outputHeight {
+		output = output[len(output)-outputHeight:]
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		strings.Join(rows, "\n"),
+		"",
+		headerStyle.UnsetWidth().Render("Output of "+selected.CommandLine()),
+		strings.Join(output, "\n"),
+	)
+	return historyStyle.Height(m.height).MaxHeight(m.height + 2).Render(content)
+}
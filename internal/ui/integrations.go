@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/brew"
+)
+
+// IntegrationsModel lists tools installed globally by package managers other than brew
+type IntegrationsModel struct {
+	managers []*brew.ToolManager
+	visible  bool
+	vp       viewport.Model
+}
+
+var integrationsStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+const toolNameWidth = 40
+
+func NewIntegrationsModel() IntegrationsModel {
+	return IntegrationsModel{}
+}
+
+// Show the view while the tools are being listed
+func (m *IntegrationsModel) Show() {
+	m.managers = nil
+	m.visible = true
+	m.updateContent()
+}
+
+func (m *IntegrationsModel) SetManagers(managers []*brew.ToolManager) {
+	m.managers = managers
+	m.updateContent()
+}
+
+func (m *IntegrationsModel) Hide() {
+	m.visible = false
+	m.managers = nil
+}
+
+func (m *IntegrationsModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *IntegrationsModel) SetDimensions(width, height int) {
+	m.vp.Width = width - 2
+	m.vp.Height = height
+	integrationsStyle = integrationsStyle.
+		BorderStyle(getRoundedBorderWithTitle("Integrations", width)).
+		Width(width)
+}
+
+func (m IntegrationsModel) Update(msg tea.Msg) (IntegrationsModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.vp, cmd = m.vp.Update(msg)
+	return m, cmd
+}
+
+func formatGlobalTool(tool *brew.GlobalTool) string {
+	if tool.IsOutdated() {
+		return fmt.Sprintf("  %s %s %s",
+			outdatedStyle.Render(outdatedSymbol),
+			fitCell(tool.Name, toolNameWidth, false),
+			outdatedStyle.Render(fmt.Sprintf("%s -> %s", tool.Version, tool.Latest)))
+	}
+	return fmt.Sprintf("  %s %s %s", installedStyle.Render(installedSymbol), fitCell(tool.Name, toolNameWidth, false), tool.Version)
+}
+
+func formatToolManager(manager *brew.ToolManager) string {
+	var b strings.Builder
+	title := fmt.Sprintf("%s (%d tools, %d outdated):", manager.Name, len(manager.Tools), manager.OutdatedCount())
+	if !manager.Installed {
+		title = manager.Name + ": not installed"
+	}
+	b.WriteString(headerStyle.UnsetWidth().Render(title) + "\n")
+	if manager.Err != nil {
+		b.WriteString("  " + deprecatedStyle.Render(manager.Err.Error()) + "\n")
+	}
+	for _, tool := range manager.Tools {
+		b.WriteString(formatGlobalTool(tool) + "\n")
+	}
+	return b.String()
+}
+
+func (m *IntegrationsModel) updateContent() {
+	if m.managers == nil {
+		m.vp.SetContent("Listing global tools and checking them for updates...")
+		return
+	}
+	sections := []string{}
+	for _, manager := range m.managers {
+		sections = append(sections, formatToolManager(manager))
+	}
+	m.vp.SetContent(strings.Join(sections, "\n"))
+	m.vp.GotoTop()
+}
+
+func (m IntegrationsModel) View() string {
+	if !m.visible {
+		return ""
+	}
+	return integrationsStyle.Render(m.vp.View())
+}
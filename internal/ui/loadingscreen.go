@@ -3,13 +3,13 @@ package ui
 import (
 	"fmt"
 	"strings"
-	"taproom/internal/loading"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/stopwatch"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/hzqtc/taproom/internal/loading"
 	"github.com/spf13/pflag"
 )
 
@@ -92,6 +92,16 @@ func (m *LoadingScreenModel) SetError(err string) tea.Cmd {
 	return m.StopLoading()
 }
 
+// Data is being loaded, searching is the only thing to do meanwhile
+func (m *LoadingScreenModel) IsLoading() bool {
+	return m.isLoading
+}
+
+// Loading failed and the error is shown until it's retried
+func (m *LoadingScreenModel) HasError() bool {
+	return m.errorMsg != ""
+}
+
 func (m LoadingScreenModel) Update(msg tea.Msg) (LoadingScreenModel, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
@@ -111,7 +121,7 @@ func (m LoadingScreenModel) Update(msg tea.Msg) (LoadingScreenModel, tea.Cmd) {
 
 func (m LoadingScreenModel) View() string {
 	if m.errorMsg != "" {
-		return fmt.Sprintf("An error occurred: %s\nPress 'q' to quit.", m.errorMsg)
+		return fmt.Sprintf("An error occurred: %s\nPress 'R' to retry or 'q' to quit.", m.errorMsg)
 	}
 
 	if m.isLoading {
@@ -128,6 +138,7 @@ func (m LoadingScreenModel) View() string {
 		if *flagShowLoadTimer {
 			b.WriteString(m.stopwatch.View())
 		}
+		b.WriteString("\n\nPress '/' to search, results show up as soon as loading is done")
 		return b.String()
 	}
 
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/brew"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// OptionsModel asks for extra brew flags before installing or upgrading a package
+type OptionsModel struct {
+	pkg     *data.Package
+	upgrade bool
+	input   textinput.Model
+	err     error
+	active  bool
+}
+
+var optionsStyle = baseStyle.
+	Margin(1 /* top */, 0 /* horizontal */, 0 /* bottom */).
+	Padding(0, 1).
+	BorderForeground(focusedBorderColor)
+
+func NewOptionsModel() OptionsModel {
+	input := textinput.New()
+	input.Placeholder = "--build-from-source"
+	input.Prompt = "Options: "
+	return OptionsModel{input: input}
+}
+
+// Show the input for installing the package, or upgrading it when it's installed
+func (m *OptionsModel) Show(pkg *data.Package) tea.Cmd {
+	m.pkg = pkg
+	m.upgrade = pkg.IsInstalled
+	m.err = nil
+	m.active = true
+	m.input.Reset()
+	return m.input.Focus()
+}
+
+func (m *OptionsModel) Dismiss() {
+	m.active = false
+	m.pkg = nil
+	m.input.Blur()
+}
+
+func (m *OptionsModel) IsActive() bool {
+	return m.active
+}
+
+func (m *OptionsModel) Package() *data.Package {
+	return m.pkg
+}
+
+func (m *OptionsModel) IsUpgrade() bool {
+	return m.upgrade
+}
+
+// Parse the entered options, an error is shown in the input until it's fixed
+func (m *OptionsModel) Options() ([]string, error) {
+	options, err := parseOptions(m.input.Value())
+	m.err = err
+	return options, err
+}
+
+func parseOptions(s string) ([]string, error) {
+	options := strings.Fields(s)
+	for _, opt := range options {
+		if !strings.HasPrefix(opt, "-") {
+			return nil, fmt.Errorf("%s is not a flag, options must start with -", opt)
+		}
+	}
+	return options, nil
+}
+
+func (m OptionsModel) Update(msg tea.Msg) (OptionsModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *OptionsModel) SetWidth(w int) {
+	m.input.Width = w - 4 - len(m.input.Prompt)
+	optionsStyle = optionsStyle.
+		BorderStyle(getRoundedBorderWithTitle("Options", w)).
+		Width(w)
+}
+
+func (m OptionsModel) View() string {
+	if !m.active {
+		return ""
+	}
+
+	var b strings.Builder
+	if m.upgrade {
+		b.WriteString(headerStyle.UnsetWidth().Render("Upgrade " + m.pkg.Name))
+		b.WriteString("\nFlags: " + strings.Join(brew.UpgradeFlags(m.pkg), " "))
+	} else {
+		b.WriteString(headerStyle.UnsetWidth().Render("Install " + m.pkg.Name))
+		b.WriteString("\nFlags: " + strings.Join(brew.InstallFlags(m.pkg), " "))
+		if dirs := brew.CaskDirs(); m.pkg.IsCask && len(dirs) > 0 {
+			b.WriteString("\nDirectories: " + strings.Join(dirs, " "))
+		}
+	}
+	b.WriteString("\n" + m.input.View())
+	if options, err := parseOptions(m.input.Value()); err == nil && !m.upgrade && brew.SkipsQuarantine(m.pkg, options) {
+		b.WriteString("\n" + deprecatedStyle.Render("Without quarantine, macOS won't check the app with Gatekeeper before its first launch"))
+	}
+	if m.err != nil {
+		b.WriteString("\n" + deprecatedStyle.Render(m.err.Error()))
+	}
+	b.WriteString("\n" + keyStyle.Render("enter") + ": run " + keyStyle.Render("esc") + ": cancel")
+	return optionsStyle.Render(b.String())
+}
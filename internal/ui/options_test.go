@@ -0,0 +1,24 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	options, err := parseOptions("  --HEAD   --build-from-source ")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !slices.Equal(options, []string{"--HEAD", "--build-from-source"}) {
+		t.Errorf("expected [--HEAD --build-from-source], got %v", options)
+	}
+
+	if options, err := parseOptions(""); err != nil || len(options) != 0 {
+		t.Errorf("expected no options, got %v, %v", options, err)
+	}
+
+	if _, err := parseOptions("--force wget"); err == nil {
+		t.Errorf("expected an error for a package name, got none")
+	}
+}
@@ -1,17 +1,44 @@
 package ui
 
-import "strings"
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/lipgloss"
+)
 
 type OutputModel struct {
-	lines    []string
-	hasError bool
+	lines     []string
+	hasError  bool
+	downloads []downloadProgress
+	label     string // Name of the package or file currently being downloaded
+	eta       string
+	width     int
+}
+
+type downloadProgress struct {
+	label   string
+	percent float64
 }
 
 var outputStyle = baseStyle.
 	Margin(1 /* top */, 0 /* horizontal */, 0 /* bottom */).
 	Padding(0, 1)
 
-const outputMaxLines = 10
+const (
+	outputMaxLines     = 10
+	outputMaxDownloads = 3
+	downloadLabelWidth = 30
+)
+
+// Lines printed by brew before a download starts
+const (
+	fetchingPrefix    = "==> Fetching "
+	downloadingPrefix = "==> Downloading "
+)
 
 func NewOutputModel() OutputModel {
 	return OutputModel{}
@@ -20,10 +47,42 @@ func NewOutputModel() OutputModel {
 func (m *OutputModel) Clear() {
 	m.lines = []string{}
 	m.hasError = false
+	m.downloads = nil
+	m.label = ""
+	m.eta = ""
 }
 
 func (m *OutputModel) Append(l string) {
 	m.lines = append(m.lines, l)
+
+	if name, ok := strings.CutPrefix(l, fetchingPrefix); ok {
+		m.label = strings.TrimPrefix(name, "downloads for: ")
+	} else if url, ok := strings.CutPrefix(l, downloadingPrefix); ok && m.label == "" {
+		m.label = path.Base(url)
+	}
+}
+
+// Update the progress of the current download, lines of the progress bar are not kept in the output
+func (m *OutputModel) SetProgress(percent float64) {
+	label := m.label
+	if label == "" {
+		label = "Downloading"
+	}
+	if n := len(m.downloads); n > 0 && m.downloads[n-1].label == label {
+		m.downloads[n-1].percent = percent
+	} else {
+		m.downloads = append(m.downloads, downloadProgress{label: label, percent: percent})
+	}
+}
+
+// Show the estimated remaining time of a command working on multiple packages
+func (m *OutputModel) SetEta(remaining time.Duration, done, total int) {
+	m.eta = fmt.Sprintf("ETA: ~%s remaining (%d/%d packages done)", remaining.Round(time.Second), done, total)
+}
+
+// All the lines of the output, including those scrolled out of view
+func (m *OutputModel) Lines() []string {
+	return m.lines
 }
 
 func (m *OutputModel) SetError() {
@@ -31,11 +90,12 @@ func (m *OutputModel) SetError() {
 }
 
 func (m *OutputModel) SetWidth(w int) {
+	m.width = w
 	outputStyle = outputStyle.Width(w)
 }
 
 func (m OutputModel) View() string {
-	if len(m.lines) == 0 {
+	if len(m.lines) == 0 && len(m.downloads) == 0 {
 		return ""
 	}
 
@@ -45,6 +105,12 @@ func (m OutputModel) View() string {
 	} else {
 		output = strings.Join(m.lines, "\n")
 	}
+	if m.eta != "" {
+		output = lipgloss.JoinVertical(lipgloss.Left, output, keyStyle.Render(m.eta))
+	}
+	if downloads := m.downloadsView(); downloads != "" {
+		output = lipgloss.JoinVertical(lipgloss.Left, output, downloads)
+	}
 
 	if m.hasError {
 		return outputStyle.BorderForeground(errBorderColor).Render(output)
@@ -52,3 +118,27 @@ func (m OutputModel) View() string {
 		return outputStyle.Render(output)
 	}
 }
+
+func (m OutputModel) downloadsView() string {
+	if len(m.downloads) == 0 {
+		return ""
+	}
+
+	fill := highlightColor.Light
+	if lipgloss.HasDarkBackground() {
+		fill = highlightColor.Dark
+	}
+	bar := progress.New(progress.WithSolidFill(fill))
+	// Leave room for padding, the label and a space in between
+	bar.Width = max(10, m.width-downloadLabelWidth-3)
+
+	downloads := m.downloads
+	if len(downloads) > outputMaxDownloads {
+		downloads = downloads[len(downloads)-outputMaxDownloads:]
+	}
+	rows := make([]string, len(downloads))
+	for i, d := range downloads {
+		rows[i] = fmt.Sprintf("%s %s", fitCell(d.label, downloadLabelWidth, false), bar.ViewAs(d.percent))
+	}
+	return strings.Join(rows, "\n")
+}
@@ -5,11 +5,14 @@ import (
 	"os"
 	"slices"
 	"sort"
-	"taproom/internal/data"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/util"
 	"github.com/spf13/pflag"
 )
 
@@ -17,13 +20,23 @@ var (
 	flagHideCols = pflag.StringSlice(
 		"hide-columns",
 		[]string{},
-		"Hide specific columns seprated by comma (no spaces): Version, Tap, Description, Installs, Size, Status",
+		"Hide specific columns seprated by comma (no spaces): Version, Tap, Description, Installs, Size, Status, Priority, Stars",
 	)
 	flagSortColumn = pflag.StringP(
 		"sort-column",
 		"s",
 		"Name",
-		"Choose which column (Name, Tap, Installs, Size, Status) to sort by initially",
+		"Choose which column (Name, Tap, Installs, Size, Status, Priority, Stars) to sort by initially",
+	)
+	flagStarsColumn = pflag.Bool(
+		"stars-column",
+		false,
+		"Show a column with the GitHub stars of packages, fetched with gh for the rows in view",
+	)
+	flagSummaryRow = pflag.Bool(
+		"summary-row",
+		false,
+		"Show a row below the table with the number of packages, total installs and total size of the packages shown",
 	)
 )
 
@@ -42,6 +55,15 @@ var MaxTableWidth = func() int {
 
 var (
 	tableStyle = baseStyle.BorderForeground(focusedBorderColor)
+
+	// Same as the header, with the border on the other side
+	summaryCellStyle = lipgloss.NewStyle().
+				Padding(0, 1).
+				Foreground(highlightColor).
+				BorderStyle(roundedBorder).
+				BorderForeground(borderColor).
+				BorderTop(true).
+				Bold(true)
 )
 
 type TableSelectionChangedMsg struct {
@@ -59,6 +81,7 @@ type PackageTableModel struct {
 	sortColumn     packageTableColumn
 	columns        []packageTableColumn // Enabled table columns
 	visibleColumns []packageTableColumn // Columns currently visible in the UI, depending on screen width
+	noInstalls     bool                 // Analytics failed to load, installs are unknown rather than zero
 
 	// Key bindings
 	sortNext key.Binding
@@ -92,7 +115,7 @@ func NewPackageTableModel() PackageTableModel {
 	columns := []packageTableColumn{}
 	for i := range int(totalNumColumns) {
 		col := packageTableColumn(i)
-		if _, hidden := hiddenColumns[col]; !hidden {
+		if _, hidden := hiddenColumns[col]; !hidden && (col != colStars || *flagStarsColumn) {
 			columns = append(columns, col)
 		}
 	}
@@ -150,11 +173,53 @@ func (m PackageTableModel) Update(msg tea.Msg) (PackageTableModel, tea.Cmd) {
 }
 
 func (m PackageTableModel) View() string {
+	if *flagSummaryRow {
+		return tableStyle.Render(lipgloss.JoinVertical(lipgloss.Left, m.table.View(), m.summaryView()))
+	}
 	return tableStyle.Render(m.table.View())
 }
 
+// Totals of the packages in the table, below the columns they add up
+func (m PackageTableModel) summaryView() string {
+	var installs, installed int
+	var size int64
+	for _, pkg := range m.packages {
+		installs += pkg.Installs90d
+		if pkg.IsInstalled {
+			installed++
+			size += pkg.Size
+		}
+	}
+	tableCols := m.table.Columns()
+	cells := []string{}
+	for j, col := range m.visibleColumns {
+		colWidth := col.width()
+		if j < len(tableCols) {
+			colWidth = tableCols[j].Width
+		}
+		cell := ""
+		switch col {
+		case colName:
+			cell = fmt.Sprintf("%d packages", len(m.packages))
+		case colInstalls:
+			if !m.noInstalls {
+				cell = fmt.Sprintf("%d", installs)
+			}
+		case colSize:
+			cell = util.FormatSize(size)
+		case colStatus:
+			cell = fmt.Sprintf("%d installed", installed)
+		}
+		cells = append(cells, summaryCellStyle.Render(fitCell(cell, colWidth, col.rightAligned())))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, cells...)
+}
+
 func (m *PackageTableModel) SetDimensions(width, height int) {
 	m.table.SetWidth(width)
+	if *flagSummaryRow {
+		height -= lipgloss.Height(summaryCellStyle.Render(""))
+	}
 	m.table.SetHeight(height)
 	m.updateColumns()
 	m.UpdateRows()
@@ -191,6 +256,36 @@ func (m *PackageTableModel) Selected() *data.Package {
 	}
 }
 
+// Move the cursor to a package, returns false when the package isn't in the table
+func (m *PackageTableModel) Select(pkg *data.Package) (tea.Cmd, bool) {
+	i := slices.Index(m.packages, pkg)
+	if i < 0 {
+		return nil, false
+	}
+	m.table.SetCursor(i)
+	return m.sendSelectionChangedMsg(), true
+}
+
+// Move the cursor to the first package whose name starts with the character, returns false when there's none.
+// Sorted by name, the package is found with a binary search.
+func (m *PackageTableModel) JumpTo(r rune) (tea.Cmd, bool) {
+	prefix := strings.ToLower(string(r))
+	i := -1
+	if m.sortColumn == colName {
+		j := sort.Search(len(m.packages), func(j int) bool { return m.packages[j].Name >= prefix })
+		if j < len(m.packages) && strings.HasPrefix(m.packages[j].Name, prefix) {
+			i = j
+		}
+	} else {
+		i = slices.IndexFunc(m.packages, func(pkg *data.Package) bool { return strings.HasPrefix(pkg.Name, prefix) })
+	}
+	if i < 0 {
+		return nil, false
+	}
+	m.table.SetCursor(i)
+	return m.sendSelectionChangedMsg(), true
+}
+
 func (m *PackageTableModel) sendSelectionChangedMsg() tea.Cmd {
 	return func() tea.Msg {
 		return TableSelectionChangedMsg{
@@ -203,6 +298,21 @@ func (m *PackageTableModel) ShowPackageInstalls() bool {
 	return m.isColumnEnabled(colInstalls)
 }
 
+// Show installs as N/A when analytics couldn't be loaded
+func (m *PackageTableModel) SetInstallsUnavailable(unavailable bool) {
+	m.noInstalls = unavailable
+}
+
+func (m *PackageTableModel) ShowStars() bool {
+	return m.isColumnEnabled(colStars)
+}
+
+// Packages in the rows around the cursor, at most a screen above and below it
+func (m *PackageTableModel) PackagesInView() []*data.Package {
+	cursor, height := m.table.Cursor(), m.table.Height()
+	return m.packages[max(0, cursor-height):min(len(m.packages), cursor+height)]
+}
+
 func (m *PackageTableModel) ShowPackageSizes() bool {
 	return m.isColumnEnabled(colSize)
 }
@@ -237,6 +347,16 @@ func (m *PackageTableModel) sortPrevColumn() {
 	m.sortRows()
 }
 
+// Sort by upgrade priority when the column is visible, used when viewing outdated packages
+func (m *PackageTableModel) SortByPriority() {
+	if m.sortColumn == colPriority || !m.isColumnVisible(colPriority) {
+		return
+	}
+	m.sortColumn = colPriority
+	m.updateColumns()
+	m.sortRows()
+}
+
 func (m *PackageTableModel) sortRows() {
 	switch m.sortColumn {
 	case colName:
@@ -259,6 +379,21 @@ func (m *PackageTableModel) sortRows() {
 		sort.Slice(m.packages, func(i, j int) bool {
 			return m.packages[i].Status() < m.packages[j].Status()
 		})
+	case colPriority:
+		sort.SliceStable(m.packages, func(i, j int) bool {
+			return m.packages[i].UpgradePriority() > m.packages[j].UpgradePriority()
+		})
+	case colStars:
+		// Packages not fetched yet go last
+		stars := func(pkg *data.Package) int {
+			if pkg.Repo == nil {
+				return -1
+			}
+			return pkg.Repo.Stars
+		}
+		sort.SliceStable(m.packages, func(i, j int) bool {
+			return stars(m.packages[i]) > stars(m.packages[j])
+		})
 	}
 	m.UpdateRows()
 }
@@ -324,15 +459,20 @@ func (m *PackageTableModel) getVisibleCols() ([]packageTableColumn, []table.Colu
 }
 
 func (m *PackageTableModel) UpdateRows() {
+	tableCols := m.table.Columns()
 	rows := make([]table.Row, len(m.packages))
 	for i, pkg := range m.packages {
-		rowData := []string{}
-		for _, col := range m.visibleColumns {
-			colData := col.getColumnData(pkg)
-			if col.rightAligned() {
-				colData = fmt.Sprintf("%*s", col.width(), colData)
+		rowData := make([]string, 0, len(m.visibleColumns))
+		for j, col := range m.visibleColumns {
+			colWidth := col.width()
+			if j < len(tableCols) {
+				colWidth = tableCols[j].Width
+			}
+			cell := col.getColumnData(pkg)
+			if col == colInstalls && m.noInstalls {
+				cell = "N/A"
 			}
-			rowData = append(rowData, colData)
+			rowData = append(rowData, fitCell(cell, colWidth, col.rightAligned()))
 		}
 		rows[i] = table.Row(rowData)
 	}
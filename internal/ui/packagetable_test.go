@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+func TestSummaryView(t *testing.T) {
+	m := NewPackageTableModel()
+	m.SetDimensions(MaxTableWidth, 20)
+	m.SetPackages([]*data.Package{
+		{Name: "wget", Installs90d: 100, IsInstalled: true, Size: 1024},
+		{Name: "jq", Installs90d: 50, IsInstalled: true, Size: 2048},
+		{Name: "git", Installs90d: 25},
+	})
+
+	summary := m.summaryView()
+	for _, want := range []string{"3 packages", "175", "3MB", "2 installed"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("expected %q in the summary, got %q", want, summary)
+		}
+	}
+
+	m.SetInstallsUnavailable(true)
+	if summary := m.summaryView(); strings.Contains(summary, "175") {
+		t.Errorf("expected no total installs without analytics, got %q", summary)
+	}
+}
+
+func TestJumpTo(t *testing.T) {
+	m := NewPackageTableModel()
+	m.SetDimensions(MaxTableWidth, 20)
+	pkgs := []*data.Package{{Name: "wget"}, {Name: "jq"}, {Name: "git"}, {Name: "gnupg"}, {Name: "zstd", Size: 1}}
+	m.SetPackages(pkgs)
+
+	m.sortColumn = colName
+	m.sortRows()
+	if _, ok := m.JumpTo('G'); !ok || m.Selected().Name != "git" {
+		t.Errorf("expected git, got %v", m.Selected())
+	}
+	if _, ok := m.JumpTo('x'); ok {
+		t.Errorf("expected no package starting with x")
+	}
+
+	m.sortColumn = colSize
+	m.sortRows()
+	if _, ok := m.JumpTo('z'); !ok || m.Selected().Name != "zstd" {
+		t.Errorf("expected zstd, got %v", m.Selected())
+	}
+	if _, ok := m.JumpTo('g'); !ok || !strings.HasPrefix(m.Selected().Name, "g") {
+		t.Errorf("expected a package starting with g, got %v", m.Selected())
+	}
+}
@@ -0,0 +1,215 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PagerModel shows the complete output of a command in a full screen scrollable view with search
+type PagerModel struct {
+	lines     []string
+	vp        viewport.Model
+	input     textinput.Model
+	searching bool
+	pattern   *regexp.Regexp
+	matches   []int // Indexes of lines matching the search
+	current   int   // Index into matches
+	visible   bool
+	width     int
+
+	search key.Binding
+	next   key.Binding
+	prev   key.Binding
+	top    key.Binding
+	bottom key.Binding
+	enter  key.Binding
+	cancel key.Binding
+}
+
+var (
+	pagerStyle = baseStyle.
+			BorderForeground(focusedBorderColor).
+			Padding(0, 1)
+
+	pagerMatchStyle = lipgloss.NewStyle().
+			Foreground(highlightForegroundColor).
+			Background(highlightColor)
+)
+
+func NewPagerModel() PagerModel {
+	input := textinput.New()
+	input.Placeholder = "Search output..."
+	input.Prompt = "/"
+	return PagerModel{
+		input:  input,
+		search: key.NewBinding(key.WithKeys("/")),
+		next:   key.NewBinding(key.WithKeys("n")),
+		prev:   key.NewBinding(key.WithKeys("N")),
+		top:    key.NewBinding(key.WithKeys("g", "home")),
+		bottom: key.NewBinding(key.WithKeys("G", "end")),
+		enter:  key.NewBinding(key.WithKeys("enter")),
+		cancel: key.NewBinding(key.WithKeys("esc")),
+	}
+}
+
+func (m *PagerModel) Show(lines []string) {
+	m.lines = lines
+	m.pattern = nil
+	m.matches = nil
+	m.input.SetValue("")
+	m.visible = true
+	m.render()
+	m.vp.GotoBottom()
+}
+
+func (m *PagerModel) Hide() {
+	m.visible = false
+	m.searching = false
+	m.input.Blur()
+	m.lines = nil
+}
+
+func (m *PagerModel) IsVisible() bool {
+	return m.visible
+}
+
+// Whether the search input is taking key presses
+func (m *PagerModel) IsSearching() bool {
+	return m.searching
+}
+
+func (m *PagerModel) SetDimensions(width, height int) {
+	m.width = width
+	// Leave a line for the search input or status
+	m.vp.Width = width - 2
+	m.vp.Height = max(1, height-1)
+	m.input.Width = width - 4
+	pagerStyle = pagerStyle.
+		BorderStyle(getRoundedBorderWithTitle("Output", width)).
+		Width(width)
+	m.render()
+}
+
+func (m PagerModel) Update(msg tea.Msg) (PagerModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if m.searching {
+		switch {
+		case key.Matches(keyMsg, m.enter):
+			m.searching = false
+			m.input.Blur()
+			m.setQuery(m.input.Value())
+		case key.Matches(keyMsg, m.cancel):
+			m.searching = false
+			m.input.Blur()
+		default:
+			m.input, cmd = m.input.Update(msg)
+		}
+		return m, cmd
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.search):
+		m.searching = true
+		m.input.SetValue("")
+		cmd = m.input.Focus()
+	case key.Matches(keyMsg, m.next):
+		if len(m.matches) > 0 {
+			m.current = (m.current + 1) % len(m.matches)
+			m.scrollToMatch()
+		}
+	case key.Matches(keyMsg, m.prev):
+		if len(m.matches) > 0 {
+			m.current = (m.current - 1 + len(m.matches)) % len(m.matches)
+			m.scrollToMatch()
+		}
+	case key.Matches(keyMsg, m.top):
+		m.vp.GotoTop()
+	case key.Matches(keyMsg, m.bottom):
+		m.vp.GotoBottom()
+	default:
+		m.vp, cmd = m.vp.Update(msg)
+	}
+	return m, cmd
+}
+
+// Search the output for a case-insensitive substring and jump to the first match
+func (m *PagerModel) setQuery(query string) {
+	m.pattern = nil
+	m.matches = nil
+	m.current = 0
+	if query != "" {
+		m.pattern = regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+		m.matches = findMatches(m.lines, m.pattern)
+	}
+	m.render()
+	m.scrollToMatch()
+}
+
+func findMatches(lines []string, pattern *regexp.Regexp) []int {
+	matches := []int{}
+	for i, line := range lines {
+		if pattern.MatchString(line) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+func highlightMatches(line string, pattern *regexp.Regexp) string {
+	if pattern == nil {
+		return line
+	}
+	return pattern.ReplaceAllStringFunc(line, func(s string) string {
+		return pagerMatchStyle.Render(s)
+	})
+}
+
+func (m *PagerModel) render() {
+	lines := make([]string, len(m.lines))
+	for i, line := range m.lines {
+		lines[i] = highlightMatches(line, m.pattern)
+	}
+	m.vp.SetContent(strings.Join(lines, "\n"))
+}
+
+// Scroll so the current match is in the middle of the view
+func (m *PagerModel) scrollToMatch() {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.vp.SetYOffset(m.matches[m.current] - m.vp.Height/2)
+}
+
+func (m PagerModel) statusView() string {
+	if m.searching {
+		return m.input.View()
+	}
+	status := fmt.Sprintf("Line %d/%d", min(m.vp.YOffset+m.vp.Height, len(m.lines)), len(m.lines))
+	if m.pattern != nil {
+		if len(m.matches) == 0 {
+			status += "  No matches"
+		} else {
+			status += fmt.Sprintf("  Match %d/%d", m.current+1, len(m.matches))
+		}
+	}
+	return keyStyle.Render(status)
+}
+
+func (m PagerModel) View() string {
+	if !m.visible {
+		return ""
+	}
+	return pagerStyle.Render(lipgloss.JoinVertical(lipgloss.Left, m.vp.View(), m.statusView()))
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestFindMatches(t *testing.T) {
+	lines := []string{
+		"==> Fetching wget",
+		"Error: wget: failed to download",
+		"",
+		"==> Upgrading WGET",
+	}
+	pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta("wget"))
+	if got := findMatches(lines, pattern); !slices.Equal(got, []int{0, 1, 3}) {
+		t.Errorf("expected matches [0 1 3], got %v", got)
+	}
+
+	pattern = regexp.MustCompile("(?i)" + regexp.QuoteMeta("error:"))
+	if got := findMatches(lines, pattern); !slices.Equal(got, []int{1}) {
+		t.Errorf("expected matches [1], got %v", got)
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	line := "Error: wget failed"
+	if got := highlightMatches(line, nil); got != line {
+		t.Errorf("expected line unchanged without a search, got %q", got)
+	}
+
+	pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta("WGET"))
+	got := highlightMatches(line, pattern)
+	if lipgloss.Width(got) != lipgloss.Width(line) {
+		t.Errorf("expected highlighting to keep the width %d, got %d", lipgloss.Width(line), lipgloss.Width(got))
+	}
+	want := "Error: " + pagerMatchStyle.Render("wget") + " failed"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PromptOption is a single choice in a prompt, selected by pressing Key
+type PromptOption struct {
+	Key    string
+	Desc   string
+	Action func() tea.Cmd // Nil action simply dismisses the prompt
+}
+
+// PromptModel asks the user to pick one of a few options before running a command
+type PromptModel struct {
+	title   string
+	lines   []string
+	options []PromptOption
+	active  bool
+	choice  bool // Actions are not brew commands
+}
+
+var promptStyle = baseStyle.
+	Margin(1 /* top */, 0 /* horizontal */, 0 /* bottom */).
+	Padding(0, 1).
+	BorderForeground(focusedBorderColor)
+
+func NewPromptModel() PromptModel {
+	return PromptModel{}
+}
+
+func (m *PromptModel) Show(title string, lines []string, options ...PromptOption) {
+	m.title = title
+	m.lines = lines
+	m.options = options
+	m.active = true
+	m.choice = false
+}
+
+// Show a prompt whose actions change settings instead of running brew commands
+func (m *PromptModel) ShowChoice(title string, lines []string, options ...PromptOption) {
+	m.Show(title, lines, options...)
+	m.choice = true
+}
+
+func (m *PromptModel) IsChoice() bool {
+	return m.choice
+}
+
+func (m *PromptModel) Dismiss() {
+	m.active = false
+	m.title = ""
+	m.lines = nil
+	m.options = nil
+}
+
+func (m *PromptModel) Title() string {
+	return m.title
+}
+
+func (m *PromptModel) IsActive() bool {
+	return m.active
+}
+
+// Update handles a key press and returns the command of the selected option.
+// Esc always dismisses the prompt without running anything.
+func (m PromptModel) Update(msg tea.Msg) (PromptModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || !m.active {
+		return m, nil
+	}
+
+	if keyMsg.String() == "esc" {
+		m.Dismiss()
+		return m, nil
+	}
+
+	for _, opt := range m.options {
+		if keyMsg.String() == opt.Key {
+			m.Dismiss()
+			if opt.Action != nil {
+				return m, opt.Action()
+			}
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+func (m *PromptModel) SetWidth(w int) {
+	promptStyle = promptStyle.
+		BorderStyle(getRoundedBorderWithTitle("Confirm", w)).
+		Width(w)
+}
+
+func (m PromptModel) View() string {
+	if !m.active {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(headerStyle.UnsetWidth().Render(m.title))
+	for _, l := range m.lines {
+		b.WriteString("\n")
+		b.WriteString(l)
+	}
+	b.WriteString("\n")
+	opts := make([]string, len(m.options))
+	for i, opt := range m.options {
+		opts[i] = keyStyle.Render(opt.Key) + ": " + opt.Desc
+	}
+	b.WriteString(strings.Join(opts, " "))
+	return promptStyle.Render(b.String())
+}
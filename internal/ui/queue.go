@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// QueueItem is a command waiting for the running command to finish
+type QueueItem struct {
+	Label string
+	Cmd   tea.Cmd
+}
+
+// QueueModel holds pending commands, which can be reordered or removed while focused
+type QueueModel struct {
+	items   []QueueItem
+	cursor  int
+	focused bool
+	paused  bool
+
+	up       key.Binding
+	down     key.Binding
+	moveUp   key.Binding
+	moveDown key.Binding
+	remove   key.Binding
+}
+
+var (
+	queueStyle = baseStyle.
+			Margin(1 /* top */, 0 /* horizontal */, 0 /* bottom */).
+			Padding(0, 1)
+
+	queueSelectedStyle = lipgloss.NewStyle().
+				Foreground(highlightForegroundColor).
+				Background(highlightColor)
+)
+
+func NewQueueModel() QueueModel {
+	return QueueModel{
+		up:       key.NewBinding(key.WithKeys("k", "up")),
+		down:     key.NewBinding(key.WithKeys("j", "down")),
+		moveUp:   key.NewBinding(key.WithKeys("K", "shift+up")),
+		moveDown: key.NewBinding(key.WithKeys("J", "shift+down")),
+		remove:   key.NewBinding(key.WithKeys("x", "d", "delete")),
+	}
+}
+
+func (m *QueueModel) Push(item QueueItem) {
+	m.items = append(m.items, item)
+}
+
+// Remove and return the first pending command
+func (m *QueueModel) Pop() (QueueItem, bool) {
+	if len(m.items) == 0 {
+		return QueueItem{}, false
+	}
+	item := m.items[0]
+	m.items = m.items[1:]
+	m.cursor = max(0, min(m.cursor-1, len(m.items)-1))
+	return item, true
+}
+
+func (m *QueueModel) Len() int {
+	return len(m.items)
+}
+
+// A paused queue doesn't start the next command automatically, e.g. after a command failed
+func (m *QueueModel) SetPaused(paused bool) {
+	m.paused = paused
+}
+
+func (m *QueueModel) IsPaused() bool {
+	return m.paused
+}
+
+func (m *QueueModel) SetFocused(focused bool) {
+	m.focused = focused
+	if focused {
+		queueStyle = queueStyle.BorderForeground(focusedBorderColor)
+	} else {
+		queueStyle = queueStyle.BorderForeground(borderColor)
+	}
+}
+
+func (m *QueueModel) SetWidth(w int) {
+	queueStyle = queueStyle.
+		BorderStyle(getRoundedBorderWithTitle("Queue", w)).
+		Width(w)
+}
+
+func (m QueueModel) Update(msg tea.Msg) (QueueModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || len(m.items) == 0 {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(keyMsg, m.down):
+		m.cursor = min(len(m.items)-1, m.cursor+1)
+	case key.Matches(keyMsg, m.moveUp):
+		if m.cursor > 0 {
+			m.items[m.cursor-1], m.items[m.cursor] = m.items[m.cursor], m.items[m.cursor-1]
+			m.cursor--
+		}
+	case key.Matches(keyMsg, m.moveDown):
+		if m.cursor < len(m.items)-1 {
+			m.items[m.cursor+1], m.items[m.cursor] = m.items[m.cursor], m.items[m.cursor+1]
+			m.cursor++
+		}
+	case key.Matches(keyMsg, m.remove):
+		m.items = append(m.items[:m.cursor], m.items[m.cursor+1:]...)
+		m.cursor = max(0, min(m.cursor, len(m.items)-1))
+	}
+	return m, nil
+}
+
+func (m QueueModel) View() string {
+	if len(m.items) == 0 {
+		return ""
+	}
+
+	rows := make([]string, len(m.items))
+	for i, item := range m.items {
+		row := fmt.Sprintf("%d. %s", i+1, item.Label)
+		if m.focused && i == m.cursor {
+			row = queueSelectedStyle.Render(row)
+		}
+		rows[i] = row
+	}
+	if m.paused {
+		rows = append(rows, keyStyle.Render("Paused after a failed command, press enter in the queue to resume"))
+	}
+	return queueStyle.Render(strings.Join(rows, "\n"))
+}
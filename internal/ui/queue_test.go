@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func queueLabels(m QueueModel) []string {
+	labels := []string{}
+	for _, item := range m.items {
+		labels = append(labels, item.Label)
+	}
+	return labels
+}
+
+func TestQueueReorderAndRemove(t *testing.T) {
+	m := NewQueueModel()
+	for _, l := range []string{"a", "b", "c"} {
+		m.Push(QueueItem{Label: l})
+	}
+
+	keys := func(ks ...string) {
+		for _, k := range ks {
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		}
+	}
+
+	keys("j", "K")
+	if want := []string{"b", "a", "c"}; !slices.Equal(queueLabels(m), want) {
+		t.Errorf("expected %v after moving up, got %v", want, queueLabels(m))
+	}
+
+	keys("J", "J")
+	if want := []string{"a", "c", "b"}; !slices.Equal(queueLabels(m), want) {
+		t.Errorf("expected %v after moving down, got %v", want, queueLabels(m))
+	}
+
+	keys("x")
+	if want := []string{"a", "c"}; !slices.Equal(queueLabels(m), want) {
+		t.Errorf("expected %v after removing, got %v", want, queueLabels(m))
+	}
+
+	if item, ok := m.Pop(); !ok || item.Label != "a" || m.Len() != 1 {
+		t.Errorf("expected to pop a, got %v (ok=%v), %d left", item.Label, ok, m.Len())
+	}
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/brew"
+)
+
+// RollbackModel shows what changed since the snapshots taken before upgrading all, and how to revert it
+type RollbackModel struct {
+	snapshots []brew.Snapshot
+	plans     [][]brew.RollbackStep
+	loading   bool
+	selected  int // Index of the snapshot shown, newest first
+	visible   bool
+	vp        viewport.Model
+}
+
+var rollbackStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+func NewRollbackModel() RollbackModel {
+	return RollbackModel{}
+}
+
+// Show the view while the plans are being made
+func (m *RollbackModel) Show() {
+	m.snapshots = nil
+	m.plans = nil
+	m.selected = 0
+	m.loading = true
+	m.visible = true
+	m.updateContent()
+}
+
+func (m *RollbackModel) SetPlans(snapshots []brew.Snapshot, plans [][]brew.RollbackStep) {
+	m.snapshots = snapshots
+	m.plans = plans
+	m.loading = false
+	m.updateContent()
+}
+
+// Show the snapshot before the current one
+func (m *RollbackModel) Older() {
+	if m.selected < len(m.snapshots)-1 {
+		m.selected++
+		m.updateContent()
+	}
+}
+
+// Show the snapshot after the current one
+func (m *RollbackModel) Newer() {
+	if m.selected > 0 {
+		m.selected--
+		m.updateContent()
+	}
+}
+
+func (m *RollbackModel) Hide() {
+	m.visible = false
+	m.snapshots = nil
+	m.plans = nil
+}
+
+func (m *RollbackModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *RollbackModel) SetDimensions(width, height int) {
+	m.vp.Width = width - 2
+	m.vp.Height = height
+	rollbackStyle = rollbackStyle.
+		BorderStyle(getRoundedBorderWithTitle("Rollback", width)).
+		Width(width)
+}
+
+func (m RollbackModel) Update(msg tea.Msg) (RollbackModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.vp, cmd = m.vp.Update(msg)
+	return m, cmd
+}
+
+func formatRollbackStep(step brew.RollbackStep) string {
+	var b strings.Builder
+	symbol := outdatedStyle.Render(outdatedSymbol)
+	switch step.Change {
+	case "installed":
+		symbol = installedStyle.Render(installedSymbol)
+	case "uninstalled":
+		symbol = uninstalledStyle.Render(uninstalledSymbol)
+	}
+	b.WriteString(fmt.Sprintf("  %s %s: %s\n", symbol, step.Name, step.Change))
+	for _, command := range step.Commands {
+		b.WriteString("      " + keyStyle.Render(command) + "\n")
+	}
+	if step.Note != "" {
+		style := uninstalledStyle
+		if len(step.Commands) == 0 {
+			style = deprecatedStyle
+		}
+		b.WriteString("      " + style.Render(step.Note) + "\n")
+	}
+	return b.String()
+}
+
+func (m *RollbackModel) updateContent() {
+	if m.loading {
+		m.vp.SetContent("Loading snapshots...")
+		return
+	}
+	if len(m.snapshots) == 0 {
+		m.vp.SetContent("No snapshots yet, one is taken before each upgrade all")
+		return
+	}
+
+	snapshot, steps := m.snapshots[m.selected], m.plans[m.selected]
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(
+		"%s %s (%d of %d, %d packages)\n",
+		headerStyle.UnsetWidth().Render("Snapshot:"),
+		snapshot.Time.Format(time.DateTime),
+		m.selected+1,
+		len(m.snapshots),
+		len(snapshot.Packages),
+	))
+	b.WriteString(fmt.Sprintf("%s: older  %s: newer\n", keyStyle.Render("]"), keyStyle.Render("[")))
+
+	b.WriteString("\n" + headerStyle.UnsetWidth().Render(fmt.Sprintf("Changes since (%d):", len(steps))) + "\n")
+	if len(steps) == 0 {
+		b.WriteString("  Installed packages are the same as in the snapshot\n")
+	}
+	for _, step := range steps {
+		b.WriteString(formatRollbackStep(step))
+	}
+
+	m.vp.SetContent(b.String())
+	m.vp.GotoTop()
+}
+
+func (m RollbackModel) View() string {
+	if !m.visible {
+		return ""
+	}
+	return rollbackStyle.Render(m.vp.View())
+}
@@ -43,6 +43,13 @@ func (m *SearchInputModel) Clear() tea.Cmd {
 	return m.sendSearchMsg()
 }
 
+// Replace the query, e.g. when picking a category
+func (m *SearchInputModel) SetValue(query string) tea.Cmd {
+	m.input.SetValue(query)
+	m.input.CursorEnd()
+	return m.sendSearchMsg()
+}
+
 func (m *SearchInputModel) sendSearchMsg() tea.Cmd {
 	return func() tea.Msg {
 		return SearchMsg{
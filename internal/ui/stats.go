@@ -2,14 +2,15 @@ package ui
 
 import (
 	"fmt"
-	"taproom/internal/data"
-	"taproom/internal/util"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/hzqtc/taproom/internal/util"
 )
 
 type StatsModel struct {
-	pkgs []*data.Package
+	pkgs     []*data.Package
+	warnings []string
 }
 
 var statsStyle = lipgloss.NewStyle().
@@ -23,6 +24,11 @@ func (m *StatsModel) SetPackages(pkgs []*data.Package) {
 	m.pkgs = pkgs
 }
 
+// Warnings shown after the stats, like data sources that failed to load
+func (m *StatsModel) SetWarnings(warnings []string) {
+	m.warnings = warnings
+}
+
 func (m *StatsModel) SetWidth(w int) {
 	statsStyle = statsStyle.Width(w)
 }
@@ -53,15 +59,18 @@ func (m StatsModel) View() string {
 			}
 		}
 	}
-	return statsStyle.Render(
-		fmt.Sprintf(
-			"%s Formulae available | %s Casks available | %s Formulae (incl. %s deps) installed taking %s | %s Casks installed taking %s",
-			keyStyle.Render(fmt.Sprintf("%d", formulaeNum)),
-			keyStyle.Render(fmt.Sprintf("%d", casksNum)),
-			keyStyle.Render(fmt.Sprintf("%d", installedFormulaeNum)),
-			keyStyle.Render(fmt.Sprintf("%d", installedFormulaeDepNum)),
-			keyStyle.Render(util.FormatSize(formulaeSize)),
-			keyStyle.Render(fmt.Sprintf("%d", installedCasksNum)),
-			keyStyle.Render(util.FormatSize(casksSize)),
-		))
+	stats := fmt.Sprintf(
+		"%s Formulae available | %s Casks available | %s Formulae (incl. %s deps) installed taking %s | %s Casks installed taking %s",
+		keyStyle.Render(fmt.Sprintf("%d", formulaeNum)),
+		keyStyle.Render(fmt.Sprintf("%d", casksNum)),
+		keyStyle.Render(fmt.Sprintf("%d", installedFormulaeNum)),
+		keyStyle.Render(fmt.Sprintf("%d", installedFormulaeDepNum)),
+		keyStyle.Render(util.FormatSize(formulaeSize)),
+		keyStyle.Render(fmt.Sprintf("%d", installedCasksNum)),
+		keyStyle.Render(util.FormatSize(casksSize)),
+	)
+	for _, warning := range m.warnings {
+		stats += " | " + deprecatedStyle.Render(deprecatedSymbol+" "+warning)
+	}
+	return statsStyle.Render(stats)
 }
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/brew"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// SummaryModel tells what changed since the last run, shown on launch
+type SummaryModel struct {
+	visible bool
+	vp      viewport.Model
+}
+
+var summaryStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+func NewSummaryModel() SummaryModel {
+	return SummaryModel{}
+}
+
+func (m *SummaryModel) Show(summary *brew.LaunchSummary) {
+	m.visible = true
+	m.vp.SetContent(formatSummary(summary))
+	m.vp.GotoTop()
+}
+
+func (m *SummaryModel) Hide() {
+	m.visible = false
+}
+
+func (m *SummaryModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *SummaryModel) SetDimensions(width, height int) {
+	m.vp.Width = width - 2
+	m.vp.Height = height
+	summaryStyle = summaryStyle.
+		BorderStyle(getRoundedBorderWithTitle("Since Last Run", width)).
+		Width(width)
+}
+
+func (m SummaryModel) Update(msg tea.Msg) (SummaryModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.vp, cmd = m.vp.Update(msg)
+	return m, cmd
+}
+
+func formatSummary(s *brew.LaunchSummary) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(
+		"%s %d new outdated packages, %d were upgraded externally, %d deprecated\n",
+		headerStyle.UnsetWidth().Render(fmt.Sprintf("Since %s:", s.Since.Format(time.DateTime))),
+		len(s.Outdated),
+		len(s.Upgraded),
+		len(s.Deprecated),
+	))
+	b.WriteString(fmt.Sprintf("%s: continue\n", keyStyle.Render("enter")))
+
+	section := func(title string, pkgs []*data.Package, line func(pkg *data.Package) string) {
+		if len(pkgs) == 0 {
+			return
+		}
+		b.WriteString("\n" + headerStyle.UnsetWidth().Render(fmt.Sprintf("%s (%d):", title, len(pkgs))) + "\n")
+		for _, pkg := range pkgs {
+			b.WriteString(fmt.Sprintf("  %s %s\n", formatStatusSymbol(pkg), line(pkg)))
+		}
+	}
+	section("New outdated", s.Outdated, func(pkg *data.Package) string {
+		return fmt.Sprintf("%s: %s", pkg.Name, pkg.LongVersion())
+	})
+	section("Upgraded externally", s.Upgraded, func(pkg *data.Package) string {
+		return fmt.Sprintf("%s: %s", pkg.Name, pkg.InstalledVersion)
+	})
+	section("Deprecated", s.Deprecated, func(pkg *data.Package) string {
+		if pkg.IsDisabled {
+			return pkg.Name + deprecatedStyle.Render(" (disabled)")
+		}
+		return pkg.Name
+	})
+	return b.String()
+}
+
+func (m SummaryModel) View() string {
+	if !m.visible {
+		return ""
+	}
+	return summaryStyle.Render(m.vp.View())
+}
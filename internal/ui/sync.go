@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/brew"
+)
+
+// SyncModel compares installed packages with the ones of another machine
+type SyncModel struct {
+	diff    *brew.SyncDiff
+	err     error
+	visible bool
+	vp      viewport.Model
+}
+
+var syncStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+func NewSyncModel() SyncModel {
+	return SyncModel{}
+}
+
+// Show the view while the file of the other machine is being loaded
+func (m *SyncModel) Show() {
+	m.diff = nil
+	m.err = nil
+	m.visible = true
+	m.updateContent()
+}
+
+func (m *SyncModel) SetDiff(diff *brew.SyncDiff, err error) {
+	m.diff = diff
+	m.err = err
+	m.updateContent()
+}
+
+func (m *SyncModel) Diff() *brew.SyncDiff {
+	return m.diff
+}
+
+func (m *SyncModel) Hide() {
+	m.visible = false
+	m.diff = nil
+}
+
+func (m *SyncModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *SyncModel) SetDimensions(width, height int) {
+	m.vp.Width = width - 2
+	m.vp.Height = height
+	syncStyle = syncStyle.
+		BorderStyle(getRoundedBorderWithTitle("Sync", width)).
+		Width(width)
+}
+
+func (m SyncModel) Update(msg tea.Msg) (SyncModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.vp, cmd = m.vp.Update(msg)
+	return m, cmd
+}
+
+func (m *SyncModel) updateContent() {
+	if m.err != nil {
+		m.vp.SetContent(deprecatedStyle.Render(m.err.Error()))
+		return
+	}
+	if m.diff == nil {
+		m.vp.SetContent("Loading the packages of the other machine...")
+		return
+	}
+
+	d := m.diff
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s\n", headerStyle.UnsetWidth().Render("Other machine:"), d.Path))
+	if len(d.Install)+len(d.Upgrade)+len(d.OnlyHere) > 0 {
+		b.WriteString(keyStyle.Render("enter") + ": converge with the other machine\n")
+	}
+
+	b.WriteString("\n" + headerStyle.UnsetWidth().Render(fmt.Sprintf("Only there (%d):", len(d.OnlyThere))) + "\n")
+	installable := make(map[string]bool)
+	for _, pkg := range d.Install {
+		installable[pkg.Name] = true
+	}
+	for _, entry := range d.OnlyThere {
+		line := fmt.Sprintf("  %s %s %s", uninstalledStyle.Render(uninstalledSymbol), entry.Kind, entry.Name)
+		if !installable[entry.PackageName()] {
+			line += deprecatedStyle.Render(" (not in the catalog)")
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n" + headerStyle.UnsetWidth().Render(fmt.Sprintf("Only here (%d):", len(d.OnlyHere))) + "\n")
+	for _, pkg := range d.OnlyHere {
+		kind := "brew"
+		if pkg.IsCask {
+			kind = "cask"
+		}
+		b.WriteString(fmt.Sprintf("  %s %s %s\n", formatStatusSymbol(pkg), kind, pkg.Name))
+	}
+
+	b.WriteString("\n" + headerStyle.UnsetWidth().Render(fmt.Sprintf("Version mismatch (%d):", len(d.Mismatched))) + "\n")
+	for _, mismatch := range d.Mismatched {
+		line := fmt.Sprintf("  %s %s: %s here, %s there",
+			formatStatusSymbol(mismatch.Pkg), mismatch.Pkg.Name, mismatch.Pkg.InstalledVersion, mismatch.Version)
+		if mismatch.Pkg.IsOutdated {
+			line += outdatedStyle.Render(" (outdated here)")
+		}
+		b.WriteString(line + "\n")
+	}
+
+	m.vp.SetContent(b.String())
+	m.vp.GotoTop()
+}
+
+func (m SyncModel) View() string {
+	if !m.visible {
+		return ""
+	}
+	return syncStyle.Render(m.vp.View())
+}
@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hzqtc/taproom/internal/brew"
+)
+
+// TapsModel lists tapped repositories and their auto-update settings
+type TapsModel struct {
+	taps    []*brew.Tap
+	err     error
+	loading bool
+	cursor  int
+	visible bool
+	width   int
+	height  int
+
+	up     key.Binding
+	down   key.Binding
+	top    key.Binding
+	bottom key.Binding
+}
+
+var tapsStyle = baseStyle.
+	BorderForeground(focusedBorderColor).
+	Padding(0, 1)
+
+const tapNameWidth = 40
+
+func NewTapsModel() TapsModel {
+	return TapsModel{
+		up:     key.NewBinding(key.WithKeys("k", "up")),
+		down:   key.NewBinding(key.WithKeys("j", "down")),
+		top:    key.NewBinding(key.WithKeys("g", "home")),
+		bottom: key.NewBinding(key.WithKeys("G", "end")),
+	}
+}
+
+// Show the view while taps are being loaded
+func (m *TapsModel) Show() {
+	m.taps = nil
+	m.err = nil
+	m.cursor = 0
+	m.loading = true
+	m.visible = true
+}
+
+func (m *TapsModel) SetTaps(taps []*brew.Tap, err error) {
+	m.taps = taps
+	m.err = err
+	m.loading = false
+}
+
+func (m *TapsModel) SetError(err error) {
+	m.err = err
+}
+
+func (m *TapsModel) Hide() {
+	m.visible = false
+	m.taps = nil
+}
+
+func (m *TapsModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *TapsModel) Selected() *brew.Tap {
+	if m.cursor >= 0 && m.cursor < len(m.taps) {
+		return m.taps[m.cursor]
+	}
+	return nil
+}
+
+func (m *TapsModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	tapsStyle = tapsStyle.
+		BorderStyle(getRoundedBorderWithTitle("Taps", width)).
+		Width(width)
+}
+
+func (m TapsModel) Update(msg tea.Msg) (TapsModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || len(m.taps) == 0 {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(keyMsg, m.down):
+		m.cursor = min(len(m.taps)-1, m.cursor+1)
+	case key.Matches(keyMsg, m.top):
+		m.cursor = 0
+	case key.Matches(keyMsg, m.bottom):
+		m.cursor = len(m.taps) - 1
+	}
+	return m, nil
+}
+
+func formatTap(tap *brew.Tap) string {
+	var autoUpdate string
+	if tap.ForceAutoUpdate {
+		autoUpdate = installedStyle.Render("auto-update: forced")
+	} else if tap.IsOnGithub() {
+		autoUpdate = "auto-update: yes (on GitHub)"
+	} else {
+		autoUpdate = deprecatedStyle.Render("auto-update: no (not on GitHub)")
+	}
+	return fmt.Sprintf(
+		"%s  %5d formulae  %5d casks  %s",
+		fitCell(tap.Name, tapNameWidth, false),
+		len(tap.Formulae),
+		len(tap.Casks),
+		autoUpdate,
+	)
+}
+
+func (m TapsModel) View() string {
+	if !m.visible {
+		return ""
+	}
+
+	header := []string{
+		fmt.Sprintf("%s %s", headerStyle.UnsetWidth().Render("Auto-update:"), brew.AutoUpdateSetting()),
+		keyStyle.Render("enter") + ": toggle forced auto-update of the selected tap " +
+			keyStyle.Render("m") + ": migrate packages moved to other taps",
+		"",
+	}
+	if m.err != nil {
+		header = append(header, deprecatedStyle.Render(m.err.Error()), "")
+	}
+	if m.loading {
+		header = append(header, "Loading taps...")
+	}
+
+	listHeight := max(1, m.height-len(header))
+	start := max(0, min(m.cursor-listHeight/2, len(m.taps)-listHeight))
+	end := min(len(m.taps), start+listHeight)
+	rows := []string{}
+	for i := start; i < end; i++ {
+		row := fitCell(formatTap(m.taps[i]), m.width-2, false)
+		if i == m.cursor {
+			row = queueSelectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, strings.Join(header, "\n"), strings.Join(rows, "\n"))
+	return tapsStyle.Height(m.height).MaxHeight(m.height + 2).Render(content)
+}
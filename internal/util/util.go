@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"strconv"
+	"strings"
 )
 
 func SortAndUniq(input []string) []string {
@@ -52,6 +54,27 @@ func FormatSize(kbs int64) string {
 	return "0"
 }
 
+// Parse sizes printed by brew like 24.5MB or 512B to KBs
+func ParseSize(s string) (int64, bool) {
+	for _, unit := range sizeUnits {
+		if num, ok := strings.CutSuffix(s, unit); ok {
+			value, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, false
+			}
+			return int64(value * float64(sizeMultipliers[unit])), true
+		}
+	}
+	if num, ok := strings.CutSuffix(s, "B"); ok {
+		value, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return 0, false
+		}
+		return int64(value / 1024), true
+	}
+	return 0, false
+}
+
 func GetEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"taproom/internal/model"
-	"taproom/internal/ui"
-	"taproom/internal/util"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hzqtc/taproom/internal/brew"
+	"github.com/hzqtc/taproom/internal/launchd"
+	"github.com/hzqtc/taproom/internal/model"
+	"github.com/hzqtc/taproom/internal/release"
+	"github.com/hzqtc/taproom/internal/ui"
+	"github.com/hzqtc/taproom/internal/util"
 	"github.com/spf13/pflag"
 )
 
@@ -18,6 +21,20 @@ var (
 	flagShowHelp    = pflag.BoolP("help", "h", false, "Show help message")
 )
 
+func init() {
+	pflag.CommandLine.AddFlagSet(brew.Flags)
+	pflag.CommandLine.AddFlagSet(release.Flags)
+	pflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [subcommand]\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Subcommands:")
+		fmt.Fprintln(os.Stderr, "  sizes    Recompute sizes of installed packages, refresh the size cache and print them")
+		fmt.Fprintln(os.Stderr, "  prewarm  Refresh cached catalog, analytics and sizes quietly, e.g. from launchd or cron")
+		fmt.Fprintln(os.Stderr, "  agent    Install, remove or check a launchd agent running prewarm: agent install|remove|status")
+		fmt.Fprintln(os.Stderr, "\nFlags:")
+		pflag.PrintDefaults()
+	}
+}
+
 //go:embed .version
 var version string
 
@@ -34,8 +51,6 @@ func main() {
 		os.Exit(0)
 	}
 
-	ui.InitTheme()
-
 	logfile := util.GetEnv("TAPROOM_LOG", "/tmp/taproom.log")
 	f, err := os.OpenFile(logfile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
@@ -45,6 +60,37 @@ func main() {
 	// Send log output to the file
 	log.SetOutput(f)
 
+	if err := brew.LocateBrew(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Subcommands run without the TUI
+	switch pflag.Arg(0) {
+	case "":
+	case "sizes":
+		brew.PrintSizes(os.Stdout)
+		os.Exit(0)
+	case "prewarm":
+		// Quiet unless it fails, so cron doesn't send mails for every run
+		if err := brew.Prewarm(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "agent":
+		if err := launchd.Run(pflag.Args()[1:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown subcommand: %s\n", pflag.Arg(0))
+		os.Exit(1)
+	}
+
+	ui.InitTheme()
+
 	// The WithAltScreen() option provides a full-screen TUI experience.
 	p := tea.NewProgram(model.InitialModel(), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
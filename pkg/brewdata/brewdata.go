@@ -0,0 +1,96 @@
+// Package brewdata loads Homebrew formulae and casks merged the way taproom shows them: the catalog
+// from formulae.brew.sh (or local taps), install receipts and directories of installed packages,
+// third-party taps, 90 day install analytics and sizes on disk.
+//
+// Downloads are cached in ~/.cache/taproom and shared with taproom, so loading is quick once either
+// of them ran in the last few hours. Progress and problems are written with the standard log package.
+package brewdata
+
+import (
+	"github.com/hzqtc/taproom/internal/brew"
+	"github.com/hzqtc/taproom/internal/data"
+)
+
+// Options select what to load besides the catalog and the installed state of packages
+type Options struct {
+	Analytics     bool // Download install counts of the last 90 days
+	Sizes         bool // Compute sizes of installed packages, cached until their directory changes
+	InstalledOnly bool // Only load installed packages with `brew info --installed`, which is much faster
+}
+
+// Package is a formula or cask with its catalog data and, when it's installed, its installed state.
+// A package is identified by its kind, tap and name: formulae and casks can share a name, and so can
+// packages of different taps, like a third-party formula shadowing a core one.
+type Package struct {
+	Name              string   // Short name, without the tap
+	Aliases           []string // Other names of a formula, like python for python@3.13
+	Tap               string   // Like homebrew/core or homebrew/cask, part of the identity of the package
+	Desc              string
+	Homepage          string
+	License           string // SPDX expression, "N/A" for casks
+	IsCask            bool
+	Version           string   // Latest version in the catalog
+	Revision          int      // Rebuilds of the same version, formulae only
+	Dependencies      []string // Formulae and casks needed to run the package
+	BuildDependencies []string // Formulae needed to build a formula from source
+	Dependents        []string // Packages in the catalog depending on this one
+	Conflicts         []string
+	Installs90d       int // Zero unless Options.Analytics is set
+	IsDeprecated      bool
+	IsDisabled        bool
+
+	IsInstalled           bool
+	InstalledVersion      string
+	InstalledRevision     int
+	InstalledDate         string // Like 2025-06-30
+	InstalledAsDependency bool   // Installed for another package rather than on request
+	IsOutdated            bool
+	IsPinned              bool
+	IsLinked              bool  // A formula's keg is symlinked into the brew prefix
+	IsKegOnly             bool  // A formula isn't linked into the prefix by default
+	Size                  int64 // In kbs, zero unless Options.Sizes is set
+}
+
+// Load all packages, sorted by name. Fails when brew can't be found.
+func Load(opts Options) ([]*Package, error) {
+	loaded, err := brew.LoadPackages(opts.Analytics, opts.Sizes, opts.InstalledOnly)
+	if err != nil {
+		return nil, err
+	}
+	pkgs := make([]*Package, len(loaded))
+	for i, pkg := range loaded {
+		pkgs[i] = fromPackage(pkg)
+	}
+	return pkgs, nil
+}
+
+func fromPackage(pkg *data.Package) *Package {
+	return &Package{
+		Name:                  pkg.Name,
+		Aliases:               pkg.Aliases,
+		Tap:                   pkg.Tap,
+		Desc:                  pkg.Desc(),
+		Homepage:              pkg.Homepage(),
+		License:               pkg.License,
+		IsCask:                pkg.IsCask,
+		Version:               pkg.Version,
+		Revision:              pkg.Revision,
+		Dependencies:          pkg.Dependencies,
+		BuildDependencies:     pkg.BuildDependencies,
+		Dependents:            pkg.Dependents,
+		Conflicts:             pkg.Conflicts,
+		Installs90d:           pkg.Installs90d,
+		IsDeprecated:          pkg.IsDeprecated,
+		IsDisabled:            pkg.IsDisabled,
+		IsInstalled:           pkg.IsInstalled,
+		InstalledVersion:      pkg.InstalledVersion,
+		InstalledRevision:     pkg.InstalledRevision,
+		InstalledDate:         pkg.InstalledDate,
+		InstalledAsDependency: pkg.InstalledAsDependency,
+		IsOutdated:            pkg.IsOutdated,
+		IsPinned:              pkg.IsPinned,
+		IsLinked:              pkg.IsLinked,
+		IsKegOnly:             pkg.IsKegOnly,
+		Size:                  pkg.Size,
+	}
+}
@@ -0,0 +1,39 @@
+package brewdata
+
+import (
+	"testing"
+
+	"github.com/hzqtc/taproom/internal/data"
+	"github.com/spf13/pflag"
+)
+
+func TestFromPackage(t *testing.T) {
+	pkg := &data.Package{
+		Name:             "wget",
+		Tap:              "homebrew/core",
+		Version:          "1.25.0",
+		Dependencies:     []string{"openssl@3"},
+		IsInstalled:      true,
+		InstalledVersion: "1.24.5",
+		IsOutdated:       true,
+		Size:             4608,
+	}
+	pkg.SetTexts(nil, "Internet file retriever", "https://www.gnu.org/software/wget/")
+
+	got := fromPackage(pkg)
+	if got.Name != "wget" || got.Tap != "homebrew/core" || got.Version != "1.25.0" {
+		t.Errorf("expected wget 1.25.0 from homebrew/core, got %s %s from %s", got.Name, got.Version, got.Tap)
+	}
+	if got.Desc != "Internet file retriever" || got.Homepage != "https://www.gnu.org/software/wget/" {
+		t.Errorf("expected the description and home page, got %q and %q", got.Desc, got.Homepage)
+	}
+	if !got.IsOutdated || got.InstalledVersion != "1.24.5" || got.Size != 4608 {
+		t.Errorf("expected outdated 1.24.5 taking 4608kbs, got outdated=%t %s taking %dkbs", got.IsOutdated, got.InstalledVersion, got.Size)
+	}
+}
+
+func TestImportRegistersNoFlags(t *testing.T) {
+	pflag.CommandLine.VisitAll(func(f *pflag.Flag) {
+		t.Errorf("expected no flags on the command line of the importing program, got --%s", f.Name)
+	})
+}